@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"io"
+	"os"
 	"sort"
 	"strings"
 
@@ -38,6 +39,14 @@ func runList(cmd *cobra.Command, args []string) error {
 		result = resources.ResourceNames
 	}
 
+	output := ListOutput{Items: append(make([]string, 0, len(result)), result...)}
+	if len(args) == 1 {
+		output.Type = args[0]
+	}
+	if ok, err := printStructured(output); ok {
+		return err
+	}
+
 	var items []pterm.BulletListItem
 	for _, r := range result {
 		items = append(items, pterm.BulletListItem{
@@ -91,6 +100,16 @@ func runShow(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	output := ShowOutput{
+		Type:   typ,
+		Name:   name,
+		Config: toKeyValues(cfg.Config),
+		Trace:  toKeyValues(trace.Trace),
+	}
+	if ok, err := printStructured(output); ok {
+		return err
+	}
+
 	final := append(cfg.Config, trace.Trace...)
 	err = printPTermTree(final)
 
@@ -121,6 +140,30 @@ func runMapping(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	output := MappingOutput{Entries: make([]MappingEntry, 0, len(result.Info))}
+	for _, v := range result.Info {
+		// Idle
+		if v.Type == rpc.ResourceMappingType_MappingTypeNormal && v.PodName == "" {
+			v.Type = rpc.ResourceMappingType_MappingTypeIdle
+		}
+		if v.Type == rpc.ResourceMappingType_MappingTypeError {
+			err = fmt.Errorf(mappingStringErrorExists)
+		}
+		output.Entries = append(output.Entries, MappingEntry{
+			Type:     mappingStatus[v.Type],
+			Pod:      v.PodName,
+			Resource: v.ResourceName,
+			Factory:  v.FactoryResourceName,
+		})
+	}
+
+	if ok, printErr := printStructured(output); ok {
+		if printErr != nil {
+			return printErr
+		}
+		return err
+	}
+
 	tableData := pterm.TableData{
 		{
 			mappingTableHeaderStatus,
@@ -133,15 +176,10 @@ func runMapping(cmd *cobra.Command, args []string) error {
 	for _, v := range result.Info {
 		clr := pterm.FgDefault
 		switch v.Type {
-		case rpc.ResourceMappingType_MappingTypeNormal:
-			// Idle
-			if v.PodName == "" {
-				v.Type = rpc.ResourceMappingType_MappingTypeIdle
-				clr = pterm.FgLightCyan
-			}
+		case rpc.ResourceMappingType_MappingTypeIdle:
+			clr = pterm.FgLightCyan
 		case rpc.ResourceMappingType_MappingTypeError:
 			clr = pterm.FgLightRed
-			err = fmt.Errorf(mappingStringErrorExists)
 		}
 
 		row := []string{
@@ -183,19 +221,31 @@ func runExecute(cmd *cobra.Command, args []string) error {
 
 	var message *rpc.ResourceExecuteReply
 
+	output := ExecuteOutput{
+		Type:     typ,
+		Name:     name,
+		Command:  command,
+		Args:     append(make([]string, 0, len(args)), args...),
+		Messages: make([]string, 0),
+	}
 	for {
 		message, err = stream.Recv()
 		if err != nil {
 			break
 		}
 
+		if outputFormat != outputTable {
+			output.Messages = append(output.Messages, message.Message)
+			continue
+		}
 		fmt.Print(message.Message) // print message
 	}
 
-	if err == io.EOF {
-		return nil
+	if err != io.EOF {
+		return err
 	}
 
+	_, err = printStructured(output)
 	return err
 }
 
@@ -209,120 +259,66 @@ const (
 )
 
 func runMetadata(cmd *cobra.Command, args []string) error {
-	leveledList := pterm.LeveledList{}
-
-	vsw, err := metadata.GetLocalVswitch()
-	if err != nil {
-		return err
-	}
-
-	leveledList = append(leveledList, pterm.LeveledListItem{
-		Level: metadataLevelVSwitch,
-		Text:  printKV("vswitch", vsw),
-	})
-
-	enis, err := metadata.GetENIsMAC()
+	output, err := getMetadata()
 	if err != nil {
 		return err
 	}
-
-	primaryENI, err := metadata.GetPrimaryENIMAC()
-	if err != nil {
+	if ok, err := printStructured(output); ok {
 		return err
 	}
 
-	// make primary to the first
-	sort.Slice(enis, func(i, j int) bool {
-		return enis[i] == primaryENI
+	leveledList := pterm.LeveledList{}
+	leveledList = append(leveledList, pterm.LeveledListItem{
+		Level: metadataLevelVSwitch,
+		Text:  printKV("vswitch", output.VSwitch),
 	})
 
-	for _, eni := range enis {
+	for _, eni := range output.ENIs {
 		leveledList = append(leveledList, pterm.LeveledListItem{
 			Level: metadataLevelENI,
-			Text:  printKV("eni", eni),
+			Text:  printKV("eni", eni.MAC),
 		}, pterm.LeveledListItem{
 			Level: metadataLevelAttribute,
-			Text:  printKV("primary", fmt.Sprintf("%t", primaryENI == eni)),
+			Text:  printKV("primary", fmt.Sprintf("%t", eni.Primary)),
 		})
 
-		// network interface
-		nif, err := getInterfaceByMAC(eni)
-		if err != nil && err.Error() != "not found" {
-			return err
-		}
-
-		ifname := ""
-		if err != nil {
+		ifname := eni.Interface
+		if ifname == "" {
 			ifname = pterm.Red("!!!NOT FOUND")
-		} else {
-			ifname = nif.Name
 		}
 
 		leveledList = append(leveledList, pterm.LeveledListItem{
 			Level: metadataLevelAttribute,
 			Text:  printKV("interface", ifname),
-		})
-
-		primaryIP, err := metadata.GetENIPrimaryIP(eni)
-		if err != nil {
-			return err
-		}
-
-		leveledList = append(leveledList, pterm.LeveledListItem{
+		}, pterm.LeveledListItem{
 			Level: metadataLevelAttribute,
-			Text:  printKV("primary_ipv4", primaryIP.String()),
+			Text:  printKV("primary_ipv4", eni.PrimaryIPv4),
 		})
 
-		// ipv4
-		ipv4s, err := metadata.GetENIPrivateIPs(eni)
-		if err != nil {
-			return err
-		}
-
-		// when len(ipv4) == 1, only primary ipv4 exists
-		if len(ipv4s) > 1 {
+		if len(eni.SecondaryIPv4s) != 0 {
 			leveledList = append(leveledList, pterm.LeveledListItem{
 				Level: metadataLevelAttribute,
 				Text:  "secondary_ipv4s",
 			})
 
-			sort.Slice(ipv4s, func(i, j int) bool {
-				return ipv4s[i].String() < ipv4s[j].String()
-			})
-
-			for _, ip := range ipv4s {
-				if ip.Equal(primaryIP) {
-					continue
-				}
-
+			for _, ip := range eni.SecondaryIPv4s {
 				leveledList = append(leveledList, pterm.LeveledListItem{
 					Level: metadataLevelAttributeItem,
-					Text:  pterm.ThemeDefault.WarningMessageStyle.Sprint(ip.String()),
+					Text:  pterm.ThemeDefault.WarningMessageStyle.Sprint(ip),
 				})
 			}
 		}
 
-		// ipv6
-		ipv6s, err := metadata.GetENIPrivateIPv6IPs(eni)
-		if err != nil {
-			pterm.Error.Printf(metadataErrorStringIPV6, eni, err)
-			continue
-		}
-
-		if len(ipv6s) != 0 {
+		if len(eni.IPv6s) != 0 {
 			leveledList = append(leveledList, pterm.LeveledListItem{
 				Level: metadataLevelAttribute,
 				Text:  "ipv6s",
 			})
 
-			sort.Slice(ipv6s, func(i, j int) bool {
-				return strings.Compare(ipv6s[i].String(), ipv6s[j].String()) < 0
-			})
-
-			for _, ip := range ipv6s {
+			for _, ip := range eni.IPv6s {
 				leveledList = append(leveledList, pterm.LeveledListItem{
 					Level: metadataLevelAttributeItem,
-					Text:  pterm.ThemeDefault.WarningMessageStyle.Sprint(ip.String()),
+					Text:  pterm.ThemeDefault.WarningMessageStyle.Sprint(ip),
 				})
 			}
 		}
@@ -335,6 +331,94 @@ func runMetadata(cmd *cobra.Command, args []string) error {
 		Render()
 }
 
+// getMetadata collect the vswitch and enis of this node from metadata
+func getMetadata() (*MetadataOutput, error) {
+	vsw, err := metadata.GetLocalVswitch()
+	if err != nil {
+		return nil, err
+	}
+
+	enis, err := metadata.GetENIsMAC()
+	if err != nil {
+		return nil, err
+	}
+
+	primaryENI, err := metadata.GetPrimaryENIMAC()
+	if err != nil {
+		return nil, err
+	}
+
+	// make primary to the first
+	sort.Slice(enis, func(i, j int) bool {
+		return enis[i] == primaryENI
+	})
+
+	output := &MetadataOutput{
+		VSwitch: vsw,
+		ENIs:    make([]MetadataENI, 0, len(enis)),
+	}
+	for _, eni := range enis {
+		item := MetadataENI{
+			MAC:            eni,
+			Primary:        primaryENI == eni,
+			SecondaryIPv4s: make([]string, 0),
+			IPv6s:          make([]string, 0),
+		}
+
+		// network interface
+		nif, err := getInterfaceByMAC(eni)
+		if err != nil && err.Error() != "not found" {
+			return nil, err
+		}
+		if err == nil {
+			item.Interface = nif.Name
+		}
+
+		primaryIP, err := metadata.GetENIPrimaryIP(eni)
+		if err != nil {
+			return nil, err
+		}
+		item.PrimaryIPv4 = primaryIP.String()
+
+		// ipv4
+		ipv4s, err := metadata.GetENIPrivateIPs(eni)
+		if err != nil {
+			return nil, err
+		}
+
+		sort.Slice(ipv4s, func(i, j int) bool {
+			return ipv4s[i].String() < ipv4s[j].String()
+		})
+
+		// when len(ipv4) == 1, only primary ipv4 exists
+		for _, ip := range ipv4s {
+			if ip.Equal(primaryIP) {
+				continue
+			}
+			item.SecondaryIPv4s = append(item.SecondaryIPv4s, ip.String())
+		}
+
+		// ipv6
+		ipv6s, err := metadata.GetENIPrivateIPv6IPs(eni)
+		if err != nil {
+			// keep stdout parsable for json/yaml output
+			pterm.Error.WithWriter(os.Stderr).Printf(metadataErrorStringIPV6, eni, err)
+			output.ENIs = append(output.ENIs, item)
+			continue
+		}
+
+		sort.Slice(ipv6s, func(i, j int) bool {
+			return strings.Compare(ipv6s[i].String(), ipv6s[j].String()) < 0
+		})
+
+		for _, ip := range ipv6s {
+			item.IPv6s = append(item.IPv6s, ip.String())
+		}
+		output.ENIs = append(output.ENIs, item)
+	}
+	return output, nil
+}
+
 func printKV(key, value string) string {
 	return fmt.Sprintf("%s: %s", key, pterm.ThemeDefault.WarningMessageStyle.Sprint(value))
 }
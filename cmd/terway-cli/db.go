@@ -0,0 +1,213 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AliyunContainerService/terway/pkg/storage"
+	"github.com/AliyunContainerService/terway/types"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+const (
+	defaultResDBPath = "/var/lib/cni/terway/ResRelation.db"
+	resDBName        = "relation"
+
+	dbLockTimeout = time.Second
+
+	dbTableHeaderBucket   = "Bucket"
+	dbTableHeaderRecords  = "Records"
+	dbTableHeaderVersions = "Versions"
+	dbTableHeaderInvalid  = "Invalid"
+)
+
+var (
+	dbCmd = &cobra.Command{
+		Use:   "db",
+		Short: "export, import or inspect the resource db of terway daemon.",
+		Long: "operate the resource db file directly, the daemon is not required, so the file can be inspected off node. " +
+			"export and inspect read a copy of the file if it is held by the daemon, import requires the daemon stopped.",
+		// the db commands do not talk to the daemon
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return validateOutputFormat()
+		},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {},
+	}
+
+	dbExportCmd = &cobra.Command{
+		Use:   "export",
+		Short: "export the pod resource relations in json, migrated to the current schema version.",
+		Args:  cobra.NoArgs,
+		RunE:  runDBExport,
+	}
+
+	dbImportCmd = &cobra.Command{
+		Use:   "import <file>",
+		Short: "replace the pod resource relations with an export, the daemon must be stopped.",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runDBImport,
+	}
+
+	dbInspectCmd = &cobra.Command{
+		Use:   "inspect",
+		Short: "show the buckets, schema versions of records and the integrity of the resource db.",
+		Args:  cobra.NoArgs,
+		RunE:  runDBInspect,
+	}
+
+	dbPath       string
+	dbExportFile string
+)
+
+func init() {
+	dbCmd.PersistentFlags().StringVar(&dbPath, "path", defaultResDBPath, "path of the resource db file")
+	dbExportCmd.Flags().StringVar(&dbExportFile, "to", "", "write the export to the file instead of stdout")
+	dbCmd.AddCommand(dbExportCmd, dbImportCmd, dbInspectCmd)
+	rootCmd.AddCommand(dbCmd)
+}
+
+// withSnapshot run fn on the db file, or on a copy of it if the file is held by the daemon
+func withSnapshot(path string, fn func(path string) error) (bool, error) {
+	err := fn(path)
+	if !errors.Is(err, storage.ErrLocked) {
+		return false, err
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer src.Close()
+	dst, err := os.CreateTemp("", "terway-db-")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(dst.Name())
+	defer dst.Close()
+	_, err = io.Copy(dst, src)
+	if err != nil {
+		return false, err
+	}
+	return true, fn(dst.Name())
+}
+
+func runDBExport(cmd *cobra.Command, args []string) error {
+	var export *storage.Export
+	_, err := withSnapshot(dbPath, func(path string) error {
+		var err error
+		export, err = storage.ExportFile(path, resDBName, types.PodResourcesSchema, dbLockTimeout)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	out, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return err
+	}
+	out = append(out, '\n')
+	if dbExportFile != "" {
+		return os.WriteFile(dbExportFile, out, 0600)
+	}
+	_, err = outputWriter.Write(out)
+	return err
+}
+
+func runDBImport(cmd *cobra.Command, args []string) error {
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+	export := &storage.Export{}
+	err = json.Unmarshal(data, export)
+	if err != nil {
+		return fmt.Errorf("error parse export %s, %w", args[0], err)
+	}
+	if export.Bucket != resDBName {
+		return fmt.Errorf("export of bucket %s can not be imported to resource db", export.Bucket)
+	}
+
+	err = storage.ImportFile(dbPath, types.PodResourcesSchema, export, dbLockTimeout)
+	if errors.Is(err, storage.ErrLocked) {
+		return fmt.Errorf("%w, stop terway daemon before import", err)
+	}
+	if err != nil {
+		return err
+	}
+	pterm.Success.Printfln("%d records imported to %s", len(export.Records), dbPath)
+	return nil
+}
+
+func runDBInspect(cmd *cobra.Command, args []string) error {
+	var info *storage.Info
+	snapshot, err := withSnapshot(dbPath, func(path string) error {
+		var err error
+		info, err = storage.InspectFile(path, dbLockTimeout)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	output := DBInspectOutput{
+		Path:          dbPath,
+		Size:          info.Size,
+		SchemaVersion: types.PodResourcesSchema.Version,
+		Snapshot:      snapshot,
+		Buckets:       make([]DBBucket, 0, len(info.Buckets)),
+		Errors:        info.Errors,
+	}
+	for _, v := range info.Buckets {
+		output.Buckets = append(output.Buckets, DBBucket{
+			Name:     v.Name,
+			Records:  v.Records,
+			Versions: v.Versions,
+			Invalid:  v.Invalid,
+		})
+	}
+
+	if len(output.Errors) > 0 {
+		err = fmt.Errorf("resource db is corrupted")
+	}
+	if ok, printErr := printStructured(output); ok {
+		if printErr != nil {
+			return printErr
+		}
+		return err
+	}
+
+	pterm.Printfln("path: %s, size: %d, schema version: %d, snapshot: %t",
+		output.Path, output.Size, output.SchemaVersion, output.Snapshot)
+	tableData := pterm.TableData{
+		{dbTableHeaderBucket, dbTableHeaderRecords, dbTableHeaderVersions, dbTableHeaderInvalid},
+	}
+	for _, v := range output.Buckets {
+		versions := make([]int, 0, len(v.Versions))
+		for version := range v.Versions {
+			versions = append(versions, version)
+		}
+		sort.Ints(versions)
+		var str []string
+		for _, version := range versions {
+			str = append(str, fmt.Sprintf("v%d:%d", version, v.Versions[version]))
+		}
+		tableData = append(tableData, []string{v.Name, strconv.Itoa(v.Records), strings.Join(str, " "), strconv.Itoa(v.Invalid)})
+	}
+	if err := pterm.DefaultTable.WithHasHeader().WithData(tableData).Render(); err != nil {
+		return err
+	}
+
+	for _, v := range output.Errors {
+		pterm.Error.Println(v)
+	}
+	return err
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/AliyunContainerService/terway/pkg/aliyun/metadata"
+	"github.com/AliyunContainerService/terway/pkg/link"
+	"github.com/AliyunContainerService/terway/plugin/datapath"
+	"github.com/AliyunContainerService/terway/plugin/driver/types"
+	"github.com/AliyunContainerService/terway/plugin/driver/utils"
+	"github.com/AliyunContainerService/terway/rpc"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultCNIConfPath = "/etc/eni/10-terway.conf"
+	defaultVethPrefix  = "cali"
+	defaultMTU         = 1500
+
+	diagnoseTableHeaderCheck   = "Check"
+	diagnoseTableHeaderResult  = "Result"
+	diagnoseTableHeaderMessage = "Message"
+
+	diagnoseStringFailed = "diagnose failed"
+
+	hintHostNs   = "host sysctl is not as expected, it is set when the next pod is created on this node"
+	hintMetadata = "eni or ip is not found in metadata, it may be released in OpenAPI, restart terway to resync it"
+)
+
+var (
+	diagnoseCmd = &cobra.Command{
+		Use:   "diagnose",
+		Short: "diagnose the network of the given resource.",
+	}
+
+	diagnosePodCmd = &cobra.Command{
+		Use:   "pod <namespace>/<name>",
+		Short: "check the datapath of the pod end to end.",
+		Long:  "check the addresses, routes, neighbours, rules, tc filters and sysctl of the pod against the expected datapath, nothing is changed.",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runDiagnosePod,
+	}
+
+	diagnoseCNIConfPath string
+	diagnoseNetNS       string
+)
+
+func init() {
+	diagnosePodCmd.Flags().StringVar(&diagnoseCNIConfPath, "cni-conf", defaultCNIConfPath, "path of the terway cni config")
+	diagnosePodCmd.Flags().StringVar(&diagnoseNetNS, "netns", "", "path of the pod netns, read from terway daemon if not specified")
+	diagnoseCmd.AddCommand(diagnosePodCmd)
+	rootCmd.AddCommand(diagnoseCmd)
+}
+
+func runDiagnosePod(cmd *cobra.Command, args []string) error {
+	namespace, name, err := parsePodKey(args[0])
+	if err != nil {
+		return err
+	}
+
+	conf, err := loadCNIConf(diagnoseCNIConfPath)
+	if err != nil {
+		return err
+	}
+
+	info, err := rpc.NewTerwayBackendClient(grpcConn).GetIPInfo(ctx, &rpc.GetInfoRequest{
+		K8SPodName:      name,
+		K8SPodNamespace: namespace,
+	})
+	if err != nil {
+		return fmt.Errorf("error get ip info of pod %s/%s, %w", namespace, name, err)
+	}
+	if info.Error != rpc.Error_ErrNoErr {
+		return fmt.Errorf("error get ip info of pod %s/%s, %s", namespace, name, info.Error)
+	}
+	if len(info.NetConfs) == 0 {
+		return fmt.Errorf("no net conf found for pod %s/%s", namespace, name)
+	}
+
+	netNSPath := diagnoseNetNS
+	if netNSPath == "" {
+		netNSPath, err = getPodNetNS(namespace, name)
+		if err != nil {
+			return err
+		}
+	}
+	netNS, err := ns.GetNS(netNSPath)
+	if err != nil {
+		return fmt.Errorf("error open netns %s, %w", netNSPath, err)
+	}
+	defer netNS.Close()
+
+	hostIPSet, err := utils.GetHostIP(info.IPv4, info.IPv6)
+	if err != nil {
+		return fmt.Errorf("error get host ip, %w", err)
+	}
+
+	var results []datapath.CheckResult
+	results = append(results, diagnoseHostNs(info.IPv4, info.IPv6))
+
+	for _, netConf := range info.NetConfs {
+		eniMAC := netConf.GetENIInfo().GetMAC()
+		alloc := netConf
+		dp := datapath.GetDataPath(info.IPType, conf.VlanStripType, netConf.GetENIInfo().GetTrunk())
+		if dp == types.ExclusiveENI && eniMAC != "" {
+			// the exclusive eni is moved into pod netns, it is not visible in host
+			alloc = proto.Clone(netConf).(*rpc.NetConf)
+			alloc.ENIInfo.MAC = ""
+		}
+
+		setupCfg, err := datapath.ParseSetupConf("eth0", alloc, conf, info.IPType)
+		if err != nil {
+			return fmt.Errorf("error parse config, %w", err)
+		}
+		setupCfg.HostVETHName, _ = link.VethNameForPod(name, namespace, netConf.IfName, defaultVethPrefix)
+		setupCfg.HostIPSet = hostIPSet
+		setupCfg.MultiNetwork = len(info.NetConfs) > 1
+
+		if setupCfg.DP == types.IPVlan {
+			available := false
+			if conf.IPVlan() {
+				available, err = datapath.CheckIPVLanAvailable()
+				if err != nil {
+					return err
+				}
+			}
+			if !available {
+				setupCfg.DP = types.PolicyRoute
+			}
+		}
+
+		if setupCfg.DP != types.VPCRoute {
+			results = append(results, diagnoseMetadata(ecsMetadata{}, setupCfg.ContainerIfName, netConf, setupCfg.DP))
+		}
+		for _, r := range datapath.Diagnose(setupCfg, eniMAC, netNS) {
+			r.Name = fmt.Sprintf("[%s] %s", setupCfg.ContainerIfName, r.Name)
+			results = append(results, r)
+		}
+	}
+
+	return printDiagnoseResults(results)
+}
+
+func parsePodKey(key string) (string, string, error) {
+	parts := strings.Split(key, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid pod %s, expected <namespace>/<name>", key)
+	}
+	return parts[0], parts[1], nil
+}
+
+func loadCNIConf(path string) (*types.CNIConf, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error read cni config %s, %w", path, err)
+	}
+	conf := &types.CNIConf{}
+	err = json.Unmarshal(data, conf)
+	if err != nil {
+		return nil, fmt.Errorf("error parse cni config %s, %w", path, err)
+	}
+	if conf.MTU == 0 {
+		conf.MTU = defaultMTU
+	}
+	return conf, nil
+}
+
+// getPodNetNS read the pod netns recorded by terway daemon
+func getPodNetNS(namespace, name string) (string, error) {
+	trace, err := client.GetResourceTrace(ctx, &rpc.ResourceTypeNameRequest{
+		Type: "network_service",
+		Name: "default",
+	})
+	if err != nil {
+		return "", err
+	}
+	key := fmt.Sprintf("pods/%s/%s/netns", namespace, name)
+	for _, v := range trace.Trace {
+		if v.Key != key {
+			continue
+		}
+		if _, err = os.Stat(v.Value); err == nil {
+			return v.Value, nil
+		}
+		// the netns is not mounted in terway container, find it from host
+		return filepath.Join("/proc/1/root/", v.Value), nil
+	}
+	return "", fmt.Errorf("netns of pod %s/%s is not found, specify it by --netns", namespace, name)
+}
+
+func diagnoseHostNs(ipv4, ipv6 bool) datapath.CheckResult {
+	result := datapath.CheckResult{Name: "host sysctl", Passed: true}
+	diffs, err := utils.DiffHostNsConfig(ipv4, ipv6)
+	if err != nil {
+		diffs = append(diffs, err.Error())
+	}
+	if len(diffs) > 0 {
+		result.Passed = false
+		result.Message = strings.Join(diffs, "; ")
+		result.Hint = hintHostNs
+	}
+	return result
+}
+
+// eniMetadata is the eni info in ecs metadata used by diagnose
+type eniMetadata interface {
+	GetENIsMAC() ([]string, error)
+	GetENIPrimaryIP(mac string) (net.IP, error)
+	GetENIPrivateIPs(mac string) ([]net.IP, error)
+	GetENIPrivateIPv6IPs(mac string) ([]net.IP, error)
+}
+
+type ecsMetadata struct{}
+
+func (ecsMetadata) GetENIsMAC() ([]string, error) {
+	return metadata.GetENIsMAC()
+}
+
+func (ecsMetadata) GetENIPrimaryIP(mac string) (net.IP, error) {
+	return metadata.GetENIPrimaryIP(mac)
+}
+
+func (ecsMetadata) GetENIPrivateIPs(mac string) ([]net.IP, error) {
+	return metadata.GetENIPrivateIPs(mac)
+}
+
+func (ecsMetadata) GetENIPrivateIPv6IPs(mac string) ([]net.IP, error) {
+	return metadata.GetENIPrivateIPv6IPs(mac)
+}
+
+// diagnoseMetadata check the eni and ip of the pod is consistent with metadata
+func diagnoseMetadata(meta eniMetadata, ifName string, netConf *rpc.NetConf, dp types.DataPath) (result datapath.CheckResult) {
+	mac := netConf.GetENIInfo().GetMAC()
+	result = datapath.CheckResult{Name: fmt.Sprintf("[%s] eni metadata", ifName), Passed: true}
+
+	var diffs []string
+	defer func() {
+		if len(diffs) > 0 {
+			result.Passed = false
+			result.Message = strings.Join(diffs, "; ")
+			result.Hint = hintMetadata
+		}
+	}()
+
+	macs, err := meta.GetENIsMAC()
+	if err != nil {
+		diffs = append(diffs, fmt.Sprintf("error get enis from metadata, %v", err))
+		return result
+	}
+	found := false
+	for _, v := range macs {
+		if strings.EqualFold(v, mac) {
+			// query the ips by the mac in metadata
+			mac = v
+			found = true
+			break
+		}
+	}
+	if !found {
+		diffs = append(diffs, fmt.Sprintf("eni %s is not attached", mac))
+		return result
+	}
+
+	// pod ip of trunk is on the member eni, which is not in metadata
+	if netConf.GetENIInfo().GetTrunk() {
+		return result
+	}
+
+	podIP := netConf.GetBasicInfo().GetPodIP()
+	if podIP.GetIPv4() != "" {
+		var ips []net.IP
+		if dp == types.ExclusiveENI {
+			var primary net.IP
+			primary, err = meta.GetENIPrimaryIP(mac)
+			ips = append(ips, primary)
+		} else {
+			ips, err = meta.GetENIPrivateIPs(mac)
+		}
+		if err != nil {
+			diffs = append(diffs, fmt.Sprintf("error get ips of eni %s from metadata, %v", mac, err))
+		} else if !containsIP(ips, podIP.GetIPv4()) {
+			diffs = append(diffs, fmt.Sprintf("ip %s is not assigned to eni %s", podIP.GetIPv4(), mac))
+		}
+	}
+	if podIP.GetIPv6() != "" {
+		ips, err := meta.GetENIPrivateIPv6IPs(mac)
+		if err != nil {
+			diffs = append(diffs, fmt.Sprintf("error get ipv6 ips of eni %s from metadata, %v", mac, err))
+		} else if !containsIP(ips, podIP.GetIPv6()) {
+			diffs = append(diffs, fmt.Sprintf("ip %s is not assigned to eni %s", podIP.GetIPv6(), mac))
+		}
+	}
+	return result
+}
+
+func containsIP(ips []net.IP, s string) bool {
+	target := net.ParseIP(s)
+	for _, v := range ips {
+		if v.Equal(target) {
+			return true
+		}
+	}
+	return false
+}
+
+func printDiagnoseResults(results []datapath.CheckResult) error {
+	output := DiagnoseOutput{Passed: true, Results: make([]DiagnoseResult, 0, len(results))}
+	for _, r := range results {
+		output.Passed = output.Passed && r.Passed
+		output.Results = append(output.Results, DiagnoseResult{
+			Name:    r.Name,
+			Passed:  r.Passed,
+			Message: r.Message,
+			Hint:    r.Hint,
+		})
+	}
+	if ok, err := printStructured(output); ok {
+		if err != nil {
+			return err
+		}
+		if !output.Passed {
+			return fmt.Errorf(diagnoseStringFailed)
+		}
+		return nil
+	}
+
+	tableData := pterm.TableData{
+		{
+			diagnoseTableHeaderCheck,
+			diagnoseTableHeaderResult,
+			diagnoseTableHeaderMessage,
+		},
+	}
+
+	var hints []pterm.BulletListItem
+	var err error
+	for _, r := range results {
+		clr, status := pterm.FgLightGreen, "PASS"
+		if !r.Passed {
+			clr, status = pterm.FgLightRed, "FAIL"
+			err = fmt.Errorf(diagnoseStringFailed)
+			if r.Hint != "" {
+				hints = append(hints, pterm.BulletListItem{Level: 0, Text: fmt.Sprintf("%s: %s", r.Name, r.Hint)})
+			}
+		}
+		tableData = append(tableData, []string{r.Name, clr.Sprint(status), r.Message})
+	}
+
+	renderErr := pterm.DefaultTable.WithHasHeader().WithData(tableData).Render()
+	if renderErr != nil {
+		return renderErr
+	}
+
+	if len(hints) > 0 {
+		pterm.Println("Hints:")
+		renderErr = pterm.DefaultBulletList.WithBullet("*").WithItems(hints).Render()
+		if renderErr != nil {
+			return renderErr
+		}
+	}
+
+	return err
+}
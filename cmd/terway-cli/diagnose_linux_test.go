@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/AliyunContainerService/terway/plugin/driver/types"
+	"github.com/AliyunContainerService/terway/rpc"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeMetadata struct {
+	err  error
+	enis map[string]fakeENI
+}
+
+type fakeENI struct {
+	primary net.IP
+	ipv4s   []net.IP
+	ipv6s   []net.IP
+}
+
+func (f *fakeMetadata) GetENIsMAC() ([]string, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	var macs []string
+	for mac := range f.enis {
+		macs = append(macs, mac)
+	}
+	return macs, nil
+}
+
+func (f *fakeMetadata) GetENIPrimaryIP(mac string) (net.IP, error) {
+	return f.enis[mac].primary, nil
+}
+
+func (f *fakeMetadata) GetENIPrivateIPs(mac string) ([]net.IP, error) {
+	return f.enis[mac].ipv4s, nil
+}
+
+func (f *fakeMetadata) GetENIPrivateIPv6IPs(mac string) ([]net.IP, error) {
+	return f.enis[mac].ipv6s, nil
+}
+
+func TestParsePodKey(t *testing.T) {
+	tests := []struct {
+		key       string
+		namespace string
+		name      string
+		wantErr   bool
+	}{
+		{key: "default/foo", namespace: "default", name: "foo"},
+		{key: "foo", wantErr: true},
+		{key: "/foo", wantErr: true},
+		{key: "default/", wantErr: true},
+		{key: "default/foo/bar", wantErr: true},
+	}
+	for _, tt := range tests {
+		namespace, name, err := parsePodKey(tt.key)
+		if tt.wantErr {
+			assert.Error(t, err, tt.key)
+			continue
+		}
+		assert.NoError(t, err, tt.key)
+		assert.Equal(t, tt.namespace, namespace)
+		assert.Equal(t, tt.name, name)
+	}
+}
+
+func TestDiagnoseMetadata(t *testing.T) {
+	meta := &fakeMetadata{enis: map[string]fakeENI{
+		"00:16:3e:00:00:01": {
+			primary: net.ParseIP("192.168.0.1"),
+			ipv4s:   []net.IP{net.ParseIP("192.168.0.1"), net.ParseIP("192.168.0.2")},
+			ipv6s:   []net.IP{net.ParseIP("fd00::2")},
+		},
+	}}
+	netConf := func(mac, ipv4, ipv6 string, trunk bool) *rpc.NetConf {
+		return &rpc.NetConf{
+			BasicInfo: &rpc.BasicInfo{PodIP: &rpc.IPSet{IPv4: ipv4, IPv6: ipv6}},
+			ENIInfo:   &rpc.ENIInfo{MAC: mac, Trunk: trunk},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		meta    *fakeMetadata
+		netConf *rpc.NetConf
+		dp      types.DataPath
+		passed  bool
+		message string
+	}{
+		{
+			name:    "shared eni",
+			meta:    meta,
+			netConf: netConf("00:16:3E:00:00:01", "192.168.0.2", "fd00::2", false),
+			dp:      types.IPVlan,
+			passed:  true,
+		},
+		{
+			name:    "exclusive eni use the primary ip",
+			meta:    meta,
+			netConf: netConf("00:16:3e:00:00:01", "192.168.0.2", "", false),
+			dp:      types.ExclusiveENI,
+			message: "ip 192.168.0.2 is not assigned to eni 00:16:3e:00:00:01",
+		},
+		{
+			name:    "eni is not attached",
+			meta:    meta,
+			netConf: netConf("00:16:3e:00:00:02", "192.168.0.2", "", false),
+			dp:      types.IPVlan,
+			message: "eni 00:16:3e:00:00:02 is not attached",
+		},
+		{
+			name:    "ip is released",
+			meta:    meta,
+			netConf: netConf("00:16:3e:00:00:01", "192.168.0.3", "fd00::3", false),
+			dp:      types.PolicyRoute,
+			message: "ip 192.168.0.3 is not assigned to eni 00:16:3e:00:00:01; ip fd00::3 is not assigned to eni 00:16:3e:00:00:01",
+		},
+		{
+			name:    "pod ip of trunk is not checked",
+			meta:    meta,
+			netConf: netConf("00:16:3e:00:00:01", "192.168.0.3", "", true),
+			dp:      types.Vlan,
+			passed:  true,
+		},
+		{
+			name:    "metadata error",
+			meta:    &fakeMetadata{err: fmt.Errorf("timeout")},
+			netConf: netConf("00:16:3e:00:00:01", "192.168.0.2", "", false),
+			dp:      types.IPVlan,
+			message: "error get enis from metadata, timeout",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := diagnoseMetadata(tt.meta, "eth0", tt.netConf, tt.dp)
+			assert.Equal(t, "[eth0] eni metadata", result.Name)
+			assert.Equal(t, tt.passed, result.Passed)
+			assert.Equal(t, tt.message, result.Message)
+			if !tt.passed {
+				assert.Equal(t, hintMetadata, result.Hint)
+			}
+		})
+	}
+}
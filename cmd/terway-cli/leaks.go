@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/AliyunContainerService/terway/rpc"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+const (
+	leaksTableHeaderType     = "Type"
+	leaksTableHeaderPodName  = "Pod Name"
+	leaksTableHeaderResource = "Res ID"
+	leaksTableHeaderMessage  = "Message"
+	leaksTableHeaderRepaired = "Repaired"
+
+	leaksStringExists = "unrepaired leaks exist"
+)
+
+var (
+	leakTypes = map[rpc.LeakType]string{
+		rpc.LeakType_LeakTypeStaleRelation: "StaleRelation",
+		rpc.LeakType_LeakTypeOrphanInUse:   "OrphanInUse",
+		rpc.LeakType_LeakTypeMissingLocal:  "MissingLocal",
+		rpc.LeakType_LeakTypeMissingRemote: "MissingRemote",
+		rpc.LeakType_LeakTypeUntracked:     "Untracked",
+		rpc.LeakType_LeakTypeInvalid:       "Invalid",
+	}
+
+	leaksCmd = &cobra.Command{
+		Use:   "leaks",
+		Short: "find the enis/ips not backing any pod on this node.",
+		Long: "cross-check enis/ips in metadata, resource db, pool and pods on this node. " +
+			"with --repair, stale relations and orphan in use resources are released, the others are only reported.",
+		RunE: runLeaks,
+	}
+
+	leaksRepair bool
+)
+
+func init() {
+	leaksCmd.Flags().BoolVar(&leaksRepair, "repair", false, "release the stale relations and orphan in use resources")
+	rootCmd.AddCommand(leaksCmd)
+}
+
+func runLeaks(cmd *cobra.Command, args []string) error {
+	result, err := client.GetLeaks(ctx, &rpc.LeakRequest{Repair: leaksRepair})
+	if err != nil {
+		return err
+	}
+
+	output := LeaksOutput{Leaks: make([]LeakEntry, 0, len(result.Leaks))}
+	for _, v := range result.Leaks {
+		if !v.Repaired {
+			err = fmt.Errorf(leaksStringExists)
+		}
+		output.Leaks = append(output.Leaks, LeakEntry{
+			Type:     leakTypes[v.Type],
+			Pod:      v.PodName,
+			Resource: v.ResourceID,
+			Message:  v.Message,
+			Repaired: v.Repaired,
+		})
+	}
+
+	if ok, printErr := printStructured(output); ok {
+		if printErr != nil {
+			return printErr
+		}
+		return err
+	}
+
+	tableData := pterm.TableData{
+		{
+			leaksTableHeaderType,
+			leaksTableHeaderPodName,
+			leaksTableHeaderResource,
+			leaksTableHeaderMessage,
+			leaksTableHeaderRepaired,
+		},
+	}
+	for _, v := range output.Leaks {
+		clr := pterm.FgLightRed
+		if v.Repaired {
+			clr = pterm.FgLightGreen
+		}
+		tableData = append(tableData, []string{
+			clr.Sprint(v.Type),
+			clr.Sprint(v.Pod),
+			clr.Sprint(v.Resource),
+			clr.Sprint(v.Message),
+			clr.Sprint(v.Repaired),
+		})
+	}
+
+	if err := pterm.DefaultTable.WithHasHeader().WithData(tableData).Render(); err != nil {
+		return err
+	}
+
+	return err
+}
@@ -30,6 +30,10 @@ var (
 		Use:   "terway-cli",
 		Short: "terway-cil is a command tool for diagnosing terway & network internal status.",
 		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			if err := validateOutputFormat(); err != nil {
+				return err
+			}
+
 			// create connection and grpc client
 			ctx, contextCancel = context.WithTimeout(context.Background(), connTimeout)
 			conn, err := grpc.DialContext(ctx, defaultSocketPath, grpc.WithInsecure(), grpc.WithContextDialer(
@@ -92,6 +96,7 @@ var (
 )
 
 func init() {
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", outputTable, "output format, one of json|yaml|table")
 	rootCmd.AddCommand(listCmd, showCmd, mappingCmd, executeCmd, metadataCmd)
 }
 
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/AliyunContainerService/terway/rpc"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+var (
+	outputFormat string
+
+	outputWriter io.Writer = os.Stdout
+)
+
+// the schemas below are the stable output of each command in json/yaml format
+
+// ListOutput is the output of list command
+type ListOutput struct {
+	// Type is empty when types are listed
+	Type  string   `json:"type,omitempty"`
+	Items []string `json:"items"`
+}
+
+// KeyValue is a config or trace entry
+type KeyValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// ShowOutput is the output of show command
+type ShowOutput struct {
+	Type   string     `json:"type"`
+	Name   string     `json:"name"`
+	Config []KeyValue `json:"config"`
+	Trace  []KeyValue `json:"trace"`
+}
+
+// MappingEntry is a resource mapping, Type is one of Normal, Idle, ERROR
+type MappingEntry struct {
+	Type     string `json:"type"`
+	Pod      string `json:"pod"`
+	Resource string `json:"resource"`
+	Factory  string `json:"factory"`
+}
+
+// MappingOutput is the output of mapping command
+type MappingOutput struct {
+	Entries []MappingEntry `json:"entries"`
+}
+
+// ExecuteOutput is the output of execute command
+type ExecuteOutput struct {
+	Type     string   `json:"type"`
+	Name     string   `json:"name"`
+	Command  string   `json:"command"`
+	Args     []string `json:"args"`
+	Messages []string `json:"messages"`
+}
+
+// MetadataENI is an eni in metadata
+type MetadataENI struct {
+	MAC            string   `json:"mac"`
+	Primary        bool     `json:"primary"`
+	Interface      string   `json:"interface"`
+	PrimaryIPv4    string   `json:"primaryIPv4"`
+	SecondaryIPv4s []string `json:"secondaryIPv4s"`
+	IPv6s          []string `json:"ipv6s"`
+}
+
+// MetadataOutput is the output of metadata command
+type MetadataOutput struct {
+	VSwitch string        `json:"vswitch"`
+	ENIs    []MetadataENI `json:"enis"`
+}
+
+// LeakEntry is a leaked resource, Type is one of StaleRelation, OrphanInUse, MissingLocal, MissingRemote, Untracked, Invalid
+type LeakEntry struct {
+	Type     string `json:"type"`
+	Pod      string `json:"pod"`
+	Resource string `json:"resource"`
+	Message  string `json:"message"`
+	Repaired bool   `json:"repaired"`
+}
+
+// LeaksOutput is the output of leaks command
+type LeaksOutput struct {
+	Leaks []LeakEntry `json:"leaks"`
+}
+
+// DiagnoseResult is a check item of diagnose command
+type DiagnoseResult struct {
+	Name    string `json:"name"`
+	Passed  bool   `json:"passed"`
+	Message string `json:"message,omitempty"`
+	Hint    string `json:"hint,omitempty"`
+}
+
+// DiagnoseOutput is the output of diagnose command, Passed is false if any check failed
+type DiagnoseOutput struct {
+	Passed  bool             `json:"passed"`
+	Results []DiagnoseResult `json:"results"`
+}
+
+// DBBucket is a bucket in the resource db, Versions is the count of records in each schema version
+type DBBucket struct {
+	Name     string      `json:"name"`
+	Records  int         `json:"records"`
+	Versions map[int]int `json:"versions"`
+	Invalid  int         `json:"invalid"`
+}
+
+// DBInspectOutput is the output of db inspect command,
+// Snapshot is true if the db is held by the daemon and a copy of it is inspected
+type DBInspectOutput struct {
+	Path          string     `json:"path"`
+	Size          int64      `json:"size"`
+	SchemaVersion int        `json:"schemaVersion"`
+	Snapshot      bool       `json:"snapshot"`
+	Buckets       []DBBucket `json:"buckets"`
+	Errors        []string   `json:"errors"`
+}
+
+func validateOutputFormat() error {
+	switch outputFormat {
+	case outputTable, outputJSON, outputYAML:
+		return nil
+	}
+	return fmt.Errorf("unsupported output format %s, must be one of %s|%s|%s", outputFormat, outputJSON, outputYAML, outputTable)
+}
+
+// printStructured print v in json or yaml, return false if the output is table
+func printStructured(v interface{}) (bool, error) {
+	var out []byte
+	var err error
+	switch outputFormat {
+	case outputJSON:
+		out, err = json.MarshalIndent(v, "", "  ")
+		out = append(out, '\n')
+	case outputYAML:
+		out, err = yaml.Marshal(v)
+	default:
+		return false, nil
+	}
+	if err != nil {
+		return true, err
+	}
+	_, err = outputWriter.Write(out)
+	return true, err
+}
+
+func toKeyValues(entries []*rpc.MapKeyValueEntry) []KeyValue {
+	result := make([]KeyValue, 0, len(entries))
+	for _, v := range entries {
+		result = append(result, KeyValue{Key: v.Key, Value: v.Value})
+	}
+	return result
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintStructured(t *testing.T) {
+	output := MappingOutput{Entries: []MappingEntry{
+		{Type: "Normal", Pod: "default/foo", Resource: "eni-1.192.168.0.1", Factory: "eni-1.192.168.0.1"},
+	}}
+
+	tests := []struct {
+		format  string
+		printed bool
+		want    string
+	}{
+		{
+			format:  outputJSON,
+			printed: true,
+			want: `{
+  "entries": [
+    {
+      "type": "Normal",
+      "pod": "default/foo",
+      "resource": "eni-1.192.168.0.1",
+      "factory": "eni-1.192.168.0.1"
+    }
+  ]
+}
+`,
+		},
+		{
+			format:  outputYAML,
+			printed: true,
+			want: `entries:
+- factory: eni-1.192.168.0.1
+  pod: default/foo
+  resource: eni-1.192.168.0.1
+  type: Normal
+`,
+		},
+		{
+			format:  outputTable,
+			printed: false,
+			want:    "",
+		},
+	}
+
+	defer func(format string, w io.Writer) {
+		outputFormat = format
+		outputWriter = w
+	}(outputFormat, outputWriter)
+	for _, tt := range tests {
+		buf := &bytes.Buffer{}
+		outputWriter = buf
+		outputFormat = tt.format
+
+		printed, err := printStructured(output)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.printed, printed)
+		assert.Equal(t, tt.want, buf.String())
+	}
+}
+
+func TestValidateOutputFormat(t *testing.T) {
+	defer func(format string) { outputFormat = format }(outputFormat)
+
+	for _, format := range []string{outputJSON, outputYAML, outputTable} {
+		outputFormat = format
+		assert.NoError(t, validateOutputFormat())
+	}
+
+	outputFormat = "xml"
+	assert.Error(t, validateOutputFormat())
+}
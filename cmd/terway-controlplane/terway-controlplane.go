@@ -17,29 +17,37 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"math/rand"
+	"os"
 	"time"
 
 	aliyun "github.com/AliyunContainerService/terway/pkg/aliyun/client"
 	"github.com/AliyunContainerService/terway/pkg/aliyun/credential"
 	"github.com/AliyunContainerService/terway/pkg/apis/crds"
 	networkv1beta1 "github.com/AliyunContainerService/terway/pkg/apis/network.alibabacloud.com/v1beta1"
+	"github.com/AliyunContainerService/terway/pkg/audit"
 	"github.com/AliyunContainerService/terway/pkg/backoff"
 	"github.com/AliyunContainerService/terway/pkg/cert"
 	register "github.com/AliyunContainerService/terway/pkg/controller"
 	_ "github.com/AliyunContainerService/terway/pkg/controller/all"
 	"github.com/AliyunContainerService/terway/pkg/controller/delegate"
+	"github.com/AliyunContainerService/terway/pkg/controller/shard"
 	"github.com/AliyunContainerService/terway/pkg/controller/vswitch"
 	"github.com/AliyunContainerService/terway/pkg/controller/webhook"
 	"github.com/AliyunContainerService/terway/pkg/metric"
+	"github.com/AliyunContainerService/terway/pkg/provider"
+	"github.com/AliyunContainerService/terway/pkg/telemetry"
 	"github.com/AliyunContainerService/terway/pkg/utils"
 	"github.com/AliyunContainerService/terway/pkg/version"
 	"github.com/AliyunContainerService/terway/types/controlplane"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
-	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2/klogr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
@@ -56,6 +64,17 @@ func init() {
 	utilruntime.Must(networkv1beta1.AddToScheme(scheme))
 
 	metrics.Registry.MustRegister(metric.OpenAPILatency)
+	metrics.Registry.MustRegister(metric.OpenAPILimiterQueueDepth)
+	metrics.Registry.MustRegister(metric.OpenAPILimiterWaitTime)
+	metrics.Registry.MustRegister(metric.OpenAPILimiterRate)
+	metrics.Registry.MustRegister(metric.OpenAPIThrottled)
+	metrics.Registry.MustRegister(metric.CredentialExpiry)
+	metrics.Registry.MustRegister(metric.OpenAPIErrors)
+	metrics.Registry.MustRegister(metric.OpenAPICircuitBreakerState)
+	metrics.Registry.MustRegister(metric.GCCandidates)
+	metrics.Registry.MustRegister(metric.GCActions)
+	metrics.Registry.MustRegister(metric.ShardOwned)
+	metrics.Registry.MustRegister(metric.ShardMembers)
 }
 
 func main() {
@@ -99,12 +118,7 @@ func main() {
 		panic(err)
 	}
 
-	clientSet, err := credential.NewClientSet(string(cfg.Credential.AccessKey), string(cfg.Credential.AccessSecret), cfg.RegionID, cfg.CredentialPath, cfg.SecretNamespace, cfg.SecretName)
-	if err != nil {
-		panic(err)
-	}
-
-	aliyunClient, err := aliyun.New(clientSet, flowcontrol.NewTokenBucketRateLimiter(cfg.ReadOnlyQPS, cfg.ReadOnlyBurst), flowcontrol.NewTokenBucketRateLimiter(cfg.MutatingQPS, cfg.MutatingBurst))
+	aliyunClient, clientSet, err := newCloudClient(cfg)
 	if err != nil {
 		panic(err)
 	}
@@ -125,6 +139,28 @@ func main() {
 		panic(err)
 	}
 
+	if clientSet != nil {
+		recorder := mgr.GetEventRecorderFor("TerwayCredential")
+		ref := credentialEventRef(cfg)
+		clientSet.SetEventRecorder(func(eventType, reason, message string) {
+			recorder.Event(ref, eventType, reason, message)
+		})
+		go clientSet.Watch(ctx.Done())
+	}
+
+	err = setupAudit(cfg, mgr.GetEventRecorderFor("TerwayAudit"))
+	if err != nil {
+		panic(err)
+	}
+
+	shutdownTelemetry, err := telemetry.Setup(ctx, "terway-controlplane", telemetry.Config{
+		Endpoint:      cfg.TracingEndpoint,
+		SamplingRatio: cfg.TracingSamplingRatio,
+	})
+	if err != nil {
+		panic(err)
+	}
+
 	err = mgr.AddHealthzCheck("healthz", healthz.Ping)
 	if err != nil {
 		panic(err)
@@ -142,6 +178,18 @@ func main() {
 		panic(err)
 	}
 
+	if cfg.ShardCount > 0 {
+		shardMgr, err := newShardManager(cfg)
+		if err != nil {
+			panic(err)
+		}
+		err = mgr.Add(shardMgr)
+		if err != nil {
+			panic(err)
+		}
+		shard.SetDefault(shardMgr)
+	}
+
 	ctrlCtx := &register.ControllerCtx{
 		Config:         cfg,
 		VSwitchPool:    vSwitchCtrl,
@@ -161,7 +209,85 @@ func main() {
 
 	log.Info("controller started")
 	err = mgr.Start(ctx)
+
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = shutdownTelemetry(flushCtx)
 	if err != nil {
 		panic(err)
 	}
 }
+
+// newCloudClient create the client of the provider, the credential is only used by aliyun
+func newCloudClient(cfg *controlplane.Config) (register.Interface, *credential.ClientMgr, error) {
+	if cfg.Provider == provider.NameStatic {
+		return provider.NewStaticControlPlane(cfg.StaticProvider), nil, nil
+	}
+
+	clientSet, err := credential.NewClientSet(string(cfg.Credential.AccessKey), string(cfg.Credential.AccessSecret), cfg.RegionID, cfg.CredentialPath, cfg.SecretNamespace, cfg.SecretName, cfg.Credential.GetOIDCConfig())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	breakerCooldown, _ := time.ParseDuration(cfg.CircuitBreakerCooldown)
+	aliyunClient, err := aliyun.New(clientSet, aliyun.NewLimiter("readOnly", cfg.ReadOnlyQPS, cfg.ReadOnlyBurst), aliyun.NewLimiter("mutating", cfg.MutatingQPS, cfg.MutatingBurst),
+		aliyun.NewBreakerSet(cfg.CircuitBreakerThreshold, breakerCooldown))
+	if err != nil {
+		return nil, nil, err
+	}
+	return aliyunClient, clientSet, nil
+}
+
+// credentialEventRef return the object for credential events, the secret if used, otherwise the controlplane pod
+func credentialEventRef(cfg *controlplane.Config) *corev1.ObjectReference {
+	if cfg.CredentialPath == "" && cfg.SecretNamespace != "" && cfg.SecretName != "" {
+		return &corev1.ObjectReference{Kind: "Secret", APIVersion: "v1", Namespace: cfg.SecretNamespace, Name: cfg.SecretName}
+	}
+	name, _ := os.Hostname()
+	return &corev1.ObjectReference{Kind: "Pod", APIVersion: "v1", Namespace: cfg.ControllerNamespace, Name: name}
+}
+
+// setupAudit record cloud mutations to the audit log file, and to pod events if enabled
+func setupAudit(cfg *controlplane.Config, recorder record.EventRecorder) error {
+	var sinks []audit.Sink
+	if cfg.AuditLogPath != "" {
+		fileSink, err := audit.NewFileSink(cfg.AuditLogPath, cfg.AuditLogMaxSize, *cfg.AuditLogMaxBackups)
+		if err != nil {
+			return fmt.Errorf("error setup audit log, %w", err)
+		}
+		sinks = append(sinks, fileSink)
+	}
+	if cfg.AuditEvent {
+		sinks = append(sinks, audit.NewEventSink(func(r *audit.Record, eventType, reason, message string) {
+			// controlplane is not bound to a node, only record for pods
+			if r.Pod == "" {
+				return
+			}
+			recorder.Event(&corev1.ObjectReference{Kind: "Pod", APIVersion: "v1", Namespace: r.Namespace, Name: r.Pod}, eventType, reason, message)
+		}))
+	}
+	if len(sinks) == 0 {
+		return nil
+	}
+	name, _ := os.Hostname()
+	audit.SetDefault("controlplane/"+name, audit.Multi(sinks...))
+	return nil
+}
+
+func newShardManager(cfg *controlplane.Config) (*shard.Manager, error) {
+	identity, err := os.Hostname()
+	if err != nil {
+		return nil, fmt.Errorf("error get hostname, %w", err)
+	}
+	leaseDuration, err := time.ParseDuration(cfg.ShardLeaseDuration)
+	if err != nil {
+		return nil, err
+	}
+	return shard.New(shard.Config{
+		Name:          cfg.ControllerName,
+		Namespace:     cfg.ControllerNamespace,
+		Identity:      identity,
+		Shards:        cfg.ShardCount,
+		LeaseDuration: leaseDuration,
+	}, utils.K8sClient), nil
+}
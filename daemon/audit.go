@@ -0,0 +1,40 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/AliyunContainerService/terway/pkg/audit"
+	"github.com/AliyunContainerService/terway/types/daemon"
+)
+
+const (
+	defaultAuditLogMaxSize    = 100
+	defaultAuditLogMaxBackups = 5
+)
+
+// setupAudit record cloud mutations to the audit log file, and to node or pod events if enabled
+func setupAudit(cfg *daemon.Config, k8s Kubernetes) error {
+	var sinks []audit.Sink
+	if cfg.AuditLogPath != "" {
+		fileSink, err := audit.NewFileSink(cfg.AuditLogPath, cfg.AuditLogMaxSize, *cfg.AuditLogMaxBackups)
+		if err != nil {
+			return fmt.Errorf("error setup audit log, %w", err)
+		}
+		sinks = append(sinks, fileSink)
+	}
+	if cfg.AuditEvent {
+		sinks = append(sinks, audit.NewEventSink(func(r *audit.Record, eventType, reason, message string) {
+			if r.Pod != "" {
+				_ = k8s.RecordPodEvent(r.Pod, r.Namespace, eventType, reason, message)
+				return
+			}
+			k8s.RecordNodeEvent(eventType, reason, message)
+		}))
+	}
+	if len(sinks) == 0 {
+		return nil
+	}
+	audit.SetDefault("node/"+os.Getenv("NODE_NAME"), audit.Multi(sinks...))
+	return nil
+}
@@ -14,15 +14,18 @@ import (
 	"time"
 
 	"github.com/AliyunContainerService/terway/pkg/aliyun"
-	"github.com/AliyunContainerService/terway/pkg/aliyun/client"
 	podENITypes "github.com/AliyunContainerService/terway/pkg/apis/network.alibabacloud.com/v1beta1"
+	"github.com/AliyunContainerService/terway/pkg/audit"
 	"github.com/AliyunContainerService/terway/pkg/backoff"
 	terwayIP "github.com/AliyunContainerService/terway/pkg/ip"
+	"github.com/AliyunContainerService/terway/pkg/ipam"
 	"github.com/AliyunContainerService/terway/pkg/link"
 	"github.com/AliyunContainerService/terway/pkg/logger"
 	"github.com/AliyunContainerService/terway/pkg/metric"
 	"github.com/AliyunContainerService/terway/pkg/pool"
+	"github.com/AliyunContainerService/terway/pkg/provider"
 	"github.com/AliyunContainerService/terway/pkg/storage"
+	"github.com/AliyunContainerService/terway/pkg/telemetry"
 	"github.com/AliyunContainerService/terway/pkg/tracing"
 	"github.com/AliyunContainerService/terway/pkg/utils"
 	"github.com/AliyunContainerService/terway/rpc"
@@ -32,8 +35,11 @@ import (
 	"github.com/containernetworking/cni/libcni"
 	containertypes "github.com/containernetworking/cni/pkg/types"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
 	corev1 "k8s.io/api/core/v1"
 	k8sErr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 )
 
@@ -45,6 +51,8 @@ const (
 	gcPeriod        = 5 * time.Minute
 	poolCheckPeriod = 10 * time.Minute
 
+	defaultENIConsolidateThreshold = 0.3
+
 	conditionFalse = "false"
 	conditionTrue  = "true"
 
@@ -55,6 +63,7 @@ const (
 	tracingKeyKubeConfig       = "kubeconfig"
 	tracingKeyMaster           = "master"
 	tracingKeyPendingPodsCount = "pending_pods_count"
+	tracingKeyLimitSource      = "limit_source"
 
 	commandMapping = "mapping"
 
@@ -72,6 +81,7 @@ type networkService struct {
 	kubeConfig     string
 	master         string
 	k8s            Kubernetes
+	api            ipam.API
 	resourceDB     storage.Storage
 	vethResMgr     ResourceManager
 	eniResMgr      ResourceManager
@@ -85,11 +95,22 @@ type networkService struct {
 	cniBinPath string
 
 	enableTrunk bool
+	// enablePodEIP the eip is managed by the PodEIP cr
+	enablePodEIP bool
 
 	ipFamily     *types.IPFamily
 	ipamType     types.IPAMType
 	eniCapPolicy types.ENICapPolicy
 
+	// shutdownTelemetry flush the spans on exit
+	shutdownTelemetry func(context.Context) error
+	// tracePropagateRatio is the ratio of the sampled PodENI waits propagated to terway-controlplane
+	tracePropagateRatio float64
+	// nodeStatus mirror the node network status to the NodeNetworkStatus cr, nil if disabled
+	nodeStatus *nodeStatusSyncer
+	// availability set the node condition by the allocation results, nil if disabled
+	availability *networkAvailability
+
 	rpc.UnimplementedTerwayBackendServer
 }
 
@@ -153,6 +174,7 @@ func (n *networkService) allocateENI(ctx *networkContext, old *types.PodResource
 	}
 
 	res, err := n.eniResMgr.Allocate(ctx, oldENIID)
+	n.availability.observe(err)
 	if err != nil {
 		return nil, err
 	}
@@ -173,6 +195,7 @@ func (n *networkService) allocateENIMultiIP(ctx *networkContext, old *types.PodR
 	}
 
 	res, err := n.eniIPResMgr.Allocate(ctx, oldENIIPID)
+	n.availability.observe(err)
 	if err != nil {
 		return nil, err
 	}
@@ -200,6 +223,8 @@ func (n *networkService) allocateEIP(ctx *networkContext, old *types.PodResource
 }
 
 func (n *networkService) AllocIP(ctx context.Context, r *rpc.AllocIPRequest) (*rpc.AllocIPReply, error) {
+	telemetry.SetPodAttributes(ctx, r.K8SPodNamespace, r.K8SPodName, r.K8SPodInfraContainerId)
+	defer n.notifyNodeStatus()
 	serviceLog.WithFields(map[string]interface{}{
 		"pod":         podInfoKey(r.K8SPodNamespace, r.K8SPodName),
 		"containerID": r.K8SPodInfraContainerId,
@@ -233,7 +258,7 @@ func (n *networkService) AllocIP(ctx context.Context, r *rpc.AllocIPRequest) (*r
 
 	// 1. Init Context
 	networkContext := &networkContext{
-		Context:    ctx,
+		Context:    audit.WithPod(ctx, r.K8SPodNamespace, r.K8SPodName),
 		resources:  []types.ResourceItem{},
 		pod:        podinfo,
 		k8sService: n.k8s,
@@ -276,6 +301,9 @@ func (n *networkService) AllocIP(ctx context.Context, r *rpc.AllocIPRequest) (*r
 				}
 				_ = n.k8s.PatchPodIPInfo(podinfo, strings.Join(ips, ","))
 			}
+			if n.enablePodEIP && podinfo.EipInfo.PodEip {
+				n.checkPodEIP(podinfo)
+			}
 		}
 	}()
 
@@ -294,10 +322,13 @@ func (n *networkService) AllocIP(ctx context.Context, r *rpc.AllocIPRequest) (*r
 	case podNetworkTypeENIMultiIP:
 		allocIPReply.IPType = rpc.IPType_TypeENIMultiIP
 		var netConfs []*rpc.NetConf
-		netConfs, err = n.multiIPFromCRD(podinfo, true)
+		netConfs, err = n.multiIPFromCRD(networkContext, podinfo, true)
 		if err != nil {
 			return nil, err
 		}
+		if len(netConfs) > 0 {
+			n.setPodENIDatapathReady(podinfo)
+		}
 		netConf = append(netConf, netConfs...)
 
 		defaultIfSet := false
@@ -371,10 +402,11 @@ func (n *networkService) AllocIP(ctx context.Context, r *rpc.AllocIPRequest) (*r
 		allocIPReply.IPType = rpc.IPType_TypeVPCENI
 		if n.ipamType == types.IPAMTypeCRD {
 			var netConfs []*rpc.NetConf
-			netConfs, err = n.exclusiveENIFromCRD(podinfo, true)
+			netConfs, err = n.exclusiveENIFromCRD(networkContext, podinfo, true)
 			if err != nil {
 				return nil, err
 			}
+			n.setPodENIDatapathReady(podinfo)
 			netConf = append(netConf, netConfs...)
 		} else {
 			var eni *types.ENI
@@ -488,6 +520,8 @@ func (n *networkService) AllocIP(ctx context.Context, r *rpc.AllocIPRequest) (*r
 }
 
 func (n *networkService) ReleaseIP(ctx context.Context, r *rpc.ReleaseIPRequest) (*rpc.ReleaseIPReply, error) {
+	telemetry.SetPodAttributes(ctx, r.K8SPodNamespace, r.K8SPodName, r.K8SPodInfraContainerId)
+	defer n.notifyNodeStatus()
 	serviceLog.WithFields(map[string]interface{}{
 		"pod":         podInfoKey(r.K8SPodNamespace, r.K8SPodName),
 		"containerID": r.K8SPodInfraContainerId,
@@ -519,7 +553,7 @@ func (n *networkService) ReleaseIP(ctx context.Context, r *rpc.ReleaseIPRequest)
 
 	// 1. Init Context
 	netCtx := &networkContext{
-		Context:    ctx,
+		Context:    audit.WithPod(ctx, r.K8SPodNamespace, r.K8SPodName),
 		resources:  []types.ResourceItem{},
 		pod:        podinfo,
 		k8sService: n.k8s,
@@ -565,6 +599,7 @@ func (n *networkService) ReleaseIP(ctx context.Context, r *rpc.ReleaseIPRequest)
 			if err = mgr.Release(netCtx, res); err != nil && err != pool.ErrInvalidState {
 				return nil, errors.Wrapf(err, "error release request network resource for: %+v", r)
 			}
+			n.availability.released()
 			if err = n.deletePodResource(podinfo); err != nil {
 				return nil, errors.Wrapf(err, "error delete resource from db: %+v", r)
 			}
@@ -580,6 +615,7 @@ func (n *networkService) ReleaseIP(ctx context.Context, r *rpc.ReleaseIPRequest)
 }
 
 func (n *networkService) GetIPInfo(ctx context.Context, r *rpc.GetInfoRequest) (*rpc.GetInfoReply, error) {
+	telemetry.SetPodAttributes(ctx, r.K8SPodNamespace, r.K8SPodName, r.K8SPodInfraContainerId)
 	serviceLog.Debugf("GetIPInfo request: %+v", r)
 	// 0. Get pod Info
 	podinfo, err := n.k8s.GetPod(r.K8SPodNamespace, r.K8SPodName)
@@ -625,7 +661,7 @@ func (n *networkService) GetIPInfo(ctx context.Context, r *rpc.GetInfoRequest) (
 	switch podinfo.PodNetworkType {
 	case podNetworkTypeENIMultiIP:
 		getIPInfoResult.IPType = rpc.IPType_TypeENIMultiIP
-		netConfs, err2 := n.multiIPFromCRD(podinfo, false)
+		netConfs, err2 := n.multiIPFromCRD(networkContext, podinfo, false)
 		if err != nil {
 			if k8sErr.IsNotFound(err2) {
 				getIPInfoResult.Error = rpc.Error_ErrCRDNotFound
@@ -696,7 +732,7 @@ func (n *networkService) GetIPInfo(ctx context.Context, r *rpc.GetInfoRequest) (
 	case podNetworkTypeVPCENI:
 		getIPInfoResult.IPType = rpc.IPType_TypeVPCENI
 		if n.ipamType == types.IPAMTypeCRD {
-			netConfs, err2 := n.exclusiveENIFromCRD(podinfo, false)
+			netConfs, err2 := n.exclusiveENIFromCRD(networkContext, podinfo, false)
 			if err2 != nil {
 				if k8sErr.IsNotFound(err2) {
 					getIPInfoResult.Error = rpc.Error_ErrCRDNotFound
@@ -995,12 +1031,17 @@ func (n *networkService) startPeriodCheck() {
 
 // requestCRD get crd from api
 // note: need tolerate crd is not exist, so contained can del pod normally
-func (n *networkService) requestCRD(podInfo *types.PodInfo, waitReady bool) (*podENITypes.PodENI, error) {
+func (n *networkService) requestCRD(ctx context.Context, podInfo *types.PodInfo, waitReady bool) (*podENITypes.PodENI, error) {
 	if n.ipamType == types.IPAMTypeCRD || podInfo.PodENI && n.enableTrunk {
 		var podENI *podENITypes.PodENI
 		var err error
 		if waitReady {
+			var span trace.Span
+			ctx, span = telemetry.Start(ctx, "WaitPodENI")
+			done := n.propagateTrace(ctx, podInfo)
 			podENI, err = n.k8s.WaitPodENIInfo(podInfo)
+			done()
+			telemetry.End(span, err)
 		} else {
 			podENI, err = n.k8s.GetPodENIInfo(podInfo)
 		}
@@ -1016,11 +1057,32 @@ func (n *networkService) requestCRD(podInfo *types.PodInfo, waitReady bool) (*po
 	return nil, nil
 }
 
-func (n *networkService) multiIPFromCRD(podInfo *types.PodInfo, waitReady bool) ([]*rpc.NetConf, error) {
+// checkPodEIP report the eip reconciled by terway-controlplane, the daemon never allocate eip in this mode
+func (n *networkService) checkPodEIP(podInfo *types.PodInfo) {
+	podEIP, err := n.k8s.GetPodEIP(podInfo)
+	if err != nil {
+		// the podEIP is created by controlplane once the pod is seen
+		serviceLog.Debugf("error get podEIP for %s/%s, %v", podInfo.Namespace, podInfo.Name, err)
+		return
+	}
+	if podEIP.Status.Phase == podENITypes.PodEIPPhaseFailed {
+		_ = n.k8s.RecordPodEvent(podInfo.Name, podInfo.Namespace, eventTypeWarning, "EIPFailed", podEIP.Status.Message)
+	}
+}
+
+// setPodENIDatapathReady report the podENI is consumed by this node, failure is not fatal for pod creation
+func (n *networkService) setPodENIDatapathReady(podInfo *types.PodInfo) {
+	err := n.k8s.SetPodENIDatapathReady(podInfo)
+	if err != nil {
+		serviceLog.Warnf("error set podENI datapath ready for %s/%s, %v", podInfo.Namespace, podInfo.Name, err)
+	}
+}
+
+func (n *networkService) multiIPFromCRD(ctx context.Context, podInfo *types.PodInfo, waitReady bool) ([]*rpc.NetConf, error) {
 	var netConf []*rpc.NetConf
 
 	var nodeTrunkENI *types.ENI
-	podEni, err := n.requestCRD(podInfo, waitReady)
+	podEni, err := n.requestCRD(ctx, podInfo, waitReady)
 	if err != nil {
 		return nil, fmt.Errorf("error wait pod eni info, %w", err)
 	}
@@ -1090,11 +1152,11 @@ func (n *networkService) multiIPFromCRD(podInfo *types.PodInfo, waitReady bool)
 	return netConf, nil
 }
 
-func (n *networkService) exclusiveENIFromCRD(podInfo *types.PodInfo, waitReady bool) ([]*rpc.NetConf, error) {
+func (n *networkService) exclusiveENIFromCRD(ctx context.Context, podInfo *types.PodInfo, waitReady bool) ([]*rpc.NetConf, error) {
 	var netConf []*rpc.NetConf
 
 	var nodeTrunkENI *types.ENI
-	podEni, err := n.requestCRD(podInfo, waitReady)
+	podEni, err := n.requestCRD(ctx, podInfo, waitReady)
 	if err != nil {
 		return nil, fmt.Errorf("error wait pod eni info, %w", err)
 	}
@@ -1177,6 +1239,7 @@ func (n *networkService) Config() []tracing.MapKeyValueEntry {
 		{Key: tracingKeyConfigFilePath, Value: n.configFilePath},
 		{Key: tracingKeyKubeConfig, Value: n.kubeConfig},
 		{Key: tracingKeyMaster, Value: n.master},
+		{Key: tracingKeyLimitSource, Value: string(aliyun.GetLimitSource(aliyun.GetInstanceMeta().InstanceType))},
 	}
 
 	return config
@@ -1209,6 +1272,11 @@ func (n *networkService) Trace() []tracing.MapKeyValueEntry {
 
 		key := fmt.Sprintf("pods/%s/%s/resources", res.PodInfo.Namespace, res.PodInfo.Name)
 		trace = append(trace, tracing.MapKeyValueEntry{Key: key, Value: strings.Join(resources, " ")})
+
+		if res.NetNs != nil {
+			key = fmt.Sprintf("pods/%s/%s/netns", res.PodInfo.Namespace, res.PodInfo.Name)
+			trace = append(trace, tracing.MapKeyValueEntry{Key: key, Value: *res.NetNs})
+		}
 	}
 
 	return trace
@@ -1325,7 +1393,7 @@ func toResMapping(poolStats tracing.ResourcePoolStats, pods []interface{}) ([]*t
 	return mapping, nil
 }
 
-func newNetworkService(configFilePath, kubeconfig, master, daemonMode string) (rpc.TerwayBackendServer, error) {
+func newNetworkService(configFilePath, kubeconfig, master, daemonMode string) (*networkService, error) {
 	serviceLog.Debugf("start network service with: %s, %s", configFilePath, daemonMode)
 	cniBinPath := os.Getenv("CNI_PATH")
 	if cniBinPath == "" {
@@ -1387,32 +1455,45 @@ func newNetworkService(configFilePath, kubeconfig, master, daemonMode string) (r
 	netSrv.ipamType = config.IPAMType
 	netSrv.eniCapPolicy = config.ENICapPolicy
 
-	ins := aliyun.GetInstanceMeta()
 	ipFamily := types.NewIPFamilyFromIPStack(types.IPStack(config.IPStack))
 	netSrv.ipFamily = ipFamily
 
-	aliyunClient, err := client.NewAliyun(config.AccessID, config.AccessSecret, ins.RegionID, utils.NormalizePath(config.CredentialPath), "", "")
+	err = setupAudit(config, netSrv.k8s)
+	if err != nil {
+		return nil, err
+	}
+
+	err = netSrv.setupTelemetry(config)
 	if err != nil {
-		return nil, errors.Wrapf(err, "error create aliyun client")
+		return nil, err
 	}
 
-	limit, err := aliyun.GetLimit(aliyunClient, ins.InstanceType)
+	prov, err := newProvider(config, ipFamily)
+	if err != nil {
+		return nil, err
+	}
+	ins := prov.Instance()
+	ecs := prov.API()
+	netSrv.api = ecs
+
+	aliyun.SetLimitOverrides(limitOverrides(config.InstanceTypeLimits))
+	limit, err := aliyun.GetLimit(ecs, ins.InstanceType)
 	if err != nil {
 		return nil, fmt.Errorf("upable get instance limit, %w", err)
 	}
+	serviceLog.Infof("instance limit of %s from %s", ins.InstanceType, aliyun.GetLimitSource(ins.InstanceType))
 	if ipFamily.IPv6 {
 		if !limit.SupportIPv6() {
 			ipFamily.IPv6 = false
-			serviceLog.Warnf("instance %s is not support ipv6", aliyun.GetInstanceMeta().InstanceType)
+			serviceLog.Warnf("instance %s is not support ipv6", ins.InstanceType)
 		} else if daemonMode == daemonModeENIMultiIP && !limit.SupportMultiIPIPv6() {
 			ipFamily.IPv6 = false
-			serviceLog.Warnf("instance %s is not support ipv6", aliyun.GetInstanceMeta().InstanceType)
+			serviceLog.Warnf("instance %s is not support ipv6", ins.InstanceType)
 		}
 	}
 
-	ecs := aliyun.NewAliyunImpl(aliyunClient, config.EnableENITrunking && !config.WaitTrunkENI, ipFamily)
-
 	netSrv.enableTrunk = config.EnableENITrunking
+	netSrv.enablePodEIP = config.EnablePodEIP
 
 	ipNetSet := &types.IPNetSet{}
 	if config.ServiceCIDR != "" {
@@ -1430,15 +1511,12 @@ func newNetworkService(configFilePath, kubeconfig, master, daemonMode string) (r
 
 	_ = netSrv.k8s.SetCustomStatefulWorkloadKinds(config.CustomStatefulWorkloadKinds)
 
-	netSrv.resourceDB, err = storage.NewDiskStorage(
-		resDBName, utils.NormalizePath(resDBPath), json.Marshal, func(bytes []byte) (interface{}, error) {
-			resourceRel := &types.PodResources{}
-			err = json.Unmarshal(bytes, resourceRel)
-			if err != nil {
-				return nil, errors.Wrapf(err, "error unmarshal pod relate resource")
-			}
-			return *resourceRel, nil
-		})
+	err = netSrv.k8s.SetENIGroups(config.ENIGroups)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error set eni groups")
+	}
+
+	netSrv.resourceDB, err = openResourceDB(daemonMode, netSrv.k8s, ecs)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error init resource manager storage")
 	}
@@ -1501,7 +1579,10 @@ func newNetworkService(configFilePath, kubeconfig, master, daemonMode string) (r
 			return nil, errors.Wrapf(err, "error init ENI ip resource manager")
 		}
 		if config.EnableEIPPool == conditionTrue {
-			netSrv.eipResMgr = newEipResourceManager(ecs, netSrv.k8s, config.AllowEIPRob == conditionTrue)
+			netSrv.eipResMgr, err = newEipResourceManager(ecs, netSrv.k8s, config)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error init eip resource manager")
+			}
 		}
 		netSrv.mgrForResource = map[string]ResourceManager{
 			types.ResourceTypeENIIP: netSrv.eniIPResMgr,
@@ -1514,7 +1595,10 @@ func newNetworkService(configFilePath, kubeconfig, master, daemonMode string) (r
 			return nil, errors.Wrapf(err, "error init eni resource manager")
 		}
 		if config.EnableEIPPool == conditionTrue && !config.EnableENITrunking {
-			netSrv.eipResMgr = newEipResourceManager(ecs, netSrv.k8s, config.AllowEIPRob == conditionTrue)
+			netSrv.eipResMgr, err = newEipResourceManager(ecs, netSrv.k8s, config)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error init eip resource manager")
+			}
 		}
 		netSrv.mgrForResource = map[string]ResourceManager{
 			types.ResourceTypeENI: netSrv.eniResMgr,
@@ -1535,9 +1619,40 @@ func newNetworkService(configFilePath, kubeconfig, master, daemonMode string) (r
 
 	go wait.JitterUntil(netSrv.startPeriodCheck, period, 1, true, wait.NeverStop)
 
+	if config.NodeNetworkStatusInterval != "" {
+		interval, _ := time.ParseDuration(config.NodeNetworkStatusInterval)
+		base := podENITypes.NodeNetworkStatusStatus{
+			Mode:         daemonMode,
+			InstanceID:   ins.InstanceID,
+			InstanceType: ins.InstanceType,
+			Limits: podENITypes.NodeNetworkLimits{
+				Adapters:           limit.Adapters,
+				IPv4PerAdapter:     limit.IPv4PerAdapter,
+				IPv6PerAdapter:     limit.IPv6PerAdapter,
+				MemberAdapterLimit: limit.MemberAdapterLimit,
+			},
+		}
+		if mgr, ok := netSrv.eniIPResMgr.(*eniIPResourceManager); ok && mgr.trunkENI != nil {
+			base.TrunkENI = mgr.trunkENI.ID
+		}
+		netSrv.nodeStatus = newNodeStatusSyncer(interval, base)
+		go netSrv.nodeStatus.run(netSrv.syncNodeStatus)
+	}
+
+	if config.NetworkUnavailableThreshold != "" {
+		threshold, _ := time.ParseDuration(config.NetworkUnavailableThreshold)
+		netSrv.availability = newNetworkAvailability(threshold, func(lastErr error) bool {
+			return netSrv.probeNetworkAvailable(poolConfig.VSwitch, lastErr)
+		})
+		go netSrv.availability.run(func(available bool, message string) error {
+			return netSrv.k8s.SetNodeNetworkCondition(available, message, config.NetworkUnavailableTaint)
+		})
+	}
+
 	// register for tracing
 	_ = tracing.Register(tracing.ResourceTypeNetworkService, "default", netSrv)
 	tracing.RegisterResourceMapping(netSrv)
+	tracing.RegisterLeakFinder(netSrv)
 	tracing.RegisterEventRecorder(netSrv.k8s.RecordNodeEvent, netSrv.k8s.RecordPodEvent)
 
 	return netSrv, nil
@@ -1558,9 +1673,51 @@ func setDefault(cfg *daemon.Config) error {
 		cfg.IPStack = string(types.IPStackIPv4)
 	}
 
+	if cfg.IPAllocationPolicy == types.IPAllocationPolicyDefault {
+		cfg.IPAllocationPolicy = types.IPAllocationPolicySpread
+	}
+
+	if cfg.ENIConsolidateThreshold == 0 {
+		cfg.ENIConsolidateThreshold = defaultENIConsolidateThreshold
+	}
+
+	if cfg.AuditLogMaxSize == 0 {
+		cfg.AuditLogMaxSize = defaultAuditLogMaxSize
+	}
+
+	if cfg.AuditLogMaxBackups == nil {
+		n := defaultAuditLogMaxBackups
+		cfg.AuditLogMaxBackups = &n
+	}
+
+	// same as the default eip of pods
+	if cfg.EIPPool != nil {
+		if cfg.EIPPool.Bandwidth == 0 {
+			cfg.EIPPool.Bandwidth = 5
+		}
+		if cfg.EIPPool.InternetChargeType == "" {
+			cfg.EIPPool.InternetChargeType = string(types.PayByTraffic)
+		}
+	}
+
 	return nil
 }
 
+func limitOverrides(in map[string]daemon.InstanceTypeLimit) map[string]*aliyun.Limits {
+	overrides := make(map[string]*aliyun.Limits, len(in))
+	for instanceType, l := range in {
+		overrides[instanceType] = &aliyun.Limits{
+			Adapters:              l.Adapters,
+			TotalAdapters:         l.TotalAdapters,
+			IPv4PerAdapter:        l.IPv4PerAdapter,
+			IPv6PerAdapter:        l.IPv6PerAdapter,
+			MemberAdapterLimit:    l.MemberAdapterLimit,
+			MaxMemberAdapterLimit: l.MaxMemberAdapterLimit,
+		}
+	}
+	return overrides
+}
+
 func validateConfig(cfg *daemon.Config) error {
 	switch cfg.IPStack {
 	case "", string(types.IPStackIPv4), string(types.IPStackDual):
@@ -1568,6 +1725,112 @@ func validateConfig(cfg *daemon.Config) error {
 		return fmt.Errorf("unsupported ipStack %s in configMap", cfg.IPStack)
 	}
 
+	switch cfg.IPAllocationPolicy {
+	case types.IPAllocationPolicyDefault, types.IPAllocationPolicySpread, types.IPAllocationPolicyPack:
+	default:
+		return fmt.Errorf("unsupported ipAllocationPolicy %s in configMap", cfg.IPAllocationPolicy)
+	}
+
+	if cfg.ENIConsolidateInterval != "" {
+		_, err := time.ParseDuration(cfg.ENIConsolidateInterval)
+		if err != nil {
+			return fmt.Errorf("invalid eniConsolidateInterval %s in configMap, %w", cfg.ENIConsolidateInterval, err)
+		}
+	}
+
+	if cfg.EIPReuseTTL != "" {
+		_, err := time.ParseDuration(cfg.EIPReuseTTL)
+		if err != nil {
+			return fmt.Errorf("invalid eip_reuse_ttl %s in configMap, %w", cfg.EIPReuseTTL, err)
+		}
+	}
+	if cfg.TracingPropagateRatio < 0 || cfg.TracingPropagateRatio > 1 {
+		return fmt.Errorf("invalid tracing_propagate_ratio %v in configMap, must be in [0, 1]", cfg.TracingPropagateRatio)
+	}
+	if cfg.NodeNetworkStatusInterval != "" {
+		interval, err := time.ParseDuration(cfg.NodeNetworkStatusInterval)
+		if err != nil || interval <= 0 {
+			return fmt.Errorf("invalid node_network_status_interval %s in configMap", cfg.NodeNetworkStatusInterval)
+		}
+	}
+	if cfg.NetworkUnavailableThreshold != "" {
+		threshold, err := time.ParseDuration(cfg.NetworkUnavailableThreshold)
+		if err != nil || threshold <= 0 {
+			return fmt.Errorf("invalid network_unavailable_threshold %s in configMap", cfg.NetworkUnavailableThreshold)
+		}
+	}
+	if cfg.EIPPool != nil {
+		if cfg.EIPPool.MinPoolSize < 0 || cfg.EIPPool.MinPoolSize > cfg.EIPPool.MaxPoolSize {
+			return fmt.Errorf("eip_pool min_pool_size %d should between 0 and max_pool_size %d", cfg.EIPPool.MinPoolSize, cfg.EIPPool.MaxPoolSize)
+		}
+	}
+
+	if cfg.EnablePodEIP && cfg.EnableEIPPool == conditionTrue {
+		return fmt.Errorf("enable_pod_eip and enable_eip_pool can not be both enabled")
+	}
+
+	switch cfg.Provider {
+	case "", provider.NameAliyun:
+	case provider.NameStatic:
+		if cfg.StaticProvider == nil || len(cfg.StaticProvider.NICs) == 0 {
+			return fmt.Errorf("static_provider with nics is required for the static provider")
+		}
+		if cfg.EnableENITrunking || cfg.EnableEIPPool == conditionTrue {
+			return fmt.Errorf("trunk and eip are not supported by the static provider")
+		}
+	default:
+		return fmt.Errorf("unsupported provider %s in configMap", cfg.Provider)
+	}
+
+	for instanceType, l := range cfg.InstanceTypeLimits {
+		if l.Adapters < 1 || l.IPv4PerAdapter < 1 {
+			return fmt.Errorf("adapters and ipv4_per_adapter of instance_type_limits %s should be positive", instanceType)
+		}
+	}
+
+	if cfg.ENIConsolidateThreshold < 0 || cfg.ENIConsolidateThreshold > 1 {
+		return fmt.Errorf("eniConsolidateThreshold %v in configMap should between 0 and 1", cfg.ENIConsolidateThreshold)
+	}
+
+	return validateENIGroups(cfg.ENIGroups)
+}
+
+func validateENIGroups(groups []daemon.ENIGroup) error {
+	names := sets.NewString()
+	sgSets := sets.NewString()
+	for _, group := range groups {
+		if group.Name == "" {
+			return fmt.Errorf("eni group name is empty")
+		}
+		if names.Has(group.Name) {
+			return fmt.Errorf("duplicate eni group %s", group.Name)
+		}
+		names.Insert(group.Name)
+
+		if len(group.SecurityGroups) == 0 || len(group.SecurityGroups) > 5 {
+			return fmt.Errorf("eni group %s should have 1 to 5 security groups", group.Name)
+		}
+		// enis are assigned to the group by security groups, so they must be unique
+		key := strings.Join(sets.NewString(group.SecurityGroups...).List(), ",")
+		if sgSets.Has(key) {
+			return fmt.Errorf("eni group %s has the same security groups with other group", group.Name)
+		}
+		sgSets.Insert(key)
+
+		if group.MinPoolSize > group.MaxPoolSize {
+			return fmt.Errorf("eni group %s min_pool_size %d bigger than max_pool_size %d", group.Name, group.MinPoolSize, group.MaxPoolSize)
+		}
+		if group.PodSelector != nil {
+			if _, err := metav1.LabelSelectorAsSelector(group.PodSelector); err != nil {
+				return fmt.Errorf("eni group %s has invalid pod_selector, %w", group.Name, err)
+			}
+		}
+		if group.NamespaceSelector != nil {
+			if _, err := metav1.LabelSelectorAsSelector(group.NamespaceSelector); err != nil {
+				return fmt.Errorf("eni group %s has invalid namespace_selector, %w", group.Name, err)
+			}
+		}
+	}
 	return nil
 }
 
@@ -1586,8 +1849,14 @@ func getPoolConfig(cfg *daemon.Config, ipamType types.IPAMType) (*types.PoolConf
 		EnableENITrunking:         cfg.EnableENITrunking,
 		ENICapPolicy:              cfg.ENICapPolicy,
 		DisableDevicePlugin:       cfg.DisableDevicePlugin,
+		EnableENIIPResource:       cfg.EnableENIIPResource,
 		WaitTrunkENI:              cfg.WaitTrunkENI,
 		DisableSecurityGroupCheck: cfg.DisableSecurityGroupCheck,
+		IPAllocationPolicy:        cfg.IPAllocationPolicy,
+		ENIConsolidateThreshold:   cfg.ENIConsolidateThreshold,
+	}
+	if cfg.ENIConsolidateInterval != "" {
+		poolConfig.ENIConsolidateInterval, _ = time.ParseDuration(cfg.ENIConsolidateInterval)
 	}
 	if len(poolConfig.SecurityGroups) > 5 {
 		return nil, fmt.Errorf("security groups should not be more than 5, current %d", len(poolConfig.SecurityGroups))
@@ -1607,11 +1876,22 @@ func getPoolConfig(cfg *daemon.Config, ipamType types.IPAMType) (*types.PoolConf
 	poolConfig.VPC = ins.VPCID
 	poolConfig.InstanceID = ins.InstanceID
 
+	for _, group := range cfg.ENIGroups {
+		poolConfig.ENIGroups = append(poolConfig.ENIGroups, types.ENIGroupConfig{
+			Name:           group.Name,
+			VSwitch:        group.VSwitches[zone],
+			SecurityGroups: group.SecurityGroups,
+			MaxPoolSize:    group.MaxPoolSize,
+			MinPoolSize:    group.MinPoolSize,
+		})
+	}
+
 	if ipamType == types.IPAMTypeCRD {
 		poolConfig.MaxPoolSize = 0
 		poolConfig.MinPoolSize = 0
 		poolConfig.MaxENI = 0
 		poolConfig.MinENI = 0
+		poolConfig.ENIGroups = nil
 	}
 	return poolConfig, nil
 }
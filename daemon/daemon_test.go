@@ -5,6 +5,7 @@ import (
 
 	"github.com/AliyunContainerService/terway/pkg/tracing"
 	"github.com/AliyunContainerService/terway/types"
+	"github.com/AliyunContainerService/terway/types/daemon"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -89,3 +90,67 @@ func Test_toResMapping(t *testing.T) {
 		}
 	}
 }
+
+func Test_validateENIGroups(t *testing.T) {
+	tests := []struct {
+		name    string
+		groups  []daemon.ENIGroup
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			groups: []daemon.ENIGroup{
+				{Name: "a", SecurityGroups: []string{"sg-1", "sg-2"}},
+				{Name: "b", SecurityGroups: []string{"sg-3"}, MaxPoolSize: 5, MinPoolSize: 1},
+			},
+		},
+		{
+			name:    "empty name",
+			groups:  []daemon.ENIGroup{{SecurityGroups: []string{"sg-1"}}},
+			wantErr: true,
+		},
+		{
+			name: "duplicate name",
+			groups: []daemon.ENIGroup{
+				{Name: "a", SecurityGroups: []string{"sg-1"}},
+				{Name: "a", SecurityGroups: []string{"sg-2"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "same security groups",
+			groups: []daemon.ENIGroup{
+				{Name: "a", SecurityGroups: []string{"sg-1", "sg-2"}},
+				{Name: "b", SecurityGroups: []string{"sg-2", "sg-1"}},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "no security group",
+			groups:  []daemon.ENIGroup{{Name: "a"}},
+			wantErr: true,
+		},
+		{
+			name:    "min bigger than max",
+			groups:  []daemon.ENIGroup{{Name: "a", SecurityGroups: []string{"sg-1"}, MinPoolSize: 2}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateENIGroups(tt.groups)
+			assert.Equal(t, tt.wantErr, err != nil, err)
+		})
+	}
+}
+
+func Test_setDefault_auditLogMaxBackups(t *testing.T) {
+	cfg := &daemon.Config{}
+	assert.NoError(t, setDefault(cfg))
+	assert.Equal(t, defaultAuditLogMaxBackups, *cfg.AuditLogMaxBackups)
+
+	none := 0
+	cfg = &daemon.Config{AuditLogMaxBackups: &none}
+	assert.NoError(t, setDefault(cfg))
+	assert.Equal(t, 0, *cfg.AuditLogMaxBackups)
+}
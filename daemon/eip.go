@@ -2,32 +2,92 @@ package daemon
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/AliyunContainerService/terway/pkg/aliyun"
+	apiErr "github.com/AliyunContainerService/terway/pkg/aliyun/client/errors"
 	"github.com/AliyunContainerService/terway/pkg/ipam"
 	"github.com/AliyunContainerService/terway/pkg/logger"
+	"github.com/AliyunContainerService/terway/pkg/storage"
 	"github.com/AliyunContainerService/terway/pkg/tracing"
-
+	"github.com/AliyunContainerService/terway/pkg/utils"
 	"github.com/AliyunContainerService/terway/types"
+	"github.com/AliyunContainerService/terway/types/daemon"
+
+	k8sErr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
 )
 
 var eipLog = logger.DefaultLogger
 
+const (
+	eipDBPath = "/var/lib/cni/terway/eip.db"
+	eipDBName = "eip"
+
+	eipPoolCheckPeriod = time.Minute
+)
+
+// eipEntry is an unassociated eip idle in the warm pool
+// NOTE: this is the type store in db
+type eipEntry struct {
+	ID string `json:"id"`
+}
+
 // eip resource manager for pod public ip address
+// eips created by terway are reserved for the pod identity for a ttl after the pod deletion, the reservations are
+// recorded in the PodEIP cr, the pod with the same name on any node reuse it and terway-controlplane release it after
+// the ttl. Idle eips of the warm pool are persisted in the store.
 type eipResourceManager struct {
 	ecs         ipam.API
 	k8s         Kubernetes
 	allowEipRob bool
+
+	// reuseTTL is the default ttl for stateful workload pods
+	reuseTTL time.Duration
+	pool     *daemon.EIPPool
+
+	lock  sync.Mutex
+	store storage.Storage
 }
 
-func newEipResourceManager(e ipam.API, k Kubernetes, allowEipRob bool) ResourceManager {
-	return &eipResourceManager{
+func newEipResourceManager(e ipam.API, k Kubernetes, cfg *daemon.Config) (ResourceManager, error) {
+	mgr := &eipResourceManager{
 		ecs:         e,
 		k8s:         k,
-		allowEipRob: allowEipRob,
+		allowEipRob: cfg.AllowEIPRob == conditionTrue,
+		pool:        cfg.EIPPool,
+	}
+	if cfg.EIPReuseTTL != "" {
+		ttl, err := time.ParseDuration(cfg.EIPReuseTTL)
+		if err != nil {
+			return nil, fmt.Errorf("error parse eip reuse ttl, %w", err)
+		}
+		mgr.reuseTTL = ttl
+	}
+
+	var err error
+	mgr.store, err = storage.NewDiskStorage(eipDBName, utils.NormalizePath(eipDBPath), json.Marshal, func(bytes []byte) (interface{}, error) {
+		entry := eipEntry{}
+		err := json.Unmarshal(bytes, &entry)
+		if err != nil {
+			return nil, fmt.Errorf("error unmarshal eip entry, %w", err)
+		}
+		return entry, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error init eip storage, %w", err)
+	}
+
+	if mgr.pool != nil {
+		go wait.JitterUntil(mgr.sync, eipPoolCheckPeriod, 0.2, true, wait.NeverStop)
 	}
+	return mgr, nil
 }
 
 func (e *eipResourceManager) Allocate(context *networkContext, prefer string) (types.NetworkResource, error) {
@@ -63,8 +123,43 @@ func (e *eipResourceManager) Allocate(context *networkContext, prefer string) (t
 		eipLog.Infof("eip id empty pod")
 		eipID = prefer
 	}
+
+	reuseTTL := e.reuseTTLOf(context.pod)
+	pooled := e.matchPool(&context.pod.EipInfo)
+	// take the eip reserved for the pod or an idle one from the pool
+	var (
+		reserved bool
+		entry    *eipEntry
+	)
+	if eipID == "" && reuseTTL > 0 {
+		eipID = e.reservedFor(context.pod)
+		reserved = eipID != ""
+	}
+	if eipID == "" && pooled {
+		entry = e.take()
+		if entry != nil {
+			eipID = entry.ID
+		}
+	}
+	if eipID != "" && context.pod.EipInfo.PodEipID == "" {
+		eipLog.Infof("reuse eip %s for pod %s, reserved: %v", eipID, podInfoKey(context.pod.Namespace, context.pod.Name), reserved)
+	}
+
+	// the reserved eip may still be associated to the previous pod, which is gone as the pod name is unique, so rob it
 	eipInfo, err := e.ecs.AllocateEipAddress(ctx, context.pod.EipInfo.PodEipBandWidth, context.pod.EipInfo.PodEipChargeType,
-		eipID, eniID, eniIP, e.allowEipRob, context.pod.EipInfo.PodEipISP, context.pod.EipInfo.PodEipBandwidthPackageID, context.pod.EipInfo.PodEipPoolID)
+		eipID, eniID, eniIP, e.allowEipRob || reserved, context.pod.EipInfo.PodEipISP, context.pod.EipInfo.PodEipBandwidthPackageID, context.pod.EipInfo.PodEipPoolID)
+	if err != nil && (reserved || entry != nil) {
+		if errors.Is(err, apiErr.ErrNotFound) {
+			// the eip is released by others, create a new one
+			eipLog.Warnf("eip %s held by terway is not found, %v", eipID, err)
+			eipInfo, err = e.ecs.AllocateEipAddress(ctx, context.pod.EipInfo.PodEipBandWidth, context.pod.EipInfo.PodEipChargeType,
+				"", eniID, eniIP, e.allowEipRob, context.pod.EipInfo.PodEipISP, context.pod.EipInfo.PodEipBandwidthPackageID, context.pod.EipInfo.PodEipPoolID)
+		} else if entry != nil {
+			if err1 := e.put(entry); err1 != nil {
+				eipLog.Errorf("error put back eip %s, %v", entry.ID, err1)
+			}
+		}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("error allocate eip info: %w", err)
 	}
@@ -72,17 +167,23 @@ func (e *eipResourceManager) Allocate(context *networkContext, prefer string) (t
 	// set eip to delete if pod not specific eip id
 	if context.pod.EipInfo.PodEipID == "" {
 		eipInfo.Delete = true
+		eipInfo.ReuseKey = podInfoKey(context.pod.Namespace, context.pod.Name)
+		eipInfo.ReuseTTL = reuseTTL
+		eipInfo.Pooled = pooled
+	}
+	if eipInfo.ReuseTTL > 0 {
+		err = e.k8s.ReservePodEIP(context.pod, eipInfo, eipInfo.ReuseTTL)
+		if err != nil {
+			if err1 := e.release(ctx, eipInfo.ID, eipInfo.ToResItems()[0].ExtraEipInfo); err1 != nil {
+				eipLog.Errorf("error rollback eip: %v", err1)
+			}
+			return nil, fmt.Errorf("error reserve eip for the pod: %w", err)
+		}
 	}
 	context.pod.EipInfo.PodEipIP = eipInfo.Address.String()
 	err = e.k8s.PatchEipInfo(context.pod)
 	if err != nil {
-		var err1 error
-		if eipInfo.Delete {
-			err1 = e.ecs.ReleaseEipAddress(ctx, eipInfo.ID, eniID, eniIP)
-		} else {
-			err1 = e.ecs.UnassociateEipAddress(ctx, eipInfo.ID, eniID, eniIP.String())
-		}
-		if err1 != nil {
+		if err1 := e.release(ctx, eipInfo.ID, eipInfo.ToResItems()[0].ExtraEipInfo); err1 != nil {
 			eipLog.Errorf("error rollback eip: %v", err1)
 		}
 		return nil, fmt.Errorf("error patch pod info: %w", err)
@@ -95,20 +196,7 @@ func (e *eipResourceManager) Release(context *networkContext, resItem types.Reso
 		return nil
 	}
 	eipLog.Infof("release eip: %v, %v", resItem.ID, resItem.ExtraEipInfo)
-	ctx := context.Context
-
-	if resItem.ExtraEipInfo.Delete {
-		err := e.ecs.ReleaseEipAddress(ctx, resItem.ID, resItem.ExtraEipInfo.AssociateENI, resItem.ExtraEipInfo.AssociateENIIP)
-		if err != nil {
-			return err
-		}
-	} else {
-		err := e.ecs.UnassociateEipAddress(ctx, resItem.ID, resItem.ExtraEipInfo.AssociateENI, resItem.ExtraEipInfo.AssociateENIIP.String())
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+	return e.release(context.Context, resItem.ID, resItem.ExtraEipInfo)
 }
 
 func (e *eipResourceManager) GarbageCollection(inUseResSet map[string]types.ResourceItem, expireResSet map[string]types.ResourceItem) error {
@@ -117,16 +205,9 @@ func (e *eipResourceManager) GarbageCollection(inUseResSet map[string]types.Reso
 			continue
 		}
 		eipLog.Infof("release eip: %v, %v", expireRes, expireItem)
-		if expireItem.ExtraEipInfo.Delete {
-			err := e.ecs.ReleaseEipAddress(context.Background(), expireRes, expireItem.ExtraEipInfo.AssociateENI, expireItem.ExtraEipInfo.AssociateENIIP)
-			if err != nil {
-				return err
-			}
-		} else {
-			err := e.ecs.UnassociateEipAddress(context.Background(), expireRes, expireItem.ExtraEipInfo.AssociateENI, expireItem.ExtraEipInfo.AssociateENIIP.String())
-			if err != nil {
-				return err
-			}
+		err := e.release(context.Background(), expireRes, expireItem.ExtraEipInfo)
+		if err != nil {
+			return err
 		}
 	}
 	return nil
@@ -139,3 +220,193 @@ func (e *eipResourceManager) Stat(context *networkContext, resID string) (types.
 func (e *eipResourceManager) GetResourceMapping() (tracing.ResourcePoolStats, error) {
 	return nil, fmt.Errorf("eip resource manager store network resource")
 }
+
+// release unassociate the eip specified by user, the eip created by terway is reserved for the pod,
+// put back to the pool, or released
+func (e *eipResourceManager) release(ctx context.Context, eipID string, info *types.ExtraEipInfo) error {
+	if !info.Delete {
+		return e.ecs.UnassociateEipAddress(ctx, eipID, info.AssociateENI, info.AssociateENIIP.String())
+	}
+	if info.ReuseTTL <= 0 && !(info.Pooled && e.pool != nil) {
+		return e.ecs.ReleaseEipAddress(ctx, eipID, info.AssociateENI, info.AssociateENIIP)
+	}
+
+	err := e.ecs.UnassociateEipAddress(ctx, eipID, info.AssociateENI, info.AssociateENIIP.String())
+	if err != nil {
+		return err
+	}
+	if info.ReuseTTL > 0 && info.ReuseKey != "" {
+		namespace, name, _ := strings.Cut(info.ReuseKey, "/")
+		reserved, err := e.k8s.UnassociatePodEIP(namespace, name, eipID)
+		if err != nil {
+			return err
+		}
+		if reserved {
+			eipLog.Infof("keep eip %s reserved for %s, ttl: %v", eipID, info.ReuseKey, info.ReuseTTL)
+			return nil
+		}
+	}
+	if info.Pooled && e.pool != nil {
+		kept, err := e.putIdle(eipID)
+		if err != nil {
+			return err
+		}
+		if kept {
+			eipLog.Infof("put eip %s back to the pool", eipID)
+			return nil
+		}
+	}
+	return e.ecs.ReleaseEipAddress(ctx, eipID, "", nil)
+}
+
+// sync keep the idle eips between the min and max pool size
+func (e *eipResourceManager) sync() {
+	minIdle, maxIdle := e.pool.MinPoolSize, e.pool.MaxPoolSize
+
+	e.lock.Lock()
+	entries, err := e.list()
+	if err != nil {
+		e.lock.Unlock()
+		eipLog.Errorf("error list eip entries, %v", err)
+		return
+	}
+	var toRelease []eipEntry
+	if len(entries) > maxIdle {
+		toRelease = entries[maxIdle:]
+	}
+	for _, entry := range toRelease {
+		if err = e.store.Delete(entry.ID); err != nil {
+			eipLog.Errorf("error delete eip %s from store, %v", entry.ID, err)
+		}
+	}
+	idle := len(entries) - len(toRelease)
+	e.lock.Unlock()
+
+	for i := range toRelease {
+		entry := toRelease[i]
+		err = e.ecs.ReleaseEipAddress(context.Background(), entry.ID, "", nil)
+		if err != nil {
+			eipLog.Errorf("error release eip %s, %v", entry.ID, err)
+			// retry on next sync
+			if err = e.put(&entry); err != nil {
+				eipLog.Errorf("error put back eip %s, %v", entry.ID, err)
+			}
+			continue
+		}
+		eipLog.Infof("released eip %s", entry.ID)
+	}
+
+	for i := idle; i < minIdle; i++ {
+		eip, err := e.ecs.CreateEipAddress(context.Background(), e.pool.Bandwidth, types.InternetChargeType(e.pool.InternetChargeType),
+			e.pool.ISP, e.pool.BandwidthPackageID, e.pool.PublicIPAddressPoolID)
+		if err != nil {
+			eipLog.Errorf("error create eip for pool, %v", err)
+			continue
+		}
+		if err = e.put(&eipEntry{ID: eip.ID}); err != nil {
+			eipLog.Errorf("error put eip %s to pool, %v", eip.ID, err)
+		}
+	}
+}
+
+// reservedFor return the eip reserved for the pod with the same name, empty if there is none
+func (e *eipResourceManager) reservedFor(pod *types.PodInfo) string {
+	podEIP, err := e.k8s.GetPodEIP(pod)
+	if err != nil {
+		if !k8sErr.IsNotFound(err) {
+			eipLog.Warnf("error get eip reservation of pod %s, %v", podInfoKey(pod.Namespace, pod.Name), err)
+		}
+		return ""
+	}
+	if !isEIPReservation(podEIP) || !podEIP.DeletionTimestamp.IsZero() {
+		return ""
+	}
+	return podEIP.Status.AllocationID
+}
+
+// take remove an idle eip from store
+func (e *eipResourceManager) take() *eipEntry {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	entries, err := e.list()
+	if err != nil {
+		eipLog.Errorf("error list eip entries, %v", err)
+		return nil
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	found := &entries[0]
+	if err = e.store.Delete(found.ID); err != nil {
+		eipLog.Errorf("error delete eip %s from store, %v", found.ID, err)
+		return nil
+	}
+	return found
+}
+
+func (e *eipResourceManager) put(entry *eipEntry) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.store.Put(entry.ID, *entry)
+}
+
+// putIdle put the eip to the pool, false is returned if the pool is full
+func (e *eipResourceManager) putIdle(eipID string) (bool, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	entries, err := e.list()
+	if err != nil {
+		return false, err
+	}
+	if len(entries) >= e.pool.MaxPoolSize {
+		return false, nil
+	}
+	return true, e.store.Put(eipID, eipEntry{ID: eipID})
+}
+
+// idle is the amount of eips in the warm pool
+func (e *eipResourceManager) idle() int {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	entries, err := e.list()
+	if err != nil {
+		return 0
+	}
+	return len(entries)
+}
+
+func (e *eipResourceManager) list() ([]eipEntry, error) {
+	objs, err := e.store.List()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]eipEntry, 0, len(objs))
+	for _, obj := range objs {
+		entries = append(entries, obj.(eipEntry))
+	}
+	return entries, nil
+}
+
+// matchPool whether the eip required by pod is the same as eips in the pool
+func (e *eipResourceManager) matchPool(info *types.PodEipInfo) bool {
+	if e.pool == nil {
+		return false
+	}
+	return info.PodEipBandWidth == e.pool.Bandwidth &&
+		string(info.PodEipChargeType) == e.pool.InternetChargeType &&
+		info.PodEipISP == e.pool.ISP &&
+		info.PodEipBandwidthPackageID == e.pool.BandwidthPackageID &&
+		info.PodEipPoolID == e.pool.PublicIPAddressPoolID
+}
+
+// reuseTTLOf the pod annotation take precedence over the default ttl for stateful workload pods
+func (e *eipResourceManager) reuseTTLOf(pod *types.PodInfo) time.Duration {
+	if pod.EipInfo.PodEipReuseTTL > 0 {
+		return pod.EipInfo.PodEipReuseTTL
+	}
+	if pod.IPStickTime != 0 {
+		return e.reuseTTL
+	}
+	return 0
+}
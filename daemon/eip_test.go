@@ -0,0 +1,207 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/AliyunContainerService/terway/pkg/aliyun"
+	apiErr "github.com/AliyunContainerService/terway/pkg/aliyun/client/errors"
+	"github.com/AliyunContainerService/terway/pkg/apis/network.alibabacloud.com/v1beta1"
+	crdfake "github.com/AliyunContainerService/terway/pkg/generated/clientset/versioned/fake"
+	"github.com/AliyunContainerService/terway/pkg/ipam"
+	"github.com/AliyunContainerService/terway/pkg/storage"
+	"github.com/AliyunContainerService/terway/types"
+	"github.com/AliyunContainerService/terway/types/daemon"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeEIPAPI keep eips in memory, the value is the associated eni, empty for unassociated
+type fakeEIPAPI struct {
+	ipam.API
+
+	eips    map[string]string
+	created int
+	// createErr fail the next n creations
+	createErr int
+}
+
+func (f *fakeEIPAPI) QueryEniIDByIP(ctx context.Context, vpcID string, address net.IP) (string, error) {
+	return "eni-1", nil
+}
+
+func (f *fakeEIPAPI) AllocateEipAddress(ctx context.Context, bandwidth int, chargeType types.InternetChargeType, eipID, eniID string, eniIP net.IP, allowRob bool, isp, bandwidthPackageID, poolID string) (*types.EIP, error) {
+	if eipID == "" {
+		eip, _ := f.CreateEipAddress(ctx, bandwidth, chargeType, isp, bandwidthPackageID, poolID)
+		eipID = eip.ID
+	}
+	eni, ok := f.eips[eipID]
+	if !ok {
+		return nil, fmt.Errorf("can not found eip %s, %w", eipID, apiErr.ErrNotFound)
+	}
+	if eni != "" && eni != eniID && !allowRob {
+		return nil, fmt.Errorf("eip id: %v status is not Available", eipID)
+	}
+	f.eips[eipID] = eniID
+	return &types.EIP{ID: eipID, Address: net.ParseIP("1.1.1.1"), AssociateENI: eniID, AssociateENIIP: eniIP}, nil
+}
+
+func (f *fakeEIPAPI) CreateEipAddress(ctx context.Context, bandwidth int, chargeType types.InternetChargeType, isp, bandwidthPackageID, poolID string) (*types.EIP, error) {
+	if f.createErr > 0 {
+		f.createErr--
+		return nil, fmt.Errorf("error create eip")
+	}
+	f.created++
+	id := fmt.Sprintf("eip-%d", f.created)
+	f.eips[id] = ""
+	return &types.EIP{ID: id, Address: net.ParseIP("1.1.1.1"), Delete: true}, nil
+}
+
+func (f *fakeEIPAPI) UnassociateEipAddress(ctx context.Context, eipID, eniID, eniIP string) error {
+	f.eips[eipID] = ""
+	return nil
+}
+
+func (f *fakeEIPAPI) ReleaseEipAddress(ctx context.Context, eipID, eniID string, eniIP net.IP) error {
+	delete(f.eips, eipID)
+	return nil
+}
+
+type fakeEIPKubernetes struct {
+	Kubernetes
+}
+
+func (f *fakeEIPKubernetes) PatchEipInfo(info *types.PodInfo) error {
+	return nil
+}
+
+func newTestEIPResourceManager(api ipam.API, reuseTTL time.Duration, pool *daemon.EIPPool) *eipResourceManager {
+	aliyun.SetInstanceMeta(&aliyun.Instance{VPCID: "vpc-1"})
+	return &eipResourceManager{
+		ecs:      api,
+		k8s:      &fakeEIPKubernetes{Kubernetes: &k8s{podEniClient: crdfake.NewSimpleClientset().NetworkV1beta1()}},
+		reuseTTL: reuseTTL,
+		pool:     pool,
+		store:    storage.NewMemoryStorage(),
+	}
+}
+
+func allocateTestEIP(t *testing.T, mgr *eipResourceManager, pod *types.PodInfo) *types.EIP {
+	pod.PodIPs = types.IPSet{IPv4: net.ParseIP("192.168.0.2")}
+	res, err := mgr.Allocate(&networkContext{
+		Context:   context.Background(),
+		pod:       pod,
+		resources: []types.ResourceItem{{Type: types.ResourceTypeENIIP}},
+	}, "")
+	assert.NoError(t, err)
+	return res.(*types.EIP)
+}
+
+func TestEIPReuse(t *testing.T) {
+	api := &fakeEIPAPI{eips: map[string]string{}}
+	mgr := newTestEIPResourceManager(api, time.Hour, nil)
+	k := mgr.k8s
+	podEIPs := k.(*fakeEIPKubernetes).Kubernetes.(*k8s).podEniClient.PodEIPs("default")
+	getPodEIP := func(pod *types.PodInfo) *v1beta1.PodEIP {
+		podEIP, err := k.GetPodEIP(pod)
+		assert.NoError(t, err)
+		return podEIP
+	}
+
+	sts := &types.PodInfo{Namespace: "default", Name: "web-0", IPStickTime: defaultStickTimeForSts, EipInfo: types.PodEipInfo{PodEip: true}}
+	eip := allocateTestEIP(t, mgr, sts)
+	assert.Equal(t, time.Hour, eip.ReuseTTL)
+	podEIP := getPodEIP(sts)
+	assert.Equal(t, v1beta1.PodEIPPhase(v1beta1.PodEIPPhaseAssociated), podEIP.Status.Phase)
+	assert.Equal(t, eip.ID, podEIP.Status.AllocationID)
+	assert.Equal(t, "1h0m0s", podEIP.Spec.ReleaseAfter)
+	assert.Contains(t, podEIP.Labels, types.LabelEIPReservation)
+
+	// the eip is reserved for the pod after deletion
+	assert.NoError(t, mgr.GarbageCollection(nil, map[string]types.ResourceItem{eip.ID: eip.ToResItems()[0]}))
+	assert.Equal(t, "", api.eips[eip.ID])
+	assert.Equal(t, v1beta1.PodEIPPhase(v1beta1.PodEIPPhaseUnassociated), getPodEIP(sts).Status.Phase)
+
+	// other pods do not take the reserved eip
+	other := allocateTestEIP(t, mgr, &types.PodInfo{Namespace: "default", Name: "web-1", EipInfo: types.PodEipInfo{PodEip: true}})
+	assert.NotEqual(t, eip.ID, other.ID)
+	assert.Equal(t, time.Duration(0), other.ReuseTTL)
+
+	// the pod with the same name on another node reuse it
+	otherNode := newTestEIPResourceManager(api, time.Hour, nil)
+	otherNode.k8s = k
+	again := allocateTestEIP(t, otherNode, sts)
+	assert.Equal(t, eip.ID, again.ID)
+	assert.Equal(t, 2, api.created)
+	assert.Equal(t, v1beta1.PodEIPPhase(v1beta1.PodEIPPhaseAssociated), getPodEIP(sts).Status.Phase)
+
+	// the reservation is released by terway-controlplane, the eip is released with the pod
+	podEIP = getPodEIP(sts)
+	podEIP.Status.AllocationID = ""
+	_, err := podEIPs.UpdateStatus(context.Background(), podEIP, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, otherNode.release(context.Background(), again.ID, again.ToResItems()[0].ExtraEipInfo))
+	assert.NotContains(t, api.eips, again.ID)
+
+	// eip deleted by others is replaced
+	podEIP = getPodEIP(sts)
+	podEIP.Status.AllocationID = "eip-gone"
+	_, err = podEIPs.UpdateStatus(context.Background(), podEIP, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	replaced := allocateTestEIP(t, mgr, sts)
+	assert.Equal(t, "eip-3", replaced.ID)
+	assert.Equal(t, "eip-3", getPodEIP(sts).Status.AllocationID)
+}
+
+func TestEIPPool(t *testing.T) {
+	api := &fakeEIPAPI{eips: map[string]string{}}
+	pool := &daemon.EIPPool{MinPoolSize: 2, MaxPoolSize: 2, Bandwidth: 5, InternetChargeType: string(types.PayByTraffic)}
+	mgr := newTestEIPResourceManager(api, 0, pool)
+
+	// creation error does not stop the refill
+	api.createErr = 1
+	mgr.sync()
+	assert.Equal(t, 1, mgr.idle())
+	mgr.sync()
+	assert.Equal(t, 2, mgr.idle())
+
+	// pod with the pool spec draw from the pool
+	pod := &types.PodInfo{Namespace: "default", Name: "pod-1",
+		EipInfo: types.PodEipInfo{PodEip: true, PodEipBandWidth: 5, PodEipChargeType: types.PayByTraffic}}
+	eip := allocateTestEIP(t, mgr, pod)
+	assert.True(t, eip.Pooled)
+	assert.Equal(t, 2, api.created)
+	assert.Equal(t, 1, mgr.idle())
+
+	// pod with other spec create a new one
+	custom := &types.PodInfo{Namespace: "default", Name: "pod-2",
+		EipInfo: types.PodEipInfo{PodEip: true, PodEipBandWidth: 10, PodEipChargeType: types.PayByTraffic}}
+	other := allocateTestEIP(t, mgr, custom)
+	assert.False(t, other.Pooled)
+	assert.Equal(t, 3, api.created)
+
+	// put back to the pool on release
+	assert.NoError(t, mgr.release(context.Background(), eip.ID, eip.ToResItems()[0].ExtraEipInfo))
+	assert.Equal(t, 2, mgr.idle())
+	assert.Contains(t, api.eips, eip.ID)
+	assert.NoError(t, mgr.release(context.Background(), other.ID, other.ToResItems()[0].ExtraEipInfo))
+	assert.NotContains(t, api.eips, other.ID)
+
+	// put back to the pool up to the max size
+	pooled := allocateTestEIP(t, mgr, pod)
+	assert.NoError(t, mgr.put(&eipEntry{ID: "eip-idle"}))
+	api.eips["eip-idle"] = ""
+	assert.NoError(t, mgr.release(context.Background(), pooled.ID, pooled.ToResItems()[0].ExtraEipInfo))
+	assert.Equal(t, 2, mgr.idle())
+	assert.NotContains(t, api.eips, pooled.ID)
+
+	// trim the pool above the max size
+	mgr.pool.MaxPoolSize, mgr.pool.MinPoolSize = 1, 1
+	mgr.sync()
+	assert.Equal(t, 1, mgr.idle())
+	assert.Len(t, api.eips, 1)
+}
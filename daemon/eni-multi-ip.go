@@ -17,17 +17,23 @@ import (
 	"github.com/AliyunContainerService/terway/pkg/logger"
 	"github.com/AliyunContainerService/terway/pkg/metric"
 	"github.com/AliyunContainerService/terway/pkg/pool"
+	"github.com/AliyunContainerService/terway/pkg/telemetry"
 	"github.com/AliyunContainerService/terway/pkg/tracing"
 	"github.com/AliyunContainerService/terway/pkg/utils"
 	"github.com/AliyunContainerService/terway/types"
 
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
 )
 
 type AllocCtx struct {
 	Trace []Trace
+	// SpanContext is the trace of the request, passed to the eni allocate worker
+	SpanContext trace.SpanContext
 }
 
 func (a *AllocCtx) String() string {
@@ -44,13 +50,20 @@ type Trace struct {
 
 var eniIPLog = logger.DefaultLogger
 
+// errMaxENIExceeded no more eni can be created for the ips
+var errMaxENIExceeded = errors.New("max ENI exceeded")
+
 const (
 	maxEniOperating = 3
 	maxIPBacklog    = 10
+	// maxIPRelease is the max ips can be unassigned in one openapi call
+	maxIPRelease = 50
 )
 
 const (
 	eniIPAllocInhibitTimeout = 10 * time.Minute
+	// eniIPCapacityCheckPeriod is the period the ip capacity is reported to the device plugin
+	eniIPCapacityCheckPeriod = 10 * time.Second
 
 	typeNameENIIP    = "eniip"
 	poolNameENIIP    = "eniip"
@@ -81,6 +94,18 @@ type eniIPFactory struct {
 	disableSecurityGroupCheck bool
 
 	ipFamily *types.IPFamily
+
+	allocationPolicy     types.IPAllocationPolicy
+	minENI               int
+	consolidateThreshold float64
+
+	// group is the eni group name, empty for the default factory
+	group string
+	// groupSecurityGroups is the security groups used by the groups, checked by the default factory
+	groupSecurityGroups []string
+	// eniCreateInhibitExpireAt is the time until which the vSwitches are considered running out of ips for new enis,
+	// it is only used to report the ip capacity
+	eniCreateInhibitExpireAt time.Time
 }
 
 // ENIIP the secondary ip of eni
@@ -89,15 +114,27 @@ type ENIIP struct {
 	err error
 }
 
+// releaseRequest is the request to release the secondary ip of eni
+type releaseRequest struct {
+	ip     *ENIIP
+	result chan error
+}
+
 // ENI to hold ENI's secondary config
 type ENI struct {
 	lock sync.Mutex
 	*types.ENI
 	ips       []*ENIIP
 	pending   int
-	ipBacklog chan struct{}
-	ecs       ipam.API
-	done      chan struct{}
+	releasing int
+	// ipBacklog is the ips wait to be allocated, each with the trace of the request
+	ipBacklog chan trace.SpanContext
+	// releaseBacklog is the ips wait to be released
+	releaseBacklog chan *releaseRequest
+	ecs            ipam.API
+	done           chan struct{}
+	// draining eni is not used for new ip, protected by factory lock
+	draining bool
 	// Unix timestamp to mark when this ENI can allocate Pod IP.
 	ipAllocInhibitExpireAt time.Time
 }
@@ -110,19 +147,22 @@ func (e *ENI) getIPCountLocked() int {
 func (e *ENI) allocateWorker(resultChan chan<- *ENIIP) {
 	for {
 		toAllocate := 0
+		var requests []trace.SpanContext
 		select {
 		case <-e.done:
 			return
-		case <-e.ipBacklog:
+		case sc := <-e.ipBacklog:
 			toAllocate = 1
+			requests = append(requests, sc)
 		}
 		// wait 300ms for aggregation the cni request
 		time.Sleep(300 * time.Millisecond)
 	popAll:
 		for {
 			select {
-			case <-e.ipBacklog:
+			case sc := <-e.ipBacklog:
 				toAllocate++
+				requests = append(requests, sc)
 			default:
 				break popAll
 			}
@@ -131,7 +171,9 @@ func (e *ENI) allocateWorker(resultChan chan<- *ENIIP) {
 			}
 		}
 		eniIPLog.Debugf("allocate %v ips for eni", toAllocate)
-		v4, v6, err := e.ecs.AssignNIPsForENI(context.Background(), e.ENI.ID, e.ENI.MAC, toAllocate)
+		ctx, span := telemetry.StartBatch("eni/AllocateIPs", requests, telemetry.AttrENIID.String(e.ENI.ID), telemetry.AttrIPCount.Int(toAllocate))
+		v4, v6, err := e.ecs.AssignNIPsForENI(ctx, e.ENI.ID, e.ENI.MAC, toAllocate)
+		telemetry.End(span, err)
 		eniIPLog.Debugf("allocated ips for eni: eni = %+v, v4 = %+v,v6 = %+v, err = %v", e.ENI, v4, v6, err)
 		if err != nil {
 			eniIPLog.Errorf("error allocate ips for eni: %v", err)
@@ -141,7 +183,7 @@ func (e *ENI) allocateWorker(resultChan chan<- *ENIIP) {
 					ENIIP: &types.ENIIP{
 						ENI: e.ENI,
 					},
-					err: errors.Errorf("error assign ip for ENI: %v", err),
+					err: fmt.Errorf("error assign ip for ENI: %w", err),
 				}
 			}
 		} else {
@@ -159,6 +201,93 @@ func (e *ENI) allocateWorker(resultChan chan<- *ENIIP) {
 	}
 }
 
+// eni ip releaser
+func (e *ENI) releaseWorker() {
+	for {
+		var toRelease []*releaseRequest
+		select {
+		case <-e.done:
+			return
+		case req := <-e.releaseBacklog:
+			toRelease = append(toRelease, req)
+		}
+		// wait 300ms for aggregation the release request
+		time.Sleep(300 * time.Millisecond)
+	popAll:
+		for len(toRelease) < maxIPRelease {
+			select {
+			case req := <-e.releaseBacklog:
+				toRelease = append(toRelease, req)
+			default:
+				break popAll
+			}
+		}
+		e.releaseIPs(toRelease)
+	}
+}
+
+// releaseIPs unassign the ips in one call, when failed, metadata is checked to find out which ips are still on the eni
+func (e *ENI) releaseIPs(reqs []*releaseRequest) {
+	var v4, v6 []net.IP
+	for _, req := range reqs {
+		if req.ip.IPSet.IPv4 != nil {
+			v4 = append(v4, req.ip.IPSet.IPv4)
+		}
+		if req.ip.IPSet.IPv6 != nil {
+			v6 = append(v6, req.ip.IPSet.IPv6)
+		}
+	}
+	eniIPLog.Debugf("release %v ips for eni %s", len(reqs), e.ID)
+
+	ctx := context.Background()
+	err := e.ecs.UnAssignIPsForENI(ctx, e.ENI.ID, e.ENI.MAC, v4, v6)
+	if err == nil {
+		metric.ENIIPFactoryIPReleaseCount.WithLabelValues(e.MAC, metric.ENIIPAllocActionSucceed).Add(float64(len(reqs)))
+		for _, req := range reqs {
+			req.result <- nil
+		}
+		return
+	}
+	eniIPLog.Errorf("error release ips for eni %s: %v", e.ID, err)
+
+	remainV4, remainV6, metaErr := e.ecs.GetENIIPs(ctx, e.MAC)
+	if metaErr != nil {
+		eniIPLog.Errorf("error get ips for eni %s: %v", e.ID, metaErr)
+	}
+	failed := 0
+	for _, req := range reqs {
+		if metaErr == nil &&
+			(req.ip.IPSet.IPv4 == nil || !terwayIP.IPsIntersect([]net.IP{req.ip.IPSet.IPv4}, remainV4)) &&
+			(req.ip.IPSet.IPv6 == nil || !terwayIP.IPsIntersect([]net.IP{req.ip.IPSet.IPv6}, remainV6)) {
+			req.result <- nil
+			continue
+		}
+		failed++
+		req.result <- fmt.Errorf("error unassign eniip, %w: %v", pool.ErrInvalidResource, err)
+	}
+	metric.ENIIPFactoryIPReleaseCount.WithLabelValues(e.MAC, metric.ENIIPAllocActionSucceed).Add(float64(len(reqs) - failed))
+	metric.ENIIPFactoryIPReleaseCount.WithLabelValues(e.MAC, metric.ENIIPAllocActionFail).Add(float64(failed))
+}
+
+// releaseIP submit the ip to release worker and wait for the result
+func (e *ENI) releaseIP(ip *ENIIP) error {
+	req := &releaseRequest{
+		ip:     ip,
+		result: make(chan error, 1),
+	}
+	select {
+	case e.releaseBacklog <- req:
+	case <-e.done:
+		return fmt.Errorf("eni %s is disposed", e.ID)
+	}
+	select {
+	case err := <-req.result:
+		return err
+	case <-e.done:
+		return fmt.Errorf("eni %s is disposed", e.ID)
+	}
+}
+
 func (f *eniIPFactory) getEnis(ctx *AllocCtx) ([]*ENI, error) {
 	var (
 		enis        []*ENI
@@ -169,8 +298,11 @@ func (f *eniIPFactory) getEnis(ctx *AllocCtx) ([]*ENI, error) {
 	if enisLen <= 1 {
 		return f.enis, nil
 	}
-	// pre sort eni by ip count to balance ip allocation on enis
+	// pre sort eni by ip count to balance ip allocation on enis, or pack ips on fewer enis
 	sort.Slice(f.enis, func(i, j int) bool {
+		if f.allocationPolicy == types.IPAllocationPolicyPack {
+			return f.enis[i].getIPCountLocked() > f.enis[j].getIPCountLocked()
+		}
 		return f.enis[i].getIPCountLocked() < f.enis[j].getIPCountLocked()
 	})
 
@@ -218,7 +350,7 @@ func (f *eniIPFactory) submit(ctx *AllocCtx) error {
 	defer f.Unlock()
 	var enis []*ENI
 	enis, _ = f.getEnis(ctx)
-	for _, eni := range enis {
+	for _, eni := range drainingLast(enis) {
 		eniIPLog.Infof("check existing eni: %+v", eni)
 		eni.lock.Lock()
 		now := time.Now()
@@ -241,7 +373,7 @@ func (f *eniIPFactory) submit(ctx *AllocCtx) error {
 			"eni = %+v, eni.pending = %d, len(eni.ips) = %d, eni.MaxIPs = %d", eni, eni.pending, len(eni.ips), f.eniMaxIP)
 		if eni.getIPCountLocked() < f.eniMaxIP {
 			select {
-			case eni.ipBacklog <- struct{}{}:
+			case eni.ipBacklog <- ctx.SpanContext:
 			default:
 				eni.lock.Unlock()
 				continue
@@ -256,6 +388,20 @@ func (f *eniIPFactory) submit(ctx *AllocCtx) error {
 	return errors.Errorf("trigger ENIIP throttle, max operating concurrent: %v", maxIPBacklog)
 }
 
+// drainingLast move the draining enis to the end, so they are only used when the others are full
+func drainingLast(enis []*ENI) []*ENI {
+	result := make([]*ENI, 0, len(enis))
+	var draining []*ENI
+	for _, eni := range enis {
+		if eni.draining {
+			draining = append(draining, eni)
+			continue
+		}
+		result = append(result, eni)
+	}
+	return append(result, draining...)
+}
+
 func (f *eniIPFactory) popResult() (ip *types.ENIIP, err error) {
 	result := <-f.ipResultChan
 	eniIPLog.Debugf("pop result from resultChan: %+v", result)
@@ -282,7 +428,7 @@ func (f *eniIPFactory) popResult() (ip *types.ENIIP, err error) {
 				}
 			}
 		}
-		return nil, errors.Errorf("error allocate ip from eni: %v", result.err)
+		return nil, fmt.Errorf("error allocate ip from eni: %w", result.err)
 	}
 	f.Lock()
 	defer f.Unlock()
@@ -299,8 +445,8 @@ func (f *eniIPFactory) popResult() (ip *types.ENIIP, err error) {
 	return nil, errors.Errorf("unexpected eni ip allocated: %v", result)
 }
 
-func (f *eniIPFactory) Create(count int) ([]types.NetworkResource, error) {
-	ctx := &AllocCtx{}
+func (f *eniIPFactory) Create(ctx context.Context, count int) ([]types.NetworkResource, error) {
+	allocCtx := &AllocCtx{SpanContext: trace.SpanContextFromContext(ctx)}
 	var (
 		ipResult []types.NetworkResource
 		err      error
@@ -318,7 +464,7 @@ func (f *eniIPFactory) Create(count int) ([]types.NetworkResource, error) {
 
 	// find for available ENIs and submit for ip allocation
 	for ; waiting < count; waiting++ {
-		err = f.submit(ctx)
+		err = f.submit(allocCtx)
 		if err != nil {
 			break
 		}
@@ -335,7 +481,7 @@ func (f *eniIPFactory) Create(count int) ([]types.NetworkResource, error) {
 	}
 	if initENIIPCount > 0 {
 		eniIPLog.Debugf("create eni async, ip count: %+v", initENIIPCount)
-		_, err = f.createENIAsync(initENIIPCount)
+		_, err = f.createENIAsync(ctx, initENIIPCount)
 		if err == nil {
 			waiting += initENIIPCount
 		} else {
@@ -345,7 +491,7 @@ func (f *eniIPFactory) Create(count int) ([]types.NetworkResource, error) {
 
 	// no ip has been created
 	if waiting == 0 {
-		return ipResult, errors.Errorf("error submit ip create request: %v,%s", err, ctx.String())
+		return ipResult, fmt.Errorf("error submit ip create request: %w,%s", err, allocCtx.String())
 	}
 
 	var ip *types.ENIIP
@@ -358,7 +504,7 @@ func (f *eniIPFactory) Create(count int) ([]types.NetworkResource, error) {
 		}
 	}
 	if len(ipResult) == 0 {
-		return ipResult, errors.Errorf("error allocate ip address: %v", err)
+		return ipResult, fmt.Errorf("error allocate ip address: %w", err)
 	}
 
 	return ipResult, nil
@@ -395,7 +541,7 @@ func (f *eniIPFactory) Dispose(res types.NetworkResource) (err error) {
 	}
 
 	eni.lock.Lock()
-	if len(eni.ips) == 1 {
+	if len(eni.ips)-eni.releasing == 1 {
 		if f.enableTrunk && eni.Trunk {
 			eni.lock.Unlock()
 			return fmt.Errorf("trunk ENI %+v will not dispose", eni.ID)
@@ -405,6 +551,10 @@ func (f *eniIPFactory) Dispose(res types.NetworkResource) (err error) {
 			eni.lock.Unlock()
 			return fmt.Errorf("ENI have pending ips to be allocate")
 		}
+		if eni.releasing > 0 {
+			eni.lock.Unlock()
+			return fmt.Errorf("ENI have releasing ips")
+		}
 		// block ip allocate
 		eni.pending = f.eniMaxIP
 		eni.lock.Unlock()
@@ -436,26 +586,25 @@ func (f *eniIPFactory) Dispose(res types.NetworkResource) (err error) {
 		<-f.maxENI
 		return nil
 	}
-	eni.lock.Unlock()
 
 	// main ip of ENI, raise put_it_back error
 	if ip.ENI.PrimaryIP.IPv4.Equal(ip.IPSet.IPv4) {
+		eni.lock.Unlock()
 		// if in dual-stack and have no ipv6 address may need add one ip for it
 		return fmt.Errorf("ip to be release is primary ip of ENI")
 	}
+	// keep the eni from being disposed while ips releasing
+	eni.releasing++
+	eni.lock.Unlock()
 
-	var v4, v6 []net.IP
-	if ip.IPSet.IPv4 != nil {
-		v4 = append(v4, ip.IPSet.IPv4)
-	}
-	if ip.IPSet.IPv6 != nil {
-		v6 = append(v6, ip.IPSet.IPv6)
-	}
-	err = f.eniFactory.ecs.UnAssignIPsForENI(context.Background(), ip.ENI.ID, ip.ENI.MAC, v4, v6)
+	err = eni.releaseIP(eniip)
+
+	eni.lock.Lock()
+	eni.releasing--
 	if err != nil {
-		return fmt.Errorf("error unassign eniip, %v", err)
+		eni.lock.Unlock()
+		return err
 	}
-	eni.lock.Lock()
 	for i, e := range eni.ips {
 		if e.IPSet.IPv4.Equal(eniip.IPSet.IPv4) {
 			eni.ips[len(eni.ips)-1], eni.ips[i] = eni.ips[i], eni.ips[len(eni.ips)-1]
@@ -468,6 +617,93 @@ func (f *eniIPFactory) Dispose(res types.NetworkResource) (err error) {
 	return nil
 }
 
+// DisposeBatch dispose the ips concurrently, ips on the same eni are aggregated by the release worker
+func (f *eniIPFactory) DisposeBatch(res []types.NetworkResource) []error {
+	errs := make([]error, len(res))
+	var wg sync.WaitGroup
+	for i := range res {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = f.Dispose(res[i])
+		}(i)
+	}
+	wg.Wait()
+	return errs
+}
+
+// Draining return true if the ip is on the draining eni
+func (f *eniIPFactory) Draining(res types.NetworkResource) bool {
+	ip, ok := res.(*types.ENIIP)
+	if !ok || ip.ENI == nil {
+		return false
+	}
+	f.RLock()
+	defer f.RUnlock()
+	for _, eni := range f.enis {
+		if eni.ENI != nil && eni.ID == ip.ENI.ID {
+			return eni.draining
+		}
+	}
+	return false
+}
+
+// consolidate drain the emptiest eni when its ips can be held by the other enis.
+// ips on the draining eni are no longer allocated and disposed once idle, the eni is released with its last ip.
+func (f *eniIPFactory) consolidate() {
+	f.Lock()
+	defer f.Unlock()
+
+	var (
+		candidate, draining           *ENI
+		candidateCount, drainingCount int
+		free                          int
+	)
+	for _, eni := range f.enis {
+		if eni.ENI == nil {
+			// eni is creating
+			return
+		}
+		eni.lock.Lock()
+		count := eni.getIPCountLocked()
+		eni.lock.Unlock()
+
+		free += f.eniMaxIP - count
+		if eni.draining {
+			draining, drainingCount = eni, count
+			continue
+		}
+		if eni.Trunk || eni.ID == f.trunkOnEni {
+			continue
+		}
+		if candidate == nil || count < candidateCount {
+			candidate, candidateCount = eni, count
+		}
+	}
+
+	if draining != nil {
+		// cancel the drain if the ips can not be held by the other enis any more
+		if free-(f.eniMaxIP-drainingCount) < drainingCount {
+			draining.draining = false
+			eniIPLog.Infof("cancel draining eni %s, other enis have not enough room for %d ips", draining.ID, drainingCount)
+		}
+		return
+	}
+
+	if candidate == nil || len(f.enis) <= f.minENI {
+		return
+	}
+	if float64(candidateCount) >= float64(f.eniMaxIP)*f.consolidateThreshold {
+		return
+	}
+	if free-(f.eniMaxIP-candidateCount) < candidateCount {
+		return
+	}
+	candidate.draining = true
+	eniIPLog.Infof("draining eni %s with %d ips", candidate.ID, candidateCount)
+	_ = tracing.RecordNodeEvent(corev1.EventTypeNormal, "ENIConsolidate", fmt.Sprintf("draining eni %s with %d ips", candidate.ID, candidateCount))
+}
+
 // Check resource in remote
 func (f *eniIPFactory) Check(res types.NetworkResource) error {
 	eniIP, ok := res.(*types.ENIIP)
@@ -570,19 +806,29 @@ func (f *eniIPFactory) ListResource() (map[string]types.NetworkResource, error)
 }
 
 func (f *eniIPFactory) Reconcile() {
+	// the enis of groups have their own security groups
+	if f.group != "" {
+		return
+	}
 	// check security group
-	err := f.eniFactory.ecs.CheckEniSecurityGroup(context.Background(), f.eniFactory.securityGroups)
+	sgs := append(append([]string{}, f.eniFactory.securityGroups...), f.groupSecurityGroups...)
+	err := f.eniFactory.ecs.CheckEniSecurityGroup(context.Background(), sgs)
 	if err != nil {
 		_ = tracing.RecordNodeEvent(corev1.EventTypeWarning, "ResourceInvalid", fmt.Sprintf("eni has misconfiged security group. %s", err.Error()))
 	}
 }
 
-func (f *eniIPFactory) initialENI(eni *ENI, ipCount int) {
+func (f *eniIPFactory) initialENI(ctx context.Context, eni *ENI, ipCount int) {
 	if utils.IsWindowsOS() {
 		// NB(thxCode): create eni with one more IP in windows at initialization.
 		ipCount++
 	}
-	rawEni, err := f.eniFactory.CreateWithIPCount(ipCount, false)
+	rawEni, err := f.eniFactory.CreateWithIPCount(ctx, ipCount, false)
+	if err != nil && strings.Contains(err.Error(), apiErr.InvalidVSwitchIDIPNotEnough) {
+		f.Lock()
+		f.eniCreateInhibitExpireAt = time.Now().Add(eniIPAllocInhibitTimeout)
+		f.Unlock()
+	}
 	var ipv4s []net.IP
 	var ipv6s []net.IP
 	// eni operate finished
@@ -602,7 +848,7 @@ func (f *eniIPFactory) initialENI(eni *ENI, ipCount int) {
 			}
 			<-f.maxENI
 		} else {
-			ipv4s, ipv6s, err = f.eniFactory.ecs.GetENIIPs(context.Background(), eni.MAC)
+			ipv4s, ipv6s, err = f.eniFactory.ecs.GetENIIPs(ctx, eni.MAC)
 			if err != nil {
 				eniIPLog.Errorf("error get eni secondary address: %+v, rollback it", err)
 				errDispose := f.eniFactory.Dispose(rawEni[0])
@@ -687,16 +933,44 @@ func (f *eniIPFactory) initialENI(eni *ENI, ipCount int) {
 
 	eni.lock.Unlock()
 	go eni.allocateWorker(f.ipResultChan)
+	go eni.releaseWorker()
+}
+
+// ipSlots returns the count of ips the enis can hold now, the ips not allocated yet included.
+// The enis draining or with vSwitch running out of ips only count the ips they hold.
+func (f *eniIPFactory) ipSlots(now time.Time) int {
+	f.RLock()
+	defer f.RUnlock()
+	slots := 0
+	for _, eni := range f.enis {
+		eni.lock.Lock()
+		if eni.draining || now.Before(eni.ipAllocInhibitExpireAt) {
+			slots += len(eni.ips)
+		} else {
+			slots += f.eniMaxIP
+		}
+		eni.lock.Unlock()
+	}
+	return slots
+}
+
+// canCreateENI returns false when the vSwitches are running out of ips for new enis
+func (f *eniIPFactory) canCreateENI(now time.Time) bool {
+	f.RLock()
+	defer f.RUnlock()
+	return !now.Before(f.eniCreateInhibitExpireAt)
 }
 
-func (f *eniIPFactory) createENIAsync(initIPs int) (*ENI, error) {
+func (f *eniIPFactory) createENIAsync(ctx context.Context, initIPs int) (*ENI, error) {
 	eni := &ENI{
 		ENI:       nil,
 		ips:       make([]*ENIIP, 0),
 		pending:   initIPs,
-		ipBacklog: make(chan struct{}, maxIPBacklog),
+		ipBacklog: make(chan trace.SpanContext, maxIPBacklog),
 		ecs:       f.eniFactory.ecs,
 		done:      make(chan struct{}, 1),
+
+		releaseBacklog: make(chan *releaseRequest, maxIPRelease),
 	}
 	select {
 	case f.maxENI <- struct{}{}:
@@ -706,9 +980,9 @@ func (f *eniIPFactory) createENIAsync(initIPs int) (*ENI, error) {
 			<-f.maxENI
 			return nil, fmt.Errorf("trigger ENI throttle, max operating concurrent: %v", maxEniOperating)
 		}
-		go f.initialENI(eni, eni.pending)
+		go f.initialENI(ctx, eni, eni.pending)
 	default:
-		return nil, fmt.Errorf("max ENI exceeded")
+		return nil, errMaxENIExceeded
 	}
 	f.Lock()
 	f.enis = append(f.enis, eni)
@@ -756,6 +1030,11 @@ func (f *eniIPFactory) Trace() []tracing.MapKeyValueEntry {
 			Key:   fmt.Sprintf("eni/%s/ip_alloc_inhibit_expire_at", v.MAC),
 			Value: v.ipAllocInhibitExpireAt.Format(timeFormat),
 		})
+
+		trace = append(trace, tracing.MapKeyValueEntry{
+			Key:   fmt.Sprintf("eni/%s/draining", v.MAC),
+			Value: fmt.Sprint(v.draining),
+		})
 	}
 
 	trace[1].Value = fmt.Sprint(secIPCount)
@@ -845,6 +1124,48 @@ func (f *eniIPFactory) checkAccount(message chan<- string) {
 type eniIPResourceManager struct {
 	trunkENI *types.ENI
 	pool     pool.ObjectPool
+	// groups is the pool of each eni group, keyed by group name
+	groups map[string]pool.ObjectPool
+	// factories is the default factory and the factories of the groups, they share the eni quota
+	factories []*eniIPFactory
+}
+
+// ipCapacity returns the count of ips the node can give out now, the ips in use included.
+// The enis not created yet are counted when any factory is able to create eni.
+func (m *eniIPResourceManager) ipCapacity(now time.Time) int {
+	if len(m.factories) == 0 {
+		return 0
+	}
+	capacity := 0
+	canCreate := false
+	for _, f := range m.factories {
+		capacity += f.ipSlots(now)
+		canCreate = canCreate || f.canCreateENI(now)
+	}
+	base := m.factories[0]
+	if canCreate {
+		capacity += (cap(base.maxENI) - len(base.maxENI)) * base.eniMaxIP
+	}
+	return capacity
+}
+
+// eniIPAllocatable returns the count of ips advertised as aliyun/eni-ip. The ips held by the local pods not
+// requesting aliyun/eni-ip, like the pods running before the resource is enabled, are excluded, as kubelet
+// does not count them in the allocated resource of the node.
+func (m *eniIPResourceManager) eniIPAllocatable(now time.Time, k8s Kubernetes) (int, error) {
+	pods, err := k8s.GetLocalPods()
+	if err != nil {
+		return 0, err
+	}
+	allocatable := m.ipCapacity(now)
+	for _, pod := range pods {
+		if pod.HostNetwork || pod.SandboxExited || pod.PodENI || pod.ENIIPRequested ||
+			pod.PodNetworkType != podNetworkTypeENIMultiIP {
+			continue
+		}
+		allocatable--
+	}
+	return allocatable, nil
 }
 
 func newENIIPResourceManager(poolConfig *types.PoolConfig, ecs ipam.API, k8s Kubernetes, allocatedResources map[string]resourceManagerInitItem, ipFamily *types.IPFamily) (ResourceManager, error) {
@@ -861,6 +1182,10 @@ func newENIIPResourceManager(poolConfig *types.PoolConfig, ecs ipam.API, k8s Kub
 		eniOperChan:  make(chan struct{}, maxEniOperating),
 		ipResultChan: make(chan *ENIIP, maxIPBacklog),
 		ipFamily:     ipFamily,
+
+		allocationPolicy:     poolConfig.IPAllocationPolicy,
+		minENI:               poolConfig.MinENI,
+		consolidateThreshold: poolConfig.ENIConsolidateThreshold,
 	}
 	var capacity, maxEni, memberENIPod, adapters int
 
@@ -933,6 +1258,8 @@ func newENIIPResourceManager(poolConfig *types.PoolConfig, ecs ipam.API, k8s Kub
 	// eniip factory metrics
 	factory.metricENICount = metric.ENIIPFactoryENICount.WithLabelValues(factory.name, fmt.Sprint(maxEni))
 	var trunkENI *types.ENI
+	// enis of each group, classified by the default pool initializer
+	var groupENIs map[string][]*types.ENI
 	poolCfg := pool.Config{
 		Name:     poolNameENIIP,
 		Type:     typeNameENIIP,
@@ -958,7 +1285,7 @@ func newENIIPResourceManager(poolConfig *types.PoolConfig, ecs ipam.API, k8s Kub
 					}
 				}
 				if factory.trunkOnEni == "" && len(enis) < adapters {
-					trunkENIRes, err := factory.eniFactory.CreateWithIPCount(1, true)
+					trunkENIRes, err := factory.eniFactory.CreateWithIPCount(context.Background(), 1, true)
 					if err != nil {
 						return errors.Wrapf(err, "error init trunk eni")
 					}
@@ -968,116 +1295,12 @@ func newENIIPResourceManager(poolConfig *types.PoolConfig, ecs ipam.API, k8s Kub
 				}
 			}
 
-			for _, eni := range enis {
-				ipv4s, ipv6s, err := ecs.GetENIIPs(ctx, eni.MAC)
-				if err != nil {
-					return fmt.Errorf("error get ENI's ip on pool init, %w", err)
-				}
-				err = factory.setupENICompartment(eni)
-				if err != nil {
-					// NB(thxCode): an unbinding eni stuck and then block starting,
-					// we just ignore this kind of error.
-					if strings.Contains(err.Error(), "no interface with given MAC") {
-						continue
-					}
-					return errors.Wrap(err, "error setup eni compartment")
-				}
-				if utils.IsWindowsOS() {
-					// NB(thxCode): don't assign the primary IP of one assistant eni.
-					ipv4s, ipv6s = dropPrimaryIP(eni, ipv4s, ipv6s)
-				}
-				poolENI := &ENI{
-					ENI:       eni,
-					ips:       []*ENIIP{},
-					ecs:       ecs,
-					ipBacklog: make(chan struct{}, maxIPBacklog),
-					done:      make(chan struct{}, 1),
-				}
-				factory.enis = append(factory.enis, poolENI)
-				factory.metricENICount.Inc()
-				if ipFamily.IPv4 && !ipFamily.IPv6 {
-					for _, ip := range ipv4s {
-						eniIP := &types.ENIIP{
-							ENI:   eni,
-							IPSet: types.IPSet{IPv4: ip},
-						}
-						res, ok := allocatedResources[eniIP.GetResourceID()]
-
-						poolENI.ips = append(poolENI.ips, &ENIIP{
-							ENIIP: eniIP,
-						})
-						metric.ENIIPFactoryIPCount.WithLabelValues(factory.name, poolENI.MAC, fmt.Sprint(maxEni)).Inc()
-
-						if !ok {
-							holder.AddIdle(eniIP)
-						} else {
-							holder.AddInuse(eniIP, podInfoKey(res.podInfo.Namespace, res.podInfo.Name))
-						}
-					}
-				} else {
-					v4Map := terwayIP.ToIPMap(ipv4s)
-					v6Map := terwayIP.ToIPMap(ipv6s)
-
-					// put all local res in
-					for id, res := range allocatedResources {
-						if res.item.ENIMAC != eni.MAC {
-							continue
-						}
-						ipSet := types.IPSet{}
-						eniIP := &types.ENIIP{
-							ENI:   eni,
-							IPSet: *ipSet.SetIP(res.item.IPv4).SetIP(res.item.IPv6),
-						}
-
-						poolENI.ips = append(poolENI.ips, &ENIIP{
-							ENIIP: eniIP,
-						})
-						metric.ENIIPFactoryIPCount.WithLabelValues(factory.name, poolENI.MAC, fmt.Sprint(maxEni)).Inc()
-
-						holder.AddInuse(eniIP, podInfoKey(res.podInfo.Namespace, res.podInfo.Name))
-
-						if ipSet.IPv4 != nil {
-							delete(v4Map, ipSet.IPv4.String())
-						}
-						if ipSet.IPv6 != nil {
-							delete(v6Map, ipSet.IPv6.String())
-						}
-						delete(allocatedResources, id)
-					}
-					var v4List, v6List []net.IP
-					for _, v4 := range v4Map {
-						v4List = append(v4List, v4)
-					}
-					for _, v6 := range v6Map {
-						v6List = append(v6List, v6)
-					}
-					for _, unUsed := range types.MergeIPs(v4List, v6List) {
-						eniIP := &types.ENIIP{
-							ENI:   eni,
-							IPSet: unUsed,
-						}
-						poolENI.ips = append(poolENI.ips, &ENIIP{
-							ENIIP: eniIP,
-						})
-						metric.ENIIPFactoryIPCount.WithLabelValues(factory.name, poolENI.MAC, fmt.Sprint(maxEni)).Inc()
-
-						if ipFamily.IPv4 && ipFamily.IPv6 && (unUsed.IPv6 == nil || unUsed.IPv4 == nil) {
-							holder.AddInvalid(eniIP)
-						} else {
-							holder.AddIdle(eniIP)
-						}
-					}
-				}
-
-				eniIPLog.Debugf("init factory's exist ENI: %+v", poolENI)
-				select {
-				case factory.maxENI <- struct{}{}:
-				default:
-					eniIPLog.Warnf("exist enis already over eni limits, maxENI config will not be available")
-				}
-				go poolENI.allocateWorker(factory.ipResultChan)
+			grouped, err := classifyENIs(ctx, ecs, enis, poolConfig.ENIGroups)
+			if err != nil {
+				return err
 			}
-			return nil
+			groupENIs = grouped
+			return factory.restoreENIs(ctx, holder, grouped[""], allocatedResources, maxEni)
 		},
 	}
 	p, err := pool.NewSimpleObjectPool(poolCfg)
@@ -1085,8 +1308,21 @@ func newENIIPResourceManager(poolConfig *types.PoolConfig, ecs ipam.API, k8s Kub
 		return nil, err
 	}
 	mgr := &eniIPResourceManager{
-		trunkENI: trunkENI,
-		pool:     p,
+		trunkENI:  trunkENI,
+		pool:      p,
+		groups:    make(map[string]pool.ObjectPool),
+		factories: []*eniIPFactory{factory},
+	}
+
+	for i := range poolConfig.ENIGroups {
+		group := &poolConfig.ENIGroups[i]
+		factory.groupSecurityGroups = append(factory.groupSecurityGroups, group.SecurityGroups...)
+		var groupFactory *eniIPFactory
+		mgr.groups[group.Name], groupFactory, err = newENIIPGroupPool(group, poolConfig, factory, groupENIs[group.Name], allocatedResources, capacity, maxEni)
+		if err != nil {
+			return nil, fmt.Errorf("error init eni group %s, %w", group.Name, err)
+		}
+		mgr.factories = append(mgr.factories, groupFactory)
 	}
 
 	//init device plugin for ENI
@@ -1102,24 +1338,282 @@ func newENIIPResourceManager(poolConfig *types.PoolConfig, ecs ipam.API, k8s Kub
 		}
 	}
 
+	if poolConfig.EnableENIIPResource && !poolConfig.DisableDevicePlugin {
+		dp := deviceplugin.NewENIDevicePlugin(capacity, deviceplugin.ENITypeENIIP)
+		setHealthy := func() {
+			allocatable, err := mgr.eniIPAllocatable(time.Now(), k8s)
+			if err != nil {
+				eniIPLog.Errorf("error get the allocatable eni ips: %v", err)
+				return
+			}
+			dp.SetHealthy(allocatable)
+		}
+		setHealthy()
+		err = dp.Serve()
+		if err != nil {
+			return nil, fmt.Errorf("error start eni ip device plugin on node, %w", err)
+		}
+		go wait.Until(setHealthy, eniIPCapacityCheckPeriod, wait.NeverStop)
+	}
+
+	if poolConfig.IPAllocationPolicy == types.IPAllocationPolicyPack && poolConfig.ENIConsolidateInterval > 0 {
+		go wait.JitterUntil(factory.consolidate, poolConfig.ENIConsolidateInterval, 0.2, true, wait.NeverStop)
+	}
+
 	_ = tracing.Register(tracing.ResourceTypeFactory, factory.name, factory)
 	return mgr, nil
 }
 
+// restoreENIs put the ips of the attached enis into the pool, ips used by the local pods are in use
+func (f *eniIPFactory) restoreENIs(ctx context.Context, holder pool.ResourceHolder, enis []*types.ENI, allocatedResources map[string]resourceManagerInitItem, maxEni int) error {
+	for _, eni := range enis {
+		ipv4s, ipv6s, err := f.eniFactory.ecs.GetENIIPs(ctx, eni.MAC)
+		if err != nil {
+			return fmt.Errorf("error get ENI's ip on pool init, %w", err)
+		}
+		err = f.setupENICompartment(eni)
+		if err != nil {
+			// NB(thxCode): an unbinding eni stuck and then block starting,
+			// we just ignore this kind of error.
+			if strings.Contains(err.Error(), "no interface with given MAC") {
+				continue
+			}
+			return errors.Wrap(err, "error setup eni compartment")
+		}
+		if utils.IsWindowsOS() {
+			// NB(thxCode): don't assign the primary IP of one assistant eni.
+			ipv4s, ipv6s = dropPrimaryIP(eni, ipv4s, ipv6s)
+		}
+		poolENI := &ENI{
+			ENI:       eni,
+			ips:       []*ENIIP{},
+			ecs:       f.eniFactory.ecs,
+			ipBacklog: make(chan trace.SpanContext, maxIPBacklog),
+			done:      make(chan struct{}, 1),
+
+			releaseBacklog: make(chan *releaseRequest, maxIPRelease),
+		}
+		f.enis = append(f.enis, poolENI)
+		f.metricENICount.Inc()
+		if f.ipFamily.IPv4 && !f.ipFamily.IPv6 {
+			for _, ip := range ipv4s {
+				eniIP := &types.ENIIP{
+					ENI:   eni,
+					IPSet: types.IPSet{IPv4: ip},
+				}
+				res, ok := allocatedResources[eniIP.GetResourceID()]
+
+				poolENI.ips = append(poolENI.ips, &ENIIP{
+					ENIIP: eniIP,
+				})
+				metric.ENIIPFactoryIPCount.WithLabelValues(f.name, poolENI.MAC, fmt.Sprint(maxEni)).Inc()
+
+				if !ok {
+					holder.AddIdle(eniIP)
+				} else {
+					holder.AddInuse(eniIP, podInfoKey(res.podInfo.Namespace, res.podInfo.Name))
+				}
+			}
+		} else {
+			v4Map := terwayIP.ToIPMap(ipv4s)
+			v6Map := terwayIP.ToIPMap(ipv6s)
+
+			// put all local res in
+			for id, res := range allocatedResources {
+				if res.item.ENIMAC != eni.MAC {
+					continue
+				}
+				ipSet := types.IPSet{}
+				eniIP := &types.ENIIP{
+					ENI:   eni,
+					IPSet: *ipSet.SetIP(res.item.IPv4).SetIP(res.item.IPv6),
+				}
+
+				poolENI.ips = append(poolENI.ips, &ENIIP{
+					ENIIP: eniIP,
+				})
+				metric.ENIIPFactoryIPCount.WithLabelValues(f.name, poolENI.MAC, fmt.Sprint(maxEni)).Inc()
+
+				holder.AddInuse(eniIP, podInfoKey(res.podInfo.Namespace, res.podInfo.Name))
+
+				if ipSet.IPv4 != nil {
+					delete(v4Map, ipSet.IPv4.String())
+				}
+				if ipSet.IPv6 != nil {
+					delete(v6Map, ipSet.IPv6.String())
+				}
+				delete(allocatedResources, id)
+			}
+			var v4List, v6List []net.IP
+			for _, v4 := range v4Map {
+				v4List = append(v4List, v4)
+			}
+			for _, v6 := range v6Map {
+				v6List = append(v6List, v6)
+			}
+			for _, unUsed := range types.MergeIPs(v4List, v6List) {
+				eniIP := &types.ENIIP{
+					ENI:   eni,
+					IPSet: unUsed,
+				}
+				poolENI.ips = append(poolENI.ips, &ENIIP{
+					ENIIP: eniIP,
+				})
+				metric.ENIIPFactoryIPCount.WithLabelValues(f.name, poolENI.MAC, fmt.Sprint(maxEni)).Inc()
+
+				if f.ipFamily.IPv4 && f.ipFamily.IPv6 && (unUsed.IPv6 == nil || unUsed.IPv4 == nil) {
+					holder.AddInvalid(eniIP)
+				} else {
+					holder.AddIdle(eniIP)
+				}
+			}
+		}
+
+		eniIPLog.Debugf("init factory's exist ENI: %+v", poolENI)
+		select {
+		case f.maxENI <- struct{}{}:
+		default:
+			eniIPLog.Warnf("exist enis already over eni limits, maxENI config will not be available")
+		}
+		go poolENI.allocateWorker(f.ipResultChan)
+		go poolENI.releaseWorker()
+	}
+	return nil
+}
+
+// newENIIPGroupPool create the pool for the eni group, the group share the eni quota with the default pool
+func newENIIPGroupPool(group *types.ENIGroupConfig, poolConfig *types.PoolConfig, base *eniIPFactory, enis []*types.ENI,
+	allocatedResources map[string]resourceManagerInitItem, capacity, maxEni int) (pool.ObjectPool, *eniIPFactory, error) {
+	groupConfig := *poolConfig
+	groupConfig.SecurityGroups = group.SecurityGroups
+	groupConfig.EnableENITrunking = false
+	if len(group.VSwitch) > 0 {
+		groupConfig.VSwitch = group.VSwitch
+	}
+	eniFactory, err := newENIFactory(&groupConfig, base.eniFactory.ecs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	factory := &eniIPFactory{
+		name:         factoryNameENIIP + "-" + group.Name,
+		group:        group.Name,
+		eniFactory:   eniFactory,
+		enis:         []*ENI{},
+		maxENI:       base.maxENI,
+		eniMaxIP:     base.eniMaxIP,
+		eniOperChan:  base.eniOperChan,
+		ipResultChan: make(chan *ENIIP, maxIPBacklog),
+		ipFamily:     base.ipFamily,
+
+		allocationPolicy:     poolConfig.IPAllocationPolicy,
+		consolidateThreshold: poolConfig.ENIConsolidateThreshold,
+	}
+	factory.metricENICount = metric.ENIIPFactoryENICount.WithLabelValues(factory.name, fmt.Sprint(maxEni))
+
+	minIdle, maxIdle := group.MinPoolSize, group.MaxPoolSize
+	if maxIdle > capacity {
+		maxIdle = capacity
+	}
+	if minIdle > maxIdle {
+		minIdle = maxIdle
+	}
+
+	p, err := pool.NewSimpleObjectPool(pool.Config{
+		Name:     poolNameENIIP + "-" + group.Name,
+		Type:     typeNameENIIP,
+		MaxIdle:  maxIdle,
+		MinIdle:  minIdle,
+		Factory:  factory,
+		Capacity: capacity,
+		Initializer: func(holder pool.ResourceHolder) error {
+			return factory.restoreENIs(context.Background(), holder, enis, allocatedResources, maxEni)
+		},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if poolConfig.IPAllocationPolicy == types.IPAllocationPolicyPack && poolConfig.ENIConsolidateInterval > 0 {
+		go wait.JitterUntil(factory.consolidate, poolConfig.ENIConsolidateInterval, 0.2, true, wait.NeverStop)
+	}
+
+	_ = tracing.Register(tracing.ResourceTypeFactory, factory.name, factory)
+	return p, factory, nil
+}
+
+// classifyENIs split the enis by the security groups, enis not belong to any group are in the default group ""
+func classifyENIs(ctx context.Context, ecs ipam.API, enis []*types.ENI, groups []types.ENIGroupConfig) (map[string][]*types.ENI, error) {
+	result := make(map[string][]*types.ENI)
+	if len(groups) == 0 {
+		result[""] = enis
+		return result, nil
+	}
+
+	var eniIDs []string
+	for _, eni := range enis {
+		eniIDs = append(eniIDs, eni.ID)
+	}
+	eniSGs, err := ecs.GetENISecurityGroups(ctx, eniIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error get security groups of enis, %w", err)
+	}
+
+	for _, eni := range enis {
+		name := ""
+		sgs := sets.NewString(eniSGs[eni.ID]...)
+		for _, group := range groups {
+			if !eni.Trunk && sgs.Equal(sets.NewString(group.SecurityGroups...)) {
+				name = group.Name
+				break
+			}
+		}
+		result[name] = append(result[name], eni)
+	}
+	return result, nil
+}
+
+// poolOf return the pool of the eni group, "" for the default pool
+func (m *eniIPResourceManager) poolOf(group string) (pool.ObjectPool, error) {
+	if group == "" {
+		return m.pool, nil
+	}
+	p, ok := m.groups[group]
+	if !ok {
+		return nil, fmt.Errorf("eni group %s not found", group)
+	}
+	return p, nil
+}
+
+// poolOfRes find the pool which the resource is allocated from
+func (m *eniIPResourceManager) poolOfRes(resID string) pool.ObjectPool {
+	for _, p := range m.groups {
+		if _, err := p.Stat(resID); err == nil {
+			return p
+		}
+	}
+	return m.pool
+}
+
 func (m *eniIPResourceManager) Allocate(ctx *networkContext, prefer string) (types.NetworkResource, error) {
-	return m.pool.Acquire(ctx, prefer, podInfoKey(ctx.pod.Namespace, ctx.pod.Name))
+	p, err := m.poolOf(ctx.pod.ENIGroup)
+	if err != nil {
+		return nil, err
+	}
+	return p.Acquire(ctx, prefer, podInfoKey(ctx.pod.Namespace, ctx.pod.Name))
 }
 
 func (m *eniIPResourceManager) Release(context *networkContext, resItem types.ResourceItem) error {
+	p := m.poolOfRes(resItem.ID)
 	if context != nil && context.pod != nil {
-		return m.pool.ReleaseWithReservation(resItem.ID, context.pod.IPStickTime)
+		return p.ReleaseWithReservation(resItem.ID, context.pod.IPStickTime)
 	}
-	return m.pool.Release(resItem.ID)
+	return p.Release(resItem.ID)
 }
 
 func (m *eniIPResourceManager) GarbageCollection(inUseResSet map[string]types.ResourceItem, expireResSet map[string]types.ResourceItem) error {
 	for expireRes, expireItem := range expireResSet {
-		if _, err := m.pool.Stat(expireRes); err == nil {
+		if _, err := m.poolOfRes(expireRes).Stat(expireRes); err == nil {
 			err = m.Release(nil, expireItem)
 			if err != nil {
 				return err
@@ -1130,11 +1624,35 @@ func (m *eniIPResourceManager) GarbageCollection(inUseResSet map[string]types.Re
 }
 
 func (m *eniIPResourceManager) Stat(context *networkContext, resID string) (types.NetworkResource, error) {
-	return m.pool.Stat(resID)
+	return m.poolOfRes(resID).Stat(resID)
 }
 
 func (m *eniIPResourceManager) GetResourceMapping() (tracing.ResourcePoolStats, error) {
-	return m.pool.GetResourceMapping()
+	stats, err := m.pool.GetResourceMapping()
+	if err != nil || len(m.groups) == 0 {
+		return stats, err
+	}
+	usage := &pool.Usage{
+		Local:  make(map[string]types.Res),
+		Remote: make(map[string]types.Res),
+	}
+	merge := func(stats tracing.ResourcePoolStats) {
+		for k, v := range stats.GetLocal() {
+			usage.Local[k] = v
+		}
+		for k, v := range stats.GetRemote() {
+			usage.Remote[k] = v
+		}
+	}
+	merge(stats)
+	for _, p := range m.groups {
+		stats, err = p.GetResourceMapping()
+		if err != nil {
+			return nil, err
+		}
+		merge(stats)
+	}
+	return usage, nil
 }
 
 func dropPrimaryIP(eni *types.ENI, ipv4s, ipv6s []net.IP) ([]net.IP, []net.IP) {
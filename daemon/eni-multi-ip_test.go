@@ -0,0 +1,269 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AliyunContainerService/terway/pkg/ipam"
+	"github.com/AliyunContainerService/terway/pkg/pool"
+	"github.com/AliyunContainerService/terway/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeReleaseAPI struct {
+	ipam.API
+
+	lock     sync.Mutex
+	calls    [][]net.IP
+	err      error
+	remainV4 []net.IP
+}
+
+func (f *fakeReleaseAPI) UnAssignIPsForENI(ctx context.Context, eniID, mac string, ipv4s []net.IP, ipv6s []net.IP) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.calls = append(f.calls, ipv4s)
+	return f.err
+}
+
+func (f *fakeReleaseAPI) GetENIIPs(ctx context.Context, mac string) ([]net.IP, []net.IP, error) {
+	return f.remainV4, nil, nil
+}
+
+func newReleaseTestENI(api ipam.API) *ENI {
+	return &ENI{
+		ENI: &types.ENI{
+			ID:  "eni-1",
+			MAC: "00:00:00:00:00:01",
+		},
+		ecs:            api,
+		done:           make(chan struct{}, 1),
+		releaseBacklog: make(chan *releaseRequest, maxIPRelease),
+	}
+}
+
+func releaseConcurrently(eni *ENI, ips []string) []error {
+	errs := make([]error, len(ips))
+	var wg sync.WaitGroup
+	for i := range ips {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = eni.releaseIP(&ENIIP{ENIIP: &types.ENIIP{ENI: eni.ENI, IPSet: types.IPSet{IPv4: net.ParseIP(ips[i])}}})
+		}(i)
+	}
+	wg.Wait()
+	return errs
+}
+
+func TestENIReleaseWorker_Batch(t *testing.T) {
+	api := &fakeReleaseAPI{}
+	eni := newReleaseTestENI(api)
+	go eni.releaseWorker()
+	defer close(eni.done)
+
+	errs := releaseConcurrently(eni, []string{"192.168.0.2", "192.168.0.3", "192.168.0.4"})
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Len(t, api.calls, 1)
+	assert.Len(t, api.calls[0], 3)
+}
+
+func TestENIReleaseWorker_PartialFailure(t *testing.T) {
+	api := &fakeReleaseAPI{
+		err:      errors.New("timeout"),
+		remainV4: []net.IP{net.ParseIP("192.168.0.3")},
+	}
+	eni := newReleaseTestENI(api)
+	go eni.releaseWorker()
+	defer close(eni.done)
+
+	errs := releaseConcurrently(eni, []string{"192.168.0.2", "192.168.0.3"})
+	assert.NoError(t, errs[0])
+	assert.True(t, errors.Is(errs[1], pool.ErrInvalidResource))
+}
+
+func newConsolidateTestENI(id string, ips int) *ENI {
+	eni := &ENI{
+		ENI: &types.ENI{ID: id},
+	}
+	for i := 0; i < ips; i++ {
+		eni.ips = append(eni.ips, &ENIIP{})
+	}
+	return eni
+}
+
+func TestDrainingLast(t *testing.T) {
+	eni1 := newConsolidateTestENI("eni-1", 1)
+	eni2 := newConsolidateTestENI("eni-2", 1)
+	eni3 := newConsolidateTestENI("eni-3", 1)
+	eni1.draining = true
+
+	enis := []*ENI{eni1, eni2, eni3}
+	assert.Equal(t, []*ENI{eni2, eni3, eni1}, drainingLast(enis))
+	assert.Equal(t, []*ENI{eni1, eni2, eni3}, enis)
+}
+
+func TestConsolidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		ips      []int
+		minENI   int
+		draining int
+	}{
+		{
+			name:     "drain the emptiest eni",
+			ips:      []int{8, 2, 5},
+			draining: 1,
+		},
+		{
+			name:     "usage above threshold",
+			ips:      []int{8, 4, 5},
+			draining: -1,
+		},
+		{
+			name:     "no room on other enis",
+			ips:      []int{9, 2, 10},
+			draining: -1,
+		},
+		{
+			name:     "keep min eni",
+			ips:      []int{8, 2},
+			minENI:   2,
+			draining: -1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &eniIPFactory{
+				eniMaxIP:             10,
+				minENI:               tt.minENI,
+				consolidateThreshold: 0.3,
+			}
+			for i, n := range tt.ips {
+				f.enis = append(f.enis, newConsolidateTestENI(fmt.Sprintf("eni-%d", i), n))
+			}
+			f.consolidate()
+			for i, eni := range f.enis {
+				assert.Equal(t, i == tt.draining, eni.draining, eni.ID)
+			}
+		})
+	}
+}
+
+func TestConsolidateCancel(t *testing.T) {
+	f := &eniIPFactory{
+		eniMaxIP:             10,
+		consolidateThreshold: 0.3,
+	}
+	f.enis = []*ENI{newConsolidateTestENI("eni-0", 8), newConsolidateTestENI("eni-1", 2)}
+	f.consolidate()
+	assert.True(t, f.enis[1].draining)
+	assert.True(t, f.Draining(&types.ENIIP{ENI: &types.ENI{ID: "eni-1"}}))
+
+	// other eni is full now
+	f.enis[0].ips = append(f.enis[0].ips, &ENIIP{}, &ENIIP{})
+	f.consolidate()
+	assert.False(t, f.enis[1].draining)
+}
+
+func TestIPCapacity(t *testing.T) {
+	now := time.Now()
+	maxENI := make(chan struct{}, 4)
+	f := &eniIPFactory{eniMaxIP: 10, maxENI: maxENI}
+	group := &eniIPFactory{eniMaxIP: 10, maxENI: maxENI}
+	for i, n := range []int{8, 2, 5} {
+		f.enis = append(f.enis, newConsolidateTestENI(fmt.Sprintf("eni-%d", i), n))
+		maxENI <- struct{}{}
+	}
+	mgr := &eniIPResourceManager{factories: []*eniIPFactory{f, group}}
+	assert.Equal(t, 40, mgr.ipCapacity(now))
+
+	// draining eni and eni with vSwitch running out of ips only count the held ips
+	f.enis[1].draining = true
+	f.enis[2].ipAllocInhibitExpireAt = now.Add(time.Minute)
+	assert.Equal(t, 27, mgr.ipCapacity(now))
+	assert.Equal(t, 32, mgr.ipCapacity(now.Add(2*time.Minute)))
+
+	// new eni is counted when any factory can create eni
+	f.eniCreateInhibitExpireAt = now.Add(time.Minute)
+	assert.Equal(t, 27, mgr.ipCapacity(now))
+	group.eniCreateInhibitExpireAt = now.Add(time.Minute)
+	assert.Equal(t, 17, mgr.ipCapacity(now))
+
+	assert.Equal(t, 0, (&eniIPResourceManager{}).ipCapacity(now))
+}
+
+type fakeLocalPodsKubernetes struct {
+	Kubernetes
+	pods []*types.PodInfo
+	err  error
+}
+
+func (f *fakeLocalPodsKubernetes) GetLocalPods() ([]*types.PodInfo, error) {
+	return f.pods, f.err
+}
+
+func TestENIIPAllocatable(t *testing.T) {
+	now := time.Now()
+	f := &eniIPFactory{eniMaxIP: 10, maxENI: make(chan struct{})}
+	f.enis = append(f.enis, newConsolidateTestENI("eni-0", 3))
+	mgr := &eniIPResourceManager{factories: []*eniIPFactory{f}}
+
+	k8s := &fakeLocalPodsKubernetes{pods: []*types.PodInfo{
+		{Name: "running", PodNetworkType: podNetworkTypeENIMultiIP},
+		{Name: "requested", PodNetworkType: podNetworkTypeENIMultiIP, ENIIPRequested: true},
+		{Name: "host", PodNetworkType: podNetworkTypeENIMultiIP, HostNetwork: true},
+		{Name: "exited", PodNetworkType: podNetworkTypeENIMultiIP, SandboxExited: true},
+		{Name: "trunk", PodNetworkType: podNetworkTypeENIMultiIP, PodENI: true},
+	}}
+	allocatable, err := mgr.eniIPAllocatable(now, k8s)
+	assert.NoError(t, err)
+	assert.Equal(t, 9, allocatable)
+
+	k8s.err = fmt.Errorf("apiserver unavailable")
+	_, err = mgr.eniIPAllocatable(now, k8s)
+	assert.Error(t, err)
+}
+
+type fakeSecurityGroupAPI struct {
+	ipam.API
+	sgs map[string][]string
+}
+
+func (f *fakeSecurityGroupAPI) GetENISecurityGroups(ctx context.Context, eniIDs []string) (map[string][]string, error) {
+	return f.sgs, nil
+}
+
+func TestClassifyENIs(t *testing.T) {
+	api := &fakeSecurityGroupAPI{
+		sgs: map[string][]string{
+			"eni-1": {"sg-default"},
+			"eni-2": {"sg-b", "sg-a"},
+			"eni-3": {"sg-c"},
+			"eni-4": {"sg-a", "sg-b"},
+		},
+	}
+	enis := []*types.ENI{{ID: "eni-1"}, {ID: "eni-2"}, {ID: "eni-3"}, {ID: "eni-4", Trunk: true}}
+	groups := []types.ENIGroupConfig{
+		{Name: "ab", SecurityGroups: []string{"sg-a", "sg-b"}},
+		{Name: "c", SecurityGroups: []string{"sg-c"}},
+	}
+
+	grouped, err := classifyENIs(context.Background(), api, enis, groups)
+	assert.NoError(t, err)
+	assert.Equal(t, []*types.ENI{enis[0], enis[3]}, grouped[""])
+	assert.Equal(t, []*types.ENI{enis[1]}, grouped["ab"])
+	assert.Equal(t, []*types.ENI{enis[2]}, grouped["c"])
+
+	grouped, err = classifyENIs(context.Background(), api, enis, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, enis, grouped[""])
+}
@@ -124,7 +124,7 @@ func newENIResourceManager(poolConfig *types.PoolConfig, ecs ipam.API, allocated
 					}
 				}
 				if factory.trunkOnEni == "" && len(enis) < capacity {
-					trunkENIRes, err := factory.CreateWithIPCount(1, true)
+					trunkENIRes, err := factory.CreateWithIPCount(context.Background(), 1, true)
 					if err != nil {
 						return errors.Wrapf(err, "error init trunk eni")
 					}
@@ -348,11 +348,11 @@ func (f *eniFactory) GetVSwitches() ([]string, error) {
 	return vSwitches, nil
 }
 
-func (f *eniFactory) Create(int) ([]types.NetworkResource, error) {
-	return f.CreateWithIPCount(1, false)
+func (f *eniFactory) Create(ctx context.Context, _ int) ([]types.NetworkResource, error) {
+	return f.CreateWithIPCount(ctx, 1, false)
 }
 
-func (f *eniFactory) CreateWithIPCount(count int, trunk bool) ([]types.NetworkResource, error) {
+func (f *eniFactory) CreateWithIPCount(ctx context.Context, count int, trunk bool) ([]types.NetworkResource, error) {
 	vSwitches, _ := f.GetVSwitches()
 	eniLog.Infof("adjusted vswitch slice: %+v", vSwitches)
 
@@ -362,7 +362,7 @@ func (f *eniFactory) CreateWithIPCount(count int, trunk bool) ([]types.NetworkRe
 	for k, v := range f.eniTags {
 		tags[k] = v
 	}
-	eni, err := f.ecs.AllocateENI(context.Background(), vSwitches[0], f.securityGroups, f.instanceID, trunk, count, tags)
+	eni, err := f.ecs.AllocateENI(ctx, vSwitches[0], f.securityGroups, f.instanceID, trunk, count, tags)
 	if err != nil {
 		return nil, err
 	}
@@ -28,8 +28,10 @@ import (
 	"gopkg.in/yaml.v2"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	apiTypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -39,6 +41,7 @@ import (
 	typedv1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 )
 
 const (
@@ -67,14 +70,22 @@ type Kubernetes interface {
 	PatchEipInfo(info *types.PodInfo) error
 	PatchTrunkInfo(trunkEni string) error
 	PatchPodIPInfo(info *types.PodInfo, ips string) error
+	PatchPodAnnotations(info *types.PodInfo, annotations map[string]string) error
 	WaitPodENIInfo(info *types.PodInfo) (podEni *podENITypes.PodENI, err error)
 	GetPodENIInfo(info *types.PodInfo) (podEni *podENITypes.PodENI, err error)
+	SetPodENIDatapathReady(info *types.PodInfo) error
+	GetPodEIP(info *types.PodInfo) (*podENITypes.PodEIP, error)
+	ReservePodEIP(info *types.PodInfo, eip *types.EIP, ttl time.Duration) error
+	UnassociatePodEIP(namespace, name, eipID string) (bool, error)
+	UpdateNodeNetworkStatus(status *podENITypes.NodeNetworkStatusStatus) error
+	SetNodeNetworkCondition(available bool, message string, taint bool) error
 	RecordNodeEvent(eventType, reason, message string)
 	RecordPodEvent(podName, podNamespace, eventType, reason, message string) error
 	GetNodeDynamicConfigLabel() string
 	GetDynamicConfigWithName(name string) (string, error)
 	SetSvcCidr(svcCidr *types.IPNetSet) error
 	SetCustomStatefulWorkloadKinds(kinds []string) error
+	SetENIGroups(groups []daemon.ENIGroup) error
 	WaitTrunkReady() (string, error)
 }
 
@@ -93,9 +104,17 @@ type k8s struct {
 	apiConn                 *connTracker
 	apiConnTime             time.Time
 	statefulWorkloadKindSet sets.String
+	eniGroups               []eniGroupSelector
 	sync.Locker
 }
 
+// eniGroupSelector select pods for the eni group
+type eniGroupSelector struct {
+	name              string
+	podSelector       labels.Selector
+	namespaceSelector labels.Selector
+}
+
 func (k *k8s) PatchTrunkInfo(trunkEni string) error {
 	node, err := k.client.CoreV1().Nodes().Get(context.TODO(), k.nodeName, metav1.GetOptions{
 		ResourceVersion: "0",
@@ -139,6 +158,78 @@ func (k *k8s) SetCustomStatefulWorkloadKinds(kinds []string) error {
 	return nil
 }
 
+func (k *k8s) SetENIGroups(groups []daemon.ENIGroup) error {
+	k.Lock()
+	defer k.Unlock()
+
+	k.eniGroups = nil
+	for _, group := range groups {
+		selector := eniGroupSelector{name: group.Name}
+		var err error
+		if group.PodSelector != nil {
+			selector.podSelector, err = metav1.LabelSelectorAsSelector(group.PodSelector)
+			if err != nil {
+				return err
+			}
+		}
+		if group.NamespaceSelector != nil {
+			selector.namespaceSelector, err = metav1.LabelSelectorAsSelector(group.NamespaceSelector)
+			if err != nil {
+				return err
+			}
+		}
+		k.eniGroups = append(k.eniGroups, selector)
+	}
+	return nil
+}
+
+// eniGroupOf return the eni group of the pod. The pod is only allowed in the groups selecting it, so the security groups
+// can not be chosen by the pod author. The annotation picks one of the matched groups, the first matched one is used otherwise.
+// An error is returned when the namespace can not be got, the pod must not fall back to another group.
+func (k *k8s) eniGroupOf(pod *corev1.Pod) (string, error) {
+	k.Lock()
+	groups := k.eniGroups
+	k.Unlock()
+
+	var (
+		nsLabels labels.Set
+		nsLoaded bool
+	)
+	matched := ""
+	for _, group := range groups {
+		if group.podSelector == nil && group.namespaceSelector == nil {
+			continue
+		}
+		if group.podSelector != nil && !group.podSelector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		if group.namespaceSelector != nil {
+			if !nsLoaded {
+				ns, err := k.client.CoreV1().Namespaces().Get(context.TODO(), pod.Namespace, metav1.GetOptions{
+					ResourceVersion: "0",
+				})
+				if err != nil {
+					return "", fmt.Errorf("error get namespace %s for eni group, %w", pod.Namespace, err)
+				}
+				nsLabels, nsLoaded = ns.Labels, true
+			}
+			if !group.namespaceSelector.Matches(nsLabels) {
+				continue
+			}
+		}
+		if pod.Annotations[types.PodENIGroup] == group.name {
+			return group.name, nil
+		}
+		if matched == "" {
+			matched = group.name
+		}
+	}
+	if name, ok := pod.Annotations[types.PodENIGroup]; ok && name != matched {
+		log.Warnf("pod %s/%s is not selected by eni group %s in annotation, use group %q", pod.Namespace, pod.Name, name, matched)
+	}
+	return matched, nil
+}
+
 func (k *k8s) SetSvcCidr(svcCidr *types.IPNetSet) error {
 	k.Lock()
 	defer k.Unlock()
@@ -165,16 +256,16 @@ func (k *k8s) PatchEipInfo(info *types.PodInfo) error {
 	}
 
 	if pod.GetAnnotations() != nil {
-		if eip, ok := pod.GetAnnotations()[podEipAddress]; ok {
+		if eip, ok := pod.GetAnnotations()[types.PodEIPAddress]; ok {
 			if eip == info.EipInfo.PodEipIP {
 				return nil
 			}
 			return errors.Errorf("Pod already have eip annotation: %v", eip)
 		}
 	}
-	pod.Annotations[podEipAddress] = info.EipInfo.PodEipIP
+	pod.Annotations[types.PodEIPAddress] = info.EipInfo.PodEipIP
 
-	annotationPatchStr := fmt.Sprintf(`{"metadata":{"annotations":{"%v":"%v"}}}`, podEipAddress, info.EipInfo.PodEipIP)
+	annotationPatchStr := fmt.Sprintf(`{"metadata":{"annotations":{"%v":"%v"}}}`, types.PodEIPAddress, info.EipInfo.PodEipIP)
 
 	_, err = k.client.CoreV1().Pods(info.Namespace).Patch(context.TODO(), info.Name, apiTypes.MergePatchType, []byte(annotationPatchStr), metav1.PatchOptions{})
 	if err != nil {
@@ -205,6 +296,32 @@ func (k *k8s) PatchPodIPInfo(info *types.PodInfo, ips string) error {
 	return nil
 }
 
+// PatchPodAnnotations merge the annotations into the pod, the annotations with empty value are removed
+func (k *k8s) PatchPodAnnotations(info *types.PodInfo, annotations map[string]string) error {
+	merge := make(map[string]interface{}, len(annotations))
+	for key, value := range annotations {
+		if value == "" {
+			merge[key] = nil
+			continue
+		}
+		merge[key] = value
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": merge,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = k.client.CoreV1().Pods(info.Namespace).Patch(context.TODO(), info.Name, apiTypes.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		k.reconnectOnTimeoutError(err)
+		return err
+	}
+	return nil
+}
+
 func (k *k8s) WaitPodENIInfo(info *types.PodInfo) (podEni *podENITypes.PodENI, err error) {
 	err = wait.ExponentialBackoff(backoff.Backoff(backoff.WaitPodENIStatus), func() (bool, error) {
 		podEni, err = k.podEniClient.PodENIs(info.Namespace).Get(context.TODO(), info.Name, metav1.GetOptions{
@@ -251,6 +368,213 @@ func (k *k8s) GetPodENIInfo(info *types.PodInfo) (podEni *podENITypes.PodENI, er
 	return podEni, err
 }
 
+// SetPodENIDatapathReady set the DatapathReady condition once the allocation is handed to cni
+func (k *k8s) SetPodENIDatapathReady(info *types.PodInfo) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		podENI, err := k.podEniClient.PodENIs(info.Namespace).Get(context.TODO(), info.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if meta.IsStatusConditionTrue(podENI.Status.Conditions, podENITypes.PodENIConditionDatapathReady) {
+			return nil
+		}
+		podENI.Status.SetCondition(podENITypes.PodENIConditionDatapathReady, metav1.ConditionTrue, podENITypes.PodENIReasonAllocated, fmt.Sprintf("allocated by %s", k.nodeName), podENI.Generation)
+		_, err = k.podEniClient.PodENIs(info.Namespace).UpdateStatus(context.TODO(), podENI, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// GetPodEIP get the eip reconciled by terway-controlplane
+func (k *k8s) GetPodEIP(info *types.PodInfo) (*podENITypes.PodEIP, error) {
+	return k.podEniClient.PodEIPs(info.Namespace).Get(context.TODO(), info.Name, metav1.GetOptions{
+		ResourceVersion: "0",
+	})
+}
+
+// ReservePodEIP record the eip associated to the pod in the PodEIP cr with the TTL release strategy, so the pod with
+// the same name on any node reuse it, and terway-controlplane release it after the ttl
+func (k *k8s) ReservePodEIP(info *types.PodInfo, eip *types.EIP, ttl time.Duration) error {
+	client := k.podEniClient.PodEIPs(info.Namespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		podEIP, err := client.Get(context.TODO(), info.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			podEIP, err = client.Create(context.TODO(), &podENITypes.PodEIP{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       info.Name,
+					Namespace:  info.Namespace,
+					Labels:     map[string]string{types.LabelEIPReservation: conditionTrue},
+					Finalizers: []string{types.FinalizerPodEIP},
+				},
+			}, metav1.CreateOptions{})
+		}
+		if err != nil {
+			return err
+		}
+		if !isEIPReservation(podEIP) {
+			return fmt.Errorf("PodEIP %s/%s is managed by terway-controlplane", info.Namespace, info.Name)
+		}
+		if !podEIP.DeletionTimestamp.IsZero() {
+			return fmt.Errorf("PodEIP %s/%s is being released", info.Namespace, info.Name)
+		}
+
+		if podEIP.Spec.ReleaseStrategy != podENITypes.EIPReleaseStrategyTTL || podEIP.Spec.ReleaseAfter != ttl.String() {
+			podEIP.Spec.ReleaseStrategy = podENITypes.EIPReleaseStrategyTTL
+			podEIP.Spec.ReleaseAfter = ttl.String()
+			podEIP, err = client.Update(context.TODO(), podEIP, metav1.UpdateOptions{})
+			if err != nil {
+				return err
+			}
+		}
+		podEIP.Status = podENITypes.PodEIPStatus{
+			Phase:            podENITypes.PodEIPPhaseAssociated,
+			AllocationID:     eip.ID,
+			EIPAddress:       eip.Address.String(),
+			Created:          true,
+			ENIID:            eip.AssociateENI,
+			PrivateIPAddress: eip.AssociateENIIP.String(),
+			PodUID:           info.PodUID,
+			PodLastSeen:      metav1.Now(),
+		}
+		_, err = client.UpdateStatus(context.TODO(), podEIP, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// UnassociatePodEIP mark the eip reserved for the pod unassociated, the ttl start from now.
+// false is returned if the eip is no longer reserved for the pod.
+func (k *k8s) UnassociatePodEIP(namespace, name, eipID string) (bool, error) {
+	client := k.podEniClient.PodEIPs(namespace)
+	reserved := false
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		reserved = false
+		podEIP, err := client.Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		if !isEIPReservation(podEIP) || !podEIP.DeletionTimestamp.IsZero() || podEIP.Status.AllocationID != eipID {
+			return nil
+		}
+		reserved = true
+		podEIP.Status.Phase = podENITypes.PodEIPPhaseUnassociated
+		podEIP.Status.ENIID = ""
+		podEIP.Status.PrivateIPAddress = ""
+		podEIP.Status.PodLastSeen = metav1.Now()
+		_, err = client.UpdateStatus(context.TODO(), podEIP, metav1.UpdateOptions{})
+		return err
+	})
+	return reserved, err
+}
+
+func isEIPReservation(podEIP *podENITypes.PodEIP) bool {
+	_, ok := podEIP.Labels[types.LabelEIPReservation]
+	return ok
+}
+
+// UpdateNodeNetworkStatus update the status of the NodeNetworkStatus cr of this node,
+// the cr is created if not exist and is owned by the node
+func (k *k8s) UpdateNodeNetworkStatus(status *podENITypes.NodeNetworkStatusStatus) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		nns, err := k.podEniClient.NodeNetworkStatuses().Get(context.TODO(), k.nodeName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			nns, err = k.podEniClient.NodeNetworkStatuses().Create(context.TODO(), &podENITypes.NodeNetworkStatus{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: k.nodeName,
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion: "v1",
+							Kind:       "Node",
+							Name:       k.node.Name,
+							UID:        k.node.UID,
+						},
+					},
+				},
+			}, metav1.CreateOptions{})
+		}
+		if err != nil {
+			return err
+		}
+		nns.Status = *status
+		_, err = k.podEniClient.NodeNetworkStatuses().UpdateStatus(context.TODO(), nns, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		k.reconnectOnTimeoutError(err)
+	}
+	return err
+}
+
+// SetNodeNetworkCondition set the TerwayNetworkAvailable condition of the node,
+// the NoSchedule taint is put on the node while unavailable if taint is true, and removed otherwise
+func (k *k8s) SetNodeNetworkCondition(available bool, message string, taint bool) error {
+	cond := corev1.NodeCondition{
+		Type:    types.NodeConditionNetworkAvailable,
+		Status:  corev1.ConditionTrue,
+		Reason:  reasonNetworkAvailable,
+		Message: message,
+	}
+	if !available {
+		cond.Status = corev1.ConditionFalse
+		cond.Reason = reasonResourceInsufficient
+	}
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		node, err := k.client.CoreV1().Nodes().Get(context.TODO(), k.nodeName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		now := metav1.Now()
+		cond.LastHeartbeatTime = now
+		cond.LastTransitionTime = now
+		found := false
+		for i, c := range node.Status.Conditions {
+			if c.Type != cond.Type {
+				continue
+			}
+			found = true
+			if c.Status == cond.Status {
+				cond.LastTransitionTime = c.LastTransitionTime
+			}
+			node.Status.Conditions[i] = cond
+		}
+		if !found {
+			node.Status.Conditions = append(node.Status.Conditions, cond)
+		}
+		node, err = k.client.CoreV1().Nodes().UpdateStatus(context.TODO(), node, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+
+		tainted := false
+		var taints []corev1.Taint
+		for _, t := range node.Spec.Taints {
+			if t.Key == types.TaintNetworkUnavailable {
+				tainted = true
+				continue
+			}
+			taints = append(taints, t)
+		}
+		if tainted == (taint && !available) {
+			return nil
+		}
+		if !tainted {
+			taints = append(taints, corev1.Taint{
+				Key:       types.TaintNetworkUnavailable,
+				Effect:    corev1.TaintEffectNoSchedule,
+				TimeAdded: &now,
+			})
+		}
+		node.Spec.Taints = taints
+		_, err = k.client.CoreV1().Nodes().Update(context.TODO(), node, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		k.reconnectOnTimeoutError(err)
+	}
+	return err
+}
+
 func (k *k8s) WaitTrunkReady() (string, error) {
 	id := ""
 	err := wait.ExponentialBackoff(backoff.Backoff(backoff.DefaultKey), func() (bool, error) {
@@ -452,18 +776,6 @@ const podNeedEni = "k8s.aliyun.com/ENI"
 const podIngressBandwidth = "k8s.aliyun.com/ingress-bandwidth" //deprecated
 const podEgressBandwidth = "k8s.aliyun.com/egress-bandwidth"   //deprecated
 
-const podWithEip = "k8s.aliyun.com/pod-with-eip"
-const eciWithEip = "k8s.aliyun.com/eci-with-eip" // to adopt ask annotation
-const podEipBandwidth = "k8s.aliyun.com/eip-bandwidth"
-const podEipChargeType = "k8s.aliyun.com/eip-charge-type"
-const podEipInternetChargeType = "k8s.aliyun.com/eip-internet-charge-type" // to adopt ask annotation
-const podEciEipInstanceID = "k8s.aliyun.com/eci-eip-instanceid"            // to adopt ask annotation
-const podPodEipInstanceID = "k8s.aliyun.com/pod-eip-instanceid"
-const podEipAddress = "k8s.aliyun.com/allocated-eipAddress"
-const eipBandwidthPackageID = "k8s.aliyun.com/eip-common-bandwidth-package-id"
-const eipISP = "k8s.aliyun.com/eip-isp"
-const eipPublicIPAddressPoolID = "k8s.aliyun.com/eip-public-ip-address-pool-id"
-
 const defaultStickTimeForSts = 5 * time.Minute
 
 var (
@@ -533,18 +845,18 @@ func convertPod(daemonMode string, statefulWorkloadKindSet sets.String, pod *cor
 		}
 	}
 
-	if eipAnnotation, ok := podAnnotation[podWithEip]; ok && eipAnnotation == conditionTrue {
+	if eipAnnotation, ok := podAnnotation[types.PodEIP]; ok && eipAnnotation == conditionTrue {
 		pi.EipInfo.PodEip = true
 		pi.EipInfo.PodEipBandWidth = 5
 		pi.EipInfo.PodEipChargeType = types.PayByTraffic
 	}
-	if eipAnnotation, ok := podAnnotation[eciWithEip]; ok && eipAnnotation == conditionTrue {
+	if eipAnnotation, ok := podAnnotation[types.ECIEIP]; ok && eipAnnotation == conditionTrue {
 		pi.EipInfo.PodEip = true
 		pi.EipInfo.PodEipBandWidth = 5
 		pi.EipInfo.PodEipChargeType = types.PayByTraffic
 	}
 
-	if eipAnnotation, ok := podAnnotation[podEipBandwidth]; ok {
+	if eipAnnotation, ok := podAnnotation[types.PodEIPBandwidth]; ok {
 		eipBandwidth, err := strconv.Atoi(eipAnnotation)
 		if err != nil {
 			log.Errorf("error convert eip bandwidth: %v", eipBandwidth)
@@ -553,35 +865,50 @@ func convertPod(daemonMode string, statefulWorkloadKindSet sets.String, pod *cor
 		}
 	}
 
-	if eipAnnotation, ok := podAnnotation[podEipChargeType]; ok {
+	if eipAnnotation, ok := podAnnotation[types.PodEIPChargeType]; ok {
 		pi.EipInfo.PodEipChargeType = types.InternetChargeType(eipAnnotation)
 	}
-	if eipAnnotation, ok := podAnnotation[podEipInternetChargeType]; ok {
+	if eipAnnotation, ok := podAnnotation[types.PodEIPInternetChargeType]; ok {
 		pi.EipInfo.PodEipChargeType = types.InternetChargeType(eipAnnotation)
 	}
 
-	if eipAnnotation, ok := podAnnotation[podEciEipInstanceID]; ok && eipAnnotation != "" {
+	if eipAnnotation, ok := podAnnotation[types.ECIEIPInstanceID]; ok && eipAnnotation != "" {
 		pi.EipInfo.PodEip = true
 		pi.EipInfo.PodEipID = eipAnnotation
 	}
 
-	if eipAnnotation, ok := podAnnotation[podPodEipInstanceID]; ok && eipAnnotation != "" {
+	if eipAnnotation, ok := podAnnotation[types.PodEIPInstanceID]; ok && eipAnnotation != "" {
 		pi.EipInfo.PodEip = true
 		pi.EipInfo.PodEipID = eipAnnotation
 	}
 
-	if eipAnnotation, ok := podAnnotation[eipISP]; ok && eipAnnotation != "" {
+	if eipAnnotation, ok := podAnnotation[types.EIPISP]; ok && eipAnnotation != "" {
 		pi.EipInfo.PodEipISP = eipAnnotation
 	}
 
-	if eipAnnotation, ok := podAnnotation[eipBandwidthPackageID]; ok && eipAnnotation != "" {
+	if eipAnnotation, ok := podAnnotation[types.EIPBandwidthPackageID]; ok && eipAnnotation != "" {
 		pi.EipInfo.PodEipBandwidthPackageID = eipAnnotation
 	}
-	if eipAnnotation, ok := podAnnotation[eipPublicIPAddressPoolID]; ok && eipAnnotation != "" {
+	if eipAnnotation, ok := podAnnotation[types.EIPPublicIPAddressPoolID]; ok && eipAnnotation != "" {
 		pi.EipInfo.PodEipPoolID = eipAnnotation
 	}
+	if eipAnnotation, ok := podAnnotation[types.PodEIPReuseTTL]; ok && eipAnnotation != "" {
+		if ttl, err := time.ParseDuration(eipAnnotation); err == nil && ttl > 0 {
+			pi.EipInfo.PodEipReuseTTL = ttl
+		} else {
+			_ = tracing.RecordPodEvent(pod.Name, pod.Namespace, eventTypeWarning,
+				"ParseFailed", fmt.Sprintf("Parse pod annotation %s failed.", types.PodEIPReuseTTL))
+		}
+	}
 
 	pi.SandboxExited = pod.Status.Phase == corev1.PodFailed || pod.Status.Phase == corev1.PodSucceeded
+	pi.HostNetwork = pod.Spec.HostNetwork
+	for _, c := range pod.Spec.Containers {
+		if _, ok := c.Resources.Requests[deviceplugin.ENIIPResName]; ok {
+			pi.ENIIPRequested = true
+			break
+		}
+	}
 
 	if podENI, ok := podAnnotation[types.PodENI]; ok {
 		var err error
@@ -709,6 +1036,10 @@ func (k *k8s) GetPod(namespace, name string) (*types.PodInfo, error) {
 		return nil, err
 	}
 	podInfo := convertPod(k.mode, k.statefulWorkloadKindSet, pod)
+	podInfo.ENIGroup, err = k.eniGroupOf(pod)
+	if err != nil {
+		return nil, err
+	}
 	item := &storageItem{
 		Pod: podInfo,
 	}
@@ -736,6 +1067,10 @@ func (k *k8s) GetLocalPods() ([]*types.PodInfo, error) {
 	var ret []*types.PodInfo
 	for _, pod := range list.Items {
 		podInfo := convertPod(k.mode, k.statefulWorkloadKindSet, &pod)
+		podInfo.ENIGroup, err = k.eniGroupOf(&pod)
+		if err != nil {
+			return nil, err
+		}
 		ret = append(ret, podInfo)
 	}
 
@@ -0,0 +1,125 @@
+package daemon
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/AliyunContainerService/terway/types"
+	"github.com/AliyunContainerService/terway/types/daemon"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestENIGroupOf(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "tenant-a",
+			Labels: map[string]string{"tenant": "a"},
+		},
+	}, &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "default",
+		},
+	})
+	k := &k8s{client: client, Locker: &sync.Mutex{}}
+	err := k.SetENIGroups([]daemon.ENIGroup{
+		{
+			Name:        "web",
+			PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+		},
+		{
+			Name:              "tenant-a",
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tenant": "a"}},
+		},
+		{
+			Name: "annotation-only",
+		},
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		pod   *corev1.Pod
+		group string
+	}{
+		{
+			name: "annotation not selecting the pod",
+			pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "default",
+				Labels:      map[string]string{"app": "web"},
+				Annotations: map[string]string{types.PodENIGroup: "annotation-only"},
+			}},
+			group: "web",
+		},
+		{
+			name: "annotation of unmatched group",
+			pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "default",
+				Annotations: map[string]string{types.PodENIGroup: "tenant-a"},
+			}},
+			group: "",
+		},
+		{
+			name: "annotation pick one of the matched groups",
+			pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "tenant-a",
+				Labels:      map[string]string{"app": "web"},
+				Annotations: map[string]string{types.PodENIGroup: "tenant-a"},
+			}},
+			group: "tenant-a",
+		},
+		{
+			name: "first matched group",
+			pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Namespace: "tenant-a",
+				Labels:    map[string]string{"app": "web"},
+			}},
+			group: "web",
+		},
+		{
+			name: "pod selector",
+			pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Labels:    map[string]string{"app": "web"},
+			}},
+			group: "web",
+		},
+		{
+			name: "namespace selector",
+			pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Namespace: "tenant-a",
+			}},
+			group: "tenant-a",
+		},
+		{
+			name: "default",
+			pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+			}},
+			group: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group, err := k.eniGroupOf(tt.pod)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.group, group)
+		})
+	}
+
+	// the pod must not fall back to the default or another group when the namespace is unknown
+	client.PrependReactor("get", "namespaces", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, fmt.Errorf("apiserver unavailable")
+	})
+	_, err = k.eniGroupOf(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "tenant-a",
+		Labels:    map[string]string{"app": "web"},
+	}})
+	assert.Error(t, err)
+}
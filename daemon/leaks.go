@@ -0,0 +1,293 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/AliyunContainerService/terway/pkg/tracing"
+	"github.com/AliyunContainerService/terway/types"
+)
+
+// leakInput is the view of node resources to find leaks from
+type leakInput struct {
+	// resType is the resource type managed by the pool
+	resType string
+	// pods is the alive pods on this node, key is namespace/name
+	pods      map[string]bool
+	relations []types.PodResources
+	poolStats tracing.ResourcePoolStats
+	// enis is the secondary enis attached to the node, value is the ips on the eni exclude the primary ip
+	enis map[string][]net.IP
+}
+
+// findLeaks cross-checks metadata, resource db, pool and pods, returns the discrepancies
+func findLeaks(in *leakInput) []*tracing.Leak {
+	var leaks []*tracing.Leak
+	local := in.poolStats.GetLocal()
+	remote := in.poolStats.GetRemote()
+
+	// resource id -> pod
+	bound := make(map[string]string)
+	for _, relation := range in.relations {
+		podKey := podInfoKey(relation.PodInfo.Namespace, relation.PodInfo.Name)
+		alive := in.pods[podKey]
+		for _, res := range relation.Resources {
+			if res.Type != in.resType {
+				continue
+			}
+			bound[res.ID] = podKey
+
+			switch {
+			case !alive && relation.PodInfo.IPStickTime == 0:
+				leaks = append(leaks, &tracing.Leak{
+					Type:       tracing.LeakStaleRelation,
+					ResourceID: res.ID,
+					PodName:    podKey,
+					Message:    "pod is not found in apiserver",
+				})
+			case local[res.ID] == nil:
+				leaks = append(leaks, &tracing.Leak{
+					Type:       tracing.LeakMissingLocal,
+					ResourceID: res.ID,
+					PodName:    podKey,
+					Message:    "resource is not found in pool",
+				})
+			}
+		}
+	}
+
+	for id, res := range local {
+		switch res.GetStatus() {
+		case types.ResStatusInvalid:
+			leaks = append(leaks, &tracing.Leak{
+				Type:       tracing.LeakInvalid,
+				ResourceID: id,
+				PodName:    bound[id],
+				Message:    "resource is invalid in pool",
+			})
+			continue
+		case types.ResStatusInUse:
+			if _, ok := bound[id]; !ok {
+				leaks = append(leaks, &tracing.Leak{
+					Type:       tracing.LeakOrphanInUse,
+					ResourceID: id,
+					Message:    "resource is in use in pool, but no pod relation in db",
+				})
+			}
+		}
+		if _, ok := remote[id]; !ok {
+			leaks = append(leaks, &tracing.Leak{
+				Type:       tracing.LeakMissingRemote,
+				ResourceID: id,
+				PodName:    bound[id],
+				Message:    "resource is not found in metadata",
+			})
+		}
+	}
+
+	leaks = append(leaks, findUntracked(in.resType, local, in.enis)...)
+
+	sort.Slice(leaks, func(i, j int) bool {
+		if leaks[i].Type != leaks[j].Type {
+			return leaks[i].Type < leaks[j].Type
+		}
+		return leaks[i].ResourceID < leaks[j].ResourceID
+	})
+	return leaks
+}
+
+// findUntracked find the enis or ips in metadata which is not known by the pool
+func findUntracked(resType string, local map[string]types.Res, enis map[string][]net.IP) []*tracing.Leak {
+	var leaks []*tracing.Leak
+	switch resType {
+	case types.ResourceTypeENI:
+		for mac := range enis {
+			if _, ok := local[mac]; !ok {
+				leaks = append(leaks, &tracing.Leak{
+					Type:       tracing.LeakUntracked,
+					ResourceID: mac,
+					Message:    "eni is attached, but not found in pool",
+				})
+			}
+		}
+	case types.ResourceTypeENIIP:
+		// the res id is <mac>.<ipv4>-<ipv6>
+		known := make(map[string]map[string]bool)
+		for id := range local {
+			parts := strings.SplitN(id, ".", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			if known[parts[0]] == nil {
+				known[parts[0]] = make(map[string]bool)
+			}
+			for _, ip := range strings.Split(parts[1], "-") {
+				known[parts[0]][ip] = true
+			}
+		}
+		for mac, ips := range enis {
+			// only the enis managed by the pool is checked
+			ipSet, ok := known[mac]
+			if !ok {
+				continue
+			}
+			for _, ip := range ips {
+				if ipSet[ip.String()] {
+					continue
+				}
+				leaks = append(leaks, &tracing.Leak{
+					Type:       tracing.LeakUntracked,
+					ResourceID: fmt.Sprintf("%s.%s", mac, ip),
+					Message:    "ip is assigned to eni, but not found in pool",
+				})
+			}
+		}
+	}
+	return leaks
+}
+
+// getENIIPs returns the secondary enis attached to the node and the ips exclude the primary ip, key is the eni mac
+func (n *networkService) getENIIPs() (map[string][]net.IP, error) {
+	addrs, err := getENIAddresses(context.Background(), n.api, n.trunkENIID())
+	if err != nil {
+		return nil, err
+	}
+	enis := make(map[string][]net.IP, len(addrs))
+	for _, addr := range addrs {
+		enis[addr.eni.MAC] = addr.ips
+	}
+	return enis, nil
+}
+
+// FindLeaks finds the resources not backing any pod, stale relations and orphan in use resources are released if repair is true.
+// The others need operations on OpenAPI, they are only reported.
+func (n *networkService) FindLeaks(repair bool) ([]*tracing.Leak, error) {
+	var (
+		mgr     ResourceManager
+		resType string
+	)
+	switch n.daemonMode {
+	case daemonModeENIMultiIP:
+		mgr, resType = n.eniIPResMgr, types.ResourceTypeENIIP
+	case daemonModeENIOnly:
+		mgr, resType = n.eniResMgr, types.ResourceTypeENI
+	default:
+		return nil, nil
+	}
+
+	enis, err := n.getENIIPs()
+	if err != nil {
+		return nil, fmt.Errorf("error get attached enis, %w", err)
+	}
+
+	if repair {
+		n.Lock()
+		defer n.Unlock()
+	} else {
+		n.RLock()
+		defer n.RUnlock()
+	}
+
+	// the pods must be listed with lock held, so pod allocating is not treated as stale
+	pods, err := n.k8s.GetLocalPods()
+	if err != nil {
+		return nil, fmt.Errorf("error get local pods, %w", err)
+	}
+	alive := make(map[string]bool, len(pods))
+	for _, pod := range pods {
+		if !pod.SandboxExited {
+			alive[podInfoKey(pod.Namespace, pod.Name)] = true
+		}
+	}
+
+	poolStats, err := mgr.GetResourceMapping()
+	if err != nil {
+		return nil, err
+	}
+	list, err := n.resourceDB.List()
+	if err != nil {
+		return nil, err
+	}
+	relations := make([]types.PodResources, 0, len(list))
+	for _, v := range list {
+		relations = append(relations, v.(types.PodResources))
+	}
+
+	in := &leakInput{
+		resType:   resType,
+		pods:      alive,
+		relations: relations,
+		poolStats: poolStats,
+		enis:      enis,
+	}
+	leaks := findLeaks(in)
+	if repair {
+		n.repairLeaks(mgr, in, leaks)
+	}
+	return leaks, nil
+}
+
+// repairLeaks release the stale relations and orphan in use resources, same as the garbage collection does
+func (n *networkService) repairLeaks(mgr ResourceManager, in *leakInput, leaks []*tracing.Leak) {
+	// the resource may be reused by another alive pod
+	inUse := make(map[string]bool)
+	relations := make(map[string]types.PodResources)
+	for _, relation := range in.relations {
+		podKey := podInfoKey(relation.PodInfo.Namespace, relation.PodInfo.Name)
+		relations[podKey] = relation
+		if !in.pods[podKey] {
+			continue
+		}
+		for _, res := range relation.Resources {
+			inUse[res.ID] = true
+		}
+	}
+
+	failed := make(map[string]bool)
+	for _, leak := range leaks {
+		var err error
+		switch leak.Type {
+		case tracing.LeakStaleRelation:
+			// the relation with other resources, like eip, is left to garbage collection
+			for _, res := range relations[leak.PodName].Resources {
+				if res.Type != in.resType {
+					err = fmt.Errorf("relation has %s resource %s", res.Type, res.ID)
+				}
+			}
+			if err == nil && !inUse[leak.ResourceID] {
+				err = mgr.GarbageCollection(nil, map[string]types.ResourceItem{
+					leak.ResourceID: {Type: in.resType, ID: leak.ResourceID},
+				})
+			}
+		case tracing.LeakOrphanInUse:
+			err = mgr.Release(nil, types.ResourceItem{Type: in.resType, ID: leak.ResourceID})
+		default:
+			continue
+		}
+		if err != nil {
+			serviceLog.Warnf("error repair leaked resource %s, %v", leak.ResourceID, err)
+			leak.Message = fmt.Sprintf("%s, repair failed: %v", leak.Message, err)
+			if leak.PodName != "" {
+				failed[leak.PodName] = true
+			}
+			continue
+		}
+		leak.Repaired = true
+		serviceLog.Infof("leaked resource %s repaired, type %d", leak.ResourceID, leak.Type)
+	}
+
+	for _, leak := range leaks {
+		if leak.Type != tracing.LeakStaleRelation || failed[leak.PodName] {
+			continue
+		}
+		// all resources of the relation are released
+		failed[leak.PodName] = true
+		err := n.resourceDB.Delete(leak.PodName)
+		if err != nil {
+			serviceLog.Warnf("error delete resource db relation %s, %v", leak.PodName, err)
+		}
+	}
+}
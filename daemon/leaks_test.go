@@ -0,0 +1,115 @@
+package daemon
+
+import (
+	"net"
+	"testing"
+
+	"github.com/AliyunContainerService/terway/pkg/tracing"
+	"github.com/AliyunContainerService/terway/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_findLeaks(t *testing.T) {
+	const mac = "00:16:3e:00:00:01"
+	res := func(ip string, status types.ResStatus) types.Res {
+		return &types.FakeRes{ID: mac + "." + ip, Type: types.ResourceTypeENIIP, Status: status}
+	}
+	relation := func(name, ip string) types.PodResources {
+		return types.PodResources{
+			PodInfo: &types.PodInfo{Namespace: "default", Name: name},
+			Resources: []types.ResourceItem{
+				{Type: types.ResourceTypeENIIP, ID: mac + "." + ip},
+				{Type: types.ResourceTypeEIP, ID: "eip-foo"},
+			},
+		}
+	}
+
+	in := &leakInput{
+		resType: types.ResourceTypeENIIP,
+		pods: map[string]bool{
+			"default/normal":  true,
+			"default/missing": true,
+		},
+		relations: []types.PodResources{
+			relation("normal", "192.168.0.1"),
+			relation("stale", "192.168.0.2"),
+			relation("missing", "192.168.0.3"),
+		},
+		poolStats: &tracing.FakeResourcePoolStats{
+			Local: map[string]types.Res{
+				mac + ".192.168.0.1": res("192.168.0.1", types.ResStatusInUse),
+				mac + ".192.168.0.2": res("192.168.0.2", types.ResStatusInUse),
+				mac + ".192.168.0.4": res("192.168.0.4", types.ResStatusInUse),
+				mac + ".192.168.0.5": res("192.168.0.5", types.ResStatusIdle),
+				mac + ".192.168.0.6": res("192.168.0.6", types.ResStatusInvalid),
+			},
+			Remote: map[string]types.Res{
+				mac + ".192.168.0.1": res("192.168.0.1", types.ResStatusInUse),
+				mac + ".192.168.0.2": res("192.168.0.2", types.ResStatusInUse),
+				mac + ".192.168.0.4": res("192.168.0.4", types.ResStatusInUse),
+			},
+		},
+		enis: map[string][]net.IP{
+			mac: {
+				net.ParseIP("192.168.0.1"),
+				net.ParseIP("192.168.0.2"),
+				net.ParseIP("192.168.0.4"),
+				net.ParseIP("192.168.0.6"),
+				net.ParseIP("192.168.0.7"),
+			},
+			// eni not managed by the pool is ignored
+			"00:16:3e:00:00:02": {net.ParseIP("192.168.1.1")},
+		},
+	}
+
+	leaks := findLeaks(in)
+
+	type leak struct {
+		typ tracing.LeakType
+		id  string
+		pod string
+	}
+	var got []leak
+	for _, l := range leaks {
+		got = append(got, leak{typ: l.Type, id: l.ResourceID, pod: l.PodName})
+	}
+	assert.Equal(t, []leak{
+		{typ: tracing.LeakStaleRelation, id: mac + ".192.168.0.2", pod: "default/stale"},
+		{typ: tracing.LeakOrphanInUse, id: mac + ".192.168.0.4"},
+		{typ: tracing.LeakMissingLocal, id: mac + ".192.168.0.3", pod: "default/missing"},
+		{typ: tracing.LeakMissingRemote, id: mac + ".192.168.0.5"},
+		{typ: tracing.LeakUntracked, id: mac + ".192.168.0.7"},
+		{typ: tracing.LeakInvalid, id: mac + ".192.168.0.6"},
+	}, got)
+}
+
+func Test_findLeaksStickyIP(t *testing.T) {
+	in := &leakInput{
+		resType: types.ResourceTypeENI,
+		pods:    map[string]bool{},
+		relations: []types.PodResources{
+			{
+				PodInfo:   &types.PodInfo{Namespace: "default", Name: "sts-0", IPStickTime: 1},
+				Resources: []types.ResourceItem{{Type: types.ResourceTypeENI, ID: "eni-mac"}},
+			},
+		},
+		poolStats: &tracing.FakeResourcePoolStats{
+			Local: map[string]types.Res{
+				"eni-mac": &types.FakeRes{ID: "eni-mac", Type: types.ResourceTypeENI, Status: types.ResStatusInUse},
+			},
+			Remote: map[string]types.Res{
+				"eni-mac": &types.FakeRes{ID: "eni-mac", Type: types.ResourceTypeENI, Status: types.ResStatusInUse},
+			},
+		},
+		enis: map[string][]net.IP{
+			"eni-mac":       nil,
+			"untracked-mac": nil,
+		},
+	}
+
+	leaks := findLeaks(in)
+	assert.Len(t, leaks, 1)
+	assert.Equal(t, tracing.LeakUntracked, leaks[0].Type)
+	assert.Equal(t, "untracked-mac", leaks[0].ResourceID)
+}
@@ -0,0 +1,185 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	apiErr "github.com/AliyunContainerService/terway/pkg/aliyun/client/errors"
+	"github.com/AliyunContainerService/terway/pkg/pool"
+	"github.com/AliyunContainerService/terway/types"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// networkAvailabilityCheckPeriod is the period the availability is evaluated
+const networkAvailabilityCheckPeriod = 10 * time.Second
+
+// networkAvailabilityProbePeriod is the min interval between the probes while the network is unavailable
+const networkAvailabilityProbePeriod = time.Minute
+
+// reasons of the TerwayNetworkAvailable condition
+const (
+	reasonNetworkAvailable     = "NetworkAvailable"
+	reasonResourceInsufficient = "ResourceInsufficient"
+)
+
+// networkAvailability track the results of allocation. The network is unavailable once the allocation keeps failing
+// for the eni/ip limit or the vswitches running out of ips longer than threshold, and is available again when an
+// allocation succeeds, a resource is released or the probe finds the resource available again.
+// The transient errors are ignored.
+type networkAvailability struct {
+	lock      sync.Mutex
+	threshold time.Duration
+	// failingSince is the time of the first insufficient failure, zero if the allocation is not failing
+	failingSince time.Time
+	lastErr      error
+
+	// reported is the last availability written to the node, nil before the first write
+	reported *bool
+
+	// probe returns whether the resource the allocation failed for is available again, nil to disable
+	probe     func(lastErr error) bool
+	lastProbe time.Time
+}
+
+func newNetworkAvailability(threshold time.Duration, probe func(lastErr error) bool) *networkAvailability {
+	return &networkAvailability{threshold: threshold, probe: probe}
+}
+
+// observe the result of an allocation
+func (a *networkAvailability) observe(err error) {
+	if a == nil {
+		return
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	switch {
+	case err == nil:
+		a.failingSince = time.Time{}
+		a.lastErr = nil
+	case isInsufficient(err):
+		if a.failingSince.IsZero() {
+			a.failingSince = time.Now()
+		}
+		a.lastErr = err
+	}
+}
+
+// released reset the failures, the released resource can be allocated to the next pod.
+// As the taint stops new pods, the allocation may never succeed to recover the node without this.
+func (a *networkAvailability) released() {
+	a.observe(nil)
+}
+
+// available returns whether the network is available at now, and the message for the condition
+func (a *networkAvailability) available(now time.Time) (bool, string) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.failingSince.IsZero() || now.Sub(a.failingSince) < a.threshold {
+		return true, "pod network resource is available"
+	}
+	return false, fmt.Sprintf("allocation keeps failing since %s: %v", a.failingSince.Format(time.RFC3339), a.lastErr)
+}
+
+// failure returns the last insufficient error, nil if the allocation is not failing
+func (a *networkAvailability) failure() error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return a.lastErr
+}
+
+// check evaluate the availability, and write it to the node on the first check or when changed.
+// While unavailable, the resource is probed as the node may get no pod to allocate for the taint.
+func (a *networkAvailability) check(now time.Time, update func(available bool, message string) error) {
+	available, message := a.available(now)
+	if !available && a.probe != nil && now.Sub(a.lastProbe) >= networkAvailabilityProbePeriod {
+		a.lastProbe = now
+		if a.probe(a.failure()) {
+			serviceLog.Infof("pod network resource is available again")
+			a.observe(nil)
+			available, message = a.available(now)
+		}
+	}
+	if a.reported != nil && *a.reported == available {
+		return
+	}
+	err := update(available, message)
+	if err != nil {
+		serviceLog.Warnf("error update node network condition, %v", err)
+		return
+	}
+	if available {
+		serviceLog.Infof("node network is available")
+	} else {
+		serviceLog.Warnf("node network is unavailable, %s", message)
+	}
+	a.reported = &available
+}
+
+// run the check loop
+func (a *networkAvailability) run(update func(available bool, message string) error) {
+	wait.Until(func() {
+		a.check(time.Now(), update)
+	}, networkAvailabilityCheckPeriod, wait.NeverStop)
+}
+
+// probeNetworkAvailable returns whether the resource the allocation failed for is available again: an idle resource in
+// the pool, or free ips in the vswitches if they were running out of ips.
+func (n *networkService) probeNetworkAvailable(vSwitches []string, lastErr error) bool {
+	var mgr ResourceManager
+	switch n.daemonMode {
+	case daemonModeENIMultiIP:
+		mgr = n.eniIPResMgr
+	case daemonModeENIOnly:
+		mgr = n.eniResMgr
+	}
+	if mgr != nil {
+		n.RLock()
+		stats, err := mgr.GetResourceMapping()
+		n.RUnlock()
+		if err != nil {
+			serviceLog.Warnf("error get resource mapping, %v", err)
+		} else {
+			for _, res := range stats.GetLocal() {
+				if res.GetStatus() == types.ResStatusIdle {
+					return true
+				}
+			}
+		}
+	}
+
+	// the limit of the node is only recovered by release
+	if lastErr == nil || !strings.Contains(lastErr.Error(), apiErr.InvalidVSwitchIDIPNotEnough) {
+		return false
+	}
+	for _, vSwitch := range vSwitches {
+		vsw, err := n.api.DescribeVSwitchByID(context.Background(), vSwitch)
+		if err != nil {
+			serviceLog.Warnf("error describe vswitch %s, %v", vSwitch, err)
+			continue
+		}
+		if vsw.AvailableIpAddressCount > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// isInsufficient returns whether the allocation failed for the eni/ip limit or the vswitches running out of ips,
+// which is not recovered by retry
+func isInsufficient(err error) bool {
+	if errors.Is(err, pool.ErrNoAvailableResource) || errors.Is(err, errMaxENIExceeded) {
+		return true
+	}
+	if apiErr.Classify(err) == apiErr.ClassQuotaExceeded {
+		return true
+	}
+	// the openapi error may be formatted into the message
+	return strings.Contains(err.Error(), apiErr.InvalidVSwitchIDIPNotEnough)
+}
@@ -0,0 +1,231 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	apiErr "github.com/AliyunContainerService/terway/pkg/aliyun/client/errors"
+	"github.com/AliyunContainerService/terway/pkg/ipam"
+	"github.com/AliyunContainerService/terway/pkg/pool"
+	"github.com/AliyunContainerService/terway/pkg/tracing"
+	"github.com/AliyunContainerService/terway/types"
+
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/vpc"
+
+	sdkErr "github.com/aliyun/alibaba-cloud-sdk-go/sdk/errors"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_isInsufficient(t *testing.T) {
+	assert.True(t, isInsufficient(fmt.Errorf("error create from factory: %w", pool.ErrNoAvailableResource)))
+	assert.True(t, isInsufficient(fmt.Errorf("error allocate ip address: %w", errMaxENIExceeded)))
+	assert.True(t, isInsufficient(sdkErr.NewServerError(403, "{\"Code\": \"QuotaExceed.ENI\"}", "")))
+	assert.True(t, isInsufficient(fmt.Errorf("error create eni, %s", apiErr.InvalidVSwitchIDIPNotEnough)))
+
+	assert.False(t, isInsufficient(errors.New("connection reset")))
+	assert.False(t, isInsufficient(sdkErr.NewServerError(400, "{\"Code\": \"Throttling\"}", "")))
+	assert.False(t, isInsufficient(context.DeadlineExceeded))
+}
+
+func Test_networkAvailability(t *testing.T) {
+	var nilAvailability *networkAvailability
+	nilAvailability.observe(pool.ErrNoAvailableResource)
+
+	a := newNetworkAvailability(time.Minute, nil)
+	var written []bool
+	update := func(available bool, message string) error {
+		written = append(written, available)
+		return nil
+	}
+
+	// the first check is always written
+	now := time.Now()
+	a.check(now, update)
+	assert.Equal(t, []bool{true}, written)
+
+	// transient errors are ignored
+	a.observe(errors.New("connection reset"))
+	a.check(now.Add(2*time.Minute), update)
+	assert.Equal(t, []bool{true}, written)
+
+	a.observe(pool.ErrNoAvailableResource)
+	a.observe(errMaxENIExceeded)
+	a.check(time.Now().Add(30*time.Second), update)
+	assert.Equal(t, []bool{true}, written)
+
+	available, message := a.available(time.Now().Add(2 * time.Minute))
+	assert.False(t, available)
+	assert.Contains(t, message, errMaxENIExceeded.Error())
+
+	a.check(time.Now().Add(2*time.Minute), update)
+	assert.Equal(t, []bool{true, false}, written)
+	a.check(time.Now().Add(3*time.Minute), update)
+	assert.Equal(t, []bool{true, false}, written)
+
+	// release recovers the node
+	a.released()
+	a.check(time.Now().Add(3*time.Minute), update)
+	assert.Equal(t, []bool{true, false, true}, written)
+
+	// failed write is retried on next check
+	a.observe(pool.ErrNoAvailableResource)
+	a.check(time.Now().Add(2*time.Minute), func(available bool, message string) error {
+		return errors.New("conflict")
+	})
+	assert.True(t, *a.reported)
+	a.check(time.Now().Add(2*time.Minute), update)
+	assert.Equal(t, []bool{true, false, true, false}, written)
+
+	a.observe(nil)
+	available, _ = a.available(time.Now().Add(time.Hour))
+	assert.True(t, available)
+}
+
+func Test_networkAvailabilityProbe(t *testing.T) {
+	var probed []error
+	recovered := false
+	a := newNetworkAvailability(time.Minute, func(lastErr error) bool {
+		probed = append(probed, lastErr)
+		return recovered
+	})
+	var written []bool
+	update := func(available bool, message string) error {
+		written = append(written, available)
+		return nil
+	}
+
+	now := time.Now()
+	a.observe(pool.ErrNoAvailableResource)
+	a.check(now, update)
+	assert.Empty(t, probed)
+	assert.Equal(t, []bool{true}, written)
+
+	a.check(now.Add(2*time.Minute), update)
+	assert.Equal(t, []error{pool.ErrNoAvailableResource}, probed)
+	assert.Equal(t, []bool{true, false}, written)
+
+	// the probe is throttled
+	a.check(now.Add(2*time.Minute+networkAvailabilityCheckPeriod), update)
+	assert.Len(t, probed, 1)
+
+	// recovered without any allocation or release
+	recovered = true
+	a.check(now.Add(3*time.Minute), update)
+	assert.Len(t, probed, 2)
+	assert.Equal(t, []bool{true, false, true}, written)
+	available, _ := a.available(now.Add(time.Hour))
+	assert.True(t, available)
+}
+
+type fakeProbeResourceManager struct {
+	ResourceManager
+	local map[string]types.Res
+}
+
+func (f *fakeProbeResourceManager) GetResourceMapping() (tracing.ResourcePoolStats, error) {
+	return &tracing.FakeResourcePoolStats{Local: f.local}, nil
+}
+
+type fakeVSwitchAPI struct {
+	ipam.API
+	available map[string]int64
+}
+
+func (f *fakeVSwitchAPI) DescribeVSwitchByID(ctx context.Context, vSwitch string) (*vpc.VSwitch, error) {
+	count, ok := f.available[vSwitch]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return &vpc.VSwitch{VSwitchId: vSwitch, AvailableIpAddressCount: count}, nil
+}
+
+func Test_probeNetworkAvailable(t *testing.T) {
+	mgr := &fakeProbeResourceManager{local: map[string]types.Res{
+		"eni-1.192.168.0.1": &types.FakeRes{ID: "eni-1.192.168.0.1", Status: types.ResStatusInUse},
+	}}
+	api := &fakeVSwitchAPI{available: map[string]int64{"vsw-1": 0, "vsw-2": 0}}
+	n := &networkService{daemonMode: daemonModeENIMultiIP, eniIPResMgr: mgr, api: api}
+	vSwitches := []string{"vsw-0", "vsw-1", "vsw-2"}
+	ipNotEnough := fmt.Errorf("error create eni, %s", apiErr.InvalidVSwitchIDIPNotEnough)
+
+	assert.False(t, n.probeNetworkAvailable(vSwitches, errMaxENIExceeded))
+	assert.False(t, n.probeNetworkAvailable(vSwitches, ipNotEnough))
+
+	// ips are released by other nodes
+	api.available["vsw-2"] = 10
+	assert.True(t, n.probeNetworkAvailable(vSwitches, ipNotEnough))
+	// the vswitch is not probed for the limit of node
+	assert.False(t, n.probeNetworkAvailable(vSwitches, errMaxENIExceeded))
+
+	// idle resource in pool
+	mgr.local["eni-1.192.168.0.2"] = &types.FakeRes{ID: "eni-1.192.168.0.2", Status: types.ResStatusIdle}
+	assert.True(t, n.probeNetworkAvailable(vSwitches, errMaxENIExceeded))
+}
+
+func TestSetNodeNetworkCondition(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Spec: corev1.NodeSpec{
+			Taints: []corev1.Taint{{Key: "foo", Effect: corev1.TaintEffectNoExecute}},
+		},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	})
+	k := &k8s{client: client, nodeName: "node-1"}
+
+	getNode := func() (*corev1.Node, *corev1.NodeCondition) {
+		node, err := client.CoreV1().Nodes().Get(context.Background(), "node-1", metav1.GetOptions{})
+		assert.NoError(t, err)
+		for i := range node.Status.Conditions {
+			if node.Status.Conditions[i].Type == types.NodeConditionNetworkAvailable {
+				return node, &node.Status.Conditions[i]
+			}
+		}
+		return node, nil
+	}
+
+	err := k.SetNodeNetworkCondition(true, "ok", true)
+	assert.NoError(t, err)
+	node, cond := getNode()
+	assert.Len(t, node.Status.Conditions, 2)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, reasonNetworkAvailable, cond.Reason)
+	assert.Len(t, node.Spec.Taints, 1)
+
+	err = k.SetNodeNetworkCondition(false, "ip not enough", true)
+	assert.NoError(t, err)
+	node, cond = getNode()
+	assert.Len(t, node.Status.Conditions, 2)
+	assert.Equal(t, corev1.ConditionFalse, cond.Status)
+	assert.Equal(t, reasonResourceInsufficient, cond.Reason)
+	assert.Equal(t, "ip not enough", cond.Message)
+	assert.Len(t, node.Spec.Taints, 2)
+	assert.Equal(t, types.TaintNetworkUnavailable, node.Spec.Taints[1].Key)
+	assert.Equal(t, corev1.TaintEffectNoSchedule, node.Spec.Taints[1].Effect)
+
+	// taint is not duplicated
+	err = k.SetNodeNetworkCondition(false, "ip not enough", true)
+	assert.NoError(t, err)
+	node, _ = getNode()
+	assert.Len(t, node.Spec.Taints, 2)
+
+	err = k.SetNodeNetworkCondition(true, "ok", true)
+	assert.NoError(t, err)
+	node, cond = getNode()
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+	assert.Equal(t, []corev1.Taint{{Key: "foo", Effect: corev1.TaintEffectNoExecute}}, node.Spec.Taints)
+
+	// taint is not put when disabled
+	err = k.SetNodeNetworkCondition(false, "ip not enough", false)
+	assert.NoError(t, err)
+	node, cond = getNode()
+	assert.Equal(t, corev1.ConditionFalse, cond.Status)
+	assert.Len(t, node.Spec.Taints, 1)
+}
@@ -0,0 +1,244 @@
+package daemon
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	podENITypes "github.com/AliyunContainerService/terway/pkg/apis/network.alibabacloud.com/v1beta1"
+	"github.com/AliyunContainerService/terway/pkg/tracing"
+	"github.com/AliyunContainerService/terway/types"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// nodeNetworkStatusResync is the period the status is written even nothing changed
+const nodeNetworkStatusResync = 5 * time.Minute
+
+// nodeStatusSyncer mirror the node network status to the NodeNetworkStatus cr,
+// the updates are triggered by allocation and release, and are at least interval apart
+type nodeStatusSyncer struct {
+	interval time.Duration
+	trigger  chan struct{}
+	// base is the static part of the status, like the instance and the limits
+	base podENITypes.NodeNetworkStatusStatus
+	last *podENITypes.NodeNetworkStatusStatus
+}
+
+func newNodeStatusSyncer(interval time.Duration, base podENITypes.NodeNetworkStatusStatus) *nodeStatusSyncer {
+	return &nodeStatusSyncer{
+		interval: interval,
+		trigger:  make(chan struct{}, 1),
+		base:     base,
+	}
+}
+
+// notify the syncer the status may be changed, the notifications in the interval are merged
+func (s *nodeStatusSyncer) notify() {
+	if s == nil {
+		return
+	}
+	select {
+	case s.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// run the sync loop, the status is always written on resync
+func (s *nodeStatusSyncer) run(sync func(force bool) error) {
+	resync := time.NewTicker(nodeNetworkStatusResync)
+	defer resync.Stop()
+	force := true
+	for {
+		err := sync(force)
+		if err != nil {
+			serviceLog.Warnf("error sync node network status, %v", err)
+		}
+		time.Sleep(s.interval)
+
+		select {
+		case <-s.trigger:
+			force = false
+		case <-resync.C:
+			force = true
+		}
+	}
+}
+
+// changed returns whether the status differs from the last written one, the update time is ignored
+func (s *nodeStatusSyncer) changed(status *podENITypes.NodeNetworkStatusStatus) bool {
+	if s.last == nil {
+		return true
+	}
+	last := *s.last
+	last.UpdateAt = status.UpdateAt
+	return !reflect.DeepEqual(&last, status)
+}
+
+// nodeStatusInput is the view of node resources to summarise
+type nodeStatusInput struct {
+	resType   string
+	poolStats tracing.ResourcePoolStats
+	relations []types.PodResources
+	enis      []*eniAddresses
+}
+
+// buildNodeNetworkStatus summarise the pool, the relations and the enis in metadata into the status
+func buildNodeNetworkStatus(base podENITypes.NodeNetworkStatusStatus, in *nodeStatusInput) *podENITypes.NodeNetworkStatusStatus {
+	status := base
+	status.Pool = podENITypes.NodeNetworkPool{}
+	status.ENIs = nil
+	if in.poolStats == nil {
+		return &status
+	}
+
+	// resource id -> pod
+	bound := make(map[string]string)
+	for _, relation := range in.relations {
+		for _, res := range relation.Resources {
+			if res.Type == in.resType {
+				bound[res.ID] = podInfoKey(relation.PodInfo.Namespace, relation.PodInfo.Name)
+			}
+		}
+	}
+
+	local := in.poolStats.GetLocal()
+	// mac -> ips in pool
+	ips := make(map[string][]podENITypes.NodeNetworkIP)
+	for id, res := range local {
+		resStatus := toNodeNetworkResourceStatus(res.GetStatus())
+		switch resStatus {
+		case podENITypes.NodeNetworkResourceIdle:
+			status.Pool.Idle++
+		case podENITypes.NodeNetworkResourceInUse:
+			status.Pool.InUse++
+		default:
+			status.Pool.Invalid++
+		}
+
+		if in.resType != types.ResourceTypeENIIP {
+			continue
+		}
+		// the res id is <mac>.<ipv4>-<ipv6>
+		parts := strings.SplitN(id, ".", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		ip := podENITypes.NodeNetworkIP{
+			Status: resStatus,
+			Pod:    bound[id],
+		}
+		for _, str := range strings.Split(parts[1], "-") {
+			addr := net.ParseIP(str)
+			switch {
+			case addr == nil:
+			case addr.To4() != nil:
+				ip.IPv4 = str
+			default:
+				ip.IPv6 = str
+			}
+		}
+		ips[parts[0]] = append(ips[parts[0]], ip)
+	}
+
+	for _, addr := range in.enis {
+		eni := podENITypes.NodeNetworkENI{
+			ID:        addr.eni.ID,
+			MAC:       addr.eni.MAC,
+			PrimaryIP: addr.eni.PrimaryIP.GetIPv4(),
+		}
+		switch in.resType {
+		case types.ResourceTypeENI:
+			res, ok := local[addr.eni.MAC]
+			if !ok {
+				break
+			}
+			status.Pool.ENIs++
+			eni.Status = toNodeNetworkResourceStatus(res.GetStatus())
+			eni.Pod = bound[addr.eni.MAC]
+		case types.ResourceTypeENIIP:
+			eni.IPs = ips[addr.eni.MAC]
+			if len(eni.IPs) == 0 {
+				break
+			}
+			status.Pool.ENIs++
+			sort.Slice(eni.IPs, func(i, j int) bool {
+				if eni.IPs[i].IPv4 != eni.IPs[j].IPv4 {
+					return eni.IPs[i].IPv4 < eni.IPs[j].IPv4
+				}
+				return eni.IPs[i].IPv6 < eni.IPs[j].IPv6
+			})
+		}
+		status.ENIs = append(status.ENIs, eni)
+	}
+	sort.Slice(status.ENIs, func(i, j int) bool {
+		return status.ENIs[i].MAC < status.ENIs[j].MAC
+	})
+	return &status
+}
+
+func toNodeNetworkResourceStatus(status types.ResStatus) string {
+	switch status {
+	case types.ResStatusIdle:
+		return podENITypes.NodeNetworkResourceIdle
+	case types.ResStatusInUse:
+		return podENITypes.NodeNetworkResourceInUse
+	}
+	return podENITypes.NodeNetworkResourceInvalid
+}
+
+// notifyNodeStatus trigger the update of NodeNetworkStatus cr if enabled
+func (n *networkService) notifyNodeStatus() {
+	n.nodeStatus.notify()
+}
+
+// syncNodeStatus write the status of node network resources to the NodeNetworkStatus cr,
+// the update is skipped if nothing changed unless force is true
+func (n *networkService) syncNodeStatus(force bool) error {
+	in := &nodeStatusInput{}
+	var mgr ResourceManager
+	switch n.daemonMode {
+	case daemonModeENIMultiIP:
+		mgr, in.resType = n.eniIPResMgr, types.ResourceTypeENIIP
+	case daemonModeENIOnly:
+		mgr, in.resType = n.eniResMgr, types.ResourceTypeENI
+	}
+
+	if mgr != nil {
+		enis, err := getENIAddresses(context.Background(), n.api, n.trunkENIID())
+		if err != nil {
+			return err
+		}
+		in.enis = enis
+
+		n.RLock()
+		in.poolStats, err = mgr.GetResourceMapping()
+		if err != nil {
+			n.RUnlock()
+			return err
+		}
+		list, err := n.resourceDB.List()
+		n.RUnlock()
+		if err != nil {
+			return err
+		}
+		for _, v := range list {
+			in.relations = append(in.relations, v.(types.PodResources))
+		}
+	}
+
+	status := buildNodeNetworkStatus(n.nodeStatus.base, in)
+	status.UpdateAt = metav1.Now()
+	if !force && !n.nodeStatus.changed(status) {
+		return nil
+	}
+	err := n.k8s.UpdateNodeNetworkStatus(status)
+	if err != nil {
+		return err
+	}
+	n.nodeStatus.last = status
+	return nil
+}
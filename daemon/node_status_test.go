@@ -0,0 +1,136 @@
+package daemon
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	podENITypes "github.com/AliyunContainerService/terway/pkg/apis/network.alibabacloud.com/v1beta1"
+	crdfake "github.com/AliyunContainerService/terway/pkg/generated/clientset/versioned/fake"
+	"github.com/AliyunContainerService/terway/pkg/tracing"
+	"github.com/AliyunContainerService/terway/types"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_buildNodeNetworkStatus(t *testing.T) {
+	const (
+		mac1 = "00:16:3e:00:00:01"
+		mac2 = "00:16:3e:00:00:02"
+	)
+	base := podENITypes.NodeNetworkStatusStatus{
+		Mode:       daemonModeENIMultiIP,
+		InstanceID: "i-1",
+	}
+	enis := []*eniAddresses{
+		{eni: &types.ENI{ID: "eni-2", MAC: mac2, PrimaryIP: types.IPSet{IPv4: net.ParseIP("192.168.1.10")}}},
+		{eni: &types.ENI{ID: "eni-1", MAC: mac1, PrimaryIP: types.IPSet{IPv4: net.ParseIP("192.168.0.10")}}},
+	}
+	res := func(id, resType string, status types.ResStatus) types.Res {
+		return &types.FakeRes{ID: id, Type: resType, Status: status}
+	}
+	relation := func(name, resType, id string) types.PodResources {
+		return types.PodResources{
+			PodInfo:   &types.PodInfo{Namespace: "default", Name: name},
+			Resources: []types.ResourceItem{{Type: resType, ID: id}},
+		}
+	}
+
+	status := buildNodeNetworkStatus(base, &nodeStatusInput{
+		resType: types.ResourceTypeENIIP,
+		poolStats: &tracing.FakeResourcePoolStats{
+			Local: map[string]types.Res{
+				mac1 + ".192.168.0.2-fd00::2": res(mac1+".192.168.0.2-fd00::2", types.ResourceTypeENIIP, types.ResStatusIdle),
+				mac1 + ".192.168.0.1-fd00::1": res(mac1+".192.168.0.1-fd00::1", types.ResourceTypeENIIP, types.ResStatusInUse),
+				mac1 + ".192.168.0.3-fd00::3": res(mac1+".192.168.0.3-fd00::3", types.ResourceTypeENIIP, types.ResStatusInvalid),
+			},
+		},
+		relations: []types.PodResources{relation("foo", types.ResourceTypeENIIP, mac1+".192.168.0.1-fd00::1")},
+		enis:      enis,
+	})
+	assert.Equal(t, &podENITypes.NodeNetworkStatusStatus{
+		Mode:       daemonModeENIMultiIP,
+		InstanceID: "i-1",
+		Pool:       podENITypes.NodeNetworkPool{ENIs: 1, Idle: 1, InUse: 1, Invalid: 1},
+		ENIs: []podENITypes.NodeNetworkENI{
+			{
+				ID:        "eni-1",
+				MAC:       mac1,
+				PrimaryIP: "192.168.0.10",
+				IPs: []podENITypes.NodeNetworkIP{
+					{IPv4: "192.168.0.1", IPv6: "fd00::1", Status: podENITypes.NodeNetworkResourceInUse, Pod: "default/foo"},
+					{IPv4: "192.168.0.2", IPv6: "fd00::2", Status: podENITypes.NodeNetworkResourceIdle},
+					{IPv4: "192.168.0.3", IPv6: "fd00::3", Status: podENITypes.NodeNetworkResourceInvalid},
+				},
+			},
+			{ID: "eni-2", MAC: mac2, PrimaryIP: "192.168.1.10"},
+		},
+	}, status)
+
+	base.Mode = daemonModeENIOnly
+	status = buildNodeNetworkStatus(base, &nodeStatusInput{
+		resType: types.ResourceTypeENI,
+		poolStats: &tracing.FakeResourcePoolStats{
+			Local: map[string]types.Res{
+				mac1: res(mac1, types.ResourceTypeENI, types.ResStatusInUse),
+			},
+		},
+		relations: []types.PodResources{relation("foo", types.ResourceTypeENI, mac1)},
+		enis:      enis,
+	})
+	assert.Equal(t, podENITypes.NodeNetworkPool{ENIs: 1, InUse: 1}, status.Pool)
+	assert.Equal(t, []podENITypes.NodeNetworkENI{
+		{ID: "eni-1", MAC: mac1, PrimaryIP: "192.168.0.10", Status: podENITypes.NodeNetworkResourceInUse, Pod: "default/foo"},
+		{ID: "eni-2", MAC: mac2, PrimaryIP: "192.168.1.10"},
+	}, status.ENIs)
+
+	// vpc mode has no pool
+	base.Mode = daemonModeVPC
+	status = buildNodeNetworkStatus(base, &nodeStatusInput{})
+	assert.Equal(t, &base, status)
+}
+
+func Test_nodeStatusSyncer(t *testing.T) {
+	var s *nodeStatusSyncer
+	// disabled
+	s.notify()
+
+	s = newNodeStatusSyncer(0, podENITypes.NodeNetworkStatusStatus{})
+	s.notify()
+	s.notify()
+	assert.Len(t, s.trigger, 1)
+
+	status := &podENITypes.NodeNetworkStatusStatus{Mode: daemonModeENIMultiIP, UpdateAt: metav1.Now()}
+	assert.True(t, s.changed(status))
+	s.last = status
+
+	same := status.DeepCopy()
+	same.UpdateAt = metav1.NewTime(status.UpdateAt.Add(1))
+	assert.False(t, s.changed(same))
+	same.Pool.Idle = 1
+	assert.True(t, s.changed(same))
+}
+
+func TestUpdateNodeNetworkStatus(t *testing.T) {
+	client := crdfake.NewSimpleClientset()
+	k := &k8s{
+		podEniClient: client.NetworkV1beta1(),
+		nodeName:     "node-1",
+		node:         &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1", UID: "uid-1"}},
+	}
+
+	err := k.UpdateNodeNetworkStatus(&podENITypes.NodeNetworkStatusStatus{Mode: daemonModeENIMultiIP})
+	assert.NoError(t, err)
+	nns, err := client.NetworkV1beta1().NodeNetworkStatuses().Get(context.Background(), "node-1", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, daemonModeENIMultiIP, nns.Status.Mode)
+	assert.Equal(t, "uid-1", string(nns.OwnerReferences[0].UID))
+
+	err = k.UpdateNodeNetworkStatus(&podENITypes.NodeNetworkStatusStatus{Mode: daemonModeENIMultiIP, Pool: podENITypes.NodeNetworkPool{Idle: 2}})
+	assert.NoError(t, err)
+	nns, err = client.NetworkV1beta1().NodeNetworkStatuses().Get(context.Background(), "node-1", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, nns.Status.Pool.Idle)
+}
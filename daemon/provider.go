@@ -0,0 +1,46 @@
+package daemon
+
+import (
+	"fmt"
+
+	"github.com/AliyunContainerService/terway/pkg/aliyun"
+	"github.com/AliyunContainerService/terway/pkg/aliyun/client"
+	"github.com/AliyunContainerService/terway/pkg/aliyun/credential"
+	"github.com/AliyunContainerService/terway/pkg/provider"
+	"github.com/AliyunContainerService/terway/pkg/tracing"
+	"github.com/AliyunContainerService/terway/pkg/utils"
+	"github.com/AliyunContainerService/terway/types"
+	"github.com/AliyunContainerService/terway/types/daemon"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// newProvider create the cloud backend selected by config
+func newProvider(cfg *daemon.Config, ipFamily *types.IPFamily) (provider.Provider, error) {
+	switch cfg.Provider {
+	case provider.NameStatic:
+		p, err := provider.NewStatic(cfg.StaticProvider, ipFamily)
+		if err != nil {
+			return nil, fmt.Errorf("error create static provider, %w", err)
+		}
+		// the node has no metadata service
+		aliyun.SetInstanceMeta(p.Instance())
+		serviceLog.Infof("using static provider, instance %+v", p.Instance())
+		return p, nil
+	default:
+		ins := aliyun.GetInstanceMeta()
+		aliyunClient, err := client.NewAliyun(cfg.AccessID, cfg.AccessSecret, ins.RegionID, utils.NormalizePath(cfg.CredentialPath), "", "", cfg.GetOIDCConfig())
+		if err != nil {
+			return nil, fmt.Errorf("error create aliyun client, %w", err)
+		}
+		if mgr, ok := aliyunClient.ClientSet.(*credential.ClientMgr); ok {
+			mgr.SetEventRecorder(func(eventType, reason, message string) {
+				_ = tracing.RecordNodeEvent(eventType, reason, message)
+			})
+			go mgr.Watch(wait.NeverStop)
+		}
+		_ = tracing.Register(tracing.ResourceTypeCircuitBreaker, "openapi", aliyunClient.Breakers)
+
+		return provider.NewAliyun(aliyunClient, cfg.EnableENITrunking && !cfg.WaitTrunkENI, ipFamily), nil
+	}
+}
@@ -0,0 +1,166 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/AliyunContainerService/terway/pkg/ipam"
+	"github.com/AliyunContainerService/terway/pkg/storage"
+	"github.com/AliyunContainerService/terway/pkg/tracing"
+	"github.com/AliyunContainerService/terway/pkg/utils"
+	"github.com/AliyunContainerService/terway/types"
+)
+
+// eniAddresses is an eni attached to the node and the secondary ips on it
+type eniAddresses struct {
+	eni *types.ENI
+	ips []net.IP
+}
+
+func deserializePodResources(bytes []byte) (interface{}, error) {
+	resourceRel := &types.PodResources{}
+	err := json.Unmarshal(bytes, resourceRel)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshal pod relate resource, %w", err)
+	}
+	return *resourceRel, nil
+}
+
+// openResourceDB open the resource db, if the db is corrupted or written by a newer version,
+// it is moved aside and the relations are rebuilt from the attached enis and the alive pods.
+func openResourceDB(daemonMode string, k8s Kubernetes, api ipam.API) (storage.Storage, error) {
+	path := utils.NormalizePath(resDBPath)
+	db, err := storage.NewVersionedDiskStorage(resDBName, path, types.PodResourcesSchema, json.Marshal, deserializePodResources)
+	if err == nil {
+		return db, nil
+	}
+	if !errors.Is(err, storage.ErrCorrupted) && !errors.Is(err, storage.ErrUnsupportedVersion) {
+		return nil, err
+	}
+
+	backup, qErr := storage.Quarantine(path)
+	if qErr != nil {
+		return nil, fmt.Errorf("error move aside resource db, %v, %w", qErr, err)
+	}
+	serviceLog.Errorf("resource db is unreadable, moved to %s and rebuild, %v", backup, err)
+	_ = tracing.RecordNodeEvent(eventTypeWarning, "ResourceDBRebuild",
+		fmt.Sprintf("resource db is unreadable and moved to %s, rebuild from metadata and pods: %v", backup, err))
+
+	db, err = storage.NewVersionedDiskStorage(resDBName, path, types.PodResourcesSchema, json.Marshal, deserializePodResources)
+	if err != nil {
+		return nil, err
+	}
+
+	var enis []*eniAddresses
+	switch daemonMode {
+	case daemonModeENIMultiIP, daemonModeENIOnly:
+		enis, err = getENIAddresses(context.Background(), api, "")
+		if err != nil {
+			return nil, fmt.Errorf("error get attached enis, %w", err)
+		}
+	}
+	pods, err := k8s.GetLocalPods()
+	if err != nil {
+		return nil, fmt.Errorf("error get local pods, %w", err)
+	}
+
+	for _, podRes := range rebuildPodResources(daemonMode, pods, enis) {
+		key := podInfoKey(podRes.PodInfo.Namespace, podRes.PodInfo.Name)
+		serviceLog.Infof("rebuild resource relation of %s, %+v", key, podRes.Resources)
+		err = db.Put(key, podRes)
+		if err != nil {
+			return nil, fmt.Errorf("error put rebuilt relation %s, %w", key, err)
+		}
+	}
+	return db, nil
+}
+
+// rebuildPodResources restore the relations of the alive pods by matching the pod ips with the ips on the enis.
+// Pods with resources not in metadata, like the veth and the member enis of trunk, are skipped.
+func rebuildPodResources(daemonMode string, pods []*types.PodInfo, enis []*eniAddresses) []types.PodResources {
+	var ret []types.PodResources
+	for _, pod := range pods {
+		if pod.SandboxExited || (pod.PodIPs.IPv4 == nil && pod.PodIPs.IPv6 == nil) {
+			continue
+		}
+
+		var items []types.ResourceItem
+		for _, addr := range enis {
+			switch {
+			case daemonMode == daemonModeENIOnly && pod.PodNetworkType == podNetworkTypeVPCENI:
+				if ipSetMatch(&pod.PodIPs, addr.eni.PrimaryIP.IPv4) {
+					items = addr.eni.ToResItems()
+				}
+			case daemonMode == daemonModeENIMultiIP && pod.PodNetworkType == podNetworkTypeENIMultiIP:
+				if ipSetMatch(&pod.PodIPs, addr.ips...) {
+					items = (&types.ENIIP{ENI: addr.eni, IPSet: pod.PodIPs}).ToResItems()
+				}
+			}
+			if items != nil {
+				break
+			}
+		}
+		if items == nil {
+			continue
+		}
+		ret = append(ret, types.PodResources{
+			PodInfo:   pod,
+			Resources: items,
+		})
+	}
+	return ret
+}
+
+// ipSetMatch returns whether the ipv4 (or ipv6 for ipv6 only pods) of set is in ips
+func ipSetMatch(set *types.IPSet, ips ...net.IP) bool {
+	ip := set.IPv4
+	if ip == nil {
+		ip = set.IPv6
+	}
+	for _, v := range ips {
+		if v != nil && v.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// getENIAddresses returns the secondary enis attached to the node, trunkENIID is passed to the provider to mark the trunk eni
+func getENIAddresses(ctx context.Context, api ipam.API, trunkENIID string) ([]*eniAddresses, error) {
+	enis, err := api.GetAttachedENIs(ctx, false, trunkENIID)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []*eniAddresses
+	for _, eni := range enis {
+		ipv4s, ipv6s, err := api.GetENIIPs(ctx, eni.MAC)
+		if err != nil {
+			return nil, err
+		}
+
+		addr := &eniAddresses{eni: eni}
+		for _, ip := range append(ipv4s, ipv6s...) {
+			if ip.Equal(eni.PrimaryIP.IPv4) {
+				continue
+			}
+			addr.ips = append(addr.ips, ip)
+		}
+		ret = append(ret, addr)
+	}
+	return ret, nil
+}
+
+// trunkENIID returns the id of the trunk eni on this node, empty if there is no trunk eni
+func (n *networkService) trunkENIID() string {
+	if mgr, ok := n.eniIPResMgr.(*eniIPResourceManager); ok && mgr.trunkENI != nil {
+		return mgr.trunkENI.ID
+	}
+	if mgr, ok := n.eniResMgr.(*eniResourceManager); ok && mgr.trunkENI != nil {
+		return mgr.trunkENI.ID
+	}
+	return ""
+}
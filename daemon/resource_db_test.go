@@ -0,0 +1,156 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AliyunContainerService/terway/pkg/ipam"
+	"github.com/AliyunContainerService/terway/pkg/storage"
+	"github.com/AliyunContainerService/terway/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_rebuildPodResources(t *testing.T) {
+	const mac = "00:16:3e:00:00:01"
+	eni := &types.ENI{
+		ID:        "eni-1",
+		MAC:       mac,
+		PrimaryIP: types.IPSet{IPv4: net.ParseIP("192.168.0.10")},
+	}
+	enis := []*eniAddresses{
+		{
+			eni: eni,
+			ips: []net.IP{net.ParseIP("192.168.0.1"), net.ParseIP("192.168.0.2"), net.ParseIP("fd00::1")},
+		},
+	}
+	pod := func(name, networkType string, exited bool, ips ...string) *types.PodInfo {
+		p := &types.PodInfo{Namespace: "default", Name: name, PodNetworkType: networkType, SandboxExited: exited}
+		for _, ip := range ips {
+			p.PodIPs.SetIP(ip)
+		}
+		return p
+	}
+	pods := []*types.PodInfo{
+		pod("dual", podNetworkTypeENIMultiIP, false, "192.168.0.1", "fd00::1"),
+		pod("exited", podNetworkTypeENIMultiIP, true, "192.168.0.2"),
+		pod("pending", podNetworkTypeENIMultiIP, false),
+		pod("unknown", podNetworkTypeENIMultiIP, false, "192.168.0.3"),
+		pod("exclusive", podNetworkTypeVPCENI, false, "192.168.0.10"),
+	}
+
+	got := rebuildPodResources(daemonModeENIMultiIP, pods, enis)
+	assert.Equal(t, []types.PodResources{
+		{
+			PodInfo: pods[0],
+			Resources: []types.ResourceItem{
+				{
+					Type:   types.ResourceTypeENIIP,
+					ID:     mac + ".192.168.0.1-fd00::1",
+					ENIID:  "eni-1",
+					ENIMAC: mac,
+					IPv4:   "192.168.0.1",
+					IPv6:   "fd00::1",
+				},
+			},
+		},
+	}, got)
+
+	got = rebuildPodResources(daemonModeENIOnly, pods, enis)
+	assert.Equal(t, []types.PodResources{
+		{
+			PodInfo: pods[4],
+			Resources: []types.ResourceItem{
+				{
+					Type:   types.ResourceTypeENI,
+					ID:     mac,
+					ENIID:  "eni-1",
+					ENIMAC: mac,
+					IPv4:   "192.168.0.10",
+				},
+			},
+		},
+	}, got)
+
+	assert.Empty(t, rebuildPodResources(daemonModeVPC, pods, nil))
+}
+
+type fakeENIAPI struct {
+	ipam.API
+	enis  []*types.ENI
+	ipv4s map[string][]net.IP
+	ipv6s map[string][]net.IP
+}
+
+func (f *fakeENIAPI) GetAttachedENIs(ctx context.Context, containsMainENI bool, trunkENIID string) ([]*types.ENI, error) {
+	for _, eni := range f.enis {
+		eni.Trunk = eni.ID == trunkENIID
+	}
+	return f.enis, nil
+}
+
+func (f *fakeENIAPI) GetENIIPs(ctx context.Context, mac string) ([]net.IP, []net.IP, error) {
+	return f.ipv4s[mac], f.ipv6s[mac], nil
+}
+
+func Test_getENIAddresses(t *testing.T) {
+	const mac = "00:16:3e:00:00:01"
+	eni := &types.ENI{
+		ID:        "eni-1",
+		MAC:       mac,
+		PrimaryIP: types.IPSet{IPv4: net.ParseIP("192.168.0.10")},
+	}
+	api := &fakeENIAPI{
+		enis:  []*types.ENI{eni},
+		ipv4s: map[string][]net.IP{mac: {net.ParseIP("192.168.0.10"), net.ParseIP("192.168.0.1")}},
+		ipv6s: map[string][]net.IP{mac: {net.ParseIP("fd00::1")}},
+	}
+
+	got, err := getENIAddresses(context.Background(), api, "eni-1")
+	assert.NoError(t, err)
+	if assert.Len(t, got, 1) {
+		assert.Equal(t, eni, got[0].eni)
+		assert.True(t, got[0].eni.Trunk)
+		assert.Equal(t, []net.IP{net.ParseIP("192.168.0.1"), net.ParseIP("fd00::1")}, got[0].ips)
+	}
+}
+
+func TestPodResourcesSchemaRollback(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ResRelation.db")
+	podRes := types.PodResources{
+		PodInfo:   &types.PodInfo{Namespace: "default", Name: "foo"},
+		Resources: []types.ResourceItem{{Type: types.ResourceTypeENIIP, ID: "00:16:3e:00:00:01.192.168.0.1"}},
+	}
+	data, err := json.Marshal(podRes)
+	assert.NoError(t, err)
+
+	// the record written before the version field
+	err = storage.ImportFile(path, types.PodResourcesSchema, &storage.Export{
+		Bucket:  resDBName,
+		Records: []storage.Record{{Key: "default/foo", Data: data}},
+	}, time.Second)
+	assert.NoError(t, err)
+
+	// the versioned record is still readable by the daemon without schema
+	export, err := storage.ExportFile(path, resDBName, types.PodResourcesSchema, time.Second)
+	assert.NoError(t, err)
+	if assert.Len(t, export.Records, 1) {
+		version, err := storage.RecordVersion(export.Records[0].Data)
+		assert.NoError(t, err)
+		assert.Equal(t, types.PodResourcesSchema.Version, version)
+
+		old := types.PodResources{}
+		assert.NoError(t, json.Unmarshal(export.Records[0].Data, &old))
+		assert.Equal(t, podRes, old)
+	}
+
+	db, err := storage.NewVersionedDiskStorage(resDBName, path, types.PodResourcesSchema, json.Marshal, deserializePodResources)
+	assert.NoError(t, err)
+	obj, err := db.Get("default/foo")
+	assert.NoError(t, err)
+	assert.Equal(t, podRes, obj)
+}
@@ -1,6 +1,7 @@
 package daemon
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"net/http"
@@ -11,9 +12,11 @@ import (
 	"runtime"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/AliyunContainerService/terway/pkg/logger"
 	"github.com/AliyunContainerService/terway/pkg/metric"
+	"github.com/AliyunContainerService/terway/pkg/telemetry"
 	"github.com/AliyunContainerService/terway/pkg/tracing"
 	"github.com/AliyunContainerService/terway/pkg/utils"
 	"github.com/AliyunContainerService/terway/rpc"
@@ -95,7 +98,7 @@ func Run(pidFilePath, socketFilePath, debugSocketListen, configFilePath, kubecon
 		return err
 	}
 
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(telemetry.UnaryServerInterceptor()))
 	rpc.RegisterTerwayBackendServer(grpcServer, networkService)
 	rpc.RegisterTerwayTracingServer(grpcServer, tracing.DefaultRPCServer())
 
@@ -125,6 +128,13 @@ func Run(pidFilePath, socketFilePath, debugSocketListen, configFilePath, kubecon
 
 	<-stop
 	grpcServer.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = networkService.shutdownTelemetry(ctx)
+	if err != nil {
+		log.Warnf("error flush spans: %v", err)
+	}
 	return nil
 }
 
@@ -171,7 +181,16 @@ func runDebugServer(debugSocketListen string) error {
 func registerPrometheus() {
 	prometheus.MustRegister(metric.RPCLatency)
 	prometheus.MustRegister(metric.OpenAPILatency)
+	prometheus.MustRegister(metric.OpenAPILimiterQueueDepth)
+	prometheus.MustRegister(metric.OpenAPILimiterWaitTime)
+	prometheus.MustRegister(metric.OpenAPILimiterRate)
+	prometheus.MustRegister(metric.OpenAPIThrottled)
+	prometheus.MustRegister(metric.CredentialExpiry)
+	prometheus.MustRegister(metric.OpenAPIErrors)
+	prometheus.MustRegister(metric.OpenAPICircuitBreakerState)
 	prometheus.MustRegister(metric.MetadataLatency)
+	prometheus.MustRegister(metric.ENICacheRequests)
+	prometheus.MustRegister(metric.MetadataLag)
 	// ResourcePool
 	prometheus.MustRegister(metric.ResourcePoolTotal)
 	prometheus.MustRegister(metric.ResourcePoolIdle)
@@ -180,4 +199,5 @@ func registerPrometheus() {
 	prometheus.MustRegister(metric.ENIIPFactoryIPCount)
 	prometheus.MustRegister(metric.ENIIPFactoryENICount)
 	prometheus.MustRegister(metric.ENIIPFactoryIPAllocCount)
+	prometheus.MustRegister(metric.ENIIPFactoryIPReleaseCount)
 }
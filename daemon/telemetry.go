@@ -0,0 +1,61 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+
+	"github.com/AliyunContainerService/terway/pkg/telemetry"
+	"github.com/AliyunContainerService/terway/types"
+	"github.com/AliyunContainerService/terway/types/daemon"
+)
+
+// setupTelemetry export the spans of the daemon to the otlp collector if configured
+func (n *networkService) setupTelemetry(cfg *daemon.Config) error {
+	shutdown, err := telemetry.Setup(context.Background(), "terway-daemon", telemetry.Config{
+		Endpoint:      cfg.TracingEndpoint,
+		SamplingRatio: cfg.TracingSamplingRatio,
+	})
+	if err != nil {
+		return fmt.Errorf("error setup tracing, %w", err)
+	}
+	n.shutdownTelemetry = shutdown
+	n.tracePropagateRatio = cfg.TracingPropagateRatio
+	return nil
+}
+
+// propagateTrace pass the trace context to terway-controlplane by the pod annotations, only the sampled requests are
+// patched by the ratio of tracing_propagate_ratio. The patch is sent in background so the cni call is not delayed,
+// the returned func remove the annotations after the wait.
+func (n *networkService) propagateTrace(ctx context.Context, podInfo *types.PodInfo) func() {
+	if n.tracePropagateRatio <= 0 || rand.Float64() >= n.tracePropagateRatio {
+		return func() {}
+	}
+	annotations := telemetry.InjectAnnotations(ctx)
+	if annotations == nil {
+		return func() {}
+	}
+
+	patched := make(chan struct{})
+	go func() {
+		defer close(patched)
+		err := n.k8s.PatchPodAnnotations(podInfo, annotations)
+		if err != nil {
+			serviceLog.Warnf("error patch trace context to pod %s/%s, %v", podInfo.Namespace, podInfo.Name, err)
+		}
+	}()
+
+	return func() {
+		go func() {
+			<-patched
+			remove := make(map[string]string, len(annotations))
+			for key := range annotations {
+				remove[key] = ""
+			}
+			err := n.k8s.PatchPodAnnotations(podInfo, remove)
+			if err != nil {
+				serviceLog.Warnf("error remove trace context from pod %s/%s, %v", podInfo.Namespace, podInfo.Name, err)
+			}
+		}()
+	}
+}
@@ -0,0 +1,67 @@
+package daemon
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AliyunContainerService/terway/pkg/telemetry"
+	"github.com/AliyunContainerService/terway/types"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type fakeTraceKubernetes struct {
+	Kubernetes
+
+	lock    sync.Mutex
+	patches []map[string]string
+}
+
+func (f *fakeTraceKubernetes) PatchPodAnnotations(info *types.PodInfo, annotations map[string]string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.patches = append(f.patches, annotations)
+	return nil
+}
+
+func (f *fakeTraceKubernetes) getPatches() []map[string]string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]map[string]string(nil), f.patches...)
+}
+
+func TestPropagateTrace(t *testing.T) {
+	podInfo := &types.PodInfo{Namespace: "default", Name: "foo"}
+	sampled := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+	}))
+	notSampled := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1},
+		SpanID:  trace.SpanID{1},
+	}))
+
+	// disabled by default
+	k8s := &fakeTraceKubernetes{}
+	n := &networkService{k8s: k8s}
+	n.propagateTrace(sampled, podInfo)()
+	time.Sleep(100 * time.Millisecond)
+	assert.Empty(t, k8s.getPatches())
+
+	n.tracePropagateRatio = 1
+	n.propagateTrace(notSampled, podInfo)()
+	time.Sleep(100 * time.Millisecond)
+	assert.Empty(t, k8s.getPatches())
+
+	n.propagateTrace(sampled, podInfo)()
+	assert.Eventually(t, func() bool {
+		return len(k8s.getPatches()) == 2
+	}, time.Second, 10*time.Millisecond)
+	patches := k8s.getPatches()
+	assert.NotEmpty(t, patches[0][telemetry.AnnotationTraceParent])
+	assert.Equal(t, map[string]string{telemetry.AnnotationTraceParent: ""}, patches[1])
+}
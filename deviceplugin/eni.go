@@ -23,10 +23,13 @@ import (
 const (
 	ENITypeENI    = "eni"
 	ENITypeMember = "member"
+	ENITypeENIIP  = "eniip"
 
 	// ENIResName aliyun eni resource name in kubernetes container resource
 	ENIResName       = "aliyun/eni"
 	MemberENIResName = "aliyun/member-eni"
+	// ENIIPResName is the resource of the shared eni ips in eni multi-ip mode
+	ENIIPResName = "aliyun/eni-ip"
 )
 
 type eniRes struct {
@@ -46,6 +49,11 @@ var eniMap = map[string]eniRes{
 		re:      regexp.MustCompile("^.*" + "-member-eni.sock"),
 		sock:    pluginapi.DevicePluginPath + "%d-" + "member-eni.sock",
 	},
+	ENITypeENIIP: {
+		resName: ENIIPResName,
+		re:      regexp.MustCompile("^.*" + "-eni-ip.sock"),
+		sock:    pluginapi.DevicePluginPath + "%d-" + "eni-ip.sock",
+	},
 }
 
 // ENIDevicePlugin implements the Kubernetes device plugin API
@@ -53,9 +61,13 @@ type ENIDevicePlugin struct {
 	socket string
 	server *grpc.Server
 	count  int
+	// healthy is the count of the healthy devices, the rest are reported unhealthy
+	healthy int
+	// update notify ListAndWatch to send the devices on health changes
+	update chan struct{}
 	stop   chan struct{}
 	eniRes eniRes
-	sync.Locker
+	sync.Mutex
 }
 
 // NewENIDevicePlugin returns an initialized ENIDevicePlugin
@@ -66,10 +78,49 @@ func NewENIDevicePlugin(count int, eniType string) *ENIDevicePlugin {
 	}
 	pluginEndpoint := fmt.Sprintf(res.sock, time.Now().Unix())
 	return &ENIDevicePlugin{
-		socket: pluginEndpoint,
-		count:  count,
-		eniRes: res,
+		socket:  pluginEndpoint,
+		count:   count,
+		healthy: count,
+		update:  make(chan struct{}, 1),
+		eniRes:  res,
+	}
+}
+
+// SetHealthy set the count of healthy devices, the devices beyond it are reported unhealthy,
+// so kubelet only counts the healthy ones in the allocatable of the node
+func (m *ENIDevicePlugin) SetHealthy(healthy int) {
+	if healthy > m.count {
+		healthy = m.count
+	}
+	if healthy < 0 {
+		healthy = 0
+	}
+	m.Lock()
+	changed := m.healthy != healthy
+	m.healthy = healthy
+	m.Unlock()
+	if !changed {
+		return
+	}
+	log.Infof("%s healthy devices changed to %d", m.eniRes.resName, healthy)
+	select {
+	case m.update <- struct{}{}:
+	default:
+	}
+}
+
+func (m *ENIDevicePlugin) devices() []*pluginapi.Device {
+	m.Lock()
+	defer m.Unlock()
+	devs := make([]*pluginapi.Device, 0, m.count)
+	for i := 0; i < m.count; i++ {
+		health := pluginapi.Healthy
+		if i >= m.healthy {
+			health = pluginapi.Unhealthy
+		}
+		devs = append(devs, &pluginapi.Device{ID: fmt.Sprintf("eni-%d", i), Health: health})
 	}
+	return devs
 }
 
 // dial establishes the gRPC communication with the registered device plugin.
@@ -170,11 +221,7 @@ func (m *ENIDevicePlugin) Register(request pluginapi.RegisterRequest) error {
 
 // ListAndWatch lists devices and update that list according to the health status
 func (m *ENIDevicePlugin) ListAndWatch(e *pluginapi.Empty, s pluginapi.DevicePlugin_ListAndWatchServer) error {
-	var devs []*pluginapi.Device
-	for i := 0; i < m.count; i++ {
-		devs = append(devs, &pluginapi.Device{ID: fmt.Sprintf("eni-%d", i), Health: pluginapi.Healthy})
-	}
-	err := s.Send(&pluginapi.ListAndWatchResponse{Devices: devs})
+	err := s.Send(&pluginapi.ListAndWatchResponse{Devices: m.devices()})
 	if err != nil {
 		return err
 	}
@@ -182,14 +229,16 @@ func (m *ENIDevicePlugin) ListAndWatch(e *pluginapi.Empty, s pluginapi.DevicePlu
 	for {
 		select {
 		case <-ticker.C:
-			log.Debugf("send list and watch res: %+v", devs)
-			err := s.Send(&pluginapi.ListAndWatchResponse{Devices: devs})
-			if err != nil {
-				log.Errorf("error send device informance: error: %v", err)
-			}
+		case <-m.update:
 		case <-m.stop:
 			return nil
 		}
+		devs := m.devices()
+		log.Debugf("send list and watch res: %+v", devs)
+		err := s.Send(&pluginapi.ListAndWatchResponse{Devices: devs})
+		if err != nil {
+			log.Errorf("error send device informance: error: %v", err)
+		}
 	}
 }
 
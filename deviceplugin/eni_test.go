@@ -0,0 +1,42 @@
+package deviceplugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	pluginapi "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+)
+
+func TestSetHealthy(t *testing.T) {
+	m := NewENIDevicePlugin(4, ENITypeENIIP)
+	assert.Equal(t, ENIIPResName, m.eniRes.resName)
+
+	health := func() []string {
+		var result []string
+		for _, dev := range m.devices() {
+			result = append(result, dev.Health)
+		}
+		return result
+	}
+	assert.Equal(t, []string{pluginapi.Healthy, pluginapi.Healthy, pluginapi.Healthy, pluginapi.Healthy}, health())
+
+	m.SetHealthy(2)
+	assert.Equal(t, []string{pluginapi.Healthy, pluginapi.Healthy, pluginapi.Unhealthy, pluginapi.Unhealthy}, health())
+	assert.Len(t, m.update, 1)
+
+	// unchanged count is not notified again
+	<-m.update
+	m.SetHealthy(2)
+	assert.Len(t, m.update, 0)
+
+	m.SetHealthy(10)
+	assert.Equal(t, 4, m.healthy)
+	m.SetHealthy(-1)
+	assert.Equal(t, 0, m.healthy)
+}
+
+func TestSocketPattern(t *testing.T) {
+	assert.True(t, eniMap[ENITypeENIIP].re.MatchString("1600000000-eni-ip.sock"))
+	assert.False(t, eniMap[ENITypeENIIP].re.MatchString("1600000000-eni.sock"))
+	assert.False(t, eniMap[ENITypeENI].re.MatchString("1600000000-eni-ip.sock"))
+}
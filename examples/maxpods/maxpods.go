@@ -7,6 +7,7 @@ import (
 	"log"
 
 	"github.com/AliyunContainerService/terway/pkg/aliyun/client"
+	"github.com/AliyunContainerService/terway/pkg/aliyun/credential"
 	"github.com/AliyunContainerService/terway/types"
 	"github.com/sirupsen/logrus"
 
@@ -20,6 +21,8 @@ var (
 	region          string
 	mode            string
 	ipStack         string
+	instanceType    string
+	offline         bool
 )
 
 func init() {
@@ -29,16 +32,27 @@ func init() {
 	flag.StringVar(&region, "region", "", "AlibabaCloud Access Key Secret")
 	flag.StringVar(&mode, "mode", "terway-eniip", "max pod cal mode: eni-ip|eni")
 	flag.StringVar(&ipStack, "ip-stack", "ipv4", "ip stack")
+	flag.StringVar(&instanceType, "instance-type", "", "instance type, read from metadata if not set")
+	flag.BoolVar(&offline, "offline", false, "only use the embedded limit table, no credential is required")
 }
 
 func main() {
 	flag.Parse()
 	log.SetOutput(io.Discard)
 	logrus.SetOutput(io.Discard)
-	ins := aliyun.GetInstanceMeta()
-	api, err := client.NewAliyun(accessKeyID, accessKeySecret, ins.RegionID, credentialPath, "", "")
-	if err != nil {
-		panic(err)
+	if instanceType == "" {
+		instanceType = aliyun.GetInstanceMeta().InstanceType
+	}
+	var api client.ECS
+	if !offline {
+		if region == "" {
+			region = aliyun.GetInstanceMeta().RegionID
+		}
+		openAPI, err := client.NewAliyun(accessKeyID, accessKeySecret, region, credentialPath, "", "", credential.OIDCConfig{})
+		if err != nil {
+			panic(err)
+		}
+		api = openAPI
 	}
 
 	f := &types.IPFamily{}
@@ -50,13 +64,13 @@ func main() {
 	}
 
 	if mode == "terway-eniip" {
-		limit, err := aliyun.GetLimit(api, aliyun.GetInstanceMeta().InstanceType)
+		limit, err := aliyun.GetLimit(api, instanceType)
 		if err != nil {
 			panic(err)
 		}
 		fmt.Println(limit.IPv4PerAdapter * (limit.Adapters - 1))
 	} else if mode == "terway-eni" {
-		limit, err := aliyun.GetLimit(api, aliyun.GetInstanceMeta().InstanceType)
+		limit, err := aliyun.GetLimit(api, instanceType)
 		if err != nil {
 			panic(err)
 		}
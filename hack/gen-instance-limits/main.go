@@ -0,0 +1,75 @@
+//go:build default_build
+
+/*
+Copyright 2022 Terway Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// gen-instance-limits regenerate the embedded instance limit table in pkg/aliyun from DescribeInstanceTypes
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"sort"
+
+	"github.com/AliyunContainerService/terway/pkg/aliyun"
+	"github.com/AliyunContainerService/terway/pkg/aliyun/client"
+	"github.com/AliyunContainerService/terway/pkg/aliyun/credential"
+)
+
+var (
+	accessKeyID     string
+	accessKeySecret string
+	credentialPath  string
+	region          string
+	output          string
+)
+
+func init() {
+	flag.StringVar(&accessKeyID, "access-key-id", os.Getenv("ALIBABA_CLOUD_ACCESS_KEY_ID"), "AlibabaCloud Access Key ID")
+	flag.StringVar(&accessKeySecret, "access-key-secret", os.Getenv("ALIBABA_CLOUD_ACCESS_KEY_SECRET"), "AlibabaCloud Access Key Secret")
+	flag.StringVar(&credentialPath, "credential-path", "", "AlibabaCloud credential path")
+	flag.StringVar(&region, "region", "cn-hangzhou", "AlibabaCloud region")
+	flag.StringVar(&output, "output", "instance_types.json", "output file")
+}
+
+func main() {
+	flag.Parse()
+	api, err := client.NewAliyun(accessKeyID, accessKeySecret, region, credentialPath, "", "", credential.OIDCConfig{})
+	if err != nil {
+		panic(err)
+	}
+	ins, err := api.DescribeInstanceTypes(context.Background(), nil)
+	if err != nil {
+		panic(err)
+	}
+	records := make([]aliyun.InstanceTypeRecord, 0, len(ins))
+	for _, in := range ins {
+		records = append(records, aliyun.NewInstanceTypeRecord(in))
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].InstanceTypeID < records[j].InstanceTypeID
+	})
+	out, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	err = os.WriteFile(output, append(out, '\n'), 0644)
+	if err != nil {
+		panic(err)
+	}
+}
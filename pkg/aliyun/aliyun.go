@@ -17,6 +17,7 @@ import (
 	"github.com/AliyunContainerService/terway/pkg/ipam"
 	"github.com/AliyunContainerService/terway/pkg/logger"
 	"github.com/AliyunContainerService/terway/pkg/metric"
+	"github.com/AliyunContainerService/terway/pkg/telemetry"
 	"github.com/AliyunContainerService/terway/pkg/tracing"
 	"github.com/AliyunContainerService/terway/types"
 
@@ -35,6 +36,7 @@ var _ ipam.API = &Impl{}
 type Impl struct {
 	privateIPMutex sync.RWMutex
 	metadata       ENIInfoGetter
+	eniCache       *ENICache
 
 	// fixme remove when metadata support eni type field
 	eniTypeAttr bool
@@ -46,8 +48,10 @@ type Impl struct {
 
 // NewAliyunImpl return new API implement object
 func NewAliyunImpl(openAPI *client.OpenAPI, needENITypeAttr bool, ipFamily *types.IPFamily) ipam.API {
+	eniCache := NewENICache(NewENIMetadata(ipFamily), openAPI, DefaultENICacheConfig)
 	return &Impl{
-		metadata:    NewENIMetadata(ipFamily),
+		metadata:    eniCache,
+		eniCache:    eniCache,
 		ipFamily:    ipFamily,
 		eniTypeAttr: needENITypeAttr,
 		OpenAPI:     openAPI,
@@ -92,6 +96,9 @@ func (e *Impl) AllocateENI(ctx context.Context, vSwitch string, securityGroups [
 	err = wait.ExponentialBackoffWithContext(ctx, backoff.Backoff(backoff.WaitENIStatus), func() (bool, error) {
 		innerErr = e.AttachNetworkInterface(ctx, resp.NetworkInterfaceID, instanceID, "")
 		if innerErr != nil {
+			if !apiErr.IsRetryable(innerErr) {
+				return false, innerErr
+			}
 			return false, nil
 		}
 		return true, nil
@@ -117,6 +124,7 @@ func (e *Impl) AllocateENI(ctx context.Context, vSwitch string, securityGroups [
 
 	var eni *types.ENI
 	// backoff get eni config
+	_, span := telemetry.Start(ctx, "metadata/WaitENI", telemetry.AttrENIID.String(resp.NetworkInterfaceID))
 	err = wait.ExponentialBackoffWithContext(ctx, backoff.Backoff(backoff.WaitENIStatus),
 		func() (done bool, err error) {
 			eni, innerErr = e.metadata.GetENIByMac(eniStatus.MacAddress)
@@ -128,6 +136,8 @@ func (e *Impl) AllocateENI(ctx context.Context, vSwitch string, securityGroups [
 			return true, nil
 		},
 	)
+	e.eniCache.InvalidateENIs()
+	telemetry.End(span, err)
 	if err != nil {
 		return nil, fmt.Errorf("error get eni config, %v, %w", innerErr, err)
 	}
@@ -145,6 +155,9 @@ func (e *Impl) destroyInterface(ctx context.Context, eniID, instanceID, trunkENI
 		func() (done bool, err error) {
 			innerErr = e.DetachNetworkInterface(ctx, eniID, instanceID, trunkENIID)
 			if innerErr != nil {
+				if !apiErr.IsRetryable(innerErr) {
+					return false, innerErr
+				}
 				return false, nil
 			}
 			return true, nil
@@ -155,6 +168,7 @@ func (e *Impl) destroyInterface(ctx context.Context, eniID, instanceID, trunkENI
 		_ = tracing.RecordNodeEvent(corev1.EventTypeWarning,
 			tracing.DisposeResourceFailed, fmtErr)
 	}
+	e.eniCache.Remove(eniID)
 
 	time.Sleep(backoff.Backoff(backoff.WaitENIStatus).Duration)
 
@@ -163,6 +177,9 @@ func (e *Impl) destroyInterface(ctx context.Context, eniID, instanceID, trunkENI
 		func() (done bool, err error) {
 			innerErr = e.DeleteNetworkInterface(context.Background(), eniID)
 			if innerErr != nil {
+				if !apiErr.IsRetryable(innerErr) {
+					return false, innerErr
+				}
 				return false, nil
 			}
 			return true, nil
@@ -238,12 +255,17 @@ func (e *Impl) AssignNIPsForENI(ctx context.Context, eniID, mac string, count in
 	if eniID == "" || mac == "" || count <= 0 {
 		return nil, nil, fmt.Errorf("args error")
 	}
+	// the span includes the time waiting for other allocations on this node
+	ctx, span := telemetry.Start(ctx, "AssignNIPsForENI", telemetry.AttrENIID.String(eniID), telemetry.AttrIPCount.Int(count))
 	e.privateIPMutex.Lock()
 	defer e.privateIPMutex.Unlock()
 
 	var wg sync.WaitGroup
 	var ipv4s, ipv6s []net.IP
 	var err, v4Err, v6Err error
+	defer func() {
+		telemetry.End(span, err)
+	}()
 
 	wrap := func(e error) error {
 		err = e
@@ -276,7 +298,7 @@ func (e *Impl) AssignNIPsForENI(ctx context.Context, eniID, mac string, count in
 		err = wait.ExponentialBackoffWithContext(ctx, backoff.Backoff(backoff.ENIOps), func() (bool, error) {
 			ipv4s, innerErr = e.AssignPrivateIPAddress(ctx, eniID, count, idempotentKey)
 			if innerErr != nil {
-				if apiErr.ErrAssert(apiErr.InvalidVSwitchIDIPNotEnough, innerErr) {
+				if !apiErr.IsRetryable(innerErr) {
 					return false, innerErr
 				}
 				return false, nil
@@ -289,9 +311,14 @@ func (e *Impl) AssignNIPsForENI(ctx context.Context, eniID, mac string, count in
 		if len(ipv4s) != count {
 			return nil, nil, wrap(fmt.Errorf("openAPI return IP error.Want %d got %d", count, len(ipv4s)))
 		}
+		e.eniCache.Assigned(mac, eniID, ipv4s, nil)
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
+			_, waitSpan := telemetry.Start(ctx, "metadata/WaitIPv4s", telemetry.AttrENIID.String(eniID))
+			defer func() {
+				telemetry.End(waitSpan, v4Err)
+			}()
 			v4Err = wait.ExponentialBackoffWithContext(ctx, backoff.Backoff(backoff.MetaAssignPrivateIP),
 				func() (bool, error) {
 					var remoteIPs []net.IP
@@ -318,7 +345,7 @@ func (e *Impl) AssignNIPsForENI(ctx context.Context, eniID, mac string, count in
 		err = wait.ExponentialBackoffWithContext(ctx, backoff.Backoff(backoff.ENIOps), func() (bool, error) {
 			ipv6s, innerErr = e.AssignIpv6Addresses(ctx, eniID, count, idempotentKey)
 			if innerErr != nil {
-				if apiErr.ErrAssert(apiErr.InvalidVSwitchIDIPNotEnough, innerErr) {
+				if !apiErr.IsRetryable(innerErr) {
 					return false, innerErr
 				}
 				return false, nil
@@ -331,9 +358,14 @@ func (e *Impl) AssignNIPsForENI(ctx context.Context, eniID, mac string, count in
 		if len(ipv6s) != count {
 			return ipv4s, ipv6s, wrap(fmt.Errorf("openAPI return IP error.Want %d got %d", count, len(ipv6s)))
 		}
+		e.eniCache.Assigned(mac, eniID, nil, ipv6s)
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
+			_, waitSpan := telemetry.Start(ctx, "metadata/WaitIPv6s", telemetry.AttrENIID.String(eniID))
+			defer func() {
+				telemetry.End(waitSpan, v6Err)
+			}()
 			v6Err = wait.ExponentialBackoffWithContext(ctx, backoff.Backoff(backoff.MetaAssignPrivateIP),
 				func() (bool, error) {
 					var remoteIPs []net.IP
@@ -361,10 +393,13 @@ func (e *Impl) AssignNIPsForENI(ctx context.Context, eniID, mac string, count in
 }
 
 func (e *Impl) UnAssignIPsForENI(ctx context.Context, eniID, mac string, ipv4s []net.IP, ipv6s []net.IP) error {
+	ctx, span := telemetry.Start(ctx, "UnAssignIPsForENI", telemetry.AttrENIID.String(eniID), telemetry.AttrIPCount.Int(len(ipv4s)+len(ipv6s)))
 	e.privateIPMutex.Lock()
 	defer e.privateIPMutex.Unlock()
 
-	return e.unAssignIPsForENIUnSafe(ctx, eniID, mac, ipv4s, ipv6s)
+	err := e.unAssignIPsForENIUnSafe(ctx, eniID, mac, ipv4s, ipv6s)
+	telemetry.End(span, err)
+	return err
 }
 
 func (e *Impl) unAssignIPsForENIUnSafe(ctx context.Context, eniID, mac string, ipv4s []net.IP, ipv6s []net.IP) error {
@@ -380,6 +415,9 @@ func (e *Impl) unAssignIPsForENIUnSafe(ctx context.Context, eniID, mac string, i
 		err := wait.ExponentialBackoffWithContext(ctx, backoff.Backoff(backoff.ENIOps), func() (bool, error) {
 			innerErr = e.UnAssignPrivateIPAddresses(ctx, eniID, ipv4s)
 			if innerErr != nil {
+				if !apiErr.IsRetryable(innerErr) {
+					return false, innerErr
+				}
 				return false, nil
 			}
 			return true, nil
@@ -395,6 +433,9 @@ func (e *Impl) unAssignIPsForENIUnSafe(ctx context.Context, eniID, mac string, i
 		err := wait.ExponentialBackoffWithContext(ctx, backoff.Backoff(backoff.ENIOps), func() (bool, error) {
 			innerErr = e.UnAssignIpv6Addresses(ctx, eniID, ipv6s)
 			if innerErr != nil {
+				if !apiErr.IsRetryable(innerErr) {
+					return false, innerErr
+				}
 				return false, nil
 			}
 			return true, nil
@@ -410,8 +451,10 @@ func (e *Impl) unAssignIPsForENIUnSafe(ctx context.Context, eniID, mac string, i
 			tracing.DisposeResourceFailed, fmtErr)
 		return k8sErr.NewAggregate(errs)
 	}
+	e.eniCache.Unassigned(mac, eniID, ipv4s, ipv6s)
 
 	start := time.Now()
+	_, span := telemetry.Start(ctx, "metadata/WaitIPsRemoved", telemetry.AttrENIID.String(eniID))
 
 	// unassignPrivateIpAddresses is async api, sleep for first ip addr inspect
 	time.Sleep(backoff.Backoff(backoff.MetaUnAssignPrivateIP).Duration)
@@ -446,6 +489,7 @@ func (e *Impl) unAssignIPsForENIUnSafe(ctx context.Context, eniID, mac string, i
 		},
 	)
 	metric.OpenAPILatency.WithLabelValues("UnassignPrivateIpAddressesAsync", fmt.Sprint(err != nil)).Observe(metric.MsSince(start))
+	telemetry.End(span, err)
 	if err != nil {
 		fmtErr := fmt.Sprintf("error unassign eni private address for %s, %v", eniID, innerErr)
 		_ = tracing.RecordNodeEvent(corev1.EventTypeWarning,
@@ -471,7 +515,9 @@ func (e *Impl) GetAttachedSecurityGroups(ctx context.Context, instanceID string)
 		start := time.Now()
 		req := ecs.CreateDescribeInstanceAttributeRequest()
 		req.InstanceId = instanceID
-		resp, err := e.ClientSet.ECS().DescribeInstanceAttribute(req)
+		resp, err := client.Invoke(ctx, e.OpenAPI, "DescribeInstanceAttribute", func() (*ecs.DescribeInstanceAttributeResponse, error) {
+			return e.ClientSet.ECS().DescribeInstanceAttribute(req)
+		})
 		metric.OpenAPILatency.WithLabelValues("DescribeInstanceAttribute", fmt.Sprint(err != nil)).Observe(metric.MsSince(start))
 		if err != nil {
 			return nil, fmt.Errorf("error describe instance attribute for security group: %s,%w", instanceID, err)
@@ -491,7 +537,9 @@ func (e *Impl) GetInstanceAttributesType(ctx context.Context, instanceID string)
 	req.InstanceIds = fmt.Sprintf("[%q]", instanceID)
 
 	start := time.Now()
-	resp, err := e.ClientSet.ECS().DescribeInstances(req)
+	resp, err := client.Invoke(ctx, e.OpenAPI, "DescribeInstances", func() (*ecs.DescribeInstancesResponse, error) {
+		return e.ClientSet.ECS().DescribeInstances(req)
+	})
 	metric.OpenAPILatency.WithLabelValues("DescribeInstances", fmt.Sprint(err != nil)).Observe(metric.MsSince(start))
 	if err != nil {
 		return nil, err
@@ -507,13 +555,31 @@ func (e *Impl) QueryEniIDByIP(ctx context.Context, vpcID string, address net.IP)
 	req.VpcId = vpcID
 	req.PrivateIpAddress = &[]string{address.String()}
 
-	resp, err := e.ClientSet.ECS().DescribeNetworkInterfaces(req)
+	resp, err := client.Invoke(ctx, e.OpenAPI, "DescribeNetworkInterfaces", func() (*ecs.DescribeNetworkInterfacesResponse, error) {
+		return e.ClientSet.ECS().DescribeNetworkInterfaces(req)
+	})
 	if err != nil || len(resp.NetworkInterfaceSets.NetworkInterfaceSet) != 1 {
 		return "", fmt.Errorf("error describe network interfaces from ip: %v, %v, %v", address, err, resp)
 	}
 	return resp.NetworkInterfaceSets.NetworkInterfaceSet[0].NetworkInterfaceId, nil
 }
 
+// GetENISecurityGroups return the security groups of the enis, keyed by eni id
+func (e *Impl) GetENISecurityGroups(ctx context.Context, eniIDs []string) (map[string][]string, error) {
+	result := make(map[string][]string)
+	if len(eniIDs) == 0 {
+		return result, nil
+	}
+	enis, err := e.DescribeNetworkInterface(ctx, "", eniIDs, "", "", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, eni := range enis {
+		result[eni.NetworkInterfaceID] = eni.SecurityGroupIDs
+	}
+	return result, nil
+}
+
 // CheckEniSecurityGroup will sync eni's security with ecs's security group
 func (e *Impl) CheckEniSecurityGroup(ctx context.Context, sg []string) error {
 	instanceID := GetInstanceMeta().InstanceID
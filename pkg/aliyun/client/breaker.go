@@ -0,0 +1,283 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	apiErr "github.com/AliyunContainerService/terway/pkg/aliyun/client/errors"
+	"github.com/AliyunContainerService/terway/pkg/metric"
+	"github.com/AliyunContainerService/terway/pkg/telemetry"
+	"github.com/AliyunContainerService/terway/pkg/tracing"
+	sdkErr "github.com/aliyun/alibaba-cloud-sdk-go/sdk/errors"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+)
+
+// default circuit breaker parameters
+const (
+	DefaultBreakerThreshold = 10
+	DefaultBreakerCooldown  = 30 * time.Second
+)
+
+// BreakerState is the state of circuit breaker
+type BreakerState int
+
+// breaker states
+const (
+	// BreakerClosed calls are allowed
+	BreakerClosed BreakerState = iota
+	// BreakerHalfOpen only one probe call is allowed
+	BreakerHalfOpen
+	// BreakerOpen calls are rejected until cooldown
+	BreakerOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerHalfOpen:
+		return "halfOpen"
+	case BreakerOpen:
+		return "open"
+	}
+	return "unknown"
+}
+
+// Breaker is the circuit breaker for one api. It opens after threshold consecutive retryable or throttled
+// failures, and lets one probe call through after cooldown. Permanent errors are valid responses and not counted.
+type Breaker struct {
+	api       string
+	threshold int
+	cooldown  time.Duration
+
+	lock     sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+	lastErr  string
+
+	now func() time.Time
+}
+
+func newBreaker(api string, threshold int, cooldown time.Duration) *Breaker {
+	b := &Breaker{
+		api:       api,
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+	b.setState(BreakerClosed)
+	return b
+}
+
+// Allow return ErrCircuitOpen if the call should not be made
+func (b *Breaker) Allow() error {
+	if b == nil {
+		return nil
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return fmt.Errorf("%w, api %s, last error %s", apiErr.ErrCircuitOpen, b.api, b.lastErr)
+		}
+		b.setState(BreakerHalfOpen)
+		b.probing = true
+		return nil
+	case BreakerHalfOpen:
+		if b.probing {
+			return fmt.Errorf("%w, api %s is probing", apiErr.ErrCircuitOpen, b.api)
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// Done feedback the result of the call
+func (b *Breaker) Done(err error) {
+	if b == nil {
+		return
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		// the caller gave up, say nothing about the api. let another call probe
+		b.probing = false
+		return
+	}
+	if !countable(err) {
+		b.failures = 0
+		b.probing = false
+		if b.state != BreakerClosed {
+			b.setState(BreakerClosed)
+		}
+		return
+	}
+
+	b.failures++
+	b.lastErr = err.Error()
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.probing = false
+		b.openedAt = b.now()
+		if b.state != BreakerOpen {
+			log.WithField(LogFieldAPI, b.api).Warnf("circuit breaker open after %d failures, %s", b.failures, b.lastErr)
+		}
+		b.setState(BreakerOpen)
+	}
+}
+
+// State return the current state
+func (b *Breaker) State() BreakerState {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.state
+}
+
+// Reset close the breaker
+func (b *Breaker) Reset() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.failures = 0
+	b.probing = false
+	b.setState(BreakerClosed)
+}
+
+func (b *Breaker) setState(state BreakerState) {
+	b.state = state
+	metric.OpenAPICircuitBreakerState.WithLabelValues(b.api).Set(float64(state))
+}
+
+// countable return true if the err means the api is unhealthy, that is network error, server error or throttling.
+// Errors of a single resource, e.g. TaskConflict or IncorrectInstanceStatus, mean the api itself works and are not counted.
+func countable(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch apiErr.Classify(err) {
+	case apiErr.ClassThrottled:
+		return true
+	case apiErr.ClassRetryable:
+		var respErr *sdkErr.ServerError
+		if !errors.As(err, &respErr) {
+			// network error
+			return true
+		}
+		return respErr.HttpStatus() >= 500
+	}
+	return false
+}
+
+// BreakerSet hold breakers for each api
+type BreakerSet struct {
+	threshold int
+	cooldown  time.Duration
+
+	lock     sync.Mutex
+	breakers map[string]*Breaker
+}
+
+// NewBreakerSet create breakers with the threshold of consecutive failures and the cooldown before probing.
+// threshold <= 0 disable the circuit breaker
+func NewBreakerSet(threshold int, cooldown time.Duration) *BreakerSet {
+	return &BreakerSet{
+		threshold: threshold,
+		cooldown:  cooldown,
+		breakers:  make(map[string]*Breaker),
+	}
+}
+
+// Get return the breaker for api, nil if disabled
+func (s *BreakerSet) Get(api string) *Breaker {
+	if s == nil || s.threshold <= 0 {
+		return nil
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	b, ok := s.breakers[api]
+	if !ok {
+		b = newBreaker(api, s.threshold, s.cooldown)
+		s.breakers[api] = b
+	}
+	return b
+}
+
+func (s *BreakerSet) apis() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var apis []string
+	for api := range s.breakers {
+		apis = append(apis, api)
+	}
+	sort.Strings(apis)
+	return apis
+}
+
+func (s *BreakerSet) Config() []tracing.MapKeyValueEntry {
+	return []tracing.MapKeyValueEntry{
+		{Key: "threshold", Value: fmt.Sprint(s.threshold)},
+		{Key: "cooldown", Value: s.cooldown.String()},
+	}
+}
+
+func (s *BreakerSet) Trace() []tracing.MapKeyValueEntry {
+	var trace []tracing.MapKeyValueEntry
+	for _, api := range s.apis() {
+		b := s.Get(api)
+		b.lock.Lock()
+		value := fmt.Sprintf("%s, failures %d", b.state, b.failures)
+		if b.state != BreakerClosed {
+			value = fmt.Sprintf("%s, opened at %s, last error %s", value, b.openedAt.Format(time.RFC3339), b.lastErr)
+		}
+		b.lock.Unlock()
+		trace = append(trace, tracing.MapKeyValueEntry{Key: api, Value: value})
+	}
+	return trace
+}
+
+// Execute support "reset [api...]" to close the breakers, all breakers are reset if no api is given
+func (s *BreakerSet) Execute(cmd string, args []string, message chan<- string) {
+	defer close(message)
+	switch cmd {
+	case "reset":
+		if len(args) == 0 {
+			args = s.apis()
+		}
+		for _, api := range args {
+			s.Get(api).Reset()
+			message <- fmt.Sprintf("breaker %s reset\n", api)
+		}
+	default:
+		message <- "can't recognize command\n"
+	}
+}
+
+// Invoke call the openapi through the circuit breaker of the api, the error is classified and counted in metrics.
+// Each call is recorded as a span, the calls rejected by the breaker included.
+func Invoke[T any](ctx context.Context, a *OpenAPI, api string, fn func() (T, error)) (T, error) {
+	_, span := telemetry.Start(ctx, "openapi/"+api, semconv.RPCSystemKey.String("aliyun"), semconv.RPCMethodKey.String(api))
+	b := a.Breakers.Get(api)
+	err := b.Allow()
+	if err != nil {
+		metric.OpenAPIErrors.WithLabelValues(api, string(apiErr.Classify(err))).Inc()
+		telemetry.End(span, err)
+		var empty T
+		return empty, err
+	}
+	resp, err := fn()
+	b.Done(err)
+	if err != nil {
+		metric.OpenAPIErrors.WithLabelValues(api, string(apiErr.Classify(err))).Inc()
+		span.SetAttributes(telemetry.AttrErrorType.String(string(apiErr.Classify(err))), telemetry.AttrRequestID.String(apiErr.ErrRequestID(err)))
+	}
+	telemetry.End(span, err)
+	return resp, err
+}
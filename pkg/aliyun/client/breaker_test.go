@@ -0,0 +1,131 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	apiErr "github.com/AliyunContainerService/terway/pkg/aliyun/client/errors"
+	"github.com/AliyunContainerService/terway/pkg/telemetry"
+	sdkErr "github.com/aliyun/alibaba-cloud-sdk-go/sdk/errors"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Now()
+	s := NewBreakerSet(3, time.Minute)
+	b := s.Get("DescribeNetworkInterfaces")
+	b.now = func() time.Time { return now }
+
+	retryable := errors.New("connection reset")
+	permanent := sdkErr.NewServerError(400, "{\"Code\": \"InvalidParameter\"}", "")
+	conflict := sdkErr.NewServerError(400, "{\"Code\": \"TaskConflict\"}", "")
+
+	// permanent errors reset the counter
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, b.Allow())
+		b.Done(retryable)
+		assert.NoError(t, b.Allow())
+		b.Done(permanent)
+	}
+	assert.Equal(t, BreakerClosed, b.State())
+
+	// errors of a single resource or the caller are not counted
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, b.Allow())
+		b.Done(conflict)
+		assert.NoError(t, b.Allow())
+		b.Done(context.DeadlineExceeded)
+	}
+	assert.Equal(t, BreakerClosed, b.State())
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, b.Allow())
+		b.Done(retryable)
+	}
+	assert.Equal(t, BreakerOpen, b.State())
+	assert.True(t, errors.Is(b.Allow(), apiErr.ErrCircuitOpen))
+
+	// one probe after cooldown
+	now = now.Add(time.Minute)
+	assert.NoError(t, b.Allow())
+	assert.Equal(t, BreakerHalfOpen, b.State())
+	assert.True(t, errors.Is(b.Allow(), apiErr.ErrCircuitOpen))
+
+	// probe failed, open again
+	b.Done(retryable)
+	assert.Equal(t, BreakerOpen, b.State())
+	assert.Error(t, b.Allow())
+
+	now = now.Add(time.Minute)
+	assert.NoError(t, b.Allow())
+	b.Done(nil)
+	assert.Equal(t, BreakerClosed, b.State())
+	assert.NoError(t, b.Allow())
+}
+
+func TestBreakerSet_Disabled(t *testing.T) {
+	var nilSet *BreakerSet
+	assert.Nil(t, nilSet.Get("foo"))
+	assert.Nil(t, NewBreakerSet(0, time.Minute).Get("foo"))
+
+	// nil breaker always allow
+	var b *Breaker
+	assert.NoError(t, b.Allow())
+	b.Done(errors.New("foo"))
+}
+
+func TestInvoke(t *testing.T) {
+	a := &OpenAPI{Breakers: NewBreakerSet(2, time.Minute)}
+	calls := 0
+	fn := func() (string, error) {
+		calls++
+		return "", fmt.Errorf("timeout")
+	}
+	for i := 0; i < 5; i++ {
+		_, err := Invoke(context.Background(), a, "AttachNetworkInterface", fn)
+		assert.Error(t, err)
+	}
+	assert.Equal(t, 2, calls)
+
+	a.Breakers.Execute("reset", nil, make(chan string, 10))
+	resp, err := Invoke(context.Background(), a, "AttachNetworkInterface", func() (string, error) {
+		return "ok", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", resp)
+	assert.Equal(t, []string{"AttachNetworkInterface"}, a.Breakers.apis())
+	assert.Equal(t, "closed, failures 0", a.Breakers.Trace()[0].Value)
+}
+
+func TestInvokeSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(telemetry.NewTracerProvider("test", 1, sdktrace.WithSpanProcessor(recorder)))
+
+	ctx, parent := telemetry.Start(context.Background(), "parent")
+	a := &OpenAPI{Breakers: NewBreakerSet(1, time.Minute)}
+	_, err := Invoke(ctx, a, "AssignPrivateIpAddresses", func() (string, error) {
+		return "", fmt.Errorf("timeout")
+	})
+	assert.Error(t, err)
+	// rejected by the breaker
+	_, err = Invoke(ctx, a, "AssignPrivateIpAddresses", func() (string, error) {
+		return "ok", nil
+	})
+	assert.ErrorIs(t, err, apiErr.ErrCircuitOpen)
+	parent.End()
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 3)
+	for _, span := range spans[:2] {
+		assert.Equal(t, "openapi/AssignPrivateIpAddresses", span.Name())
+		assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+		assert.Equal(t, codes.Error, span.Status().Code)
+	}
+}
@@ -13,13 +13,13 @@ import (
 
 	apiErr "github.com/AliyunContainerService/terway/pkg/aliyun/client/errors"
 	"github.com/AliyunContainerService/terway/pkg/aliyun/credential"
+	"github.com/AliyunContainerService/terway/pkg/audit"
 	"github.com/AliyunContainerService/terway/pkg/ip"
 	"github.com/AliyunContainerService/terway/pkg/metric"
 
 	"github.com/aliyun/alibaba-cloud-sdk-go/sdk/requests"
 	"github.com/aliyun/alibaba-cloud-sdk-go/services/ecs"
 	"k8s.io/apimachinery/pkg/util/wait"
-	"k8s.io/client-go/util/flowcontrol"
 )
 
 var _ VSwitch = &OpenAPI{}
@@ -28,30 +28,35 @@ var _ ENI = &OpenAPI{}
 type OpenAPI struct {
 	ClientSet credential.Client
 
-	ReadOnlyRateLimiter flowcontrol.RateLimiter
-	MutatingRateLimiter flowcontrol.RateLimiter
+	ReadOnlyRateLimiter *Limiter
+	MutatingRateLimiter *Limiter
+
+	// Breakers stop calling the api after sustained failures
+	Breakers *BreakerSet
 }
 
-func New(c credential.Client, readOnly, mutating flowcontrol.RateLimiter) (*OpenAPI, error) {
+func New(c credential.Client, readOnly, mutating *Limiter, breakers *BreakerSet) (*OpenAPI, error) {
 	return &OpenAPI{
 		ClientSet:           c,
 		ReadOnlyRateLimiter: readOnly,
 		MutatingRateLimiter: mutating,
+		Breakers:            breakers,
 	}, nil
 }
 
-func NewAliyun(ak, sk, regionID, credentialPath, secretNamespace, secretName string) (*OpenAPI, error) {
+func NewAliyun(ak, sk, regionID, credentialPath, secretNamespace, secretName string, oidc credential.OIDCConfig) (*OpenAPI, error) {
 	if regionID == "" {
 		return nil, fmt.Errorf("regionID unset")
 	}
-	clientSet, err := credential.NewClientMgr(ak, sk, credentialPath, regionID, secretNamespace, secretName)
+	clientSet, err := credential.NewClientMgr(ak, sk, credentialPath, regionID, secretNamespace, secretName, oidc)
 	if err != nil {
 		return nil, fmt.Errorf("error get clientset, %w", err)
 	}
 	return &OpenAPI{
 		ClientSet:           clientSet,
-		ReadOnlyRateLimiter: flowcontrol.NewTokenBucketRateLimiter(8, 10),
-		MutatingRateLimiter: flowcontrol.NewTokenBucketRateLimiter(4, 5),
+		ReadOnlyRateLimiter: NewLimiter("readOnly", 8, 10),
+		MutatingRateLimiter: NewLimiter("mutating", 4, 5),
+		Breakers:            NewBreakerSet(DefaultBreakerThreshold, DefaultBreakerCooldown),
 	}, nil
 }
 
@@ -90,22 +95,40 @@ func (a *OpenAPI) CreateNetworkInterface(ctx context.Context, trunk bool, vSwitc
 		LogFieldSgID:            securityGroups,
 		LogFieldResourceGroupID: resourceGroupID,
 	})
+	params := map[string]interface{}{
+		LogFieldVSwitchID:       vSwitch,
+		LogFieldSgID:            securityGroups,
+		LogFieldResourceGroupID: resourceGroupID,
+		"trunk":                 trunk,
+		"ipCount":               ipCount,
+		"ipv6Count":             ipv6Count,
+		"clientToken":           req.ClientToken,
+	}
 	var (
 		innerErr error
 		resp     *ecs.CreateNetworkInterfaceResponse
 	)
 	err := wait.ExponentialBackoffWithContext(ctx, backoff.Backoff(backoff.ENICreate), func() (bool, error) {
-		a.MutatingRateLimiter.Accept()
+		innerErr = a.MutatingRateLimiter.Wait(ctx, LaneFromCtx(ctx, LanePodCreate))
+		if innerErr != nil {
+			return false, innerErr
+		}
 		start := time.Now()
-		resp, innerErr = a.ClientSet.ECS().CreateNetworkInterface(req)
+		resp, innerErr = Invoke(ctx, a, "CreateNetworkInterface", func() (*ecs.CreateNetworkInterfaceResponse, error) {
+			return a.ClientSet.ECS().CreateNetworkInterface(req)
+		})
+		a.MutatingRateLimiter.Done(innerErr)
 		metric.OpenAPILatency.WithLabelValues("CreateNetworkInterface", fmt.Sprint(innerErr != nil)).Observe(metric.MsSince(start))
 		if innerErr != nil {
-			if apiErr.ErrAssert(apiErr.InvalidVSwitchIDIPNotEnough, innerErr) {
+			audit.Log(ctx, "CreateNetworkInterface", params, apiErr.ErrRequestID(innerErr), innerErr, start)
+			if !apiErr.IsRetryable(innerErr) {
 				return false, innerErr
 			}
 			l.WithField(LogFieldRequestID, apiErr.ErrRequestID(innerErr)).Errorf("error create ENI, %s", innerErr.Error())
 			return false, nil
 		}
+		params[LogFieldENIID] = resp.NetworkInterfaceId
+		audit.Log(ctx, "CreateNetworkInterface", params, resp.RequestId, nil, start)
 		return true, nil
 	})
 	if err != nil {
@@ -154,9 +177,15 @@ func (a *OpenAPI) DescribeNetworkInterface(ctx context.Context, vpcID string, en
 			LogFieldENIID:      eniID,
 			LogFieldInstanceID: instanceID,
 		})
-		a.ReadOnlyRateLimiter.Accept()
+		err := a.ReadOnlyRateLimiter.Wait(ctx, LaneFromCtx(ctx, LaneDescribe))
+		if err != nil {
+			return nil, err
+		}
 		start := time.Now()
-		resp, err := a.ClientSet.ECS().DescribeNetworkInterfaces(req)
+		resp, err := Invoke(ctx, a, "DescribeNetworkInterfaces", func() (*ecs.DescribeNetworkInterfacesResponse, error) {
+			return a.ClientSet.ECS().DescribeNetworkInterfaces(req)
+		})
+		a.ReadOnlyRateLimiter.Done(err)
 		metric.OpenAPILatency.WithLabelValues("DescribeNetworkInterfaces", fmt.Sprint(err != nil)).Observe(metric.MsSince(start))
 		if err != nil {
 			l.WithField(LogFieldRequestID, apiErr.ErrRequestID(err)).Warn(err)
@@ -186,14 +215,27 @@ func (a *OpenAPI) AttachNetworkInterface(ctx context.Context, eniID, instanceID,
 		LogFieldENIID:      eniID,
 		LogFieldInstanceID: instanceID,
 	})
-	a.MutatingRateLimiter.Accept()
+	err := a.MutatingRateLimiter.Wait(ctx, LaneFromCtx(ctx, LaneAttach))
+	if err != nil {
+		return err
+	}
 	start := time.Now()
-	resp, err := a.ClientSet.ECS().AttachNetworkInterface(req)
+	resp, err := Invoke(ctx, a, "AttachNetworkInterface", func() (*ecs.AttachNetworkInterfaceResponse, error) {
+		return a.ClientSet.ECS().AttachNetworkInterface(req)
+	})
+	a.MutatingRateLimiter.Done(err)
 	metric.OpenAPILatency.WithLabelValues("AttachNetworkInterface", fmt.Sprint(err != nil)).Observe(metric.MsSince(start))
+	params := map[string]interface{}{
+		LogFieldENIID:      eniID,
+		LogFieldInstanceID: instanceID,
+		"trunkENIID":       trunkENIID,
+	}
 	if err != nil {
+		audit.Log(ctx, "AttachNetworkInterface", params, apiErr.ErrRequestID(err), err, start)
 		l.WithField(LogFieldRequestID, apiErr.ErrRequestID(err)).Warnf("attach ENI failed, %s", err.Error())
 		return err
 	}
+	audit.Log(ctx, "AttachNetworkInterface", params, resp.RequestId, nil, start)
 	l.WithField(LogFieldRequestID, resp.RequestId).Infof("attach eni")
 	return nil
 }
@@ -210,17 +252,30 @@ func (a *OpenAPI) DetachNetworkInterface(ctx context.Context, eniID, instanceID,
 		LogFieldENIID:      eniID,
 		LogFieldInstanceID: instanceID,
 	})
-	a.MutatingRateLimiter.Accept()
+	err := a.MutatingRateLimiter.Wait(ctx, LaneFromCtx(ctx, LaneAttach))
+	if err != nil {
+		return err
+	}
 	start := time.Now()
-	resp, err := a.ClientSet.ECS().DetachNetworkInterface(req)
+	resp, err := Invoke(ctx, a, "DetachNetworkInterface", func() (*ecs.DetachNetworkInterfaceResponse, error) {
+		return a.ClientSet.ECS().DetachNetworkInterface(req)
+	})
+	a.MutatingRateLimiter.Done(err)
 	metric.OpenAPILatency.WithLabelValues("DetachNetworkInterface", fmt.Sprint(err != nil)).Observe(metric.MsSince(start))
+	params := map[string]interface{}{
+		LogFieldENIID:      eniID,
+		LogFieldInstanceID: instanceID,
+		"trunkENIID":       trunkENIID,
+	}
 	if err != nil {
+		audit.Log(ctx, "DetachNetworkInterface", params, apiErr.ErrRequestID(err), err, start)
 		if apiErr.ErrAssert(apiErr.ErrInvalidENINotFound, err) {
 			return nil
 		}
 		l.WithField(LogFieldRequestID, apiErr.ErrRequestID(err)).Errorf("detach eni failed, %v", err)
 		return err
 	}
+	audit.Log(ctx, "DetachNetworkInterface", params, resp.RequestId, nil, start)
 	l.WithField(LogFieldRequestID, resp.RequestId).Infof("detach eni")
 	return nil
 }
@@ -234,14 +289,25 @@ func (a *OpenAPI) DeleteNetworkInterface(ctx context.Context, eniID string) erro
 		LogFieldAPI:   "DeleteNetworkInterface",
 		LogFieldENIID: eniID,
 	})
-	a.MutatingRateLimiter.Accept()
+	err := a.MutatingRateLimiter.Wait(ctx, LaneFromCtx(ctx, LaneAttach))
+	if err != nil {
+		return err
+	}
 	start := time.Now()
-	resp, err := a.ClientSet.ECS().DeleteNetworkInterface(req)
+	resp, err := Invoke(ctx, a, "DeleteNetworkInterface", func() (*ecs.DeleteNetworkInterfaceResponse, error) {
+		return a.ClientSet.ECS().DeleteNetworkInterface(req)
+	})
+	a.MutatingRateLimiter.Done(err)
 	metric.OpenAPILatency.WithLabelValues("DeleteNetworkInterface", fmt.Sprint(err != nil)).Observe(metric.MsSince(start))
+	params := map[string]interface{}{
+		LogFieldENIID: eniID,
+	}
 	if err != nil {
+		audit.Log(ctx, "DeleteNetworkInterface", params, apiErr.ErrRequestID(err), err, start)
 		l.WithField(LogFieldRequestID, apiErr.ErrRequestID(err)).Errorf("delete eni failed, %v", err)
 		return err
 	}
+	audit.Log(ctx, "DeleteNetworkInterface", params, resp.RequestId, nil, start)
 	l.WithField(LogFieldRequestID, resp.RequestId).Infof("delete eni")
 	return nil
 }
@@ -256,6 +322,9 @@ func (a *OpenAPI) WaitForNetworkInterface(ctx context.Context, eniID string, sta
 		func() (done bool, err error) {
 			eni, err := a.DescribeNetworkInterface(ctx, "", []string{eniID}, "", "", "", nil)
 			if err != nil {
+				if !apiErr.IsRetryable(err) {
+					return false, err
+				}
 				return false, nil
 			}
 			if len(eni) == 0 && ignoreNotExist {
@@ -291,12 +360,22 @@ func (a *OpenAPI) AssignPrivateIPAddress(ctx context.Context, eniID string, coun
 		LogFieldSecondaryIPCount: count,
 	})
 	start := time.Now()
-	resp, err := a.ClientSet.ECS().AssignPrivateIpAddresses(req)
+	resp, err := Invoke(ctx, a, "AssignPrivateIpAddresses", func() (*ecs.AssignPrivateIpAddressesResponse, error) {
+		return a.ClientSet.ECS().AssignPrivateIpAddresses(req)
+	})
 	metric.OpenAPILatency.WithLabelValues("AssignPrivateIpAddresses", fmt.Sprint(err != nil)).Observe(metric.MsSince(start))
+	params := map[string]interface{}{
+		LogFieldENIID:            eniID,
+		LogFieldSecondaryIPCount: count,
+		"clientToken":            idempotentKey,
+	}
 	if err != nil {
+		audit.Log(ctx, "AssignPrivateIpAddresses", params, apiErr.ErrRequestID(err), err, start)
 		l.WithField(LogFieldRequestID, apiErr.ErrRequestID(err)).Warnf("assign private ip failed, %s", err.Error())
 		return nil, err
 	}
+	params["ips"] = resp.AssignedPrivateIpAddressesSet.PrivateIpSet.PrivateIpAddress
+	audit.Log(ctx, "AssignPrivateIpAddresses", params, resp.RequestId, nil, start)
 	ips, err := ip.ToIPs(resp.AssignedPrivateIpAddressesSet.PrivateIpSet.PrivateIpAddress)
 	if err != nil {
 		l.WithField(LogFieldRequestID, resp.RequestId).Errorf("assign private ip, %v", resp.AssignedPrivateIpAddressesSet.PrivateIpSet.PrivateIpAddress)
@@ -324,10 +403,16 @@ func (a *OpenAPI) UnAssignPrivateIPAddresses(ctx context.Context, eniID string,
 		LogFieldENIID: eniID,
 	})
 	start := time.Now()
-	resp, err := a.ClientSet.ECS().UnassignPrivateIpAddresses(req)
+	resp, err := Invoke(ctx, a, "UnassignPrivateIpAddresses", func() (*ecs.UnassignPrivateIpAddressesResponse, error) {
+		return a.ClientSet.ECS().UnassignPrivateIpAddresses(req)
+	})
 	metric.OpenAPILatency.WithLabelValues("UnassignPrivateIpAddresses", fmt.Sprint(err != nil)).Observe(metric.MsSince(start))
-
+	params := map[string]interface{}{
+		LogFieldENIID: eniID,
+		"ips":         str,
+	}
 	if err != nil {
+		audit.Log(ctx, "UnassignPrivateIpAddresses", params, apiErr.ErrRequestID(err), err, start)
 		if apiErr.ErrAssert(apiErr.ErrInvalidIPIPUnassigned, err) {
 			l.WithField(LogFieldRequestID, apiErr.ErrRequestID(err)).Infof("unassign private ip ,%s", str)
 			return nil
@@ -339,6 +424,7 @@ func (a *OpenAPI) UnAssignPrivateIPAddresses(ctx context.Context, eniID string,
 		l.WithField(LogFieldRequestID, apiErr.ErrRequestID(err)).Warnf("unassign private ip failed,%s %s", str, err.Error())
 		return err
 	}
+	audit.Log(ctx, "UnassignPrivateIpAddresses", params, resp.RequestId, nil, start)
 	l.WithField(LogFieldRequestID, resp.RequestId).Infof("unassign private ip ,%s", str)
 	return nil
 }
@@ -356,12 +442,22 @@ func (a *OpenAPI) AssignIpv6Addresses(ctx context.Context, eniID string, count i
 		LogFieldSecondaryIPCount: count,
 	})
 	start := time.Now()
-	resp, err := a.ClientSet.ECS().AssignIpv6Addresses(req)
+	resp, err := Invoke(ctx, a, "AssignIpv6Addresses", func() (*ecs.AssignIpv6AddressesResponse, error) {
+		return a.ClientSet.ECS().AssignIpv6Addresses(req)
+	})
 	metric.OpenAPILatency.WithLabelValues("AssignIpv6Addresses", fmt.Sprint(err != nil)).Observe(metric.MsSince(start))
+	params := map[string]interface{}{
+		LogFieldENIID:            eniID,
+		LogFieldSecondaryIPCount: count,
+		"clientToken":            idempotentKey,
+	}
 	if err != nil {
+		audit.Log(ctx, "AssignIpv6Addresses", params, apiErr.ErrRequestID(err), err, start)
 		l.WithField(LogFieldRequestID, apiErr.ErrRequestID(err)).Warnf("assign private ip failed, %s", err.Error())
 		return nil, err
 	}
+	params["ips"] = resp.Ipv6Sets.Ipv6Address
+	audit.Log(ctx, "AssignIpv6Addresses", params, resp.RequestId, nil, start)
 	ips, err := ip.ToIPs(resp.Ipv6Sets.Ipv6Address)
 	if err != nil {
 		l.WithField(LogFieldRequestID, resp.RequestId).Errorf("assign private ip, %v", resp.Ipv6Sets.Ipv6Address)
@@ -388,10 +484,16 @@ func (a *OpenAPI) UnAssignIpv6Addresses(ctx context.Context, eniID string, ips [
 		LogFieldENIID: eniID,
 	})
 	start := time.Now()
-	resp, err := a.ClientSet.ECS().UnassignIpv6Addresses(req)
+	resp, err := Invoke(ctx, a, "UnassignIpv6Addresses", func() (*ecs.UnassignIpv6AddressesResponse, error) {
+		return a.ClientSet.ECS().UnassignIpv6Addresses(req)
+	})
 	metric.OpenAPILatency.WithLabelValues("UnassignIpv6Addresses", fmt.Sprint(err != nil)).Observe(metric.MsSince(start))
-
+	params := map[string]interface{}{
+		LogFieldENIID: eniID,
+		"ips":         str,
+	}
 	if err != nil {
+		audit.Log(ctx, "UnassignIpv6Addresses", params, apiErr.ErrRequestID(err), err, start)
 		if apiErr.ErrAssert(apiErr.ErrInvalidIPIPUnassigned, err) {
 			l.WithField(LogFieldRequestID, apiErr.ErrRequestID(err)).Infof("unassign private ip ,%s", str)
 			return nil
@@ -403,6 +505,7 @@ func (a *OpenAPI) UnAssignIpv6Addresses(ctx context.Context, eniID string, ips [
 		l.WithField(LogFieldRequestID, apiErr.ErrRequestID(err)).Warnf("unassign private ipv6 failed,%s %s", str, err.Error())
 		return err
 	}
+	audit.Log(ctx, "UnassignIpv6Addresses", params, resp.RequestId, nil, start)
 	l.WithField(LogFieldRequestID, resp.RequestId).Infof("unassign ipv6 ip ,%s", str)
 	return nil
 }
@@ -420,7 +523,9 @@ func (a *OpenAPI) DescribeInstanceTypes(ctx context.Context, types []string) ([]
 			req.InstanceTypes = &types
 		}
 		start := time.Now()
-		resp, err := a.ClientSet.ECS().DescribeInstanceTypes(req)
+		resp, err := Invoke(ctx, a, "DescribeInstanceTypes", func() (*ecs.DescribeInstanceTypesResponse, error) {
+			return a.ClientSet.ECS().DescribeInstanceTypes(req)
+		})
 		metric.OpenAPILatency.WithLabelValues("DescribeInstanceTypes", fmt.Sprint(err != nil)).Observe(metric.MsSince(start))
 
 		l := log.WithFields(map[string]interface{}{
@@ -449,16 +554,24 @@ func (a *OpenAPI) ModifyNetworkInterfaceAttribute(ctx context.Context, eniID str
 	req.NetworkInterfaceId = eniID
 	req.SecurityGroupId = &securityGroupIDs
 	start := time.Now()
-	resp, err := a.ClientSet.ECS().ModifyNetworkInterfaceAttribute(req)
+	resp, err := Invoke(ctx, a, "ModifyNetworkInterfaceAttribute", func() (*ecs.ModifyNetworkInterfaceAttributeResponse, error) {
+		return a.ClientSet.ECS().ModifyNetworkInterfaceAttribute(req)
+	})
 	metric.OpenAPILatency.WithLabelValues("ModifyNetworkInterfaceAttribute", fmt.Sprint(err != nil)).Observe(metric.MsSince(start))
+	params := map[string]interface{}{
+		LogFieldENIID: eniID,
+		LogFieldSgID:  securityGroupIDs,
+	}
 
 	l := log.WithFields(map[string]interface{}{
 		LogFieldAPI: "ModifyNetworkInterfaceAttribute",
 	})
 	if err != nil {
+		audit.Log(ctx, "ModifyNetworkInterfaceAttribute", params, apiErr.ErrRequestID(err), err, start)
 		l.WithField(LogFieldRequestID, apiErr.ErrRequestID(err)).Error(err)
 		return err
 	}
+	audit.Log(ctx, "ModifyNetworkInterfaceAttribute", params, resp.RequestId, nil, start)
 	l.WithField(LogFieldRequestID, resp.RequestId).Infof("modify securityGroup %s", securityGroupIDs)
 	return nil
 }
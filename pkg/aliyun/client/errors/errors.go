@@ -1,7 +1,9 @@
 package errors
 
 import (
+	"context"
 	"errors"
+	"strings"
 
 	apiErr "github.com/aliyun/alibaba-cloud-sdk-go/sdk/errors"
 )
@@ -58,6 +60,16 @@ func ErrAssert(errCode string, err error) bool {
 	return false
 }
 
+// IsThrottling return true if err is caused by flow control, e.g. Throttling, Throttling.User
+func IsThrottling(err error) bool {
+	respErr, ok := err.(apiErr.Error)
+	if !ok {
+		return false
+	}
+	code := respErr.ErrorCode()
+	return code == ErrThrottling || strings.HasPrefix(code, ErrThrottling+".")
+}
+
 // ErrStatusCodeAssert check err is match errCode
 func ErrStatusCodeAssert(code int, err error) bool {
 	respErr, ok := err.(apiErr.Error)
@@ -75,3 +87,102 @@ func ErrRequestID(err error) string {
 	}
 	return ""
 }
+
+// Class is the category of openapi error, callers decide whether to retry by it
+type Class string
+
+// error classes
+const (
+	// ClassRetryable is transient error, e.g. network error, server error or resource in transition
+	ClassRetryable Class = "retryable"
+	// ClassThrottled is caused by flow control, retry after backoff
+	ClassThrottled Class = "throttled"
+	// ClassQuotaExceeded is caused by insufficient quota or resource, retry will not help until quota is adjusted
+	ClassQuotaExceeded Class = "quotaExceeded"
+	// ClassPermanent is caused by invalid request or permission, should not retry
+	ClassPermanent Class = "permanent"
+	// ClassNotFound the resource is not exist
+	ClassNotFound Class = "notFound"
+)
+
+// ErrCircuitOpen is returned when the circuit breaker of the api is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// codes for resource in transition, the request may success later
+var retryableCodes = map[string]struct{}{
+	ErrTaskConflict:                     {},
+	ErrInvalidENIState:                  {},
+	"OperationConflict":                 {},
+	"InvalidOperation.Conflict":         {},
+	"IncorrectInstanceStatus":           {},
+	"IncorrectVpcStatus":                {},
+	"IncorrectVSwitchStatus":            {},
+	"InvalidOperation.EniServiceStatus": {},
+	"ServiceUnavailable":                {},
+	"InternalError":                     {},
+	"UnknownError":                      {},
+}
+
+// codes for insufficient quota or resource
+var quotaCodes = map[string]struct{}{
+	InvalidVSwitchIDIPNotEnough:            {},
+	"InvalidOperation.AvailableQuota":      {},
+	"OperationDenied.NoStock":              {},
+	"InsufficientBalance":                  {},
+	"InvalidOperation.MaxEniCountExceeded": {},
+}
+
+// Classify return the class of err, empty for nil
+func Classify(err error) Class {
+	if err == nil {
+		return ""
+	}
+	if errors.Is(err, ErrNotFound) {
+		return ClassNotFound
+	}
+	if errors.Is(err, ErrCircuitOpen) {
+		return ClassThrottled
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		// the request is not finished in time, e.g. reconcile timeout
+		return ClassRetryable
+	}
+
+	var respErr *apiErr.ServerError
+	if !errors.As(err, &respErr) {
+		// client side error, e.g. network error
+		return ClassRetryable
+	}
+	code := respErr.ErrorCode()
+	switch {
+	case IsThrottling(respErr):
+		return ClassThrottled
+	case code == "NotFound" || strings.HasSuffix(code, ".NotFound"):
+		return ClassNotFound
+	}
+	if _, ok := quotaCodes[code]; ok || strings.HasPrefix(code, "QuotaExceed") {
+		return ClassQuotaExceeded
+	}
+	if _, ok := retryableCodes[code]; ok {
+		return ClassRetryable
+	}
+	if strings.HasPrefix(code, "Incorrect") && strings.HasSuffix(code, "Status") {
+		return ClassRetryable
+	}
+	if respErr.HttpStatus() >= 500 {
+		return ClassRetryable
+	}
+	return ClassPermanent
+}
+
+// IsRetryable return true if the request may success by retry in the same context
+func IsRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	switch Classify(err) {
+	case ClassRetryable, ClassThrottled:
+		return true
+	}
+	return false
+}
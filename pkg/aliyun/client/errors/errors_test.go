@@ -1,7 +1,9 @@
 package errors
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"testing"
 
 	apiErr "github.com/aliyun/alibaba-cloud-sdk-go/sdk/errors"
@@ -48,3 +50,58 @@ func TestErrAssert(t *testing.T) {
 		})
 	}
 }
+
+func TestIsThrottling(t *testing.T) {
+	assert := func(want bool, err error) {
+		if IsThrottling(err) != want {
+			t.Errorf("IsThrottling(%v) want %v", err, want)
+		}
+	}
+	assert(true, apiErr.NewServerError(400, "{\"Code\": \"Throttling\"}", ""))
+	assert(true, apiErr.NewServerError(400, "{\"Code\": \"Throttling.User\"}", ""))
+	assert(false, apiErr.NewServerError(400, "{\"Code\": \"ThrottlingFoo\"}", ""))
+	assert(false, errors.New("Throttling"))
+}
+
+func TestClassify(t *testing.T) {
+	serverErr := func(status int, code string) error {
+		return fmt.Errorf("wrapped, %w", apiErr.NewServerError(status, fmt.Sprintf("{\"Code\": \"%s\"}", code), ""))
+	}
+	tests := []struct {
+		name string
+		err  error
+		want Class
+	}{
+		{name: "nil", err: nil, want: ""},
+		{name: "not found", err: fmt.Errorf("%w, eni", ErrNotFound), want: ClassNotFound},
+		{name: "circuit open", err: fmt.Errorf("%w, api foo", ErrCircuitOpen), want: ClassThrottled},
+		{name: "canceled", err: context.Canceled, want: ClassRetryable},
+		{name: "deadline exceeded", err: fmt.Errorf("wrapped, %w", context.DeadlineExceeded), want: ClassRetryable},
+		{name: "network", err: errors.New("connection reset"), want: ClassRetryable},
+		{name: "throttling", err: serverErr(400, "Throttling.User"), want: ClassThrottled},
+		{name: "eni not found", err: serverErr(404, ErrInvalidENINotFound), want: ClassNotFound},
+		{name: "ip not enough", err: serverErr(400, InvalidVSwitchIDIPNotEnough), want: ClassQuotaExceeded},
+		{name: "quota prefix", err: serverErr(400, "QuotaExceed.ElasticQuota"), want: ClassQuotaExceeded},
+		{name: "task conflict", err: serverErr(400, ErrTaskConflict), want: ClassRetryable},
+		{name: "incorrect status", err: serverErr(400, "IncorrectEipStatus"), want: ClassRetryable},
+		{name: "server error", err: serverErr(503, "Unknown"), want: ClassRetryable},
+		{name: "invalid parameter", err: serverErr(400, "InvalidParameter"), want: ClassPermanent},
+		{name: "forbidden", err: serverErr(403, "Forbidden.RAM"), want: ClassPermanent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.err); got != tt.want {
+				t.Errorf("Classify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	if !IsRetryable(errors.New("connection reset")) {
+		t.Errorf("network error should be retryable")
+	}
+	if IsRetryable(fmt.Errorf("wrapped, %w", context.DeadlineExceeded)) {
+		t.Errorf("context error should not be retried in the same context")
+	}
+}
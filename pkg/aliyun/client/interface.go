@@ -11,9 +11,11 @@ type VSwitch interface {
 }
 
 type EIP interface {
-	AllocateEIPAddress(bandwidth, chargeType, isp string) (*vpc.AllocateEipAddressResponse, error)
-	AssociateEIPAddress(eipID, eniID, privateIP string) error
-	UnAssociateEIPAddress(eipID, eniID, eniIP string) error
-	ReleaseEIPAddress(eipID string) error
-	AddCommonBandwidthPackageIP(eipID, packageID string) error
+	AllocateEIPAddress(ctx context.Context, bandwidth, chargeType, isp, poolID, idempotentKey string) (*vpc.AllocateEipAddressResponse, error)
+	AssociateEIPAddress(ctx context.Context, eipID, eniID, privateIP string) error
+	UnAssociateEIPAddress(ctx context.Context, eipID, eniID, eniIP string) error
+	ReleaseEIPAddress(ctx context.Context, eipID string) error
+	AddCommonBandwidthPackageIP(ctx context.Context, eipID, packageID string) error
+	RemoveCommonBandwidthPackageIP(ctx context.Context, eipID, packageID string) error
+	DescribeEipAddresses(ctx context.Context, eipID, eniID string) ([]vpc.EipAddress, error)
 }
@@ -0,0 +1,233 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	apiErr "github.com/AliyunContainerService/terway/pkg/aliyun/client/errors"
+	"github.com/AliyunContainerService/terway/pkg/metric"
+)
+
+// Lane is the priority for openapi call, the smaller the higher priority
+type Lane int
+
+// lanes
+const (
+	LanePodCreate Lane = iota
+	LaneAttach
+	LanePoolWarmUp
+	LaneGC
+	LaneDescribe
+
+	laneCount
+)
+
+func (l Lane) String() string {
+	switch l {
+	case LanePodCreate:
+		return "podCreate"
+	case LaneAttach:
+		return "attach"
+	case LanePoolWarmUp:
+		return "poolWarmUp"
+	case LaneGC:
+		return "gc"
+	case LaneDescribe:
+		return "describe"
+	}
+	return "unknown"
+}
+
+type laneContextKey struct{}
+
+// LaneWithCtx set the lane for openapi calls in ctx
+func LaneWithCtx(ctx context.Context, lane Lane) context.Context {
+	return context.WithValue(ctx, laneContextKey{}, lane)
+}
+
+// LaneFromCtx return the lane in ctx, or def if not set
+func LaneFromCtx(ctx context.Context, def Lane) Lane {
+	if ctx == nil {
+		return def
+	}
+	v, ok := ctx.Value(laneContextKey{}).(Lane)
+	if ok {
+		return v
+	}
+	return def
+}
+
+// AIMD parameters
+const (
+	// decrease rate by half on throttling, at most once per adjustInterval
+	decreaseFactor = 0.5
+	// increase rate by maxRate*increaseRatio on success, at most once per adjustInterval
+	increaseRatio  = 0.05
+	minRateRatio   = 0.1
+	adjustInterval = time.Second
+)
+
+type waiter struct {
+	lane  Lane
+	ready chan struct{}
+}
+
+// Limiter is a token bucket limiter, waiters in higher priority lane take tokens first.
+// The rate is adapted by AIMD, decrease when throttling error is observed and recover on success.
+type Limiter struct {
+	name string
+
+	lock       sync.Mutex
+	maxRate    float64
+	minRate    float64
+	rate       float64
+	burst      float64
+	tokens     float64
+	last       time.Time
+	lastAdjust time.Time
+	queues     [laneCount][]*waiter
+
+	now func() time.Time
+}
+
+// NewLimiter create limiter with qps and burst, name is used in metrics
+func NewLimiter(name string, qps float32, burst int) *Limiter {
+	l := &Limiter{
+		name:    name,
+		maxRate: float64(qps),
+		minRate: float64(qps) * minRateRatio,
+		rate:    float64(qps),
+		burst:   float64(burst),
+		tokens:  float64(burst),
+		now:     time.Now,
+	}
+	l.last = l.now()
+	metric.OpenAPILimiterRate.WithLabelValues(name).Set(l.rate)
+	return l
+}
+
+// Wait block until a token is granted for the lane or ctx done
+func (l *Limiter) Wait(ctx context.Context, lane Lane) error {
+	if lane < 0 || lane >= laneCount {
+		lane = LaneDescribe
+	}
+	start := l.now()
+	defer func() {
+		metric.OpenAPILimiterWaitTime.WithLabelValues(l.name, lane.String()).Observe(metric.MsSince(start))
+	}()
+
+	w := &waiter{lane: lane, ready: make(chan struct{})}
+
+	l.lock.Lock()
+	l.queues[lane] = append(l.queues[lane], w)
+	l.setDepth(lane)
+	next := l.dispatch()
+	l.lock.Unlock()
+
+	for {
+		timer := time.NewTimer(next)
+		select {
+		case <-w.ready:
+			timer.Stop()
+			return nil
+		case <-ctx.Done():
+			timer.Stop()
+			l.lock.Lock()
+			granted := l.remove(w)
+			l.lock.Unlock()
+			if granted {
+				return nil
+			}
+			return ctx.Err()
+		case <-timer.C:
+			l.lock.Lock()
+			next = l.dispatch()
+			l.lock.Unlock()
+		}
+	}
+}
+
+// Done feedback the result of the call, throttling error will slow down the limiter
+func (l *Limiter) Done(err error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastAdjust) < adjustInterval {
+		return
+	}
+	if err != nil && apiErr.IsThrottling(err) {
+		l.rate = l.rate * decreaseFactor
+		if l.rate < l.minRate {
+			l.rate = l.minRate
+		}
+		metric.OpenAPIThrottled.WithLabelValues(l.name).Inc()
+	} else if err == nil && l.rate < l.maxRate {
+		l.rate = l.rate + l.maxRate*increaseRatio
+		if l.rate > l.maxRate {
+			l.rate = l.maxRate
+		}
+	} else {
+		return
+	}
+	l.lastAdjust = now
+	metric.OpenAPILimiterRate.WithLabelValues(l.name).Set(l.rate)
+}
+
+// Rate return the current rate
+func (l *Limiter) Rate() float64 {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.rate
+}
+
+// dispatch grant tokens to waiters by priority, return the duration until next token is available
+func (l *Limiter) dispatch() time.Duration {
+	now := l.now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	for lane := Lane(0); lane < laneCount; lane++ {
+		for len(l.queues[lane]) > 0 && l.tokens >= 1 {
+			w := l.queues[lane][0]
+			l.queues[lane] = l.queues[lane][1:]
+			l.tokens--
+			close(w.ready)
+		}
+		l.setDepth(lane)
+		if l.tokens < 1 {
+			break
+		}
+	}
+	next := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+	if next < time.Millisecond {
+		next = time.Millisecond
+	}
+	return next
+}
+
+// remove the waiter from queue, return true if the token is already granted
+func (l *Limiter) remove(w *waiter) bool {
+	select {
+	case <-w.ready:
+		return true
+	default:
+	}
+	q := l.queues[w.lane]
+	for i := range q {
+		if q[i] == w {
+			l.queues[w.lane] = append(q[:i], q[i+1:]...)
+			break
+		}
+	}
+	l.setDepth(w.lane)
+	return false
+}
+
+func (l *Limiter) setDepth(lane Lane) {
+	metric.OpenAPILimiterQueueDepth.WithLabelValues(l.name, lane.String()).Set(float64(len(l.queues[lane])))
+}
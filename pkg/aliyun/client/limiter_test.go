@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	sdkErr "github.com/aliyun/alibaba-cloud-sdk-go/sdk/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter_Priority(t *testing.T) {
+	l := NewLimiter("test", 20, 1)
+	// drain the burst
+	assert.NoError(t, l.Wait(context.Background(), LaneDescribe))
+
+	var lock sync.Mutex
+	var order []Lane
+	var wg sync.WaitGroup
+	for _, lane := range []Lane{LaneDescribe, LaneGC, LanePodCreate} {
+		wg.Add(1)
+		go func(lane Lane) {
+			defer wg.Done()
+			assert.NoError(t, l.Wait(context.Background(), lane))
+			lock.Lock()
+			order = append(order, lane)
+			lock.Unlock()
+		}(lane)
+		// make sure all waiters are queued before token is available
+		time.Sleep(5 * time.Millisecond)
+	}
+	wg.Wait()
+
+	assert.Equal(t, []Lane{LanePodCreate, LaneGC, LaneDescribe}, order)
+}
+
+func TestLimiter_Cancel(t *testing.T) {
+	l := NewLimiter("test", 1, 1)
+	assert.NoError(t, l.Wait(context.Background(), LaneGC))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, l.Wait(ctx, LaneGC), context.DeadlineExceeded)
+	assert.Empty(t, l.queues[LaneGC])
+}
+
+func TestLimiter_AIMD(t *testing.T) {
+	now := time.Now()
+	l := NewLimiter("test", 10, 10)
+	l.now = func() time.Time { return now }
+
+	throttling := sdkErr.NewServerError(400, "{\"Code\": \"Throttling.User\"}", "")
+	l.Done(throttling)
+	assert.Equal(t, 5.0, l.Rate())
+
+	// at most once per interval
+	l.Done(throttling)
+	assert.Equal(t, 5.0, l.Rate())
+
+	now = now.Add(adjustInterval)
+	l.Done(errors.New("other error"))
+	assert.Equal(t, 5.0, l.Rate())
+	l.Done(nil)
+	assert.Equal(t, 5.5, l.Rate())
+
+	for i := 0; i < 10; i++ {
+		now = now.Add(adjustInterval)
+		l.Done(throttling)
+	}
+	assert.Equal(t, 1.0, l.Rate())
+
+	for i := 0; i < 100; i++ {
+		now = now.Add(adjustInterval)
+		l.Done(nil)
+	}
+	assert.Equal(t, 10.0, l.Rate())
+}
@@ -1,34 +1,52 @@
 package client
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	apiErr "github.com/AliyunContainerService/terway/pkg/aliyun/client/errors"
+	"github.com/AliyunContainerService/terway/pkg/audit"
 	"github.com/AliyunContainerService/terway/pkg/backoff"
 	"github.com/AliyunContainerService/terway/pkg/metric"
+	"github.com/aliyun/alibaba-cloud-sdk-go/sdk/requests"
 	"github.com/aliyun/alibaba-cloud-sdk-go/services/vpc"
 	"k8s.io/client-go/util/retry"
 )
 
-// AllocateEIPAddress create EIP
-func (a *OpenAPI) AllocateEIPAddress(bandwidth, chargeType, isp string) (*vpc.AllocateEipAddressResponse, error) {
+// AllocateEIPAddress create EIP, the eip is created from the public ip address pool if poolID is set.
+// The same eip is returned for the same idempotentKey, leave it empty to always create a new one.
+func (a *OpenAPI) AllocateEIPAddress(ctx context.Context, bandwidth, chargeType, isp, poolID, idempotentKey string) (*vpc.AllocateEipAddressResponse, error) {
 	req := vpc.CreateAllocateEipAddressRequest()
 	req.Bandwidth = bandwidth
 	req.InternetChargeType = chargeType
 	req.ISP = isp
+	req.PublicIpAddressPoolId = poolID
+	req.ClientToken = idempotentKey
 
 	l := log.WithFields(map[string]interface{}{
 		LogFieldAPI: "AllocateEipAddress",
 	})
+	params := map[string]interface{}{
+		"bandwidth":   bandwidth,
+		"chargeType":  chargeType,
+		"isp":         isp,
+		"poolID":      poolID,
+		"clientToken": idempotentKey,
+	}
 	start := time.Now()
-	resp, err := a.ClientSet.VPC().AllocateEipAddress(req)
+	resp, err := Invoke(ctx, a, "AllocateEipAddress", func() (*vpc.AllocateEipAddressResponse, error) {
+		return a.ClientSet.VPC().AllocateEipAddress(req)
+	})
 	metric.OpenAPILatency.WithLabelValues("AllocateEipAddress", fmt.Sprint(err != nil)).Observe(metric.MsSince(start))
 	if err != nil {
+		audit.Log(ctx, "AllocateEipAddress", params, apiErr.ErrRequestID(err), err, start)
 		l.WithFields(map[string]interface{}{
 			LogFieldRequestID: apiErr.ErrRequestID(err)}).Errorf("alloc EIP faild, %s", err.Error())
 		return nil, fmt.Errorf("error create EIP, bandwidth %s, %w", bandwidth, err)
 	}
+	params[LogFieldEIPID] = resp.AllocationId
+	audit.Log(ctx, "AllocateEipAddress", params, resp.RequestId, nil, start)
 	l.WithFields(map[string]interface{}{
 		LogFieldEIPID:     resp.AllocationId,
 		LogFieldRequestID: resp.RequestId}).Infof("alloc EIP %s", resp.EipAddress)
@@ -36,7 +54,7 @@ func (a *OpenAPI) AllocateEIPAddress(bandwidth, chargeType, isp string) (*vpc.Al
 }
 
 // AssociateEIPAddress bind eip to ip
-func (a *OpenAPI) AssociateEIPAddress(eipID, eniID, privateIP string) error {
+func (a *OpenAPI) AssociateEIPAddress(ctx context.Context, eipID, eniID, privateIP string) error {
 	req := vpc.CreateAssociateEipAddressRequest()
 	req.AllocationId = eipID
 	req.InstanceId = eniID
@@ -48,19 +66,26 @@ func (a *OpenAPI) AssociateEIPAddress(eipID, eniID, privateIP string) error {
 		LogFieldEIPID: eipID,
 		LogFieldENIID: eniID,
 	})
+	params := map[string]interface{}{
+		LogFieldEIPID:     eipID,
+		LogFieldENIID:     eniID,
+		LogFieldPrivateIP: privateIP,
+	}
 	start := time.Now()
 
-	return retry.OnError(backoff.Backoff(backoff.DefaultKey), func(err error) bool {
-		return apiErr.ErrAssert(apiErr.ErrTaskConflict, err)
-	}, func() error {
-		resp, err := a.ClientSet.VPC().AssociateEipAddress(req)
+	return retry.OnError(backoff.Backoff(backoff.DefaultKey), apiErr.IsRetryable, func() error {
+		resp, err := Invoke(ctx, a, "AssociateEipAddress", func() (*vpc.AssociateEipAddressResponse, error) {
+			return a.ClientSet.VPC().AssociateEipAddress(req)
+		})
 		metric.OpenAPILatency.WithLabelValues("AssociateEipAddress", fmt.Sprint(err != nil)).Observe(metric.MsSince(start))
 		if err != nil {
+			audit.Log(ctx, "AssociateEipAddress", params, apiErr.ErrRequestID(err), err, start)
 			l.WithFields(map[string]interface{}{
 				LogFieldRequestID: apiErr.ErrRequestID(err)}).Warnf("associate EIP to %s failed, %s", privateIP, err.Error())
 
 			return err
 		}
+		audit.Log(ctx, "AssociateEipAddress", params, resp.RequestId, nil, start)
 		l.WithFields(map[string]interface{}{
 			LogFieldRequestID: resp.RequestId}).Infof("associate EIP to %s", privateIP)
 		return nil
@@ -68,7 +93,7 @@ func (a *OpenAPI) AssociateEIPAddress(eipID, eniID, privateIP string) error {
 }
 
 // UnAssociateEIPAddress un-bind eip
-func (a *OpenAPI) UnAssociateEIPAddress(eipID, eniID, eniIP string) error {
+func (a *OpenAPI) UnAssociateEIPAddress(ctx context.Context, eipID, eniID, eniIP string) error {
 	req := vpc.CreateUnassociateEipAddressRequest()
 	req.AllocationId = eipID
 	req.InstanceId = eniID
@@ -79,14 +104,20 @@ func (a *OpenAPI) UnAssociateEIPAddress(eipID, eniID, eniIP string) error {
 		LogFieldAPI:   "UnassociateEipAddress",
 		LogFieldEIPID: eipID,
 	})
+	params := map[string]interface{}{
+		LogFieldEIPID:     eipID,
+		LogFieldENIID:     eniID,
+		LogFieldPrivateIP: eniIP,
+	}
 
-	return retry.OnError(backoff.Backoff(backoff.DefaultKey), func(err error) bool {
-		return apiErr.ErrAssert(apiErr.ErrTaskConflict, err)
-	}, func() error {
+	return retry.OnError(backoff.Backoff(backoff.DefaultKey), apiErr.IsRetryable, func() error {
 		start := time.Now()
-		resp, err := a.ClientSet.VPC().UnassociateEipAddress(req)
+		resp, err := Invoke(ctx, a, "UnassociateEipAddress", func() (*vpc.UnassociateEipAddressResponse, error) {
+			return a.ClientSet.VPC().UnassociateEipAddress(req)
+		})
 		metric.OpenAPILatency.WithLabelValues("UnassociateEipAddress", fmt.Sprint(err != nil)).Observe(metric.MsSince(start))
 		if err != nil {
+			audit.Log(ctx, "UnassociateEipAddress", params, apiErr.ErrRequestID(err), err, start)
 			l.WithFields(map[string]interface{}{
 				LogFieldRequestID: apiErr.ErrRequestID(err)}).Warnf("unassociate EIP failed, %s", err.Error())
 			if apiErr.ErrAssert(apiErr.ErrInvalidAllocationIDNotFound, err) ||
@@ -95,6 +126,7 @@ func (a *OpenAPI) UnAssociateEIPAddress(eipID, eniID, eniIP string) error {
 			}
 			return fmt.Errorf("error unassociate EIP %s, %w", eipID, err)
 		}
+		audit.Log(ctx, "UnassociateEipAddress", params, resp.RequestId, nil, start)
 		l.WithFields(map[string]interface{}{
 			LogFieldRequestID: resp.RequestId}).Info("unassociate EIP")
 		return nil
@@ -102,7 +134,7 @@ func (a *OpenAPI) UnAssociateEIPAddress(eipID, eniID, eniIP string) error {
 }
 
 // ReleaseEIPAddress delete EIP
-func (a *OpenAPI) ReleaseEIPAddress(eipID string) error {
+func (a *OpenAPI) ReleaseEIPAddress(ctx context.Context, eipID string) error {
 	req := vpc.CreateReleaseEipAddressRequest()
 	req.AllocationId = eipID
 
@@ -110,18 +142,23 @@ func (a *OpenAPI) ReleaseEIPAddress(eipID string) error {
 		LogFieldAPI:   "ReleaseEipAddress",
 		LogFieldEIPID: eipID,
 	})
+	params := map[string]interface{}{
+		LogFieldEIPID: eipID,
+	}
 
-	return retry.OnError(backoff.Backoff(backoff.DefaultKey), func(err error) bool {
-		return apiErr.ErrAssert(apiErr.ErrTaskConflict, err)
-	}, func() error {
+	return retry.OnError(backoff.Backoff(backoff.DefaultKey), apiErr.IsRetryable, func() error {
 		start := time.Now()
-		resp, err := a.ClientSet.VPC().ReleaseEipAddress(req)
+		resp, err := Invoke(ctx, a, "ReleaseEipAddress", func() (*vpc.ReleaseEipAddressResponse, error) {
+			return a.ClientSet.VPC().ReleaseEipAddress(req)
+		})
 		metric.OpenAPILatency.WithLabelValues("ReleaseEipAddress", fmt.Sprint(err != nil)).Observe(metric.MsSince(start))
 		if err != nil {
+			audit.Log(ctx, "ReleaseEipAddress", params, apiErr.ErrRequestID(err), err, start)
 			l.WithFields(map[string]interface{}{
 				LogFieldRequestID: apiErr.ErrRequestID(err)}).Warnf("release EIP failed, %s", err.Error())
 			return err
 		}
+		audit.Log(ctx, "ReleaseEipAddress", params, resp.RequestId, nil, start)
 		l.WithFields(map[string]interface{}{
 			LogFieldRequestID: resp.RequestId}).Info("release EIP")
 		return nil
@@ -129,7 +166,7 @@ func (a *OpenAPI) ReleaseEIPAddress(eipID string) error {
 }
 
 // AddCommonBandwidthPackageIP add EIP to bandwidth package
-func (a *OpenAPI) AddCommonBandwidthPackageIP(eipID, packageID string) error {
+func (a *OpenAPI) AddCommonBandwidthPackageIP(ctx context.Context, eipID, packageID string) error {
 	req := vpc.CreateAddCommonBandwidthPackageIpRequest()
 	req.BandwidthPackageId = packageID
 	req.IpInstanceId = eipID
@@ -138,17 +175,23 @@ func (a *OpenAPI) AddCommonBandwidthPackageIP(eipID, packageID string) error {
 		LogFieldAPI:   "AddCommonBandwidthPackageIp",
 		LogFieldEIPID: eipID,
 	})
-	return retry.OnError(backoff.Backoff(backoff.DefaultKey), func(err error) bool {
-		return apiErr.ErrAssert(apiErr.ErrTaskConflict, err)
-	}, func() error {
+	params := map[string]interface{}{
+		LogFieldEIPID:        eipID,
+		"bandwidthPackageID": packageID,
+	}
+	return retry.OnError(backoff.Backoff(backoff.DefaultKey), apiErr.IsRetryable, func() error {
 		start := time.Now()
-		resp, err := a.ClientSet.VPC().AddCommonBandwidthPackageIp(req)
+		resp, err := Invoke(ctx, a, "AddCommonBandwidthPackageIp", func() (*vpc.AddCommonBandwidthPackageIpResponse, error) {
+			return a.ClientSet.VPC().AddCommonBandwidthPackageIp(req)
+		})
 		metric.OpenAPILatency.WithLabelValues("AddCommonBandwidthPackageIp", fmt.Sprint(err != nil)).Observe(metric.MsSince(start))
 		if err != nil {
+			audit.Log(ctx, "AddCommonBandwidthPackageIp", params, apiErr.ErrRequestID(err), err, start)
 			l.WithFields(map[string]interface{}{
 				LogFieldRequestID: apiErr.ErrRequestID(err)}).Warnf("add eip failed, %s", err.Error())
 			return err
 		}
+		audit.Log(ctx, "AddCommonBandwidthPackageIp", params, resp.RequestId, nil, start)
 		l.WithFields(map[string]interface{}{
 			LogFieldRequestID: resp.RequestId}).Info("add eip success")
 		return nil
@@ -156,7 +199,7 @@ func (a *OpenAPI) AddCommonBandwidthPackageIP(eipID, packageID string) error {
 }
 
 // RemoveCommonBandwidthPackageIP remove EIP from bandwidth package
-func (a *OpenAPI) RemoveCommonBandwidthPackageIP(eipID, packageID string) error {
+func (a *OpenAPI) RemoveCommonBandwidthPackageIP(ctx context.Context, eipID, packageID string) error {
 	req := vpc.CreateRemoveCommonBandwidthPackageIpRequest()
 	req.BandwidthPackageId = packageID
 	req.IpInstanceId = eipID
@@ -165,20 +208,50 @@ func (a *OpenAPI) RemoveCommonBandwidthPackageIP(eipID, packageID string) error
 		LogFieldAPI:   "RemoveCommonBandwidthPackageIp",
 		LogFieldEIPID: eipID,
 	})
+	params := map[string]interface{}{
+		LogFieldEIPID:        eipID,
+		"bandwidthPackageID": packageID,
+	}
 
-	return retry.OnError(backoff.Backoff(backoff.DefaultKey), func(err error) bool {
-		return apiErr.ErrAssert(apiErr.ErrTaskConflict, err)
-	}, func() error {
+	return retry.OnError(backoff.Backoff(backoff.DefaultKey), apiErr.IsRetryable, func() error {
 		start := time.Now()
-		resp, err := a.ClientSet.VPC().RemoveCommonBandwidthPackageIp(req)
+		resp, err := Invoke(ctx, a, "RemoveCommonBandwidthPackageIp", func() (*vpc.RemoveCommonBandwidthPackageIpResponse, error) {
+			return a.ClientSet.VPC().RemoveCommonBandwidthPackageIp(req)
+		})
 		metric.OpenAPILatency.WithLabelValues("RemoveCommonBandwidthPackageIp", fmt.Sprint(err != nil)).Observe(metric.MsSince(start))
 		if err != nil {
+			audit.Log(ctx, "RemoveCommonBandwidthPackageIp", params, apiErr.ErrRequestID(err), err, start)
 			l.WithFields(map[string]interface{}{
 				LogFieldRequestID: apiErr.ErrRequestID(err)}).Warnf("remove eip failed, %s", err.Error())
 			return err
 		}
+		audit.Log(ctx, "RemoveCommonBandwidthPackageIp", params, resp.RequestId, nil, start)
 		l.WithFields(map[string]interface{}{
 			LogFieldRequestID: resp.RequestId}).Info("remove eip success")
 		return nil
 	})
 }
+
+// DescribeEipAddresses query eip by id, or the eips associated to the eni
+func (a *OpenAPI) DescribeEipAddresses(ctx context.Context, eipID, eniID string) ([]vpc.EipAddress, error) {
+	req := vpc.CreateDescribeEipAddressesRequest()
+	req.AllocationId = eipID
+	if eniID != "" {
+		req.AssociatedInstanceType = EIPInstanceTypeNetworkInterface
+		req.AssociatedInstanceId = eniID
+	}
+	req.PageSize = requests.NewInteger(100)
+
+	l := log.WithFields(map[string]interface{}{LogFieldAPI: "DescribeEipAddresses", LogFieldEIPID: eipID, LogFieldENIID: eniID})
+	start := time.Now()
+	resp, err := Invoke(ctx, a, "DescribeEipAddresses", func() (*vpc.DescribeEipAddressesResponse, error) {
+		return a.ClientSet.VPC().DescribeEipAddresses(req)
+	})
+	metric.OpenAPILatency.WithLabelValues("DescribeEipAddresses", fmt.Sprint(err != nil)).Observe(metric.MsSince(start))
+	if err != nil {
+		l.WithFields(map[string]interface{}{LogFieldRequestID: apiErr.ErrRequestID(err)}).Warn(err)
+		return nil, err
+	}
+	l.WithFields(map[string]interface{}{LogFieldRequestID: resp.RequestId}).Debugf("get eip len %d", len(resp.EipAddresses.EipAddress))
+	return resp.EipAddresses.EipAddress, nil
+}
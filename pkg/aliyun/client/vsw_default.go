@@ -23,7 +23,9 @@ func (a *OpenAPI) DescribeVSwitchByID(ctx context.Context, vSwitchID string) (*v
 	})
 
 	start := time.Now()
-	resp, err := a.ClientSet.VPC().DescribeVSwitches(req)
+	resp, err := Invoke(ctx, a, "DescribeVSwitches", func() (*vpc.DescribeVSwitchesResponse, error) {
+		return a.ClientSet.VPC().DescribeVSwitches(req)
+	})
 	metric.OpenAPILatency.WithLabelValues("DescribeVSwitches", fmt.Sprint(err != nil)).Observe(metric.MsSince(start))
 	if err != nil {
 		l.WithField(LogFieldRequestID, apiErr.ErrRequestID(err)).Error(err)
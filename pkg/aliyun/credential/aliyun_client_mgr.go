@@ -13,10 +13,12 @@ import (
 	"time"
 
 	"github.com/AliyunContainerService/terway/pkg/logger"
+	"github.com/AliyunContainerService/terway/pkg/metric"
 
 	"github.com/aliyun/alibaba-cloud-sdk-go/sdk"
 	"github.com/aliyun/alibaba-cloud-sdk-go/services/ecs"
 	"github.com/aliyun/alibaba-cloud-sdk-go/services/vpc"
+	k8sErr "k8s.io/apimachinery/pkg/util/errors"
 )
 
 type Client interface {
@@ -44,29 +46,44 @@ func clientCfg() *sdk.Config {
 	}
 }
 
-func NewClientSet(ak, sk, regionID, credentialPath, secretNamespace, secretName string) (Client, error) {
+func NewClientSet(ak, sk, regionID, credentialPath, secretNamespace, secretName string, oidc OIDCConfig) (*ClientMgr, error) {
 	if regionID == "" {
 		return nil, fmt.Errorf("regionID unset")
 	}
-	clientSet, err := NewClientMgr(ak, sk, credentialPath, regionID, secretNamespace, secretName)
+	clientSet, err := NewClientMgr(ak, sk, credentialPath, regionID, secretNamespace, secretName, oidc)
 	if err != nil {
 		return nil, fmt.Errorf("error get clientset, %w", err)
 	}
 	return clientSet, nil
 }
 
+// EventRecorder record events about the credential
+type EventRecorder func(eventType, reason, message string)
+
 // ClientMgr manager of aliyun openapi clientset
 type ClientMgr struct {
 	regionID string
 
-	auth Interface
+	credentialPath  string
+	secretNamespace string
+	secretName      string
+
+	// providers in the order of priority
+	providers []Interface
 
 	// protect things below
 	sync.RWMutex
 
+	auth     Interface
+	recorder EventRecorder
+
 	expireAt time.Time
 	updateAt time.Time
 
+	// last error and expiration of each provider
+	providerErr    map[string]string
+	providerExpire map[string]time.Time
+
 	ecs *ecs.Client
 	vpc *vpc.Client
 
@@ -75,9 +92,14 @@ type ClientMgr struct {
 }
 
 // NewClientMgr return new aliyun client manager
-func NewClientMgr(key, secret, credentialPath, regionID, secretNamespace, secretName string) (*ClientMgr, error) {
+func NewClientMgr(key, secret, credentialPath, regionID, secretNamespace, secretName string, oidc OIDCConfig) (*ClientMgr, error) {
 	mgr := &ClientMgr{
-		regionID: regionID,
+		regionID:        regionID,
+		credentialPath:  credentialPath,
+		secretNamespace: secretNamespace,
+		secretName:      secretName,
+		providerErr:     make(map[string]string),
+		providerExpire:  make(map[string]time.Time),
 	}
 
 	var err error
@@ -90,28 +112,79 @@ func NewClientMgr(key, secret, credentialPath, regionID, secretNamespace, secret
 		return nil, err
 	}
 
-	providers := []Interface{
+	mgr.providers = []Interface{
 		NewAKPairProvider(key, secret),
+		NewOIDCProvider(oidc),
 		NewEncryptedCredentialProvider(credentialPath, secretNamespace, secretName),
 		NewMetadataProvider(),
 	}
-	for _, p := range providers {
-		c, err := p.Resolve()
+	mgr.auth, _, err = mgr.resolve()
+	if err != nil {
+		return nil, err
+	}
+	mgrLog.Infof("using %s provider", mgr.auth.Name())
+
+	return mgr, nil
+}
+
+// SetEventRecorder set the recorder for credential failures
+func (c *ClientMgr) SetEventRecorder(recorder EventRecorder) {
+	c.Lock()
+	defer c.Unlock()
+	c.recorder = recorder
+	for name, msg := range c.providerErr {
+		c.recordEvent("Warning", "CredentialInvalid", fmt.Sprintf("credential provider %s is invalid, %s", name, msg))
+	}
+}
+
+// resolve return the first provider with valid credential by priority.
+// Providers not configured are skipped, invalid ones fallback to the next.
+func (c *ClientMgr) resolve() (Interface, *Credential, error) {
+	var errs []error
+	for _, p := range c.providers {
+		cc, err := p.Resolve()
+		if err == nil && cc != nil && !cc.Expiration.After(time.Now()) {
+			err = fmt.Errorf("credential expired at %s", cc.Expiration)
+		}
 		if err != nil {
-			return nil, err
+			c.setProviderErr(p, err)
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+			continue
 		}
-		if c == nil {
+		if cc == nil {
 			continue
 		}
-		mgr.auth = p
-		mgrLog.Infof("using %s provider", mgr.auth.Name())
-		break
+		c.setProviderErr(p, nil)
+		c.providerExpire[p.Name()] = cc.Expiration
+		metric.CredentialExpiry.WithLabelValues(p.Name()).Set(time.Until(cc.Expiration).Seconds())
+		return p, cc, nil
 	}
-	if mgr.auth == nil {
-		return nil, errors.New("unable to found a valid credential provider")
+	if len(errs) > 0 {
+		return nil, nil, fmt.Errorf("unable to found a valid credential provider, %w", k8sErr.NewAggregate(errs))
 	}
+	return nil, nil, errors.New("unable to found a valid credential provider")
+}
 
-	return mgr, nil
+// setProviderErr record the error of provider, event is sent only when the error changed
+func (c *ClientMgr) setProviderErr(p Interface, err error) {
+	if err == nil {
+		delete(c.providerErr, p.Name())
+		return
+	}
+	msg := err.Error()
+	if c.providerErr[p.Name()] == msg {
+		return
+	}
+	c.providerErr[p.Name()] = msg
+	mgrLog.Errorf("credential provider %s is invalid, %s", p.Name(), msg)
+	c.recordEvent("Warning", "CredentialInvalid", fmt.Sprintf("credential provider %s is invalid, %s", p.Name(), msg))
+}
+
+func (c *ClientMgr) recordEvent(eventType, reason, message string) {
+	if c.recorder == nil {
+		return
+	}
+	c.recorder(eventType, reason, message)
 }
 
 func (c *ClientMgr) VPC() *vpc.Client {
@@ -149,10 +222,17 @@ func (c *ClientMgr) refreshToken() (bool, error) {
 			}
 		}()
 
-		cc, err := c.auth.Resolve()
+		var p Interface
+		var cc *Credential
+		p, cc, err = c.resolve()
 		if err != nil {
 			return false, err
 		}
+		if c.auth == nil || p.Name() != c.auth.Name() {
+			mgrLog.Infof("credential provider switched to %s", p.Name())
+			c.recordEvent("Normal", "CredentialProviderSwitched", fmt.Sprintf("credential provider switched to %s", p.Name()))
+		}
+		c.auth = p
 
 		c.ecs, err = ecs.NewClientWithOptions(c.regionID, clientCfg(), cc.Credential)
 		if err != nil {
@@ -0,0 +1,188 @@
+package credential
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aliyun/alibaba-cloud-sdk-go/sdk/auth/credentials"
+	"k8s.io/apimachinery/pkg/util/uuid"
+)
+
+const (
+	defaultSTSEndpoint        = "sts.aliyuncs.com"
+	defaultOIDCSessionName    = "terway"
+	defaultOIDCDuration       = time.Hour
+	defaultOIDCRefreshBefore  = 10 * time.Minute
+	defaultOIDCRequestTimeout = 10 * time.Second
+
+	stsTimeLayout = "2006-01-02T15:04:05Z"
+)
+
+// OIDCConfig is the config for assuming ram role with the service account token (RRSA)
+type OIDCConfig struct {
+	RoleARN         string
+	ProviderARN     string
+	TokenFile       string
+	STSEndpoint     string
+	SessionName     string
+	DurationSeconds int
+}
+
+// Enabled return true if the role is configured
+func (c *OIDCConfig) Enabled() bool {
+	return c.RoleARN != "" && c.ProviderARN != "" && c.TokenFile != ""
+}
+
+// OIDCConfigFromEnv fill the empty fields with the env injected by the RRSA webhook
+func OIDCConfigFromEnv(cfg OIDCConfig) OIDCConfig {
+	if cfg.RoleARN == "" {
+		cfg.RoleARN = os.Getenv("ALIBABA_CLOUD_ROLE_ARN")
+	}
+	if cfg.ProviderARN == "" {
+		cfg.ProviderARN = os.Getenv("ALIBABA_CLOUD_OIDC_PROVIDER_ARN")
+	}
+	if cfg.TokenFile == "" {
+		cfg.TokenFile = os.Getenv("ALIBABA_CLOUD_OIDC_TOKEN_FILE")
+	}
+	return cfg
+}
+
+type stsCredentials struct {
+	AccessKeyID     string `json:"AccessKeyId"`
+	AccessKeySecret string `json:"AccessKeySecret"`
+	SecurityToken   string `json:"SecurityToken"`
+	Expiration      string `json:"Expiration"`
+}
+
+type assumeRoleWithOIDCResponse struct {
+	RequestID   string          `json:"RequestId"`
+	Code        string          `json:"Code"`
+	Message     string          `json:"Message"`
+	Credentials *stsCredentials `json:"Credentials"`
+}
+
+// OIDCProvider exchange the service account token for sts token by AssumeRoleWithOIDC,
+// the token is cached and refreshed before expiration
+type OIDCProvider struct {
+	cfg           OIDCConfig
+	refreshBefore time.Duration
+	httpClient    *http.Client
+	scheme        string
+
+	lock   sync.Mutex
+	cached *Credential
+
+	now func() time.Time
+}
+
+// NewOIDCProvider create provider with the config, empty fields are set to default
+func NewOIDCProvider(cfg OIDCConfig) *OIDCProvider {
+	if cfg.STSEndpoint == "" {
+		cfg.STSEndpoint = defaultSTSEndpoint
+	}
+	if cfg.SessionName == "" {
+		cfg.SessionName = defaultOIDCSessionName
+	}
+	if cfg.DurationSeconds <= 0 {
+		cfg.DurationSeconds = int(defaultOIDCDuration.Seconds())
+	}
+	refreshBefore := defaultOIDCRefreshBefore
+	if d := time.Duration(cfg.DurationSeconds) * time.Second / 2; d < refreshBefore {
+		refreshBefore = d
+	}
+	return &OIDCProvider{
+		cfg:           cfg,
+		refreshBefore: refreshBefore,
+		httpClient:    &http.Client{Timeout: defaultOIDCRequestTimeout},
+		scheme:        "https",
+		now:           time.Now,
+	}
+}
+
+func (o *OIDCProvider) Resolve() (*Credential, error) {
+	if !o.cfg.Enabled() {
+		return nil, nil
+	}
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	now := o.now()
+	if o.cached != nil && now.Add(o.refreshBefore).Before(o.cached.Expiration) {
+		return o.cached, nil
+	}
+
+	c, err := o.assumeRole()
+	if err != nil {
+		// keep using the cached one until it expires
+		if o.cached != nil && now.Before(o.cached.Expiration) {
+			log.Warnf("error refresh oidc credential, use the cached one, %v", err)
+			return o.cached, nil
+		}
+		return nil, err
+	}
+	o.cached = c
+	log.Infof("oidc credential refreshed, expiration %s", c.Expiration)
+	return c, nil
+}
+
+func (o *OIDCProvider) Name() string {
+	return "OIDCProvider"
+}
+
+func (o *OIDCProvider) assumeRole() (*Credential, error) {
+	token, err := os.ReadFile(o.cfg.TokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("error read oidc token %s, %w", o.cfg.TokenFile, err)
+	}
+
+	form := url.Values{}
+	form.Set("Action", "AssumeRoleWithOIDC")
+	form.Set("Version", "2015-04-01")
+	form.Set("Format", "JSON")
+	form.Set("Timestamp", o.now().UTC().Format(stsTimeLayout))
+	form.Set("SignatureNonce", string(uuid.NewUUID()))
+	form.Set("RoleArn", o.cfg.RoleARN)
+	form.Set("OIDCProviderArn", o.cfg.ProviderARN)
+	form.Set("OIDCToken", strings.TrimSpace(string(token)))
+	form.Set("RoleSessionName", o.cfg.SessionName)
+	form.Set("DurationSeconds", fmt.Sprint(o.cfg.DurationSeconds))
+
+	endpoint := o.cfg.STSEndpoint
+	if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		endpoint = o.scheme + "://" + endpoint
+	}
+	resp, err := o.httpClient.PostForm(endpoint, form)
+	if err != nil {
+		return nil, fmt.Errorf("error call AssumeRoleWithOIDC, %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error read AssumeRoleWithOIDC response, %w", err)
+	}
+	result := &assumeRoleWithOIDCResponse{}
+	err = json.Unmarshal(body, result)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshal AssumeRoleWithOIDC response, status %d, %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || result.Credentials == nil {
+		return nil, fmt.Errorf("error AssumeRoleWithOIDC, status %d, code %s, message %s, requestID %s", resp.StatusCode, result.Code, result.Message, result.RequestID)
+	}
+
+	expiration, err := time.Parse(stsTimeLayout, result.Credentials.Expiration)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse expiration time, err: %w", err)
+	}
+	return &Credential{
+		Credential: credentials.NewStsTokenCredential(result.Credentials.AccessKeyID, result.Credentials.AccessKeySecret, result.Credentials.SecurityToken),
+		Expiration: expiration,
+	}, nil
+}
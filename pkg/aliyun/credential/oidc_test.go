@@ -0,0 +1,119 @@
+package credential
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aliyun/alibaba-cloud-sdk-go/sdk/auth/credentials"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSTS struct {
+	calls      int32
+	fail       int32
+	expiration time.Time
+	lastForm   map[string]string
+}
+
+func (f *fakeSTS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := atomic.AddInt32(&f.calls, 1)
+	_ = r.ParseForm()
+	f.lastForm = map[string]string{}
+	for k := range r.PostForm {
+		f.lastForm[k] = r.PostForm.Get(k)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if atomic.LoadInt32(&f.fail) > 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprint(w, `{"RequestId":"req","Code":"InvalidParameter.OIDCToken","Message":"token expired"}`)
+		return
+	}
+	_, _ = fmt.Fprintf(w, `{"RequestId":"req","Credentials":{"AccessKeyId":"ak-%d","AccessKeySecret":"sk","SecurityToken":"token","Expiration":"%s"}}`,
+		n, f.expiration.UTC().Format(stsTimeLayout))
+}
+
+func newTestOIDCProvider(t *testing.T, sts *fakeSTS, now *time.Time) *OIDCProvider {
+	server := httptest.NewServer(sts)
+	t.Cleanup(server.Close)
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	assert.NoError(t, os.WriteFile(tokenFile, []byte("sa-token\n"), 0600))
+
+	p := NewOIDCProvider(OIDCConfig{
+		RoleARN:     "acs:ram::1:role/terway",
+		ProviderARN: "acs:ram::1:oidc-provider/ack",
+		TokenFile:   tokenFile,
+		STSEndpoint: server.URL,
+	})
+	p.now = func() time.Time { return *now }
+	return p
+}
+
+func TestOIDCProvider_NotConfigured(t *testing.T) {
+	p := NewOIDCProvider(OIDCConfig{})
+	c, err := p.Resolve()
+	assert.NoError(t, err)
+	assert.Nil(t, c)
+}
+
+func TestOIDCProvider_Resolve(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	sts := &fakeSTS{expiration: now.Add(time.Hour)}
+	p := newTestOIDCProvider(t, sts, &now)
+
+	c, err := p.Resolve()
+	assert.NoError(t, err)
+	assert.Equal(t, now.Add(time.Hour), c.Expiration)
+	assert.Equal(t, "ak-1", c.Credential.(*credentials.StsTokenCredential).AccessKeyId)
+	assert.Equal(t, "token", c.Credential.(*credentials.StsTokenCredential).AccessKeyStsToken)
+
+	assert.Equal(t, "AssumeRoleWithOIDC", sts.lastForm["Action"])
+	assert.Equal(t, "acs:ram::1:role/terway", sts.lastForm["RoleArn"])
+	assert.Equal(t, "acs:ram::1:oidc-provider/ack", sts.lastForm["OIDCProviderArn"])
+	assert.Equal(t, "sa-token", sts.lastForm["OIDCToken"])
+	assert.Equal(t, "3600", sts.lastForm["DurationSeconds"])
+
+	// cached
+	now = now.Add(30 * time.Minute)
+	c, err = p.Resolve()
+	assert.NoError(t, err)
+	assert.Equal(t, "ak-1", c.Credential.(*credentials.StsTokenCredential).AccessKeyId)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&sts.calls))
+
+	// refreshed before expiration
+	now = now.Add(25 * time.Minute)
+	sts.expiration = now.Add(time.Hour)
+	c, err = p.Resolve()
+	assert.NoError(t, err)
+	assert.Equal(t, "ak-2", c.Credential.(*credentials.StsTokenCredential).AccessKeyId)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&sts.calls))
+}
+
+func TestOIDCProvider_RefreshFailed(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	sts := &fakeSTS{expiration: now.Add(time.Hour)}
+	p := newTestOIDCProvider(t, sts, &now)
+
+	_, err := p.Resolve()
+	assert.NoError(t, err)
+
+	atomic.StoreInt32(&sts.fail, 1)
+
+	// still valid, fallback to the cached one
+	now = now.Add(55 * time.Minute)
+	c, err := p.Resolve()
+	assert.NoError(t, err)
+	assert.Equal(t, "ak-1", c.Credential.(*credentials.StsTokenCredential).AccessKeyId)
+
+	// expired
+	now = now.Add(10 * time.Minute)
+	_, err = p.Resolve()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "InvalidParameter.OIDCToken")
+}
@@ -0,0 +1,150 @@
+//go:build default_build
+
+package credential
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/AliyunContainerService/terway/pkg/metric"
+	"github.com/AliyunContainerService/terway/pkg/utils"
+)
+
+var (
+	// syncPeriod is the interval to update the expiry and refresh the credential proactively
+	syncPeriod = 30 * time.Second
+	// reloadDelay merge the events of a single file update
+	reloadDelay = time.Second
+)
+
+// Watch reload the credential when the credential file or secret changed,
+// and refresh it periodically instead of waiting for openapi calls. It blocks until stopCh is closed.
+func (c *ClientMgr) Watch(stopCh <-chan struct{}) {
+	if c.credentialPath != "" {
+		go c.watchFile(stopCh)
+	} else if c.secretNamespace != "" && c.secretName != "" && utils.K8sClient != nil {
+		go c.watchSecret(stopCh)
+	}
+
+	wait.Until(c.sync, syncPeriod, stopCh)
+}
+
+// Reload resolve the credential immediately, invalid source is reported and fallback to the next provider
+func (c *ClientMgr) Reload(reason string) {
+	c.Lock()
+	defer c.Unlock()
+
+	mgrLog.Infof("reload credential, %s", reason)
+	c.updateAt = time.Time{}
+	_, err := c.refreshToken()
+	if err != nil {
+		mgrLog.Errorf("error reload credential, %v", err)
+		return
+	}
+	mgrLog.WithFields(map[string]interface{}{"updateAt": c.updateAt, "expireAt": c.expireAt}).Infof("credential update")
+}
+
+func (c *ClientMgr) sync() {
+	c.Lock()
+	defer c.Unlock()
+
+	ok, err := c.refreshToken()
+	if err != nil {
+		mgrLog.Error(err)
+	}
+	if ok {
+		mgrLog.WithFields(map[string]interface{}{"updateAt": c.updateAt, "expireAt": c.expireAt}).Infof("credential update")
+	}
+	for name, expireAt := range c.providerExpire {
+		metric.CredentialExpiry.WithLabelValues(name).Set(time.Until(expireAt).Seconds())
+	}
+}
+
+func (c *ClientMgr) watchFile(stopCh <-chan struct{}) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		mgrLog.Errorf("error create watcher for %s, %v", c.credentialPath, err)
+		return
+	}
+	defer w.Close()
+
+	// file mounted from configmap or secret is updated by swapping the symlink, so watch the dir
+	path := filepath.Clean(c.credentialPath)
+	err = w.Add(filepath.Dir(path))
+	if err != nil {
+		mgrLog.Errorf("error watch %s, %v", c.credentialPath, err)
+		return
+	}
+
+	timer := time.NewTimer(reloadDelay)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case ev, ok := <-w.Events:
+			if !ok {
+				return
+			}
+			name := filepath.Clean(ev.Name)
+			if name != path && filepath.Base(name) != "..data" {
+				continue
+			}
+			timer.Reset(reloadDelay)
+		case err, ok := <-w.Errors:
+			if !ok {
+				return
+			}
+			mgrLog.Errorf("error watch %s, %v", c.credentialPath, err)
+		case <-timer.C:
+			c.Reload("credential file changed")
+		}
+	}
+}
+
+func (c *ClientMgr) watchSecret(stopCh <-chan struct{}) {
+	selector := fields.OneTermEqualSelector("metadata.name", c.secretName).String()
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.FieldSelector = selector
+			return utils.K8sClient.CoreV1().Secrets(c.secretNamespace).List(context.Background(), options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = selector
+			return utils.K8sClient.CoreV1().Secrets(c.secretNamespace).Watch(context.Background(), options)
+		},
+	}
+	informer := cache.NewSharedIndexInformer(lw, &corev1.Secret{}, 0, cache.Indexers{})
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldSecret, ok := oldObj.(*corev1.Secret)
+			if !ok {
+				return
+			}
+			newSecret, ok := newObj.(*corev1.Secret)
+			if !ok {
+				return
+			}
+			if oldSecret.ResourceVersion == newSecret.ResourceVersion {
+				return
+			}
+			c.Reload("credential secret changed")
+		},
+		DeleteFunc: func(obj interface{}) {
+			c.Reload("credential secret deleted")
+		},
+	})
+	informer.Run(stopCh)
+}
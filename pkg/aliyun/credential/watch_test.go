@@ -0,0 +1,158 @@
+//go:build default_build
+
+package credential
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aliyun/alibaba-cloud-sdk-go/sdk/auth/credentials"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeProvider struct {
+	name string
+
+	lock       sync.Mutex
+	err        error
+	expiration time.Time
+	// read error from file if set
+	file string
+}
+
+func (f *fakeProvider) Resolve() (*Credential, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.file != "" {
+		content, err := os.ReadFile(f.file)
+		if err != nil {
+			return nil, err
+		}
+		if string(content) != "valid" {
+			return nil, fmt.Errorf("invalid content %s", content)
+		}
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &Credential{
+		Credential: credentials.NewAccessKeyCredential(f.name, f.name),
+		Expiration: f.expiration,
+	}, nil
+}
+
+func (f *fakeProvider) Name() string {
+	return f.name
+}
+
+func (f *fakeProvider) setErr(err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.err = err
+}
+
+type fakeRecorder struct {
+	lock   sync.Mutex
+	events []string
+}
+
+func (f *fakeRecorder) record(eventType, reason, message string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.events = append(f.events, eventType+"/"+reason)
+}
+
+func (f *fakeRecorder) get() []string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]string{}, f.events...)
+}
+
+func newTestClientMgr(providers ...Interface) *ClientMgr {
+	return &ClientMgr{
+		regionID:       "cn-hangzhou",
+		providers:      providers,
+		providerErr:    make(map[string]string),
+		providerExpire: make(map[string]time.Time),
+	}
+}
+
+func TestClientMgr_Fallback(t *testing.T) {
+	first := &fakeProvider{name: "first", expiration: time.Now().Add(time.Hour)}
+	second := &fakeProvider{name: "second", expiration: time.Now().Add(time.Hour)}
+	recorder := &fakeRecorder{}
+
+	mgr := newTestClientMgr(first, second)
+	mgr.SetEventRecorder(recorder.record)
+
+	mgr.Reload("test")
+	assert.Equal(t, "first", mgr.auth.Name())
+	assert.Equal(t, []string{"Normal/CredentialProviderSwitched"}, recorder.get())
+
+	// first become invalid, fallback to second
+	first.setErr(fmt.Errorf("bad token"))
+	mgr.Reload("test")
+	assert.Equal(t, "second", mgr.auth.Name())
+	assert.NotNil(t, mgr.ECS())
+	assert.Equal(t, []string{"Normal/CredentialProviderSwitched", "Warning/CredentialInvalid", "Normal/CredentialProviderSwitched"}, recorder.get())
+
+	// same error is reported only once
+	mgr.Reload("test")
+	assert.Len(t, recorder.get(), 3)
+
+	// switch back after recovered
+	first.setErr(nil)
+	mgr.Reload("test")
+	assert.Equal(t, "first", mgr.auth.Name())
+	assert.Len(t, recorder.get(), 4)
+}
+
+func TestClientMgr_Expired(t *testing.T) {
+	expired := &fakeProvider{name: "expired", expiration: time.Now().Add(-time.Minute)}
+	mgr := newTestClientMgr(expired)
+
+	_, _, err := mgr.resolve()
+	assert.Error(t, err)
+	assert.Contains(t, mgr.providerErr["expired"], "expired")
+}
+
+func TestClientMgr_WatchFile(t *testing.T) {
+	reloadDelay = 10 * time.Millisecond
+
+	path := filepath.Join(t.TempDir(), "token-config")
+	assert.NoError(t, os.WriteFile(path, []byte("valid"), 0600))
+
+	file := &fakeProvider{name: "file", file: path, expiration: time.Now().Add(time.Hour)}
+	fallback := &fakeProvider{name: "fallback", expiration: time.Now().Add(time.Hour)}
+	recorder := &fakeRecorder{}
+
+	mgr := newTestClientMgr(file, fallback)
+	mgr.credentialPath = path
+	mgr.SetEventRecorder(recorder.record)
+	mgr.Reload("test")
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go mgr.Watch(stopCh)
+	// wait the watcher started
+	time.Sleep(100 * time.Millisecond)
+
+	assert.NoError(t, os.WriteFile(path, []byte("broken"), 0600))
+	assert.Eventually(t, func() bool {
+		mgr.RLock()
+		defer mgr.RUnlock()
+		return mgr.auth.Name() == "fallback"
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Contains(t, recorder.get(), "Warning/CredentialInvalid")
+
+	assert.NoError(t, os.WriteFile(path, []byte("valid"), 0600))
+	assert.Eventually(t, func() bool {
+		mgr.RLock()
+		defer mgr.RUnlock()
+		return mgr.auth.Name() == "file"
+	}, 5*time.Second, 10*time.Millisecond)
+}
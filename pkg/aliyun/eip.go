@@ -14,7 +14,6 @@ import (
 	"github.com/AliyunContainerService/terway/pkg/metric"
 	"github.com/AliyunContainerService/terway/types"
 
-	"github.com/aliyun/alibaba-cloud-sdk-go/sdk/requests"
 	"github.com/aliyun/alibaba-cloud-sdk-go/services/vpc"
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -38,7 +37,7 @@ func (e *Impl) AllocateEipAddress(ctx context.Context, bandwidth int, chargeType
 
 	if eipID == "" {
 		var eips []vpc.EipAddress
-		eips, err = e.describeEipAddresses("", eniID)
+		eips, err = e.DescribeEipAddresses(ctx, "", eniID)
 		if err != nil {
 			return nil, err
 		}
@@ -66,48 +65,29 @@ func (e *Impl) AllocateEipAddress(ctx context.Context, bandwidth int, chargeType
 			}
 		}
 		// 2. create eip and bind to eni
-		var resp *vpc.AllocateEipAddressResponse
-		resp, err = e.AllocateEIPAddress(strconv.Itoa(bandwidth), string(chargeType), isp)
+		eipInfo, err = e.CreateEipAddress(ctx, bandwidth, chargeType, isp, bandwidthPackageID, eipPoolID)
 		if err != nil {
 			return nil, err
 		}
-		eipAddress := net.ParseIP(resp.EipAddress)
-		if eipAddress == nil {
-			return nil, fmt.Errorf("invalid eip address %s, %s", resp.AllocationId, resp.EipAddress)
-		}
-		eipInfo = &types.EIP{
-			ID:             resp.AllocationId,
-			Address:        eipAddress,
-			Delete:         true,
-			AssociateENI:   eniID,
-			AssociateENIIP: eniIP,
-		}
+		eipInfo.AssociateENI = eniID
+		eipInfo.AssociateENIIP = eniIP
 
 		defer func() {
 			if err != nil {
 				err = e.ReleaseEipAddress(ctx, eipInfo.ID, eniID, eniIP)
 				if err != nil {
-					log.Errorf("error rollback eip: %+v, %+v, may cause eip leak...", resp.AllocationId, resp.EipAddress)
+					log.Errorf("error rollback eip: %+v, %+v, may cause eip leak...", eipInfo.ID, eipInfo.Address)
 				}
 			}
 		}()
-
-		// add eip to bandwidth package
-		if bandwidthPackageID != "" {
-			err = e.AddCommonBandwidthPackageIP(eipInfo.ID, bandwidthPackageID)
-			if err != nil {
-				return nil, err
-			}
-		}
-
 	} else {
 		var eips []vpc.EipAddress
-		eips, err = e.describeEipAddresses(eipID, "")
+		eips, err = e.DescribeEipAddresses(ctx, eipID, "")
 		if err != nil {
 			return nil, err
 		}
 		if len(eips) == 0 {
-			return nil, fmt.Errorf("can not found eip %s", eipID)
+			return nil, fmt.Errorf("can not found eip %s, %w", eipID, apiErr.ErrNotFound)
 		}
 		// 1. check this eip is bind as expected
 		eip := eips[0]
@@ -131,7 +111,7 @@ func (e *Impl) AllocateEipAddress(ctx context.Context, bandwidth int, chargeType
 		}
 
 		if allowRob && eip.Status == eipStatusInUse {
-			err = e.UnAssociateEIPAddress(eipID, "", "")
+			err = e.UnAssociateEIPAddress(ctx, eipID, "", "")
 			if err != nil {
 				return nil, fmt.Errorf("error unassocicate previous eip address, %v", err)
 			}
@@ -151,7 +131,7 @@ func (e *Impl) AllocateEipAddress(ctx context.Context, bandwidth int, chargeType
 	logrus.Debugf("get eip info: %+v", eipInfo)
 
 	// bind eip to eni/secondary address
-	err = e.AssociateEIPAddress(eipInfo.ID, eniID, eniIP.String())
+	err = e.AssociateEIPAddress(ctx, eipInfo.ID, eniID, eniIP.String())
 	if err != nil {
 		err = fmt.Errorf("error associate eip:%v to eni:%v.%v, err: %v", eipInfo, eniID, eniIP, err)
 		return nil, err
@@ -167,6 +147,34 @@ func (e *Impl) AllocateEipAddress(ctx context.Context, bandwidth int, chargeType
 	return eipInfo, nil
 }
 
+// CreateEipAddress create an eip without association, the eip is added to the bandwidth package if specified
+func (e *Impl) CreateEipAddress(ctx context.Context, bandwidth int, chargeType types.InternetChargeType, isp, bandwidthPackageID, eipPoolID string) (*types.EIP, error) {
+	resp, err := e.AllocateEIPAddress(ctx, strconv.Itoa(bandwidth), string(chargeType), isp, eipPoolID, "")
+	if err != nil {
+		return nil, err
+	}
+	eipAddress := net.ParseIP(resp.EipAddress)
+	if eipAddress == nil {
+		return nil, fmt.Errorf("invalid eip address %s, %s", resp.AllocationId, resp.EipAddress)
+	}
+
+	// add eip to bandwidth package
+	if bandwidthPackageID != "" {
+		err = e.AddCommonBandwidthPackageIP(ctx, resp.AllocationId, bandwidthPackageID)
+		if err != nil {
+			if err1 := e.ReleaseEIPAddress(ctx, resp.AllocationId); err1 != nil {
+				log.Errorf("error rollback eip: %+v, %+v, may cause eip leak...", resp.AllocationId, resp.EipAddress)
+			}
+			return nil, err
+		}
+	}
+	return &types.EIP{
+		ID:      resp.AllocationId,
+		Address: eipAddress,
+		Delete:  true,
+	}, nil
+}
+
 // UnassociateEipAddress un associate eip
 // 1. if eni is deleted eip auto unassociated
 // 2. if eip is deleted , return code is InvalidAllocationId.NotFound
@@ -177,8 +185,11 @@ func (e *Impl) UnassociateEipAddress(ctx context.Context, eipID, eniID, eniIP st
 		func() (done bool, err error) {
 			// we check eip binding is not changed
 			var eips []vpc.EipAddress
-			eips, innerErr = e.describeEipAddresses(eipID, "")
+			eips, innerErr = e.DescribeEipAddresses(ctx, eipID, "")
 			if innerErr != nil {
+				if !apiErr.IsRetryable(innerErr) {
+					return false, innerErr
+				}
 				return false, nil
 			}
 			if len(eips) == 0 {
@@ -196,8 +207,11 @@ func (e *Impl) UnassociateEipAddress(ctx context.Context, eipID, eniID, eniIP st
 				}
 			}
 
-			innerErr = e.UnAssociateEIPAddress(eipID, eniID, eniIP)
+			innerErr = e.UnAssociateEIPAddress(ctx, eipID, eniID, eniIP)
 			if innerErr != nil {
+				if !apiErr.IsRetryable(innerErr) {
+					return false, innerErr
+				}
 				return false, nil
 			}
 			return true, nil
@@ -237,7 +251,7 @@ func (e *Impl) ReleaseEipAddress(ctx context.Context, eipID, eniID string, eniIP
 	var innerErr error
 	if eip.Status == eipStatusAvailable {
 		if eip.BandwidthPackageId != "" {
-			err = e.RemoveCommonBandwidthPackageIP(eip.AllocationId, eip.BandwidthPackageId)
+			err = e.RemoveCommonBandwidthPackageIP(ctx, eip.AllocationId, eip.BandwidthPackageId)
 			if err != nil {
 				if !apiErr.ErrAssert(apiErr.ErrIPNotInCbwp, err) {
 					return err
@@ -245,8 +259,11 @@ func (e *Impl) ReleaseEipAddress(ctx context.Context, eipID, eniID string, eniIP
 			}
 		}
 		err = wait.ExponentialBackoff(backoff.Backoff(backoff.ENIRelease), func() (done bool, err error) {
-			innerErr = e.ReleaseEIPAddress(eip.AllocationId)
+			innerErr = e.ReleaseEIPAddress(ctx, eip.AllocationId)
 			if innerErr != nil {
+				if !apiErr.IsRetryable(innerErr) {
+					return false, innerErr
+				}
 				return false, nil
 			}
 			return true, nil
@@ -265,8 +282,11 @@ func (e *Impl) WaitForEIP(eipID string, status string, backoff wait.Backoff) (*v
 	err := wait.ExponentialBackoff(backoff,
 		func() (done bool, err error) {
 			var eips []vpc.EipAddress
-			eips, innerErr = e.describeEipAddresses(eipID, "")
+			eips, innerErr = e.DescribeEipAddresses(context.Background(), eipID, "")
 			if innerErr != nil {
+				if !apiErr.IsRetryable(innerErr) {
+					return false, innerErr
+				}
 				return false, nil
 			}
 			if len(eips) == 0 {
@@ -288,27 +308,6 @@ func (e *Impl) WaitForEIP(eipID string, status string, backoff wait.Backoff) (*v
 	return eip, err
 }
 
-func (e *Impl) describeEipAddresses(eipID, eniID string) ([]vpc.EipAddress, error) {
-	req := vpc.CreateDescribeEipAddressesRequest()
-	req.AllocationId = eipID
-	if eniID != "" {
-		req.AssociatedInstanceType = client.EIPInstanceTypeNetworkInterface
-		req.AssociatedInstanceId = eniID
-	}
-	req.PageSize = requests.NewInteger(100)
-
-	l := log.WithFields(map[string]interface{}{client.LogFieldAPI: "DescribeEipAddresses", client.LogFieldEIPID: eipID, client.LogFieldENIID: eniID})
-	start := time.Now()
-	resp, err := e.ClientSet.VPC().DescribeEipAddresses(req)
-	metric.OpenAPILatency.WithLabelValues("DescribeEipAddresses", fmt.Sprint(err != nil)).Observe(metric.MsSince(start))
-	if err != nil {
-		l.WithFields(map[string]interface{}{client.LogFieldRequestID: apiErr.ErrRequestID(err)}).Warn(err)
-		return nil, err
-	}
-	l.WithFields(map[string]interface{}{client.LogFieldRequestID: resp.RequestId}).Debugf("get eip len %d", len(resp.EipAddresses.EipAddress))
-	return resp.EipAddresses.EipAddress, nil
-}
-
 const (
 	eipStatusInUse     = "InUse"
 	eipStatusAvailable = "Available"
@@ -0,0 +1,415 @@
+package aliyun
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/AliyunContainerService/terway/pkg/aliyun/client"
+	apiErr "github.com/AliyunContainerService/terway/pkg/aliyun/client/errors"
+	"github.com/AliyunContainerService/terway/pkg/ip"
+	"github.com/AliyunContainerService/terway/pkg/metric"
+	"github.com/AliyunContainerService/terway/types"
+)
+
+// cacheField is the eni field cached separately, each has its own staleness
+type cacheField string
+
+const (
+	cacheFieldENI  cacheField = "eni"
+	cacheFieldMACs cacheField = "macs"
+	cacheFieldIPv4 cacheField = "ipv4"
+	cacheFieldIPv6 cacheField = "ipv6"
+)
+
+// where the cached value come from
+const (
+	sourceMetadata = "metadata"
+	sourceOpenAPI  = "openAPI"
+)
+
+// result of the cache lookup
+const (
+	cacheHit      = "hit"
+	cacheMiss     = "miss"
+	cacheFallback = "fallback"
+)
+
+// ENICacheConfig is the ttl for each cached field
+type ENICacheConfig struct {
+	// ENITTL for attributes of the eni, they don't change while the eni is attached
+	ENITTL time.Duration
+	// MACsTTL for the attached eni list
+	MACsTTL time.Duration
+	// IPTTL for the ips of the eni
+	IPTTL time.Duration
+	// MutationTTL is how long to wait a local mutation be reflected in metadata
+	MutationTTL time.Duration
+	// OpenAPITimeout for the fallback call
+	OpenAPITimeout time.Duration
+}
+
+// DefaultENICacheConfig is used by the daemon
+var DefaultENICacheConfig = ENICacheConfig{
+	ENITTL:         10 * time.Minute,
+	MACsTTL:        30 * time.Second,
+	IPTTL:          10 * time.Second,
+	MutationTTL:    5 * time.Minute,
+	OpenAPITimeout: 10 * time.Second,
+}
+
+// ENIDescriber describe eni by openapi
+type ENIDescriber interface {
+	DescribeNetworkInterface(ctx context.Context, vpcID string, eniID []string, instanceID string, instanceType string, status string, tags map[string]string) ([]*client.NetworkInterface, error)
+}
+
+// mutation is the ip change made by us, metadata may take a while to reflect it
+type mutation struct {
+	ips   []net.IP
+	added bool
+	at    time.Time
+}
+
+// seen return true if ips reflect the mutation
+func (m *mutation) seen(ips []net.IP) bool {
+	if m.added {
+		return ip.IPsHasAll(ips, m.ips)
+	}
+	return !ip.IPsIntersect(ips, m.ips)
+}
+
+type ipField struct {
+	ips       []net.IP
+	source    string
+	updatedAt time.Time
+	// pending mutations not yet reflected in metadata
+	pending []*mutation
+}
+
+// satisfied return true if the cached ips reflect all pending mutations
+func (f *ipField) satisfied() bool {
+	for _, m := range f.pending {
+		if !m.seen(f.ips) {
+			return false
+		}
+	}
+	return true
+}
+
+// cancel drop the ips from the pending mutations of the given direction, e.g. an ip assigned and then
+// unassigned before metadata reflect it should not be expected to be present any more
+func (f *ipField) cancel(ips []net.IP, added bool) {
+	var pending []*mutation
+	for _, m := range f.pending {
+		if m.added != added {
+			pending = append(pending, m)
+			continue
+		}
+		var left []net.IP
+		for _, v := range m.ips {
+			if !ip.IPsIntersect([]net.IP{v}, ips) {
+				left = append(left, v)
+			}
+		}
+		if len(left) > 0 {
+			m.ips = left
+			pending = append(pending, m)
+		}
+	}
+	f.pending = pending
+}
+
+type eniEntry struct {
+	id           string
+	eni          *types.ENI
+	eniUpdatedAt time.Time
+	ipv4         ipField
+	ipv6         ipField
+}
+
+func (e *eniEntry) field(f cacheField) *ipField {
+	if f == cacheFieldIPv6 {
+		return &e.ipv6
+	}
+	return &e.ipv4
+}
+
+// ENICache is an ENIInfoGetter caching the metadata. Local mutations are tracked, when metadata is unavailable
+// or has not reflected them, the ips are taken from openapi instead of waiting for metadata.
+type ENICache struct {
+	cfg      ENICacheConfig
+	metadata ENIInfoGetter
+	api      ENIDescriber
+
+	lock          sync.Mutex
+	entries       map[string]*eniEntry
+	macs          []string
+	macsUpdatedAt time.Time
+
+	primaryMAC func() string
+	now        func() time.Time
+}
+
+// NewENICache create the cache, api is optional
+func NewENICache(metadata ENIInfoGetter, api ENIDescriber, cfg ENICacheConfig) *ENICache {
+	return &ENICache{
+		cfg:      cfg,
+		metadata: metadata,
+		api:      api,
+		entries:  make(map[string]*eniEntry),
+		primaryMAC: func() string {
+			return GetInstanceMeta().PrimaryMAC
+		},
+		now: time.Now,
+	}
+}
+
+func (c *ENICache) GetENIByMac(mac string) (*types.ENI, error) {
+	c.lock.Lock()
+	e := c.entry(mac)
+	if e.eni != nil && c.fresh(e.eniUpdatedAt, c.cfg.ENITTL) {
+		eni := *e.eni
+		c.lock.Unlock()
+		metric.ENICacheRequests.WithLabelValues(string(cacheFieldENI), cacheHit).Inc()
+		return &eni, nil
+	}
+	c.lock.Unlock()
+	metric.ENICacheRequests.WithLabelValues(string(cacheFieldENI), cacheMiss).Inc()
+
+	// gateway and vSwitch cidr are not in openapi, so the attributes are always from metadata
+	eni, err := c.metadata.GetENIByMac(mac)
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	e = c.entry(mac)
+	if e.id != eni.ID {
+		// mac is reused by another eni
+		*e = eniEntry{id: eni.ID}
+	}
+	cached := *eni
+	e.eni = &cached
+	e.eniUpdatedAt = c.now()
+	c.lock.Unlock()
+	return eni, nil
+}
+
+func (c *ENICache) GetENIPrivateAddressesByMAC(mac string) ([]net.IP, error) {
+	return c.getIPs(mac, cacheFieldIPv4, c.metadata.GetENIPrivateAddressesByMAC)
+}
+
+func (c *ENICache) GetENIPrivateIPv6AddressesByMAC(mac string) ([]net.IP, error) {
+	return c.getIPs(mac, cacheFieldIPv6, c.metadata.GetENIPrivateIPv6AddressesByMAC)
+}
+
+func (c *ENICache) GetENIs(containsMainENI bool) ([]*types.ENI, error) {
+	macs, err := c.GetSecondaryENIMACs()
+	if err != nil {
+		return nil, err
+	}
+	if containsMainENI {
+		macs = append([]string{c.primaryMAC()}, macs...)
+	}
+	var enis []*types.ENI
+	for _, mac := range macs {
+		eni, err := c.GetENIByMac(mac)
+		if err != nil {
+			return nil, fmt.Errorf("error get eni info by mac: %s from metadata, %w", mac, err)
+		}
+		enis = append(enis, eni)
+	}
+	return enis, nil
+}
+
+func (c *ENICache) GetSecondaryENIMACs() ([]string, error) {
+	c.lock.Lock()
+	if c.macs != nil && c.fresh(c.macsUpdatedAt, c.cfg.MACsTTL) {
+		macs := append([]string{}, c.macs...)
+		c.lock.Unlock()
+		metric.ENICacheRequests.WithLabelValues(string(cacheFieldMACs), cacheHit).Inc()
+		return macs, nil
+	}
+	c.lock.Unlock()
+	metric.ENICacheRequests.WithLabelValues(string(cacheFieldMACs), cacheMiss).Inc()
+
+	macs, err := c.metadata.GetSecondaryENIMACs()
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	c.macs = append([]string{}, macs...)
+	c.macsUpdatedAt = c.now()
+	c.lock.Unlock()
+	return macs, nil
+}
+
+// Assigned record the ips assigned to the eni, reads fall back to openapi until metadata reflects them
+func (c *ENICache) Assigned(mac, eniID string, ipv4s, ipv6s []net.IP) {
+	c.mutate(mac, eniID, ipv4s, ipv6s, true)
+}
+
+// Unassigned record the ips removed from the eni, reads fall back to openapi until metadata reflects them
+func (c *ENICache) Unassigned(mac, eniID string, ipv4s, ipv6s []net.IP) {
+	c.mutate(mac, eniID, ipv4s, ipv6s, false)
+}
+
+// InvalidateENIs drop the cached eni list, it should be called after eni attached or detached
+func (c *ENICache) InvalidateENIs() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.macs = nil
+}
+
+// Remove drop everything cached for the eni
+func (c *ENICache) Remove(eniID string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for mac, e := range c.entries {
+		if e.id == eniID {
+			delete(c.entries, mac)
+		}
+	}
+	c.macs = nil
+}
+
+func (c *ENICache) mutate(mac, eniID string, ipv4s, ipv6s []net.IP, added bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	e := c.entry(mac)
+	if eniID != "" {
+		e.id = eniID
+	}
+	for f, ips := range map[cacheField][]net.IP{cacheFieldIPv4: ipv4s, cacheFieldIPv6: ipv6s} {
+		if len(ips) == 0 {
+			continue
+		}
+		fld := e.field(f)
+		fld.cancel(ips, !added)
+		fld.pending = append(fld.pending, &mutation{ips: ips, added: added, at: c.now()})
+		fld.updatedAt = time.Time{}
+	}
+}
+
+func (c *ENICache) getIPs(mac string, f cacheField, fetch func(mac string) ([]net.IP, error)) ([]net.IP, error) {
+	if ips, ok := c.cachedIPs(mac, f); ok {
+		metric.ENICacheRequests.WithLabelValues(string(f), cacheHit).Inc()
+		return ips, nil
+	}
+	metric.ENICacheRequests.WithLabelValues(string(f), cacheMiss).Inc()
+
+	ips, err := fetch(mac)
+	if err == nil && c.storeIPs(mac, f, ips, sourceMetadata) {
+		return ips, nil
+	}
+
+	// metadata is unavailable or lags behind the local mutations, try openapi
+	eniID := c.eniID(mac)
+	if eniID == "" || c.api == nil {
+		return ips, err
+	}
+	remote, describeErr := c.describeIPs(eniID, f)
+	if describeErr != nil {
+		log.Warnf("error describe eni %s for metadata fallback, %s", eniID, describeErr)
+		return ips, err
+	}
+	metric.ENICacheRequests.WithLabelValues(string(f), cacheFallback).Inc()
+	c.storeIPs(mac, f, remote, sourceOpenAPI)
+	return remote, nil
+}
+
+// cachedIPs return the cached ips if they are fresh and reflect the local mutations
+func (c *ENICache) cachedIPs(mac string, f cacheField) ([]net.IP, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	fld := c.entry(mac).field(f)
+	c.expireMutations(fld)
+	if fld.updatedAt.IsZero() || !c.fresh(fld.updatedAt, c.cfg.IPTTL) || !fld.satisfied() {
+		return nil, false
+	}
+	return append([]net.IP{}, fld.ips...), true
+}
+
+// storeIPs update the cache, ips from metadata are dropped if they don't reflect the local mutations
+func (c *ENICache) storeIPs(mac string, f cacheField, ips []net.IP, source string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	fld := c.entry(mac).field(f)
+
+	if source == sourceMetadata {
+		now := c.now()
+		var pending []*mutation
+		for _, m := range fld.pending {
+			if m.seen(ips) {
+				metric.MetadataLag.WithLabelValues(string(f)).Observe(float64(now.Sub(m.at)) / float64(time.Millisecond))
+				continue
+			}
+			pending = append(pending, m)
+		}
+		fld.pending = pending
+		if len(pending) > 0 {
+			return false
+		}
+	}
+
+	fld.ips = append([]net.IP{}, ips...)
+	fld.source = source
+	fld.updatedAt = c.now()
+	return true
+}
+
+func (c *ENICache) describeIPs(eniID string, f cacheField) ([]net.IP, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.cfg.OpenAPITimeout)
+	defer cancel()
+	enis, err := c.api.DescribeNetworkInterface(ctx, "", []string{eniID}, "", "", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(enis) == 0 {
+		return nil, fmt.Errorf("%w, eni %s", apiErr.ErrNotFound, eniID)
+	}
+	var ips []net.IP
+	if f == cacheFieldIPv6 {
+		for _, v6 := range enis[0].IPv6Set {
+			ips = append(ips, net.ParseIP(v6.Ipv6Address))
+		}
+		return ips, nil
+	}
+	for _, v4 := range enis[0].PrivateIPSets {
+		ips = append(ips, net.ParseIP(v4.PrivateIpAddress))
+	}
+	return ips, nil
+}
+
+func (c *ENICache) eniID(mac string) string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.entry(mac).id
+}
+
+// expireMutations drop the mutations metadata never reflected, the eni may be changed by others
+func (c *ENICache) expireMutations(fld *ipField) {
+	var pending []*mutation
+	for _, m := range fld.pending {
+		if c.now().Sub(m.at) < c.cfg.MutationTTL {
+			pending = append(pending, m)
+		}
+	}
+	fld.pending = pending
+}
+
+func (c *ENICache) entry(mac string) *eniEntry {
+	e, ok := c.entries[mac]
+	if !ok {
+		e = &eniEntry{}
+		c.entries[mac] = e
+	}
+	return e
+}
+
+func (c *ENICache) fresh(updatedAt time.Time, ttl time.Duration) bool {
+	return c.now().Sub(updatedAt) < ttl
+}
@@ -0,0 +1,194 @@
+package aliyun
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/AliyunContainerService/terway/pkg/aliyun/client"
+	"github.com/AliyunContainerService/terway/types"
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/ecs"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeMetadata struct {
+	macs  []string
+	enis  map[string]*types.ENI
+	ipv4s map[string][]net.IP
+	err   error
+
+	calls int
+}
+
+func (f *fakeMetadata) GetENIByMac(mac string) (*types.ENI, error) {
+	f.calls++
+	eni, ok := f.enis[mac]
+	if !ok {
+		return nil, fmt.Errorf("not found")
+	}
+	cp := *eni
+	return &cp, nil
+}
+
+func (f *fakeMetadata) GetENIPrivateAddressesByMAC(mac string) ([]net.IP, error) {
+	f.calls++
+	return f.ipv4s[mac], f.err
+}
+
+func (f *fakeMetadata) GetENIPrivateIPv6AddressesByMAC(mac string) ([]net.IP, error) {
+	f.calls++
+	return nil, f.err
+}
+
+func (f *fakeMetadata) GetENIs(containsMainENI bool) ([]*types.ENI, error) {
+	panic("should not be called")
+}
+
+func (f *fakeMetadata) GetSecondaryENIMACs() ([]string, error) {
+	f.calls++
+	return f.macs, f.err
+}
+
+type fakeDescriber struct {
+	ipv4s []string
+	calls int
+}
+
+func (f *fakeDescriber) DescribeNetworkInterface(ctx context.Context, vpcID string, eniID []string, instanceID string, instanceType string, status string, tags map[string]string) ([]*client.NetworkInterface, error) {
+	f.calls++
+	eni := &client.NetworkInterface{NetworkInterfaceID: eniID[0]}
+	for _, v4 := range f.ipv4s {
+		eni.PrivateIPSets = append(eni.PrivateIPSets, ecs.PrivateIpSet{PrivateIpAddress: v4})
+	}
+	return []*client.NetworkInterface{eni}, nil
+}
+
+func newTestCache(m *fakeMetadata, d *fakeDescriber) (*ENICache, *time.Time) {
+	now := time.Now()
+	c := NewENICache(m, d, DefaultENICacheConfig)
+	c.now = func() time.Time { return now }
+	c.primaryMAC = func() string { return "00:00:00:00:00:00" }
+	return c, &now
+}
+
+func ips(s ...string) []net.IP {
+	var r []net.IP
+	for _, i := range s {
+		r = append(r, net.ParseIP(i))
+	}
+	return r
+}
+
+func TestENICache_GetENIs(t *testing.T) {
+	m := &fakeMetadata{
+		macs: []string{"00:00:00:00:00:01"},
+		enis: map[string]*types.ENI{
+			"00:00:00:00:00:00": {ID: "eni-0", MAC: "00:00:00:00:00:00"},
+			"00:00:00:00:00:01": {ID: "eni-1", MAC: "00:00:00:00:00:01"},
+		},
+	}
+	c, now := newTestCache(m, nil)
+
+	enis, err := c.GetENIs(true)
+	assert.NoError(t, err)
+	assert.Len(t, enis, 2)
+	assert.Equal(t, "eni-0", enis[0].ID)
+	assert.Equal(t, 3, m.calls)
+
+	// callers may modify the result
+	enis[1].Trunk = true
+	enis, err = c.GetENIs(false)
+	assert.NoError(t, err)
+	assert.Len(t, enis, 1)
+	assert.False(t, enis[0].Trunk)
+	assert.Equal(t, 3, m.calls)
+
+	// eni list expired
+	*now = now.Add(DefaultENICacheConfig.MACsTTL)
+	_, err = c.GetENIs(false)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, m.calls)
+
+	// eni attached
+	m.macs = append(m.macs, "00:00:00:00:00:02")
+	m.enis["00:00:00:00:00:02"] = &types.ENI{ID: "eni-2", MAC: "00:00:00:00:00:02"}
+	c.InvalidateENIs()
+	enis, err = c.GetENIs(false)
+	assert.NoError(t, err)
+	assert.Len(t, enis, 2)
+}
+
+func TestENICache_IPs(t *testing.T) {
+	mac := "00:00:00:00:00:01"
+	m := &fakeMetadata{
+		enis:  map[string]*types.ENI{mac: {ID: "eni-1", MAC: mac}},
+		ipv4s: map[string][]net.IP{mac: ips("192.168.0.1")},
+	}
+	d := &fakeDescriber{}
+	c, now := newTestCache(m, d)
+
+	_, err := c.GetENIByMac(mac)
+	assert.NoError(t, err)
+
+	got, err := c.GetENIPrivateAddressesByMAC(mac)
+	assert.NoError(t, err)
+	assert.Equal(t, ips("192.168.0.1"), got)
+	_, _ = c.GetENIPrivateAddressesByMAC(mac)
+	assert.Equal(t, 2, m.calls)
+
+	// metadata lags behind the assignment, fall back to openapi
+	c.Assigned(mac, "eni-1", ips("192.168.0.2"), nil)
+	d.ipv4s = []string{"192.168.0.1", "192.168.0.2"}
+	got, err = c.GetENIPrivateAddressesByMAC(mac)
+	assert.NoError(t, err)
+	assert.Equal(t, ips("192.168.0.1", "192.168.0.2"), got)
+	assert.Equal(t, 1, d.calls)
+	assert.Equal(t, sourceOpenAPI, c.entries[mac].ipv4.source)
+
+	// cached result from openapi
+	_, _ = c.GetENIPrivateAddressesByMAC(mac)
+	assert.Equal(t, 1, d.calls)
+
+	// metadata caught up
+	*now = now.Add(DefaultENICacheConfig.IPTTL)
+	m.ipv4s[mac] = ips("192.168.0.1", "192.168.0.2")
+	got, err = c.GetENIPrivateAddressesByMAC(mac)
+	assert.NoError(t, err)
+	assert.Equal(t, ips("192.168.0.1", "192.168.0.2"), got)
+	assert.Equal(t, 1, d.calls)
+	assert.Equal(t, sourceMetadata, c.entries[mac].ipv4.source)
+	assert.Empty(t, c.entries[mac].ipv4.pending)
+
+	// unassigned, metadata unavailable
+	c.Unassigned(mac, "eni-1", ips("192.168.0.2"), nil)
+	m.err = fmt.Errorf("timeout")
+	d.ipv4s = []string{"192.168.0.1"}
+	got, err = c.GetENIPrivateAddressesByMAC(mac)
+	assert.NoError(t, err)
+	assert.Equal(t, ips("192.168.0.1"), got)
+	assert.Equal(t, 2, d.calls)
+
+	// assigned and unassigned before metadata reflect it
+	*now = now.Add(DefaultENICacheConfig.IPTTL)
+	m.err = nil
+	m.ipv4s[mac] = ips("192.168.0.1")
+	_, _ = c.GetENIPrivateAddressesByMAC(mac)
+	assert.Empty(t, c.entries[mac].ipv4.pending)
+	c.Assigned(mac, "eni-1", ips("192.168.0.3"), nil)
+	c.Unassigned(mac, "eni-1", ips("192.168.0.3"), nil)
+	assert.Len(t, c.entries[mac].ipv4.pending, 1)
+	got, err = c.GetENIPrivateAddressesByMAC(mac)
+	assert.NoError(t, err)
+	assert.Equal(t, ips("192.168.0.1"), got)
+	assert.Equal(t, 2, d.calls)
+	assert.Equal(t, sourceMetadata, c.entries[mac].ipv4.source)
+
+	// eni is deleted
+	m.err = fmt.Errorf("timeout")
+	c.Remove("eni-1")
+	_, err = c.GetENIPrivateAddressesByMAC(mac)
+	assert.Error(t, err)
+	assert.Equal(t, 2, d.calls)
+}
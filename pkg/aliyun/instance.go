@@ -9,6 +9,8 @@ import (
 	"github.com/AliyunContainerService/terway/pkg/aliyun/metadata"
 	"github.com/AliyunContainerService/terway/pkg/logger"
 	"github.com/AliyunContainerService/terway/pkg/utils"
+
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/ecs"
 )
 
 var defaultIns *Instance
@@ -80,6 +82,14 @@ func GetInstanceMeta() *Instance {
 	return defaultIns
 }
 
+// SetInstanceMeta set the instance info instead of reading metadata, for nodes not running on aliyun.
+// It takes no effect once GetInstanceMeta is called.
+func SetInstanceMeta(ins *Instance) {
+	once.Do(func() {
+		defaultIns = ins
+	})
+}
+
 // Limits specifies the IPAM relevant instance limits
 type Limits struct {
 	// Adapters specifies the maximum number of interfaces that can be
@@ -133,55 +143,62 @@ func (l *Limits) ExclusiveENIPod() int {
 
 var limits sync.Map
 
+type limitEntry struct {
+	limits *Limits
+	source LimitSource
+}
+
 // GetLimit returns the instance limits of a particular instance type. // https://www.alibabacloud.com/help/doc-detail/25620.htm
-// if instanceType is empty will list all instanceType and warm the cache, no error and Limits will return
+// The limits are looked up from the user overrides, then the openapi. The embedded table is only a fallback when
+// client is nil or the openapi fails, and limits from it are looked up from the openapi again next time.
+// if instanceType is empty will list all instanceType from openapi and warm the cache, no error and Limits will return
 func GetLimit(client client.ECS, instanceType string) (*Limits, error) {
+	if instanceType != "" {
+		if v, ok := limitOverrides.Load(instanceType); ok {
+			storeLimit(instanceType, v.(*Limits), LimitSourceOverride)
+			return v.(*Limits), nil
+		}
+	}
 	v, ok := limits.Load(instanceType)
-	if ok {
-		return v.(*Limits), nil
+	if ok && (client == nil || v.(*limitEntry).source != LimitSourceTable) {
+		return v.(*limitEntry).limits, nil
 	}
+	if client == nil {
+		if l, ok := tableLimits()[instanceType]; ok && instanceType != "" {
+			storeLimit(instanceType, l, LimitSourceTable)
+			return l, nil
+		}
+		return nil, fmt.Errorf("instance type %s is not found in the limit table, %w", instanceType, ErrLimitNotFound)
+	}
+
 	var req []string
 	if instanceType != "" {
 		req = append(req, instanceType)
 	}
 	ins, err := client.DescribeInstanceTypes(context.Background(), req)
 	if err != nil {
+		if l, ok := tableLimits()[instanceType]; ok && instanceType != "" {
+			logger.DefaultLogger.WithField("instance-type", instanceType).Warnf("error describe instance type, use the embedded limit table, %s", err)
+			storeLimit(instanceType, l, LimitSourceTable)
+			return l, nil
+		}
 		return nil, err
 	}
 
 	for _, instanceTypeInfo := range ins {
-		instanceType := instanceTypeInfo.InstanceTypeId
-		adapterLimit := instanceTypeInfo.EniQuantity
-		ipv4PerAdapter := instanceTypeInfo.EniPrivateIpAddressQuantity
-		ipv6PerAdapter := instanceTypeInfo.EniIpv6AddressQuantity
-		memberAdapterLimit := instanceTypeInfo.EniTotalQuantity - instanceTypeInfo.EniQuantity
-		// exclude eth0 eth1
-		maxMemberAdapterLimit := instanceTypeInfo.EniTotalQuantity - 2
-		if !instanceTypeInfo.EniTrunkSupported {
-			memberAdapterLimit = 0
-			maxMemberAdapterLimit = 0
-		}
-		limits.Store(instanceType, &Limits{
-			Adapters:              adapterLimit,
-			TotalAdapters:         instanceTypeInfo.EniTotalQuantity,
-			IPv4PerAdapter:        utils.Minimal(ipv4PerAdapter),
-			IPv6PerAdapter:        utils.Minimal(ipv6PerAdapter),
-			MemberAdapterLimit:    utils.Minimal(memberAdapterLimit),
-			MaxMemberAdapterLimit: utils.Minimal(maxMemberAdapterLimit),
-			InstanceBandwidthRx:   instanceTypeInfo.InstanceBandwidthRx,
-			InstanceBandwidthTx:   instanceTypeInfo.InstanceBandwidthTx,
-		})
+		l := limitsFromInstanceType(instanceTypeInfo)
+		storeLimit(instanceTypeInfo.InstanceTypeId, l, LimitSourceOpenAPI)
 
 		logger.DefaultLogger.WithFields(map[string]interface{}{
-			"instance-type":       instanceType,
-			"adapters":            adapterLimit,
-			"total-adapters":      instanceTypeInfo.EniTotalQuantity,
-			"ipv4":                ipv4PerAdapter,
-			"ipv6":                ipv6PerAdapter,
-			"member-adapters":     memberAdapterLimit,
-			"max-member-adapters": maxMemberAdapterLimit,
-			"bandwidth-rx":        instanceTypeInfo.InstanceBandwidthRx,
-			"bandwidth-tx":        instanceTypeInfo.InstanceBandwidthTx,
+			"instance-type":       instanceTypeInfo.InstanceTypeId,
+			"adapters":            l.Adapters,
+			"total-adapters":      l.TotalAdapters,
+			"ipv4":                instanceTypeInfo.EniPrivateIpAddressQuantity,
+			"ipv6":                instanceTypeInfo.EniIpv6AddressQuantity,
+			"member-adapters":     l.MemberAdapterLimit,
+			"max-member-adapters": l.MaxMemberAdapterLimit,
+			"bandwidth-rx":        l.InstanceBandwidthRx,
+			"bandwidth-tx":        l.InstanceBandwidthTx,
 		}).Infof("instance limit")
 	}
 	if instanceType == "" {
@@ -192,5 +209,38 @@ func GetLimit(client client.ECS, instanceType string) (*Limits, error) {
 		return nil, fmt.Errorf("unexpected error")
 	}
 
-	return v.(*Limits), nil
+	return v.(*limitEntry).limits, nil
+}
+
+func storeLimit(instanceType string, l *Limits, source LimitSource) {
+	limits.Store(instanceType, &limitEntry{limits: l, source: source})
+}
+
+// GetLimitSource return where the cached limits of the instance type come from, empty if not cached
+func GetLimitSource(instanceType string) LimitSource {
+	v, ok := limits.Load(instanceType)
+	if !ok {
+		return ""
+	}
+	return v.(*limitEntry).source
+}
+
+func limitsFromInstanceType(instanceTypeInfo ecs.InstanceType) *Limits {
+	memberAdapterLimit := instanceTypeInfo.EniTotalQuantity - instanceTypeInfo.EniQuantity
+	// exclude eth0 eth1
+	maxMemberAdapterLimit := instanceTypeInfo.EniTotalQuantity - 2
+	if !instanceTypeInfo.EniTrunkSupported {
+		memberAdapterLimit = 0
+		maxMemberAdapterLimit = 0
+	}
+	return &Limits{
+		Adapters:              instanceTypeInfo.EniQuantity,
+		TotalAdapters:         instanceTypeInfo.EniTotalQuantity,
+		IPv4PerAdapter:        utils.Minimal(instanceTypeInfo.EniPrivateIpAddressQuantity),
+		IPv6PerAdapter:        utils.Minimal(instanceTypeInfo.EniIpv6AddressQuantity),
+		MemberAdapterLimit:    utils.Minimal(memberAdapterLimit),
+		MaxMemberAdapterLimit: utils.Minimal(maxMemberAdapterLimit),
+		InstanceBandwidthRx:   instanceTypeInfo.InstanceBandwidthRx,
+		InstanceBandwidthTx:   instanceTypeInfo.InstanceBandwidthTx,
+	}
 }
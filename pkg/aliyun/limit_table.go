@@ -0,0 +1,108 @@
+package aliyun
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/ecs"
+)
+
+// LimitSource is where the instance limits come from
+type LimitSource string
+
+// limit sources, the table is only a fallback of the openapi
+const (
+	LimitSourceOverride LimitSource = "override"
+	LimitSourceTable    LimitSource = "table"
+	LimitSourceOpenAPI  LimitSource = "openapi"
+)
+
+// ErrLimitNotFound the instance type is unknown without openapi
+var ErrLimitNotFound = errors.New("instance limit not found")
+
+// instance_types.json is generated by hack/gen-instance-limits from DescribeInstanceTypes, never edit it by hand.
+// Run `go generate ./pkg/aliyun` with the credential of the region to regenerate it when new instance types are released.
+//
+//go:generate go run -tags default_build ../../hack/gen-instance-limits -output instance_types.json
+//go:embed instance_types.json
+var instanceTypesJSON []byte
+
+// InstanceTypeRecord is the entry of the embedded limit table, a subset of ecs.InstanceType
+type InstanceTypeRecord struct {
+	InstanceTypeID              string `json:"instanceTypeId"`
+	EniQuantity                 int    `json:"eniQuantity"`
+	EniTotalQuantity            int    `json:"eniTotalQuantity"`
+	EniPrivateIPAddressQuantity int    `json:"eniPrivateIpAddressQuantity"`
+	EniIPv6AddressQuantity      int    `json:"eniIpv6AddressQuantity"`
+	EniTrunkSupported           bool   `json:"eniTrunkSupported"`
+	InstanceBandwidthRx         int    `json:"instanceBandwidthRx"`
+	InstanceBandwidthTx         int    `json:"instanceBandwidthTx"`
+}
+
+// NewInstanceTypeRecord convert the openapi result to the table entry
+func NewInstanceTypeRecord(in ecs.InstanceType) InstanceTypeRecord {
+	return InstanceTypeRecord{
+		InstanceTypeID:              in.InstanceTypeId,
+		EniQuantity:                 in.EniQuantity,
+		EniTotalQuantity:            in.EniTotalQuantity,
+		EniPrivateIPAddressQuantity: in.EniPrivateIpAddressQuantity,
+		EniIPv6AddressQuantity:      in.EniIpv6AddressQuantity,
+		EniTrunkSupported:           in.EniTrunkSupported,
+		InstanceBandwidthRx:         in.InstanceBandwidthRx,
+		InstanceBandwidthTx:         in.InstanceBandwidthTx,
+	}
+}
+
+func (r InstanceTypeRecord) instanceType() ecs.InstanceType {
+	return ecs.InstanceType{
+		InstanceTypeId:              r.InstanceTypeID,
+		EniQuantity:                 r.EniQuantity,
+		EniTotalQuantity:            r.EniTotalQuantity,
+		EniPrivateIpAddressQuantity: r.EniPrivateIPAddressQuantity,
+		EniIpv6AddressQuantity:      r.EniIPv6AddressQuantity,
+		EniTrunkSupported:           r.EniTrunkSupported,
+		InstanceBandwidthRx:         r.InstanceBandwidthRx,
+		InstanceBandwidthTx:         r.InstanceBandwidthTx,
+	}
+}
+
+var (
+	tableOnce sync.Once
+	table     map[string]*Limits
+)
+
+func tableLimits() map[string]*Limits {
+	tableOnce.Do(func() {
+		var err error
+		table, err = parseLimitTable(instanceTypesJSON)
+		if err != nil {
+			panic(fmt.Errorf("error parse the embedded instance limit table, %w", err))
+		}
+	})
+	return table
+}
+
+func parseLimitTable(data []byte) (map[string]*Limits, error) {
+	var records []InstanceTypeRecord
+	err := json.Unmarshal(data, &records)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]*Limits, len(records))
+	for _, r := range records {
+		result[r.InstanceTypeID] = limitsFromInstanceType(r.instanceType())
+	}
+	return result, nil
+}
+
+var limitOverrides sync.Map
+
+// SetLimitOverrides set the user defined limits, which take precedence over the table and the openapi
+func SetLimitOverrides(overrides map[string]*Limits) {
+	for instanceType, l := range overrides {
+		limitOverrides.Store(instanceType, l)
+	}
+}
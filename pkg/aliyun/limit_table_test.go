@@ -0,0 +1,120 @@
+package aliyun
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/AliyunContainerService/terway/pkg/aliyun/client"
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/ecs"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeECS struct {
+	client.ECS
+	calls int
+	err   error
+}
+
+func (f *fakeECS) DescribeInstanceTypes(ctx context.Context, types []string) ([]ecs.InstanceType, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return []ecs.InstanceType{
+		{
+			InstanceTypeId:              types[0],
+			EniQuantity:                 2,
+			EniTotalQuantity:            2,
+			EniPrivateIpAddressQuantity: 3,
+		},
+	}, nil
+}
+
+func TestParseLimitTable(t *testing.T) {
+	_, err := parseLimitTable(instanceTypesJSON)
+	assert.NoError(t, err)
+
+	table, err := parseLimitTable([]byte(`[{"instanceTypeId": "ecs.g7.2xlarge", "eniQuantity": 4, "eniTotalQuantity": 10, "eniPrivateIpAddressQuantity": 15, "eniTrunkSupported": true}]`))
+	assert.NoError(t, err)
+	assert.Equal(t, 4, table["ecs.g7.2xlarge"].Adapters)
+	assert.Equal(t, 6, table["ecs.g7.2xlarge"].MemberAdapterLimit)
+
+	_, err = parseLimitTable([]byte("{"))
+	assert.Error(t, err)
+}
+
+func TestEmbeddedLimitTable(t *testing.T) {
+	assert.NotEmpty(t, tableLimits())
+
+	// known instance type resolves without openapi, like maxpods --offline
+	l, err := GetLimit(nil, "ecs.g6.large")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, l.Adapters)
+	assert.Equal(t, 6, l.IPv4PerAdapter)
+	assert.Equal(t, LimitSourceTable, GetLimitSource("ecs.g6.large"))
+	assert.Equal(t, 6, l.IPv4PerAdapter*(l.Adapters-1))
+}
+
+func TestGetLimit(t *testing.T) {
+	tableLimits()
+	table = map[string]*Limits{
+		"ecs.g7.2xlarge": {Adapters: 4, IPv4PerAdapter: 15, MemberAdapterLimit: 6},
+	}
+	defer func() {
+		table, _ = parseLimitTable(instanceTypesJSON)
+	}()
+	api := &fakeECS{}
+
+	// openapi take precedence over the table
+	l, err := GetLimit(api, "ecs.g7.2xlarge")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, l.IPv4PerAdapter)
+	assert.Equal(t, LimitSourceOpenAPI, GetLimitSource("ecs.g7.2xlarge"))
+	assert.Equal(t, 1, api.calls)
+
+	// fall back to the table without openapi
+	l, err = GetLimit(nil, "ecs.g7.2xlarge")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, l.IPv4PerAdapter)
+	limits.Delete("ecs.g7.2xlarge")
+	l, err = GetLimit(nil, "ecs.g7.2xlarge")
+	assert.NoError(t, err)
+	assert.Equal(t, 15, l.IPv4PerAdapter)
+	assert.Equal(t, LimitSourceTable, GetLimitSource("ecs.g7.2xlarge"))
+
+	// limits from the table are looked up from openapi again
+	l, err = GetLimit(api, "ecs.g7.2xlarge")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, l.IPv4PerAdapter)
+	assert.Equal(t, 2, api.calls)
+
+	// fall back to the table when openapi fails
+	limits.Delete("ecs.g7.2xlarge")
+	api.err = fmt.Errorf("timeout")
+	l, err = GetLimit(api, "ecs.g7.2xlarge")
+	assert.NoError(t, err)
+	assert.Equal(t, 15, l.IPv4PerAdapter)
+	_, err = GetLimit(api, "ecs.test.xlarge")
+	assert.Error(t, err)
+	api.err = nil
+
+	// unknown instance type without openapi
+	_, err = GetLimit(nil, "ecs.test.offline")
+	assert.ErrorIs(t, err, ErrLimitNotFound)
+
+	// overrides take precedence over the cache and the table
+	SetLimitOverrides(map[string]*Limits{
+		"ecs.test.large": {Adapters: 5, IPv4PerAdapter: 10},
+		"ecs.g7.large":   {Adapters: 2, IPv4PerAdapter: 2},
+	})
+	calls := api.calls
+	l, err = GetLimit(api, "ecs.test.large")
+	assert.NoError(t, err)
+	assert.Equal(t, 10, l.IPv4PerAdapter)
+	assert.Equal(t, LimitSourceOverride, GetLimitSource("ecs.test.large"))
+	l, err = GetLimit(nil, "ecs.g7.large")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, l.MultiIPPod())
+	assert.Equal(t, calls, api.calls)
+}
@@ -21,6 +21,9 @@ var log = ctrl.Log.WithName("crd")
 const (
 	CRDPodENI        = "podenis.network.alibabacloud.com"
 	CRDPodNetworking = "podnetworkings.network.alibabacloud.com"
+	CRDNodeENIPool   = "nodeenipools.network.alibabacloud.com"
+	CRDPodEIP        = "podeips.network.alibabacloud.com"
+	CRDNodeNetwork   = "nodenetworkstatuses.network.alibabacloud.com"
 
 	crdVersionKey = "crd.network.alibabacloud.com/version"
 )
@@ -31,6 +34,15 @@ var (
 
 	//go:embed network.alibabacloud.com_podnetworkings.yaml
 	crdsPodNetworking []byte
+
+	//go:embed network.alibabacloud.com_nodeenipools.yaml
+	crdsNodeENIPool []byte
+
+	//go:embed network.alibabacloud.com_podeips.yaml
+	crdsPodEIP []byte
+
+	//go:embed network.alibabacloud.com_nodenetworkstatuses.yaml
+	crdsNodeNetwork []byte
 )
 
 func getCRD(name string) apiextensionsv1.CustomResourceDefinition {
@@ -40,6 +52,12 @@ func getCRD(name string) apiextensionsv1.CustomResourceDefinition {
 		crdBytes = crdsPodENI
 	case CRDPodNetworking:
 		crdBytes = crdsPodNetworking
+	case CRDNodeENIPool:
+		crdBytes = crdsNodeENIPool
+	case CRDPodEIP:
+		crdBytes = crdsPodEIP
+	case CRDNodeNetwork:
+		crdBytes = crdsNodeNetwork
 	default:
 		panic(fmt.Sprintf("crd %s name not exist", name))
 	}
@@ -85,7 +103,7 @@ func createOrUpdateCRD(cs apiextensionsclient.Interface, name string) error {
 
 // RegisterCRDs will create all crds if not present
 func RegisterCRDs() error {
-	crds := []string{CRDPodENI, CRDPodNetworking}
+	crds := []string{CRDPodENI, CRDPodNetworking, CRDNodeENIPool, CRDPodEIP, CRDNodeNetwork}
 	for _, crd := range crds {
 		err := createOrUpdateCRD(utils.APIExtensionsClient, crd)
 		if err != nil {
@@ -1,5 +1,10 @@
 package v1beta1
 
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
 func (p *PodENISpec) HaveFixedIP() bool {
 	for _, a := range p.Allocations {
 		if a.AllocationType.Type == IPAllocTypeFixed {
@@ -8,3 +13,14 @@ func (p *PodENISpec) HaveFixedIP() bool {
 	}
 	return false
 }
+
+// SetCondition add or update the condition by type, LastTransitionTime is only changed when status changed
+func (s *PodENIStatus) SetCondition(conditionType string, status metav1.ConditionStatus, reason, message string, generation int64) {
+	meta.SetStatusCondition(&s.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
@@ -51,6 +51,12 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&PodENIList{},
 		&PodNetworking{},
 		&PodNetworkingList{},
+		&NodeENIPool{},
+		&NodeENIPoolList{},
+		&PodEIP{},
+		&PodEIPList{},
+		&NodeNetworkStatus{},
+		&NodeNetworkStatusList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
@@ -25,6 +25,12 @@ import (
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Node",type=string,JSONPath=`.metadata.labels.k8s\.aliyun\.com/node`
+// +kubebuilder:printcolumn:name="ENI",type=string,JSONPath=`.spec.allocations[*].eni.id`
+// +kubebuilder:printcolumn:name="IPv4",type=string,JSONPath=`.spec.allocations[*].ipv4`
+// +kubebuilder:printcolumn:name="IPv6",type=string,JSONPath=`.spec.allocations[*].ipv6`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // PodENI is the Schema for the podenis API
 type PodENI struct {
@@ -63,10 +69,15 @@ type PodENIStatus struct {
 	TrunkENIID string `json:"trunkENIID,omitempty"`
 	// Msg additional info
 	Msg string `json:"msg,omitempty"`
-	// PodLastSeen is the timestamp when pod resource last seen
+	// PodLastSeen is the timestamp when pod resource last seen, ReleaseAfter of the TTL strategy start from it
 	PodLastSeen metav1.Time `json:"podLastSeen,omitempty"`
 	// ENIInfos is the status after eni is attached, it is indexed by eni id
 	ENIInfos map[string]ENIInfo `json:"eniInfos,omitempty"`
+	// Conditions is the latest observation of the podENI lifecycle
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // Allocation for eni record
@@ -107,28 +118,30 @@ type AllocationType struct {
 
 type Phase string
 
-//            pod create
-//                |
-//                |   podENI create
-//                |
-//         ENIPhaseInitial
-//                |
-//                |   bind eni
-//          ENIPhaseBind        <-----   ENIPhaseBinding  <----- sts pod recreate
-//                |                               |
-//                |                               |
-//                |                               |              gc reserved resource for sts pods
-//                |                         ENIPhaseUnbind    ---------------
-//                |                               |                     |    |
-//                |   sts pod delete              |                     |    |
-//                |-----------------------> ENIPhaseDetaching           |    |
-//                |                                                     |    |
-//                |   stateless pod delete                              |    |
-//                |        <---------------------------------------------    |
-//                |                                                          |
-//         ENIPhaseDeleting <------------------------------------------------|
-//                |
-//          del podENI
+// The phase transitions of the podENI:
+//
+//	   pod create
+//	       |
+//	       |   podENI create
+//	       |
+//	ENIPhaseInitial
+//	       |
+//	       |   bind eni
+//	 ENIPhaseBind        <-----   ENIPhaseBinding  <----- sts pod recreate
+//	       |                               |
+//	       |                               |
+//	       |                               |              gc reserved resource for sts pods
+//	       |                         ENIPhaseUnbind    ---------------
+//	       |                               |                     |    |
+//	       |   sts pod delete              |                     |    |
+//	       |-----------------------> ENIPhaseDetaching           |    |
+//	       |                                                     |    |
+//	       |   stateless pod delete                              |    |
+//	       |        <---------------------------------------------    |
+//	       |                                                          |
+//	ENIPhaseDeleting <------------------------------------------------|
+//	       |
+//	 del podENI
 const (
 	// ENIPhaseInitial the status when pod first created
 	ENIPhaseInitial = ""
@@ -144,6 +157,30 @@ const (
 	ENIPhaseDeleting = "Deleting"
 )
 
+// ConditionType for PodENI
+const (
+	// PodENIConditionCreated the podENI cr and the eni in spec is created
+	PodENIConditionCreated = "Created"
+	// PodENIConditionAttached all eni in spec is attached to the ECS
+	PodENIConditionAttached = "Attached"
+	// PodENIConditionDatapathReady the node agent has consumed the allocation and handed it to cni
+	PodENIConditionDatapathReady = "DatapathReady"
+	// PodENIConditionDeleting the podENI is going to be released
+	PodENIConditionDeleting = "Deleting"
+)
+
+// Reasons for PodENI conditions
+const (
+	PodENIReasonCreated        = "Created"
+	PodENIReasonAttached       = "Attached"
+	PodENIReasonAttachFailed   = "AttachFailed"
+	PodENIReasonDetached       = "Detached"
+	PodENIReasonAllocated      = "Allocated"
+	PodENIReasonPodDeleted     = "PodDeleted"
+	PodENIReasonPodNotRequired = "PodNotRequired"
+	PodENIReasonReleased       = "Released"
+)
+
 // ENIBindStatus is the current status for the eni
 type ENIBindStatus string
 
@@ -161,6 +198,11 @@ type ENIInfo struct {
 	Type   ENIType       `json:"type,omitempty"`
 	Vid    int           `json:"vid,omitempty"`    // vlan id for trunk
 	Status ENIBindStatus `json:"status,omitempty"` // the status for operate the eni
+
+	// AttachError is the last error when attach this eni, cleared once attached
+	AttachError string `json:"attachError,omitempty"`
+	// AttachErrorTime is the time AttachError is observed
+	AttachErrorTime *metav1.Time `json:"attachErrorTime,omitempty"`
 }
 
 // ENIType for this eni, only Secondary and Member is supported
@@ -265,3 +307,315 @@ type Selector struct {
 	PodSelector       *metav1.LabelSelector `json:"podSelector,omitempty"`
 	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
 }
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:openapi-gen=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Idle",type=integer,JSONPath=`.status.idle`
+// +kubebuilder:printcolumn:name="InUse",type=integer,JSONPath=`.status.inUse`
+// +kubebuilder:printcolumn:name="Deleting",type=integer,JSONPath=`.status.deleting`
+// +kubebuilder:printcolumn:name="Target",type=integer,JSONPath=`.status.targetIdle`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// NodeENIPool is the Schema for the eni pool managed by controlplane, the name is same as the node
+type NodeENIPool struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   NodeENIPoolSpec   `json:"spec,omitempty"`
+	Status NodeENIPoolStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+
+// NodeENIPoolList contains a list of NodeENIPool
+type NodeENIPoolList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NodeENIPool `json:"items"`
+}
+
+// NodeENIPoolSpec defines the desired pool size for the node
+type NodeENIPoolSpec struct {
+	// MinIdle override min_pool_size in eni-config for this node
+	// +kubebuilder:validation:Minimum=0
+	MinIdle *int `json:"minIdle,omitempty"`
+	// MaxIdle override max_pool_size in eni-config for this node
+	// +kubebuilder:validation:Minimum=0
+	MaxIdle *int `json:"maxIdle,omitempty"`
+	// WarmUp decide how many idle eni should be prepared
+	WarmUp WarmUp `json:"warmUp,omitempty"`
+}
+
+// WarmUp is the policy for pool warm-up
+type WarmUp struct {
+	// +kubebuilder:default:=Fixed
+	Policy WarmUpPolicy `json:"policy,omitempty"`
+	// Window is the duration pod churn is observed for Churn policy, go type 10m0s
+	Window string `json:"window,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=Fixed;Churn
+
+// WarmUpPolicy is the type for pool warm-up
+type WarmUpPolicy string
+
+// WarmUpPolicy
+const (
+	// WarmUpPolicyFixed keep MinIdle eni in the pool
+	WarmUpPolicyFixed = "Fixed"
+	// WarmUpPolicyChurn keep the peak allocation in the window, bounded by MinIdle and MaxIdle
+	WarmUpPolicyChurn = "Churn"
+)
+
+// NodeENIPoolStatus defines the observed state of the pool
+type NodeENIPoolStatus struct {
+	// InstanceID for ecs
+	InstanceID string `json:"instanceID,omitempty"`
+	// MaxENI is the max eni the pool can allocate
+	MaxENI int `json:"maxENI,omitempty"`
+	// MinIdle is the effective min idle
+	MinIdle int `json:"minIdle,omitempty"`
+	// MaxIdle is the effective max idle
+	MaxIdle int `json:"maxIdle,omitempty"`
+	// TargetIdle is the idle count the pool is going to keep
+	TargetIdle int `json:"targetIdle,omitempty"`
+
+	Idle     int `json:"idle,omitempty"`
+	InUse    int `json:"inUse,omitempty"`
+	Deleting int `json:"deleting,omitempty"`
+
+	// ENIs is the eni managed by pool
+	ENIs []PoolENI `json:"enis,omitempty"`
+	// UpdateAt the time status updated
+	UpdateAt metav1.Time `json:"updateAt,omitempty"`
+}
+
+// PoolENI is the eni in pool
+type PoolENI struct {
+	ID        string `json:"id"`
+	VSwitchID string `json:"vSwitchID,omitempty"`
+	Type      string `json:"type,omitempty"`
+	// Status is one of idle, inUse, deleting, unManaged
+	Status string `json:"status,omitempty"`
+	// FromPool is true when the eni is created by pool
+	FromPool bool `json:"fromPool,omitempty"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:openapi-gen=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Mode",type=string,JSONPath=`.status.mode`
+// +kubebuilder:printcolumn:name="ENIs",type=integer,JSONPath=`.status.pool.enis`
+// +kubebuilder:printcolumn:name="InUse",type=integer,JSONPath=`.status.pool.inUse`
+// +kubebuilder:printcolumn:name="Idle",type=integer,JSONPath=`.status.pool.idle`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// NodeNetworkStatus is the summary of the network resources on the node reported by terway daemon,
+// the name is same as the node
+type NodeNetworkStatus struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Status NodeNetworkStatusStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+
+// NodeNetworkStatusList contains a list of NodeNetworkStatus
+type NodeNetworkStatusList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NodeNetworkStatus `json:"items"`
+}
+
+// NodeNetworkStatusStatus defines the observed network resources on the node
+type NodeNetworkStatusStatus struct {
+	// Mode is the daemon mode, one of VPC, ENIMultiIP, ENIOnly
+	Mode string `json:"mode,omitempty"`
+	// InstanceID for ecs
+	InstanceID string `json:"instanceID,omitempty"`
+	// InstanceType for ecs
+	InstanceType string `json:"instanceType,omitempty"`
+	// TrunkENI is the id of trunk eni, empty if trunk is not enabled
+	TrunkENI string `json:"trunkENI,omitempty"`
+	// Limits is the eni limits of the instance type
+	Limits NodeNetworkLimits `json:"limits,omitempty"`
+	// Pool is the count of resources in pool
+	Pool NodeNetworkPool `json:"pool,omitempty"`
+	// ENIs is the secondary enis on the node
+	ENIs []NodeNetworkENI `json:"enis,omitempty"`
+	// UpdateAt the time status updated
+	UpdateAt metav1.Time `json:"updateAt,omitempty"`
+}
+
+// NodeNetworkLimits is the eni limits of the instance type
+type NodeNetworkLimits struct {
+	// Adapters is the max eni can be attached, include the primary eni
+	Adapters int `json:"adapters,omitempty"`
+	// IPv4PerAdapter is the max ipv4 per eni
+	IPv4PerAdapter int `json:"ipv4PerAdapter,omitempty"`
+	// IPv6PerAdapter is the max ipv6 per eni
+	IPv6PerAdapter int `json:"ipv6PerAdapter,omitempty"`
+	// MemberAdapterLimit is the max member eni for trunk
+	MemberAdapterLimit int `json:"memberAdapterLimit,omitempty"`
+}
+
+// NodeNetworkPool is the count of resources in pool, the resource is ip for ENIMultiIP and eni for ENIOnly
+type NodeNetworkPool struct {
+	ENIs    int `json:"enis,omitempty"`
+	Idle    int `json:"idle,omitempty"`
+	InUse   int `json:"inUse,omitempty"`
+	Invalid int `json:"invalid,omitempty"`
+}
+
+// NodeNetworkENI is a secondary eni on the node
+type NodeNetworkENI struct {
+	ID        string `json:"id,omitempty"`
+	MAC       string `json:"mac"`
+	PrimaryIP string `json:"primaryIP,omitempty"`
+	// Status is the status in pool of exclusive eni, one of idle, inUse, invalid.
+	// Empty if the eni is not managed by the pool.
+	Status string `json:"status,omitempty"`
+	// Pod is the pod holding the exclusive eni, in namespace/name
+	Pod string `json:"pod,omitempty"`
+	// IPs is the secondary ips on the eni for ENIMultiIP
+	IPs []NodeNetworkIP `json:"ips,omitempty"`
+}
+
+// NodeNetworkIP is a secondary ip in pool
+type NodeNetworkIP struct {
+	IPv4 string `json:"ipv4,omitempty"`
+	IPv6 string `json:"ipv6,omitempty"`
+	// Status is one of idle, inUse, invalid
+	Status string `json:"status"`
+	// Pod is the pod holding the ip, in namespace/name
+	Pod string `json:"pod,omitempty"`
+}
+
+// NodeNetworkStatus status of resources
+const (
+	NodeNetworkResourceIdle    = "idle"
+	NodeNetworkResourceInUse   = "inUse"
+	NodeNetworkResourceInvalid = "invalid"
+)
+
+// +genclient
+// +k8s:openapi-gen=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="EIP",type=string,JSONPath=`.status.eipAddress`
+// +kubebuilder:printcolumn:name="AllocationID",type=string,JSONPath=`.status.allocationID`
+// +kubebuilder:printcolumn:name="PrivateIP",type=string,JSONPath=`.status.privateIPAddress`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// PodEIP is the Schema for the eip of pod managed by controlplane, the name is same as the pod
+type PodEIP struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PodEIPSpec   `json:"spec,omitempty"`
+	Status PodEIPStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+
+// PodEIPList contains a list of PodEIP
+type PodEIPList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PodEIP `json:"items"`
+}
+
+// PodEIPSpec defines the desired eip of the pod
+type PodEIPSpec struct {
+	// AllocationID use the existing eip, a new eip is created if it is empty
+	AllocationID string `json:"allocationID,omitempty"`
+	// PublicIPAddressPoolID create the eip from the public ip address pool
+	PublicIPAddressPoolID string `json:"publicIPAddressPoolID,omitempty"`
+	// BandwidthPackageID add the created eip to the common bandwidth package
+	BandwidthPackageID string `json:"bandwidthPackageID,omitempty"`
+	// Bandwidth of the created eip in Mbps
+	// +kubebuilder:default:="5"
+	Bandwidth string `json:"bandwidth,omitempty"`
+	// InternetChargeType of the created eip, PayByTraffic or PayByBandwidth
+	InternetChargeType string `json:"internetChargeType,omitempty"`
+	// ISP of the created eip
+	ISP string `json:"isp,omitempty"`
+	// AllowRob unassociate the eip from the previous instance if it is in use
+	AllowRob bool `json:"allowRob,omitempty"`
+	// ReleaseStrategy decide what to do with the eip after the pod is deleted
+	// +kubebuilder:default:=Follow
+	ReleaseStrategy EIPReleaseStrategy `json:"releaseStrategy,omitempty"`
+	// ReleaseAfter is the duration the eip is kept after the pod is deleted for the TTL strategy, e.g. 1h
+	ReleaseAfter string `json:"releaseAfter,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=Follow;Never;TTL
+
+// EIPReleaseStrategy is the type for eip release strategy
+type EIPReleaseStrategy string
+
+// EIPReleaseStrategy
+const (
+	// EIPReleaseStrategyFollow release the created eip with the pod, the eip given by AllocationID is only unassociated
+	EIPReleaseStrategyFollow = "Follow"
+	// EIPReleaseStrategyNever only unassociate the eip, the PodEIP and the eip are kept for the next pod with the same name
+	EIPReleaseStrategyNever = "Never"
+	// EIPReleaseStrategyTTL keep the PodEIP and the eip for the next pod with the same name, they are released as Follow
+	// if no such pod is created within ReleaseAfter
+	EIPReleaseStrategyTTL = "TTL"
+)
+
+// PodEIPPhase is the status of the eip
+type PodEIPPhase string
+
+// PodEIPPhase
+const (
+	// PodEIPPhaseAllocated the eip is allocated and waiting for the pod ip
+	PodEIPPhaseAllocated = "Allocated"
+	// PodEIPPhaseAssociated the eip is associated to the pod ip
+	PodEIPPhaseAssociated = "Associated"
+	// PodEIPPhaseUnassociated the pod is deleted, the eip is kept
+	PodEIPPhaseUnassociated = "Unassociated"
+	// PodEIPPhaseFailed the last reconcile is failed, see Message
+	PodEIPPhaseFailed = "Failed"
+)
+
+// PodEIPStatus defines the observed state of PodEIP
+type PodEIPStatus struct {
+	Phase PodEIPPhase `json:"phase,omitempty"`
+	// AllocationID is the eip id
+	AllocationID string `json:"allocationID,omitempty"`
+	// EIPAddress is the public address
+	EIPAddress string `json:"eipAddress,omitempty"`
+	// ISP of the eip
+	ISP string `json:"isp,omitempty"`
+	// Created is true when the eip is created by controlplane
+	Created bool `json:"created,omitempty"`
+	// BandwidthPackageID is the common bandwidth package the created eip is added to
+	BandwidthPackageID string `json:"bandwidthPackageID,omitempty"`
+	// ENIID is the eni the eip associated to
+	ENIID string `json:"eniID,omitempty"`
+	// PrivateIPAddress is the pod ip the eip associated to
+	PrivateIPAddress string `json:"privateIPAddress,omitempty"`
+	// PodUID is the uid of the pod the eip associated to
+	PodUID string `json:"podUID,omitempty"`
+	// PodLastSeen is the timestamp when pod resource last seen, ReleaseAfter of the TTL strategy start from it
+	PodLastSeen metav1.Time `json:"podLastSeen,omitempty"`
+	// Message for the last failure
+	Message string `json:"message,omitempty"`
+}
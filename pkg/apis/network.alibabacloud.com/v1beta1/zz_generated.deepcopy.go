@@ -95,6 +95,10 @@ func (in *ENI) DeepCopy() *ENI {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ENIInfo) DeepCopyInto(out *ENIInfo) {
 	*out = *in
+	if in.AttachErrorTime != nil {
+		in, out := &in.AttachErrorTime, &out.AttachErrorTime
+		*out = (*in).DeepCopy()
+	}
 	return
 }
 
@@ -108,6 +112,365 @@ func (in *ENIInfo) DeepCopy() *ENIInfo {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeENIPool) DeepCopyInto(out *NodeENIPool) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeENIPool.
+func (in *NodeENIPool) DeepCopy() *NodeENIPool {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeENIPool)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NodeENIPool) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeENIPoolList) DeepCopyInto(out *NodeENIPoolList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]NodeENIPool, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeENIPoolList.
+func (in *NodeENIPoolList) DeepCopy() *NodeENIPoolList {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeENIPoolList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NodeENIPoolList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeENIPoolSpec) DeepCopyInto(out *NodeENIPoolSpec) {
+	*out = *in
+	if in.MinIdle != nil {
+		in, out := &in.MinIdle, &out.MinIdle
+		*out = new(int)
+		**out = **in
+	}
+	if in.MaxIdle != nil {
+		in, out := &in.MaxIdle, &out.MaxIdle
+		*out = new(int)
+		**out = **in
+	}
+	out.WarmUp = in.WarmUp
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeENIPoolSpec.
+func (in *NodeENIPoolSpec) DeepCopy() *NodeENIPoolSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeENIPoolSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeENIPoolStatus) DeepCopyInto(out *NodeENIPoolStatus) {
+	*out = *in
+	if in.ENIs != nil {
+		in, out := &in.ENIs, &out.ENIs
+		*out = make([]PoolENI, len(*in))
+		copy(*out, *in)
+	}
+	in.UpdateAt.DeepCopyInto(&out.UpdateAt)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeENIPoolStatus.
+func (in *NodeENIPoolStatus) DeepCopy() *NodeENIPoolStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeENIPoolStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeNetworkENI) DeepCopyInto(out *NodeNetworkENI) {
+	*out = *in
+	if in.IPs != nil {
+		in, out := &in.IPs, &out.IPs
+		*out = make([]NodeNetworkIP, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeNetworkENI.
+func (in *NodeNetworkENI) DeepCopy() *NodeNetworkENI {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeNetworkENI)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeNetworkIP) DeepCopyInto(out *NodeNetworkIP) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeNetworkIP.
+func (in *NodeNetworkIP) DeepCopy() *NodeNetworkIP {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeNetworkIP)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeNetworkLimits) DeepCopyInto(out *NodeNetworkLimits) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeNetworkLimits.
+func (in *NodeNetworkLimits) DeepCopy() *NodeNetworkLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeNetworkLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeNetworkPool) DeepCopyInto(out *NodeNetworkPool) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeNetworkPool.
+func (in *NodeNetworkPool) DeepCopy() *NodeNetworkPool {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeNetworkPool)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeNetworkStatus) DeepCopyInto(out *NodeNetworkStatus) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeNetworkStatus.
+func (in *NodeNetworkStatus) DeepCopy() *NodeNetworkStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeNetworkStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NodeNetworkStatus) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeNetworkStatusList) DeepCopyInto(out *NodeNetworkStatusList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]NodeNetworkStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeNetworkStatusList.
+func (in *NodeNetworkStatusList) DeepCopy() *NodeNetworkStatusList {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeNetworkStatusList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NodeNetworkStatusList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeNetworkStatusStatus) DeepCopyInto(out *NodeNetworkStatusStatus) {
+	*out = *in
+	out.Limits = in.Limits
+	out.Pool = in.Pool
+	if in.ENIs != nil {
+		in, out := &in.ENIs, &out.ENIs
+		*out = make([]NodeNetworkENI, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.UpdateAt.DeepCopyInto(&out.UpdateAt)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeNetworkStatusStatus.
+func (in *NodeNetworkStatusStatus) DeepCopy() *NodeNetworkStatusStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeNetworkStatusStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodEIP) DeepCopyInto(out *PodEIP) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodEIP.
+func (in *PodEIP) DeepCopy() *PodEIP {
+	if in == nil {
+		return nil
+	}
+	out := new(PodEIP)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PodEIP) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodEIPList) DeepCopyInto(out *PodEIPList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PodEIP, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodEIPList.
+func (in *PodEIPList) DeepCopy() *PodEIPList {
+	if in == nil {
+		return nil
+	}
+	out := new(PodEIPList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PodEIPList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodEIPSpec) DeepCopyInto(out *PodEIPSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodEIPSpec.
+func (in *PodEIPSpec) DeepCopy() *PodEIPSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PodEIPSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodEIPStatus) DeepCopyInto(out *PodEIPStatus) {
+	*out = *in
+	in.PodLastSeen.DeepCopyInto(&out.PodLastSeen)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodEIPStatus.
+func (in *PodEIPStatus) DeepCopy() *PodEIPStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PodEIPStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PodENI) DeepCopyInto(out *PodENI) {
 	*out = *in
@@ -200,7 +563,14 @@ func (in *PodENIStatus) DeepCopyInto(out *PodENIStatus) {
 		in, out := &in.ENIInfos, &out.ENIInfos
 		*out = make(map[string]ENIInfo, len(*in))
 		for key, val := range *in {
-			(*out)[key] = val
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 	return
@@ -327,6 +697,22 @@ func (in *PodNetworkingStatus) DeepCopy() *PodNetworkingStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PoolENI) DeepCopyInto(out *PoolENI) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolENI.
+func (in *PoolENI) DeepCopy() *PoolENI {
+	if in == nil {
+		return nil
+	}
+	out := new(PoolENI)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Route) DeepCopyInto(out *Route) {
 	*out = *in
@@ -384,3 +770,19 @@ func (in *VSwitch) DeepCopy() *VSwitch {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WarmUp) DeepCopyInto(out *WarmUp) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WarmUp.
+func (in *WarmUp) DeepCopy() *WarmUp {
+	if in == nil {
+		return nil
+	}
+	out := new(WarmUp)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2022 Terway Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package audit records every mutating cloud api call, so leaks and incidents can be reconstructed
+package audit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/AliyunContainerService/terway/pkg/logger"
+)
+
+var log = logger.DefaultLogger.WithField("subSys", "audit")
+
+// result of the call
+const (
+	ResultSuccess = "success"
+	ResultFailed  = "failed"
+)
+
+// Record is one mutating cloud api call
+type Record struct {
+	Time time.Time `json:"time"`
+	// Actor is who made the call, node/<name> or controlplane/<name>
+	Actor     string                 `json:"actor"`
+	API       string                 `json:"api"`
+	Namespace string                 `json:"namespace,omitempty"`
+	Pod       string                 `json:"pod,omitempty"`
+	PodENI    string                 `json:"podENI,omitempty"`
+	RequestID string                 `json:"requestID,omitempty"`
+	Params    map[string]interface{} `json:"params,omitempty"`
+	Result    string                 `json:"result"`
+	Error     string                 `json:"error,omitempty"`
+	// Latency in ms
+	Latency float64 `json:"latency"`
+}
+
+// Sink is where the records go
+type Sink interface {
+	Write(r *Record)
+}
+
+// SinkFunc adapt a func to Sink
+type SinkFunc func(r *Record)
+
+func (f SinkFunc) Write(r *Record) {
+	f(r)
+}
+
+type multiSink []Sink
+
+func (m multiSink) Write(r *Record) {
+	for _, s := range m {
+		s.Write(r)
+	}
+}
+
+// Multi write records to all the sinks
+func Multi(sinks ...Sink) Sink {
+	return multiSink(sinks)
+}
+
+var (
+	lock        sync.RWMutex
+	actor       string
+	defaultSink Sink
+)
+
+// SetDefault set the actor and sink for Log, nil sink disable the audit
+func SetDefault(a string, s Sink) {
+	lock.Lock()
+	defer lock.Unlock()
+	actor = a
+	defaultSink = s
+}
+
+// Log write the record of the call to the default sink, the pod info is taken from ctx
+func Log(ctx context.Context, api string, params map[string]interface{}, requestID string, err error, start time.Time) {
+	lock.RLock()
+	a, s := actor, defaultSink
+	lock.RUnlock()
+	if s == nil {
+		return
+	}
+
+	r := &Record{
+		Time:      time.Now(),
+		Actor:     a,
+		API:       api,
+		RequestID: requestID,
+		Params:    params,
+		Result:    ResultSuccess,
+		Latency:   float64(time.Since(start)) / float64(time.Millisecond),
+	}
+	if err != nil {
+		r.Result = ResultFailed
+		r.Error = err.Error()
+	}
+	if info, ok := fromContext(ctx); ok {
+		r.Namespace = info.namespace
+		r.Pod = info.pod
+		r.PodENI = info.podENI
+	}
+	s.Write(r)
+}
+
+type podInfo struct {
+	namespace string
+	pod       string
+	podENI    string
+}
+
+type podInfoKey struct{}
+
+// WithPod attach the pod to ctx, calls made with the ctx are recorded with the pod
+func WithPod(ctx context.Context, namespace, name string) context.Context {
+	info, _ := fromContext(ctx)
+	info.namespace = namespace
+	info.pod = name
+	return context.WithValue(ctx, podInfoKey{}, info)
+}
+
+// WithPodENI attach the podENI to ctx, podENI has the same namespace and name as the pod
+func WithPodENI(ctx context.Context, namespace, name string) context.Context {
+	info, _ := fromContext(ctx)
+	info.namespace = namespace
+	info.pod = name
+	info.podENI = name
+	return context.WithValue(ctx, podInfoKey{}, info)
+}
+
+func fromContext(ctx context.Context) (podInfo, bool) {
+	if ctx == nil {
+		return podInfo{}, false
+	}
+	info, ok := ctx.Value(podInfoKey{}).(podInfo)
+	return info, ok
+}
@@ -0,0 +1,97 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLog(t *testing.T) {
+	var records []*Record
+	SetDefault("node/foo", SinkFunc(func(r *Record) {
+		records = append(records, r)
+	}))
+	defer SetDefault("", nil)
+
+	ctx := WithPod(context.Background(), "default", "nginx")
+	Log(ctx, "AssignPrivateIpAddresses", map[string]interface{}{"eni": "eni-1"}, "req-1", nil, time.Now())
+
+	ctx = WithPodENI(context.Background(), "default", "sts-0")
+	Log(ctx, "CreateNetworkInterface", nil, "req-2", fmt.Errorf("quota exceeded"), time.Now())
+
+	Log(context.Background(), "DeleteNetworkInterface", nil, "req-3", nil, time.Now())
+
+	assert.Len(t, records, 3)
+
+	assert.Equal(t, "node/foo", records[0].Actor)
+	assert.Equal(t, "AssignPrivateIpAddresses", records[0].API)
+	assert.Equal(t, "default", records[0].Namespace)
+	assert.Equal(t, "nginx", records[0].Pod)
+	assert.Equal(t, "", records[0].PodENI)
+	assert.Equal(t, "req-1", records[0].RequestID)
+	assert.Equal(t, ResultSuccess, records[0].Result)
+
+	assert.Equal(t, "sts-0", records[1].Pod)
+	assert.Equal(t, "sts-0", records[1].PodENI)
+	assert.Equal(t, ResultFailed, records[1].Result)
+	assert.Equal(t, "quota exceeded", records[1].Error)
+
+	assert.Equal(t, "", records[2].Pod)
+}
+
+func TestLog_Disabled(t *testing.T) {
+	SetDefault("", nil)
+	// should not panic
+	Log(context.Background(), "DeleteNetworkInterface", nil, "", nil, time.Now())
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "audit.log")
+	f, err := NewFileSink(path, 1, 2)
+	assert.NoError(t, err)
+	defer f.Close()
+	// rotate every few records
+	f.maxSize = 512
+
+	for i := 0; i < 20; i++ {
+		f.Write(&Record{API: "AssignPrivateIpAddresses", RequestID: fmt.Sprint(i), Result: ResultSuccess})
+	}
+
+	_, err = os.Stat(path + ".1")
+	assert.NoError(t, err)
+	_, err = os.Stat(path + ".2")
+	assert.NoError(t, err)
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err))
+
+	// the last record is in the current file, every line is a valid record
+	file, err := os.Open(path)
+	assert.NoError(t, err)
+	defer file.Close()
+	var last Record
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &last))
+		info, err := os.Stat(path)
+		assert.NoError(t, err)
+		assert.LessOrEqual(t, info.Size(), int64(512))
+	}
+	assert.Equal(t, "19", last.RequestID)
+}
+
+func TestEventSink(t *testing.T) {
+	var got []string
+	s := NewEventSink(func(r *Record, eventType, reason, message string) {
+		got = append(got, eventType+"/"+reason)
+	})
+	s.Write(&Record{API: "AttachNetworkInterface", Result: ResultSuccess})
+	s.Write(&Record{API: "AttachNetworkInterface", Result: ResultFailed, Error: "timeout"})
+	assert.Equal(t, []string{"Normal/CloudAPIAudit", "Warning/CloudAPIAudit"}, got)
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2022 Terway Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileSink write records as json lines, the file is rotated by size
+type FileSink struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	lock sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewFileSink create the sink, maxSizeMB is the size to rotate the file, at most maxBackups rotated files are kept
+func NewFileSink(path string, maxSizeMB, maxBackups int) (*FileSink, error) {
+	if maxSizeMB <= 0 {
+		return nil, fmt.Errorf("invalid audit log max size %d", maxSizeMB)
+	}
+	if maxBackups < 0 {
+		return nil, fmt.Errorf("invalid audit log max backups %d", maxBackups)
+	}
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return nil, fmt.Errorf("error create dir for audit log, %w", err)
+	}
+	f := &FileSink{
+		path:       path,
+		maxSize:    int64(maxSizeMB) * 1024 * 1024,
+		maxBackups: maxBackups,
+	}
+	err = f.open()
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *FileSink) Write(r *Record) {
+	line, err := json.Marshal(r)
+	if err != nil {
+		log.Errorf("error marshal audit record, %v", err)
+		return
+	}
+	line = append(line, '\n')
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.size > 0 && f.size+int64(len(line)) > f.maxSize {
+		err = f.rotate()
+		if err != nil {
+			log.Errorf("error rotate audit log, %v", err)
+		}
+	}
+	if f.file == nil {
+		return
+	}
+	n, err := f.file.Write(line)
+	f.size += int64(n)
+	if err != nil {
+		log.Errorf("error write audit log, %v", err)
+	}
+}
+
+// Close the file
+func (f *FileSink) Close() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *FileSink) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("error open audit log %s, %w", f.path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("error stat audit log %s, %w", f.path, err)
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// rotate rename path.N-1 to path.N ... path to path.1, and open a new file
+func (f *FileSink) rotate() error {
+	if f.file != nil {
+		_ = f.file.Close()
+		f.file = nil
+	}
+	if f.maxBackups == 0 {
+		err := os.Remove(f.path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return f.open()
+	}
+	_ = os.Remove(backupName(f.path, f.maxBackups))
+	for i := f.maxBackups - 1; i > 0; i-- {
+		err := os.Rename(backupName(f.path, i), backupName(f.path, i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	err := os.Rename(f.path, backupName(f.path, 1))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return f.open()
+}
+
+func backupName(path string, i int) string {
+	return fmt.Sprintf("%s.%d", path, i)
+}
+
+// EventRecorder record the event, about the pod if it is set in the record
+type EventRecorder func(r *Record, eventType, reason, message string)
+
+// NewEventSink create the sink which record kubernetes events
+func NewEventSink(recorder EventRecorder) Sink {
+	return SinkFunc(func(r *Record) {
+		eventType := "Normal"
+		msg := fmt.Sprintf("%s %s, requestID %s, params %v, latency %.0fms", r.API, r.Result, r.RequestID, r.Params, r.Latency)
+		if r.Result != ResultSuccess {
+			eventType = "Warning"
+			msg = fmt.Sprintf("%s, %s", msg, r.Error)
+		}
+		recorder(r, eventType, "CloudAPIAudit", msg)
+	})
+}
@@ -22,6 +22,7 @@ import (
 	_ "github.com/AliyunContainerService/terway/pkg/controller/endpoint"
 	_ "github.com/AliyunContainerService/terway/pkg/controller/node"
 	_ "github.com/AliyunContainerService/terway/pkg/controller/pod"
+	_ "github.com/AliyunContainerService/terway/pkg/controller/pod-eip"
 	_ "github.com/AliyunContainerService/terway/pkg/controller/pod-eni"
 	_ "github.com/AliyunContainerService/terway/pkg/controller/pod-networking"
 )
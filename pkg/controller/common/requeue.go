@@ -0,0 +1,60 @@
+/*
+Copyright 2022 Terway Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	apiErr "github.com/AliyunContainerService/terway/pkg/aliyun/client/errors"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// requeue delay for each class of cloud error
+const (
+	RequeueTimeout       = 5 * time.Second
+	RequeueThrottled     = 10 * time.Second
+	RequeueQuotaExceeded = 1 * time.Minute
+	RequeuePermanent     = 5 * time.Minute
+)
+
+// RequeueForCloudErr adjust the reconcile result by the class of the openapi error.
+// Throttled and quota exceeded errors are requeued with a fixed delay instead of the rate limited backoff,
+// permanent errors are logged and retried at a low frequency. A request not finished in time is requeued shortly.
+// Other errors are returned as is.
+func RequeueForCloudErr(ctx context.Context, result reconcile.Result, err error) (reconcile.Result, error) {
+	if err == nil {
+		return result, nil
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		log.FromContext(ctx).Info("request not finished in time, requeue", "after", RequeueTimeout, "err", err.Error())
+		return reconcile.Result{RequeueAfter: RequeueTimeout}, nil
+	}
+	switch apiErr.Classify(err) {
+	case apiErr.ClassThrottled:
+		return reconcile.Result{RequeueAfter: RequeueThrottled}, nil
+	case apiErr.ClassQuotaExceeded:
+		log.FromContext(ctx).Error(err, "quota exceeded, requeue", "after", RequeueQuotaExceeded)
+		return reconcile.Result{RequeueAfter: RequeueQuotaExceeded}, nil
+	case apiErr.ClassPermanent:
+		log.FromContext(ctx).Error(err, "permanent error, requeue", "after", RequeuePermanent)
+		return reconcile.Result{RequeueAfter: RequeuePermanent}, nil
+	}
+	return result, err
+}
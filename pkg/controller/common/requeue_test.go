@@ -0,0 +1,39 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	apiErr "github.com/AliyunContainerService/terway/pkg/aliyun/client/errors"
+	sdkErr "github.com/aliyun/alibaba-cloud-sdk-go/sdk/errors"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestRequeueForCloudErr(t *testing.T) {
+	serverErr := func(status int, code string) error {
+		return fmt.Errorf("error create eni, %w", sdkErr.NewServerError(status, fmt.Sprintf(`{"Code": "%s"}`, code), ""))
+	}
+	tests := []struct {
+		name       string
+		err        error
+		wantResult reconcile.Result
+		wantErr    bool
+	}{
+		{name: "nil", err: nil, wantResult: reconcile.Result{Requeue: true}},
+		{name: "throttled", err: serverErr(400, "Throttling.User"), wantResult: reconcile.Result{RequeueAfter: RequeueThrottled}},
+		{name: "quota", err: serverErr(400, apiErr.InvalidVSwitchIDIPNotEnough), wantResult: reconcile.Result{RequeueAfter: RequeueQuotaExceeded}},
+		{name: "permanent", err: serverErr(400, "InvalidParameter"), wantResult: reconcile.Result{RequeueAfter: RequeuePermanent}},
+		{name: "retryable", err: serverErr(500, "InternalError"), wantResult: reconcile.Result{Requeue: true}, wantErr: true},
+		{name: "timeout", err: fmt.Errorf("error create eni, %w", context.DeadlineExceeded), wantResult: reconcile.Result{RequeueAfter: RequeueTimeout}},
+		{name: "circuit open", err: apiErr.ErrCircuitOpen, wantResult: reconcile.Result{RequeueAfter: RequeueThrottled}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := RequeueForCloudErr(context.Background(), reconcile.Result{Requeue: true}, tt.err)
+			assert.Equal(t, tt.wantResult, result)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2022 Terway Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"context"
+	"time"
+
+	"github.com/AliyunContainerService/terway/pkg/apis/network.alibabacloud.com/v1beta1"
+	eni_pool "github.com/AliyunContainerService/terway/pkg/controller/pool"
+	"github.com/AliyunContainerService/terway/types/daemon"
+
+	corev1 "k8s.io/api/core/v1"
+	k8sErr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// poolSize is the effective pool config for the node
+type poolSize struct {
+	minIdle int
+	maxIdle int
+	policy  string
+	window  time.Duration
+}
+
+// getPoolSize merge the eni-config with the NodeENIPool spec, spec takes precedence
+func getPoolSize(minIdle, maxIdle, maxENI int, spec *v1beta1.NodeENIPoolSpec) poolSize {
+	size := poolSize{
+		minIdle: minIdle,
+		maxIdle: maxIdle,
+		policy:  v1beta1.WarmUpPolicyFixed,
+	}
+	if spec != nil {
+		if spec.MinIdle != nil {
+			size.minIdle = *spec.MinIdle
+		}
+		if spec.MaxIdle != nil {
+			size.maxIdle = *spec.MaxIdle
+		}
+		if spec.WarmUp.Policy != "" {
+			size.policy = string(spec.WarmUp.Policy)
+		}
+		if spec.WarmUp.Window != "" {
+			d, err := time.ParseDuration(spec.WarmUp.Window)
+			if err == nil {
+				size.window = d
+			}
+		}
+	}
+
+	if size.minIdle > size.maxIdle {
+		size.minIdle = 0
+	}
+	if size.maxIdle > maxENI {
+		size.maxIdle = maxENI
+	}
+	if size.minIdle > size.maxIdle {
+		size.minIdle = size.maxIdle
+	}
+	if size.window <= 0 {
+		size.window = 10 * time.Minute
+	}
+	return size
+}
+
+// ensureNodeENIPool get or create the NodeENIPool for the node, the cr is owned by the node
+func (m *ReconcileNode) ensureNodeENIPool(ctx context.Context, node *corev1.Node) (*v1beta1.NodeENIPool, error) {
+	nodeENIPool := &v1beta1.NodeENIPool{}
+	err := m.client.Get(ctx, types.NamespacedName{Name: node.Name}, nodeENIPool)
+	if err == nil {
+		return nodeENIPool, nil
+	}
+	if !k8sErr.IsNotFound(err) {
+		return nil, err
+	}
+
+	nodeENIPool = &v1beta1.NodeENIPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: node.Name,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(node, corev1.SchemeGroupVersion.WithKind("Node")),
+			},
+		},
+	}
+	err = m.client.Create(ctx, nodeENIPool)
+	if err != nil && !k8sErr.IsAlreadyExists(err) {
+		return nil, err
+	}
+	if k8sErr.IsAlreadyExists(err) {
+		err = m.client.Get(ctx, types.NamespacedName{Name: node.Name}, nodeENIPool)
+	}
+	return nodeENIPool, err
+}
+
+// restorePoolStatus recover the deleting eni from the status, so the pool can continue the work after restart
+func restorePoolStatus(status *v1beta1.NodeENIPoolStatus, all map[string]*eni_pool.Allocation) {
+	for _, eni := range status.ENIs {
+		if eni.Status != string(eni_pool.StatusDeleting) {
+			continue
+		}
+		alloc, ok := all[eni.ID]
+		if !ok || alloc.AllocType != eni_pool.AllocPolicyPreferPool {
+			continue
+		}
+		if alloc.GetStatus() != eni_pool.StatusIdle {
+			continue
+		}
+		alloc.SetStatus(eni_pool.StatusDeleting)
+	}
+}
+
+var _ eni_pool.StatusSyncer = &poolStatusSyncer{}
+
+// poolStatusSyncer write the pool status to the NodeENIPool
+type poolStatusSyncer struct {
+	client client.Client
+	name   string
+}
+
+func (s *poolStatusSyncer) SyncStatus(ctx context.Context, status *v1beta1.NodeENIPoolStatus) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		nodeENIPool := &v1beta1.NodeENIPool{}
+		err := s.client.Get(ctx, types.NamespacedName{Name: s.name}, nodeENIPool)
+		if err != nil {
+			return err
+		}
+		update := nodeENIPool.DeepCopy()
+		update.Status = *status
+		return s.client.Status().Patch(ctx, update, client.MergeFrom(nodeENIPool))
+	})
+}
+
+// syncPoolSize apply the latest config to the running pool
+func (m *ReconcileNode) syncPoolSize(ctx context.Context, node *corev1.Node, mgr *eni_pool.Manager) error {
+	eniConfig, err := daemon.ConfigFromConfigMap(ctx, m.client, node.Name)
+	if err != nil {
+		return err
+	}
+	nodeENIPool, err := m.ensureNodeENIPool(ctx, node)
+	if err != nil {
+		return err
+	}
+	size := getPoolSize(eniConfig.MinPoolSize, eniConfig.MaxPoolSize, mgr.MaxENI(), &nodeENIPool.Spec)
+	mgr.SetPoolSize(size.minIdle, size.maxIdle, size.policy, size.window)
+	return nil
+}
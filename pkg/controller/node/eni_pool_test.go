@@ -0,0 +1,66 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	aliyunClient "github.com/AliyunContainerService/terway/pkg/aliyun/client"
+	"github.com/AliyunContainerService/terway/pkg/apis/network.alibabacloud.com/v1beta1"
+	eni_pool "github.com/AliyunContainerService/terway/pkg/controller/pool"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPoolSize(t *testing.T) {
+	intPtr := func(i int) *int { return &i }
+	tests := []struct {
+		name   string
+		spec   *v1beta1.NodeENIPoolSpec
+		maxENI int
+		want   poolSize
+	}{
+		{
+			name:   "eni-config",
+			spec:   nil,
+			maxENI: 10,
+			want:   poolSize{minIdle: 1, maxIdle: 5, policy: v1beta1.WarmUpPolicyFixed, window: 10 * time.Minute},
+		},
+		{
+			name: "override",
+			spec: &v1beta1.NodeENIPoolSpec{
+				MinIdle: intPtr(2),
+				MaxIdle: intPtr(8),
+				WarmUp:  v1beta1.WarmUp{Policy: v1beta1.WarmUpPolicyChurn, Window: "5m"},
+			},
+			maxENI: 10,
+			want:   poolSize{minIdle: 2, maxIdle: 8, policy: v1beta1.WarmUpPolicyChurn, window: 5 * time.Minute},
+		},
+		{
+			name:   "bounded by maxENI",
+			spec:   &v1beta1.NodeENIPoolSpec{MinIdle: intPtr(4)},
+			maxENI: 3,
+			want:   poolSize{minIdle: 3, maxIdle: 3, policy: v1beta1.WarmUpPolicyFixed, window: 10 * time.Minute},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, getPoolSize(1, 5, tt.maxENI, tt.spec))
+		})
+	}
+}
+
+func TestRestorePoolStatus(t *testing.T) {
+	all := map[string]*eni_pool.Allocation{
+		"eni-1": {NetworkInterface: &aliyunClient.NetworkInterface{NetworkInterfaceID: "eni-1"}, Status: eni_pool.StatusIdle, AllocType: eni_pool.AllocPolicyPreferPool},
+		"eni-2": {NetworkInterface: &aliyunClient.NetworkInterface{NetworkInterfaceID: "eni-2"}, Status: eni_pool.StatusInUse, AllocType: eni_pool.AllocPolicyPreferPool},
+		"eni-3": {NetworkInterface: &aliyunClient.NetworkInterface{NetworkInterfaceID: "eni-3"}, Status: eni_pool.StatusIdle, AllocType: eni_pool.AllocPolicyPreferPool},
+	}
+	restorePoolStatus(&v1beta1.NodeENIPoolStatus{ENIs: []v1beta1.PoolENI{
+		{ID: "eni-1", Status: string(eni_pool.StatusDeleting)},
+		{ID: "eni-2", Status: string(eni_pool.StatusDeleting)},
+		{ID: "eni-3", Status: string(eni_pool.StatusIdle)},
+	}}, all)
+
+	assert.Equal(t, eni_pool.StatusDeleting, all["eni-1"].GetStatus())
+	assert.Equal(t, eni_pool.StatusInUse, all["eni-2"].GetStatus())
+	assert.Equal(t, eni_pool.StatusIdle, all["eni-3"].GetStatus())
+}
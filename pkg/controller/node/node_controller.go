@@ -12,6 +12,7 @@ import (
 	register "github.com/AliyunContainerService/terway/pkg/controller"
 	"github.com/AliyunContainerService/terway/pkg/controller/common"
 	eni_pool "github.com/AliyunContainerService/terway/pkg/controller/pool"
+	"github.com/AliyunContainerService/terway/pkg/controller/shard"
 	"github.com/AliyunContainerService/terway/pkg/controller/vswitch"
 	"github.com/AliyunContainerService/terway/types"
 	"github.com/AliyunContainerService/terway/types/controlplane"
@@ -56,17 +57,52 @@ func init() {
 			return err
 		}
 
-		return c.Watch(
+		err = c.Watch(
 			&source.Kind{
 				Type: &corev1.Node{},
 			},
 			&handler.EnqueueRequestForObject{},
 			&predicate.ResourceVersionChangedPredicate{},
 			&predicateForNodeEvent{},
+			shard.Predicate(nodeName),
+		)
+		if err != nil {
+			return err
+		}
+		if shard.Enabled() {
+			// resync nodes when shards are taken over from other replicas
+			err = c.Watch(
+				shard.Source(mgr.GetClient(), func() client.ObjectList { return &corev1.NodeList{} }, nodeName),
+				&handler.EnqueueRequestForObject{},
+				&predicateForNodeEvent{},
+				shard.Predicate(nodeName),
+			)
+			if err != nil {
+				return err
+			}
+			shard.AddHandler(stopReleasedPools)
+		}
+		if !ctrlCtx.Config.EnableENIPool {
+			return nil
+		}
+
+		// NodeENIPool has the same name as the node
+		return c.Watch(
+			&source.Kind{
+				Type: &v1beta1.NodeENIPool{},
+			},
+			&handler.EnqueueRequestForObject{},
+			&predicate.GenerationChangedPredicate{},
+			shard.Predicate(nodeName),
 		)
 	}, false)
 }
 
+// nodeName NodeENIPool has the same name as the node
+func nodeName(obj client.Object) string {
+	return obj.GetName()
+}
+
 type Wrapper struct {
 	ctrl   controller.Controller
 	client client.Client
@@ -98,6 +134,11 @@ func (w *Wrapper) Start(ctx context.Context) error {
 	return nil
 }
 
+// NeedLeaderElection need election, when sharding is enabled every replica reconcile its own shards
+func (w *Wrapper) NeedLeaderElection() bool {
+	return !shard.Enabled()
+}
+
 // ReconcilePod implements reconcile.Reconciler
 var _ reconcile.Reconciler = &ReconcileNode{}
 
@@ -125,6 +166,11 @@ func (m *ReconcileNode) Reconcile(ctx context.Context, request reconcile.Request
 	l := log.FromContext(ctx)
 	l.V(5).Info("Reconcile")
 
+	// the request may be queued before the shard is released
+	if !shard.Owns(request.Name) {
+		return reconcile.Result{}, nil
+	}
+
 	in := &corev1.Node{}
 	err := m.client.Get(ctx, request.NamespacedName, in)
 	if err != nil {
@@ -153,20 +199,18 @@ func (m *ReconcileNode) createOrUpdate(ctx context.Context, node *corev1.Node) (
 	}
 
 	if controlplane.GetConfig().EnableENIPool {
-		needInit := false
+		var mgr *eni_pool.Manager
 		v, ok := nodePool.Load(node.Name)
-		if !ok {
-			needInit = true
-		} else {
-			if _, err = v.(*Client).GetClient(); err != nil {
-				needInit = true
-			}
+		if ok {
+			mgr, _ = v.(*Client).GetClient()
 		}
-		if needInit {
+		if mgr == nil {
 			err = m.initENIManagerForNode(ctx, node, nodeInfo)
-			if err != nil {
-				return reconcile.Result{}, err
-			}
+		} else {
+			err = m.syncPoolSize(ctx, node, mgr)
+		}
+		if err != nil {
+			return reconcile.Result{}, err
 		}
 	}
 	if controlplane.GetConfig().EnableDevicePlugin {
@@ -318,14 +362,13 @@ func (m *ReconcileNode) initENIManagerForNode(ctx context.Context, node *corev1.
 		ipv6Enable = true
 	}
 
-	maxIdle := eniConfig.MaxPoolSize
-	minIdle := eniConfig.MinPoolSize
-	if minIdle > maxIdle {
-		minIdle = 0
-	}
-	if maxIdle > maxENI {
-		maxIdle = maxENI
+	nodeENIPool, err := m.ensureNodeENIPool(ctx, node)
+	if err != nil {
+		return err
 	}
+	restorePoolStatus(&nodeENIPool.Status, all)
+
+	size := getPoolSize(eniConfig.MinPoolSize, eniConfig.MaxPoolSize, maxENI, &nodeENIPool.Spec)
 	nodeENIMgr := eni_pool.NewManager(&eni_pool.Config{
 		IPv4Enable:       true,
 		IPv6Enable:       ipv6Enable,
@@ -341,9 +384,12 @@ func (m *ReconcileNode) initENIManagerForNode(ctx context.Context, node *corev1.
 			types.TagENIAllocPolicy:             "pool",
 			types.TagK8SNodeName:                node.Name,
 		},
-		MaxENI:  maxENI,
-		MaxIdle: maxIdle,
-		MinIdle: minIdle,
+		MaxENI:       maxENI,
+		MaxIdle:      size.maxIdle,
+		MinIdle:      size.minIdle,
+		WarmUpPolicy: size.policy,
+		WarmUpWindow: size.window,
+		StatusSyncer: &poolStatusSyncer{client: m.client, name: node.Name},
 	}, all, m.swPool, m.aliyun)
 
 	l.Info("add node to pool", "maxENI", maxENI, "node", node.Name, "preveni", len(all))
@@ -0,0 +1,30 @@
+package node
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AliyunContainerService/terway/pkg/controller/shard"
+	"github.com/stretchr/testify/assert"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconcileNodeNotOwned(t *testing.T) {
+	shard.SetDefault(shard.New(shard.Config{
+		Name:          "terway-controlplane",
+		Namespace:     "kube-system",
+		Identity:      "replica-1",
+		Shards:        4,
+		LeaseDuration: time.Minute,
+	}, fake.NewSimpleClientset()))
+	defer shard.SetDefault(nil)
+
+	// the request queued before the shard is released is dropped without touching the node
+	m := &ReconcileNode{}
+	result, err := m.Reconcile(context.Background(), reconcile.Request{NamespacedName: k8stypes.NamespacedName{Name: "node-1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, reconcile.Result{}, result)
+}
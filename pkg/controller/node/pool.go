@@ -6,12 +6,33 @@ import (
 	"sync"
 
 	eni_pool "github.com/AliyunContainerService/terway/pkg/controller/pool"
+	"github.com/AliyunContainerService/terway/pkg/controller/shard"
 )
 
 var ErrNodeNotSynced = errors.New("node is not synced")
 
 var nodePool sync.Map
 
+// stopReleasedPools stop the pool for nodes no longer held by this replica, the new holder will rebuild it
+func stopReleasedPools(acquired, released []int) {
+	if len(released) == 0 {
+		return
+	}
+	nodePool.Range(func(key, value interface{}) bool {
+		name := key.(string)
+		if shard.Owns(name) {
+			return true
+		}
+		mgr, err := value.(*Client).GetClient()
+		if err != nil {
+			return true
+		}
+		mgr.Stop()
+		value.(*Client).SetClient(nil)
+		return true
+	})
+}
+
 func GetPoolManager(nodeName string) (*Client, error) {
 	v, ok := nodePool.Load(nodeName)
 	if !ok {
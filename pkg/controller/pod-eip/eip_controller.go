@@ -0,0 +1,576 @@
+/*
+Copyright 2022 Terway Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podeip
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	aliyunClient "github.com/AliyunContainerService/terway/pkg/aliyun/client"
+	apiErr "github.com/AliyunContainerService/terway/pkg/aliyun/client/errors"
+	"github.com/AliyunContainerService/terway/pkg/apis/network.alibabacloud.com/v1beta1"
+	"github.com/AliyunContainerService/terway/pkg/audit"
+	register "github.com/AliyunContainerService/terway/pkg/controller"
+	"github.com/AliyunContainerService/terway/pkg/controller/common"
+	"github.com/AliyunContainerService/terway/pkg/utils"
+	"github.com/AliyunContainerService/terway/types"
+
+	corev1 "k8s.io/api/core/v1"
+	k8sErr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	controllerName            = "pod-eip"
+	reservationControllerName = "pod-eip-reservation"
+)
+
+const (
+	eipStatusInUse     = "InUse"
+	eipStatusAvailable = "Available"
+)
+
+// requeue to wait the async eip operations
+var eipStatusWait = 3 * time.Second
+
+// reservationCheckPeriod recheck the reservation while the pod is alive, the pod may be deleted without the daemon
+// marking the reservation unassociated, e.g. the node is removed
+var reservationCheckPeriod = 5 * time.Minute
+
+// API is the openapi used by the controller
+type API interface {
+	aliyunClient.EIP
+	DescribeNetworkInterface(ctx context.Context, vpcID string, eniID []string, instanceID string, instanceType string, status string, tags map[string]string) ([]*aliyunClient.NetworkInterface, error)
+}
+
+func init() {
+	register.Add(controllerName, func(mgr manager.Manager, ctrlCtx *register.ControllerCtx) error {
+		api, ok := ctrlCtx.AliyunClient.(API)
+		if !ok {
+			return fmt.Errorf("%s controller is not supported by the cloud provider", controllerName)
+		}
+		c, err := controller.New(controllerName, mgr, controller.Options{
+			Reconciler:              NewReconcilePodEIP(mgr, api),
+			MaxConcurrentReconciles: 2,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = c.Watch(
+			&source.Kind{
+				Type: &v1beta1.PodEIP{},
+			},
+			&handler.EnqueueRequestForObject{},
+			&predicate.GenerationChangedPredicate{},
+		)
+		if err != nil {
+			return err
+		}
+
+		// the PodEIP has the same name as the pod
+		return c.Watch(
+			&source.Kind{
+				Type: &corev1.Pod{},
+			},
+			&handler.EnqueueRequestForObject{},
+			&predicateForPodEvent{},
+		)
+	}, false)
+
+	// the eips reserved by the daemon are recorded in PodEIP, release them after the ttl
+	register.Add(reservationControllerName, func(mgr manager.Manager, ctrlCtx *register.ControllerCtx) error {
+		api, ok := ctrlCtx.AliyunClient.(API)
+		if !ok {
+			return fmt.Errorf("%s controller is not supported by the cloud provider", reservationControllerName)
+		}
+		r := NewReconcilePodEIP(mgr, api)
+		r.reservation = true
+		c, err := controller.New(reservationControllerName, mgr, controller.Options{
+			Reconciler:              r,
+			MaxConcurrentReconciles: 1,
+		})
+		if err != nil {
+			return err
+		}
+
+		return c.Watch(
+			&source.Kind{
+				Type: &v1beta1.PodEIP{},
+			},
+			&handler.EnqueueRequestForObject{},
+			predicate.NewPredicateFuncs(isReservation),
+		)
+	}, true)
+}
+
+// ReconcilePodEIP implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcilePodEIP{}
+
+// ReconcilePodEIP allocate and associate the eip for pods, the daemon only read the result
+type ReconcilePodEIP struct {
+	client client.Client
+	scheme *runtime.Scheme
+	aliyun API
+
+	// reservation only reconcile the PodEIP written by the daemon, see types.LabelEIPReservation
+	reservation bool
+
+	//record event recorder
+	record record.EventRecorder
+}
+
+// NewReconcilePodEIP create the reconciler for PodEIP
+func NewReconcilePodEIP(mgr manager.Manager, api API) *ReconcilePodEIP {
+	return &ReconcilePodEIP{
+		client: mgr.GetClient(),
+		scheme: mgr.GetScheme(),
+		record: mgr.GetEventRecorderFor("TerwayPodEIPController"),
+		aliyun: api,
+	}
+}
+
+// Reconcile PodEIP and the pod with the same name
+// pod with eip annotations create -> create PodEIP from the annotations
+// PodEIP with pod ip -> allocate eip and associate to the pod ip
+// pod delete -> release or unassociate the eip by the release strategy
+// The PodEIP reserved by the daemon is only released after the ttl, the daemon associate it.
+func (m *ReconcilePodEIP) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	l := log.FromContext(ctx)
+	l.V(5).Info("Reconcile")
+	ctx = audit.WithPod(ctx, request.Namespace, request.Name)
+
+	pod := &corev1.Pod{}
+	err := m.client.Get(ctx, request.NamespacedName, pod)
+	if err != nil {
+		if !k8sErr.IsNotFound(err) {
+			return reconcile.Result{}, err
+		}
+		pod = nil
+	}
+
+	podEIP := &v1beta1.PodEIP{}
+	err = m.client.Get(ctx, request.NamespacedName, podEIP)
+	if err != nil {
+		if !k8sErr.IsNotFound(err) {
+			return reconcile.Result{}, err
+		}
+		if m.reservation || pod == nil || !podAlive(pod) || !PodRequireEIP(pod) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, m.createFromPod(ctx, pod)
+	}
+	if isReservation(podEIP) != m.reservation {
+		return reconcile.Result{}, nil
+	}
+
+	if !podEIP.DeletionTimestamp.IsZero() {
+		if !controllerutil.ContainsFinalizer(podEIP, types.FinalizerPodEIP) {
+			return reconcile.Result{}, nil
+		}
+		result, err := m.release(ctx, podEIP)
+		if err != nil {
+			m.record.Event(podEIP, corev1.EventTypeWarning, types.EventReleaseEIPFailed, err.Error())
+		}
+		return common.RequeueForCloudErr(ctx, result, err)
+	}
+
+	if !controllerutil.ContainsFinalizer(podEIP, types.FinalizerPodEIP) {
+		update := podEIP.DeepCopy()
+		controllerutil.AddFinalizer(update, types.FinalizerPodEIP)
+		err = m.client.Patch(ctx, update, client.MergeFrom(podEIP))
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		podEIP = update
+	}
+
+	if pod == nil || !podAlive(pod) {
+		return m.podGone(ctx, podEIP)
+	}
+	if m.reservation {
+		return reconcile.Result{RequeueAfter: reservationCheckPeriod}, nil
+	}
+
+	result, err := m.associate(ctx, podEIP, pod)
+	if err != nil {
+		m.record.Event(podEIP, corev1.EventTypeWarning, types.EventAssociateEIPFailed, err.Error())
+		update := podEIP.DeepCopy()
+		update.Status.Phase = v1beta1.PodEIPPhaseFailed
+		update.Status.Message = err.Error()
+		if updateErr := m.updateStatus(ctx, update, podEIP); updateErr != nil {
+			l.Error(updateErr, "error update status")
+		}
+	}
+	return common.RequeueForCloudErr(ctx, result, err)
+}
+
+// createFromPod create PodEIP from the pod annotations
+func (m *ReconcilePodEIP) createFromPod(ctx context.Context, pod *corev1.Pod) error {
+	spec, err := SpecFromPod(pod)
+	if err != nil {
+		m.record.Event(pod, corev1.EventTypeWarning, types.EventAssociateEIPFailed, err.Error())
+		return nil
+	}
+	podEIP := &v1beta1.PodEIP{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       pod.Name,
+			Namespace:  pod.Namespace,
+			Finalizers: []string{types.FinalizerPodEIP},
+		},
+		Spec: *spec,
+	}
+	err = m.client.Create(ctx, podEIP)
+	if k8sErr.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
+
+// associate allocate the eip and associate it to the pod ip
+func (m *ReconcilePodEIP) associate(ctx context.Context, podEIP *v1beta1.PodEIP, pod *corev1.Pod) (reconcile.Result, error) {
+	l := log.FromContext(ctx)
+
+	if podEIP.Status.AllocationID == "" {
+		update, err := m.allocate(ctx, podEIP)
+		if err != nil || update == nil {
+			return reconcile.Result{RequeueAfter: eipStatusWait}, err
+		}
+		// persist the eip before any further step, so it will be released on failure
+		err = m.updateStatus(ctx, update, podEIP)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		podEIP = update
+	}
+
+	if podEIP.Status.Created && podEIP.Spec.BandwidthPackageID != "" && podEIP.Status.BandwidthPackageID == "" {
+		err := m.aliyun.AddCommonBandwidthPackageIP(ctx, podEIP.Status.AllocationID, podEIP.Spec.BandwidthPackageID)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		update := podEIP.DeepCopy()
+		update.Status.BandwidthPackageID = podEIP.Spec.BandwidthPackageID
+		err = m.updateStatus(ctx, update, podEIP)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		podEIP = update
+	}
+
+	update := podEIP.DeepCopy()
+	update.Status.PodLastSeen = metav1.Now()
+	if pod.Status.PodIP == "" {
+		update.Status.Phase = v1beta1.PodEIPPhaseAllocated
+		update.Status.Message = ""
+		return reconcile.Result{}, m.updateStatus(ctx, update, podEIP)
+	}
+
+	eniID, err := m.eniByIP(ctx, pod)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	status := podEIP.Status
+	if status.Phase == v1beta1.PodEIPPhaseAssociated && status.ENIID == eniID && status.PrivateIPAddress == pod.Status.PodIP {
+		update.Status.PodUID = string(pod.UID)
+		return reconcile.Result{}, m.updateStatus(ctx, update, podEIP)
+	}
+
+	// the pod is recreated with another ip
+	if status.ENIID != "" && (status.ENIID != eniID || status.PrivateIPAddress != pod.Status.PodIP) {
+		err = m.aliyun.UnAssociateEIPAddress(ctx, status.AllocationID, status.ENIID, status.PrivateIPAddress)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	err = m.aliyun.AssociateEIPAddress(ctx, status.AllocationID, eniID, pod.Status.PodIP)
+	if err != nil && !apiErr.ErrAssert(apiErr.ErrAssociationDuplicated, err) {
+		return reconcile.Result{}, err
+	}
+	l.Info("eip associated", "eip", status.AllocationID, "eni", eniID, "ip", pod.Status.PodIP)
+
+	update.Status.Phase = v1beta1.PodEIPPhaseAssociated
+	update.Status.ENIID = eniID
+	update.Status.PrivateIPAddress = pod.Status.PodIP
+	update.Status.PodUID = string(pod.UID)
+	update.Status.Message = ""
+	err = m.updateStatus(ctx, update, podEIP)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	m.record.Eventf(podEIP, corev1.EventTypeNormal, types.EventAssociateEIPSucceed, "eip %s %s associated to %s", status.AllocationID, status.EIPAddress, pod.Status.PodIP)
+
+	return reconcile.Result{}, m.patchPodAnnotation(ctx, pod, status.EIPAddress)
+}
+
+// allocateClientToken is the idempotent key of the eip creation. The allocation id is only saved in status after the
+// creation, if the status failed to be saved, the next reconcile get the same eip back instead of leaking one.
+func allocateClientToken(podEIP *v1beta1.PodEIP) string {
+	return fmt.Sprintf("%s-%d", podEIP.UID, podEIP.Generation)
+}
+
+// allocate create the eip or take the eip in spec, nil is returned if the eip is not ready to use
+func (m *ReconcilePodEIP) allocate(ctx context.Context, podEIP *v1beta1.PodEIP) (*v1beta1.PodEIP, error) {
+	update := podEIP.DeepCopy()
+	spec := podEIP.Spec
+	if spec.AllocationID == "" {
+		resp, err := m.aliyun.AllocateEIPAddress(ctx, spec.Bandwidth, spec.InternetChargeType, spec.ISP, spec.PublicIPAddressPoolID, allocateClientToken(podEIP))
+		if err != nil {
+			return nil, err
+		}
+		update.Status.AllocationID = resp.AllocationId
+		update.Status.EIPAddress = resp.EipAddress
+		update.Status.ISP = spec.ISP
+		update.Status.Created = true
+		update.Status.Phase = v1beta1.PodEIPPhaseAllocated
+		return update, nil
+	}
+
+	eips, err := m.aliyun.DescribeEipAddresses(ctx, spec.AllocationID, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(eips) == 0 {
+		return nil, fmt.Errorf("eip %s is not found", spec.AllocationID)
+	}
+	eip := eips[0]
+	if eip.Status == eipStatusInUse {
+		if !spec.AllowRob {
+			return nil, fmt.Errorf("eip %s is in use by %s", eip.AllocationId, eip.InstanceId)
+		}
+		err = m.aliyun.UnAssociateEIPAddress(ctx, eip.AllocationId, "", "")
+		if err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if eip.Status != eipStatusAvailable {
+		return nil, nil
+	}
+	update.Status.AllocationID = eip.AllocationId
+	update.Status.EIPAddress = eip.IpAddress
+	update.Status.ISP = eip.ISP
+	update.Status.Phase = v1beta1.PodEIPPhaseAllocated
+	return update, nil
+}
+
+// podGone handle the PodEIP after the pod is deleted
+func (m *ReconcilePodEIP) podGone(ctx context.Context, podEIP *v1beta1.PodEIP) (reconcile.Result, error) {
+	// the pod is not created yet
+	if podEIP.Status.AllocationID == "" {
+		return reconcile.Result{}, nil
+	}
+	switch podEIP.Spec.ReleaseStrategy {
+	case v1beta1.EIPReleaseStrategyNever, v1beta1.EIPReleaseStrategyTTL:
+	default:
+		return reconcile.Result{}, m.delete(ctx, podEIP)
+	}
+	if podEIP.Status.Phase != v1beta1.PodEIPPhaseUnassociated {
+		err := m.unassociate(ctx, podEIP)
+		if err != nil {
+			return common.RequeueForCloudErr(ctx, reconcile.Result{}, err)
+		}
+		update := podEIP.DeepCopy()
+		update.Status.Phase = v1beta1.PodEIPPhaseUnassociated
+		update.Status.ENIID = ""
+		update.Status.PrivateIPAddress = ""
+		update.Status.PodLastSeen = metav1.Now()
+		update.Status.Message = ""
+		err = m.updateStatus(ctx, update, podEIP)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		podEIP = update
+	}
+	if podEIP.Spec.ReleaseStrategy != v1beta1.EIPReleaseStrategyTTL {
+		return reconcile.Result{}, nil
+	}
+
+	ttl, err := time.ParseDuration(podEIP.Spec.ReleaseAfter)
+	if err != nil {
+		// keep the eip until the spec is fixed
+		m.record.Eventf(podEIP, corev1.EventTypeWarning, types.EventReleaseEIPFailed, "invalid releaseAfter %s, %s", podEIP.Spec.ReleaseAfter, err)
+		return reconcile.Result{}, nil
+	}
+	expireAt := podEIP.Status.PodLastSeen.Add(ttl)
+	if now := time.Now(); now.Before(expireAt) {
+		return reconcile.Result{RequeueAfter: expireAt.Sub(now)}, nil
+	}
+	log.FromContext(ctx).Info("eip reservation expired", "eip", podEIP.Status.AllocationID, "podLastSeen", podEIP.Status.PodLastSeen)
+	return reconcile.Result{}, m.delete(ctx, podEIP)
+}
+
+// delete the PodEIP, the eip is released by the finalizer. The resource version is checked, so a PodEIP taken by a
+// new pod after it is read is not deleted.
+func (m *ReconcilePodEIP) delete(ctx context.Context, podEIP *v1beta1.PodEIP) error {
+	rv := podEIP.ResourceVersion
+	err := m.client.Delete(ctx, podEIP, client.Preconditions{ResourceVersion: &rv})
+	if k8sErr.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// release the eip created by controlplane, the eip given by user is only unassociated
+func (m *ReconcilePodEIP) release(ctx context.Context, podEIP *v1beta1.PodEIP) (reconcile.Result, error) {
+	l := log.FromContext(ctx)
+	eipID := podEIP.Status.AllocationID
+	if eipID != "" && podEIP.Status.Created && podEIP.Spec.ReleaseStrategy != v1beta1.EIPReleaseStrategyNever {
+		eips, err := m.aliyun.DescribeEipAddresses(ctx, eipID, "")
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if len(eips) > 0 {
+			eip := eips[0]
+			if eip.Status != eipStatusAvailable {
+				if eip.Status == eipStatusInUse {
+					err = m.aliyun.UnAssociateEIPAddress(ctx, eipID, eip.InstanceId, eip.PrivateIpAddress)
+					if err != nil {
+						return reconcile.Result{}, err
+					}
+				}
+				return reconcile.Result{RequeueAfter: eipStatusWait}, nil
+			}
+			if eip.BandwidthPackageId != "" {
+				err = m.aliyun.RemoveCommonBandwidthPackageIP(ctx, eipID, eip.BandwidthPackageId)
+				if err != nil && !apiErr.ErrAssert(apiErr.ErrIPNotInCbwp, err) {
+					return reconcile.Result{}, err
+				}
+			}
+			err = m.aliyun.ReleaseEIPAddress(ctx, eipID)
+			if err != nil && !apiErr.ErrAssert(apiErr.ErrInvalidAllocationIDNotFound, err) {
+				return reconcile.Result{}, err
+			}
+			l.Info("eip released", "eip", eipID)
+		}
+	} else {
+		err := m.unassociate(ctx, podEIP)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	update := podEIP.DeepCopy()
+	controllerutil.RemoveFinalizer(update, types.FinalizerPodEIP)
+	err := m.client.Patch(ctx, update, client.MergeFrom(podEIP))
+	if k8sErr.IsNotFound(err) {
+		return reconcile.Result{}, nil
+	}
+	return reconcile.Result{}, err
+}
+
+func (m *ReconcilePodEIP) unassociate(ctx context.Context, podEIP *v1beta1.PodEIP) error {
+	if podEIP.Status.AllocationID == "" || podEIP.Status.ENIID == "" {
+		return nil
+	}
+	return m.aliyun.UnAssociateEIPAddress(ctx, podEIP.Status.AllocationID, podEIP.Status.ENIID, podEIP.Status.PrivateIPAddress)
+}
+
+// eniByIP find the eni the pod ip belongs to
+func (m *ReconcilePodEIP) eniByIP(ctx context.Context, pod *corev1.Pod) (string, error) {
+	podIP := pod.Status.PodIP
+	if types.PodUseENI(pod) {
+		podENI := &v1beta1.PodENI{}
+		err := m.client.Get(ctx, client.ObjectKeyFromObject(pod), podENI)
+		if err != nil {
+			return "", err
+		}
+		for _, alloc := range podENI.Spec.Allocations {
+			if alloc.IPv4 == podIP {
+				return alloc.ENI.ID, nil
+			}
+		}
+		return "", fmt.Errorf("pod ip %s is not found in podENI", podIP)
+	}
+
+	node := &corev1.Node{}
+	err := m.client.Get(ctx, client.ObjectKey{Name: pod.Spec.NodeName}, node)
+	if err != nil {
+		return "", err
+	}
+	nodeInfo, err := common.NewNodeInfo(node)
+	if err != nil {
+		return "", err
+	}
+	enis, err := m.aliyun.DescribeNetworkInterface(ctx, "", nil, nodeInfo.InstanceID, "", "", nil)
+	if err != nil {
+		return "", err
+	}
+	for _, eni := range enis {
+		if eni.PrivateIPAddress == podIP {
+			return eni.NetworkInterfaceID, nil
+		}
+		for _, ip := range eni.PrivateIPSets {
+			if ip.PrivateIpAddress == podIP {
+				return eni.NetworkInterfaceID, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("pod ip %s is not found on instance %s", podIP, nodeInfo.InstanceID)
+}
+
+// patchPodAnnotation keep the allocated-eipAddress annotation for compatibility
+func (m *ReconcilePodEIP) patchPodAnnotation(ctx context.Context, pod *corev1.Pod, address string) error {
+	if pod.Annotations[types.PodEIPAddress] == address {
+		return nil
+	}
+	update := pod.DeepCopy()
+	if update.Annotations == nil {
+		update.Annotations = map[string]string{}
+	}
+	update.Annotations[types.PodEIPAddress] = address
+	err := m.client.Patch(ctx, update, client.MergeFrom(pod))
+	if k8sErr.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func (m *ReconcilePodEIP) updateStatus(ctx context.Context, update, old *v1beta1.PodEIP) error {
+	err := m.client.Status().Patch(ctx, update, client.MergeFrom(old))
+	if k8sErr.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// NeedLeaderElection need election
+func (m *ReconcilePodEIP) NeedLeaderElection() bool {
+	return true
+}
+
+func isReservation(obj client.Object) bool {
+	_, ok := obj.GetLabels()[types.LabelEIPReservation]
+	return ok
+}
+
+func podAlive(pod *corev1.Pod) bool {
+	return pod.DeletionTimestamp.IsZero() && !utils.PodSandboxExited(pod)
+}
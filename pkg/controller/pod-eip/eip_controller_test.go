@@ -0,0 +1,397 @@
+/*
+Copyright 2022 Terway Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podeip
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	aliyunClient "github.com/AliyunContainerService/terway/pkg/aliyun/client"
+	"github.com/AliyunContainerService/terway/pkg/apis/network.alibabacloud.com/v1beta1"
+	"github.com/AliyunContainerService/terway/types"
+
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/ecs"
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/vpc"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	k8sErr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+type fakeAPI struct {
+	eips map[string]*vpc.EipAddress
+	enis []*aliyunClient.NetworkInterface
+	// allocation id by the client token
+	tokens map[string]string
+}
+
+func (f *fakeAPI) AllocateEIPAddress(ctx context.Context, bandwidth, chargeType, isp, poolID, idempotentKey string) (*vpc.AllocateEipAddressResponse, error) {
+	if id, ok := f.tokens[idempotentKey]; ok {
+		return &vpc.AllocateEipAddressResponse{AllocationId: id, EipAddress: f.eips[id].IpAddress}, nil
+	}
+	id := fmt.Sprintf("eip-%d", len(f.tokens)+1)
+	ip := fmt.Sprintf("1.1.1.%d", len(f.tokens)+1)
+	if f.tokens == nil {
+		f.tokens = map[string]string{}
+	}
+	f.tokens[idempotentKey] = id
+	f.eips[id] = &vpc.EipAddress{AllocationId: id, IpAddress: ip, Status: eipStatusAvailable}
+	return &vpc.AllocateEipAddressResponse{AllocationId: id, EipAddress: ip}, nil
+}
+
+func (f *fakeAPI) AssociateEIPAddress(ctx context.Context, eipID, eniID, privateIP string) error {
+	eip := f.eips[eipID]
+	eip.Status = eipStatusInUse
+	eip.InstanceId = eniID
+	eip.PrivateIpAddress = privateIP
+	return nil
+}
+
+func (f *fakeAPI) UnAssociateEIPAddress(ctx context.Context, eipID, eniID, eniIP string) error {
+	eip, ok := f.eips[eipID]
+	if ok {
+		eip.Status = eipStatusAvailable
+		eip.InstanceId = ""
+		eip.PrivateIpAddress = ""
+	}
+	return nil
+}
+
+func (f *fakeAPI) ReleaseEIPAddress(ctx context.Context, eipID string) error {
+	delete(f.eips, eipID)
+	return nil
+}
+
+func (f *fakeAPI) AddCommonBandwidthPackageIP(ctx context.Context, eipID, packageID string) error {
+	f.eips[eipID].BandwidthPackageId = packageID
+	return nil
+}
+
+func (f *fakeAPI) RemoveCommonBandwidthPackageIP(ctx context.Context, eipID, packageID string) error {
+	f.eips[eipID].BandwidthPackageId = ""
+	return nil
+}
+
+func (f *fakeAPI) DescribeEipAddresses(ctx context.Context, eipID, eniID string) ([]vpc.EipAddress, error) {
+	eip, ok := f.eips[eipID]
+	if !ok {
+		return nil, nil
+	}
+	return []vpc.EipAddress{*eip}, nil
+}
+
+func (f *fakeAPI) DescribeNetworkInterface(ctx context.Context, vpcID string, eniID []string, instanceID string, instanceType string, status string, tags map[string]string) ([]*aliyunClient.NetworkInterface, error) {
+	return f.enis, nil
+}
+
+func TestSpecFromPod(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		require     bool
+		want        *v1beta1.PodEIPSpec
+		wantErr     bool
+	}{
+		{
+			name:        "no eip",
+			annotations: map[string]string{types.PodEIP: "false"},
+			want: &v1beta1.PodEIPSpec{
+				Bandwidth:          defaultBandwidth,
+				InternetChargeType: string(types.PayByTraffic),
+				ReleaseStrategy:    v1beta1.EIPReleaseStrategyFollow,
+			},
+		},
+		{
+			name: "create",
+			annotations: map[string]string{
+				types.PodEIP:                   "true",
+				types.PodEIPBandwidth:          "10",
+				types.PodEIPInternetChargeType: "PayByBandwidth",
+				types.EIPISP:                   "BGP",
+				types.EIPBandwidthPackageID:    "cbwp-1",
+				types.EIPPublicIPAddressPoolID: "pippool-1",
+			},
+			require: true,
+			want: &v1beta1.PodEIPSpec{
+				Bandwidth:             "10",
+				InternetChargeType:    "PayByBandwidth",
+				ISP:                   "BGP",
+				BandwidthPackageID:    "cbwp-1",
+				PublicIPAddressPoolID: "pippool-1",
+				ReleaseStrategy:       v1beta1.EIPReleaseStrategyFollow,
+			},
+		},
+		{
+			name:        "existing eip",
+			annotations: map[string]string{types.ECIEIPInstanceID: "eip-1"},
+			require:     true,
+			want: &v1beta1.PodEIPSpec{
+				AllocationID:       "eip-1",
+				Bandwidth:          defaultBandwidth,
+				InternetChargeType: string(types.PayByTraffic),
+				ReleaseStrategy:    v1beta1.EIPReleaseStrategyFollow,
+			},
+		},
+		{
+			name:        "reuse ttl",
+			annotations: map[string]string{types.PodEIP: "true", types.PodEIPReuseTTL: "1h"},
+			require:     true,
+			want: &v1beta1.PodEIPSpec{
+				Bandwidth:          defaultBandwidth,
+				InternetChargeType: string(types.PayByTraffic),
+				ReleaseStrategy:    v1beta1.EIPReleaseStrategyTTL,
+				ReleaseAfter:       "1h",
+			},
+		},
+		{
+			name:        "invalid reuse ttl",
+			annotations: map[string]string{types.PodEIP: "true", types.PodEIPReuseTTL: "1"},
+			require:     true,
+			wantErr:     true,
+		},
+		{
+			name:        "invalid bandwidth",
+			annotations: map[string]string{types.PodEIP: "true", types.PodEIPBandwidth: "5M"},
+			require:     true,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: tt.annotations}}
+			assert.Equal(t, tt.require, PodRequireEIP(pod))
+			got, err := SpecFromPod(pod)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestReconcilePodEIP(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+	assert.NoError(t, v1beta1.AddToScheme(scheme))
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "pod-1",
+			Namespace:   "default",
+			UID:         "uid-1",
+			Annotations: map[string]string{types.PodEIP: "true", types.EIPBandwidthPackageID: "cbwp-1"},
+		},
+		Spec: corev1.PodSpec{NodeName: "node-1"},
+	}
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1", Labels: map[string]string{corev1.LabelTopologyZone: "zone-a"}},
+		Spec:       corev1.NodeSpec{ProviderID: "cn-hangzhou.i-1"},
+	}
+	api := &fakeAPI{
+		eips: map[string]*vpc.EipAddress{},
+		enis: []*aliyunClient.NetworkInterface{
+			{NetworkInterfaceID: "eni-1", PrivateIPAddress: "192.168.0.1", PrivateIPSets: []ecs.PrivateIpSet{{PrivateIpAddress: "192.168.0.2"}}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(pod, node).Build()
+	r := &ReconcilePodEIP{client: c, scheme: scheme, aliyun: api, record: record.NewFakeRecorder(100)}
+	ctx := context.Background()
+	req := reconcile.Request{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: "pod-1"}}
+
+	getPodEIP := func() *v1beta1.PodEIP {
+		podEIP := &v1beta1.PodEIP{}
+		err := c.Get(ctx, req.NamespacedName, podEIP)
+		if k8sErr.IsNotFound(err) {
+			return nil
+		}
+		assert.NoError(t, err)
+		return podEIP
+	}
+
+	// PodEIP is created from the annotations
+	_, err := r.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	podEIP := getPodEIP()
+	assert.Equal(t, "cbwp-1", podEIP.Spec.BandwidthPackageID)
+
+	// eip is allocated before the pod ip is ready
+	_, err = r.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	podEIP = getPodEIP()
+	assert.Equal(t, v1beta1.PodEIPPhase(v1beta1.PodEIPPhaseAllocated), podEIP.Status.Phase)
+	assert.Equal(t, "eip-1", podEIP.Status.AllocationID)
+	assert.True(t, podEIP.Status.Created)
+	assert.Equal(t, "cbwp-1", api.eips["eip-1"].BandwidthPackageId)
+
+	// associate to the pod ip
+	pod.Status.PodIP = "192.168.0.2"
+	assert.NoError(t, c.Status().Update(ctx, pod))
+	_, err = r.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	podEIP = getPodEIP()
+	assert.Equal(t, v1beta1.PodEIPPhase(v1beta1.PodEIPPhaseAssociated), podEIP.Status.Phase)
+	assert.Equal(t, "eni-1", podEIP.Status.ENIID)
+	assert.Equal(t, "192.168.0.2", api.eips["eip-1"].PrivateIpAddress)
+	assert.NoError(t, c.Get(ctx, req.NamespacedName, pod))
+	assert.Equal(t, "1.1.1.1", pod.Annotations[types.PodEIPAddress])
+
+	// pod deleted, the eip is released with the PodEIP
+	assert.NoError(t, c.Delete(ctx, pod))
+	_, err = r.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	result, err := r.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, eipStatusWait, result.RequeueAfter)
+	_, err = r.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.Empty(t, api.eips)
+	assert.Nil(t, getPodEIP())
+}
+
+func TestReconcilePodEIP_Never(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+	assert.NoError(t, v1beta1.AddToScheme(scheme))
+
+	podEIP := &v1beta1.PodEIP{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-1", Namespace: "default", Finalizers: []string{types.FinalizerPodEIP}},
+		Spec:       v1beta1.PodEIPSpec{AllocationID: "eip-2", ReleaseStrategy: v1beta1.EIPReleaseStrategyNever},
+		Status: v1beta1.PodEIPStatus{
+			Phase:            v1beta1.PodEIPPhaseAssociated,
+			AllocationID:     "eip-2",
+			ENIID:            "eni-1",
+			PrivateIPAddress: "192.168.0.2",
+		},
+	}
+	api := &fakeAPI{
+		eips: map[string]*vpc.EipAddress{
+			"eip-2": {AllocationId: "eip-2", Status: eipStatusInUse, InstanceId: "eni-1", PrivateIpAddress: "192.168.0.2"},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(podEIP).Build()
+	r := &ReconcilePodEIP{client: c, scheme: scheme, aliyun: api, record: record.NewFakeRecorder(100)}
+	ctx := context.Background()
+	req := reconcile.Request{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: "pod-1"}}
+
+	// the pod is gone, eip is kept
+	_, err := r.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.NoError(t, c.Get(ctx, req.NamespacedName, podEIP))
+	assert.Equal(t, v1beta1.PodEIPPhase(v1beta1.PodEIPPhaseUnassociated), podEIP.Status.Phase)
+	assert.Equal(t, eipStatusAvailable, api.eips["eip-2"].Status)
+
+	// user given eip is never released
+	assert.NoError(t, c.Delete(ctx, podEIP))
+	_, err = r.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.Contains(t, api.eips, "eip-2")
+}
+
+func TestReconcilePodEIP_AllocateIdempotent(t *testing.T) {
+	podEIP := &v1beta1.PodEIP{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-1", Namespace: "default", UID: "uid-1", Generation: 1},
+	}
+	api := &fakeAPI{eips: map[string]*vpc.EipAddress{}}
+	r := &ReconcilePodEIP{aliyun: api}
+	ctx := context.Background()
+
+	// the status is not saved, allocate again get the same eip
+	update, err := r.allocate(ctx, podEIP)
+	assert.NoError(t, err)
+	update2, err := r.allocate(ctx, podEIP)
+	assert.NoError(t, err)
+	assert.Equal(t, update.Status.AllocationID, update2.Status.AllocationID)
+	assert.Len(t, api.eips, 1)
+
+	podEIP.UID = "uid-2"
+	update2, err = r.allocate(ctx, podEIP)
+	assert.NoError(t, err)
+	assert.NotEqual(t, update.Status.AllocationID, update2.Status.AllocationID)
+}
+
+func TestReconcilePodEIP_Reservation(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+	assert.NoError(t, v1beta1.AddToScheme(scheme))
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "default"}}
+	podEIP := &v1beta1.PodEIP{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "web-0",
+			Namespace:  "default",
+			Labels:     map[string]string{types.LabelEIPReservation: "true"},
+			Finalizers: []string{types.FinalizerPodEIP},
+		},
+		Spec: v1beta1.PodEIPSpec{ReleaseStrategy: v1beta1.EIPReleaseStrategyTTL, ReleaseAfter: "1h"},
+		Status: v1beta1.PodEIPStatus{
+			Phase:            v1beta1.PodEIPPhaseAssociated,
+			AllocationID:     "eip-1",
+			Created:          true,
+			ENIID:            "eni-1",
+			PrivateIPAddress: "192.168.0.2",
+		},
+	}
+	api := &fakeAPI{
+		eips: map[string]*vpc.EipAddress{
+			"eip-1": {AllocationId: "eip-1", Status: eipStatusInUse, InstanceId: "eni-1", PrivateIpAddress: "192.168.0.2"},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(pod, podEIP).Build()
+	r := &ReconcilePodEIP{client: c, scheme: scheme, aliyun: api, record: record.NewFakeRecorder(100)}
+	reservation := &ReconcilePodEIP{client: c, scheme: scheme, aliyun: api, record: record.NewFakeRecorder(100), reservation: true}
+	ctx := context.Background()
+	req := reconcile.Request{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: "web-0"}}
+
+	// the reservation is associated by the daemon
+	result, err := r.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, reconcile.Result{}, result)
+	result, err = reservation.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, reservationCheckPeriod, result.RequeueAfter)
+	assert.Equal(t, eipStatusInUse, api.eips["eip-1"].Status)
+
+	// the pod is deleted without the daemon, e.g. the node is removed
+	assert.NoError(t, c.Delete(ctx, pod))
+	result, err = reservation.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Hour, result.RequeueAfter, float64(time.Minute))
+	assert.NoError(t, c.Get(ctx, req.NamespacedName, podEIP))
+	assert.Equal(t, v1beta1.PodEIPPhase(v1beta1.PodEIPPhaseUnassociated), podEIP.Status.Phase)
+	assert.Equal(t, eipStatusAvailable, api.eips["eip-1"].Status)
+
+	// released after the ttl
+	update := podEIP.DeepCopy()
+	update.Status.PodLastSeen = metav1.NewTime(time.Now().Add(-time.Hour))
+	assert.NoError(t, c.Status().Update(ctx, update))
+	_, err = reservation.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	_, err = reservation.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.Empty(t, api.eips)
+	assert.True(t, k8sErr.IsNotFound(c.Get(ctx, req.NamespacedName, podEIP)))
+}
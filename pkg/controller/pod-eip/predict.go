@@ -0,0 +1,120 @@
+/*
+Copyright 2022 Terway Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podeip
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/AliyunContainerService/terway/pkg/apis/network.alibabacloud.com/v1beta1"
+	"github.com/AliyunContainerService/terway/types"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const defaultBandwidth = "5"
+
+// PodRequireEIP return true if the pod has eip annotations
+func PodRequireEIP(pod *corev1.Pod) bool {
+	annotations := pod.GetAnnotations()
+	for _, key := range []string{types.PodEIP, types.ECIEIP} {
+		if v, _ := strconv.ParseBool(annotations[key]); v {
+			return true
+		}
+	}
+	return annotations[types.PodEIPInstanceID] != "" || annotations[types.ECIEIPInstanceID] != ""
+}
+
+// SpecFromPod convert the eip annotations of the pod to PodEIPSpec
+func SpecFromPod(pod *corev1.Pod) (*v1beta1.PodEIPSpec, error) {
+	annotations := pod.GetAnnotations()
+	spec := &v1beta1.PodEIPSpec{
+		Bandwidth:          defaultBandwidth,
+		InternetChargeType: string(types.PayByTraffic),
+		ReleaseStrategy:    v1beta1.EIPReleaseStrategyFollow,
+	}
+	if v, ok := annotations[types.PodEIPBandwidth]; ok {
+		if _, err := strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("invalid %s %s", types.PodEIPBandwidth, v)
+		}
+		spec.Bandwidth = v
+	}
+	if v, ok := annotations[types.PodEIPChargeType]; ok {
+		spec.InternetChargeType = v
+	}
+	if v, ok := annotations[types.PodEIPInternetChargeType]; ok {
+		spec.InternetChargeType = v
+	}
+	if v := annotations[types.ECIEIPInstanceID]; v != "" {
+		spec.AllocationID = v
+	}
+	if v := annotations[types.PodEIPInstanceID]; v != "" {
+		spec.AllocationID = v
+	}
+	if v := annotations[types.PodEIPReuseTTL]; v != "" {
+		if _, err := time.ParseDuration(v); err != nil {
+			return nil, fmt.Errorf("invalid %s %s", types.PodEIPReuseTTL, v)
+		}
+		spec.ReleaseStrategy = v1beta1.EIPReleaseStrategyTTL
+		spec.ReleaseAfter = v
+	}
+	spec.ISP = annotations[types.EIPISP]
+	spec.BandwidthPackageID = annotations[types.EIPBandwidthPackageID]
+	spec.PublicIPAddressPoolID = annotations[types.EIPPublicIPAddressPoolID]
+	return spec, nil
+}
+
+type predicateForPodEvent struct {
+	predicate.Funcs
+}
+
+func (p *predicateForPodEvent) Create(e event.CreateEvent) bool {
+	return podEvent(e.Object)
+}
+
+func (p *predicateForPodEvent) Update(e event.UpdateEvent) bool {
+	oldPod, ok := e.ObjectOld.(*corev1.Pod)
+	if !ok {
+		return false
+	}
+	newPod, ok := e.ObjectNew.(*corev1.Pod)
+	if !ok || !podEvent(newPod) {
+		return false
+	}
+	return oldPod.Status.PodIP != newPod.Status.PodIP ||
+		oldPod.Status.Phase != newPod.Status.Phase ||
+		!newPod.DeletionTimestamp.IsZero()
+}
+
+func (p *predicateForPodEvent) Delete(e event.DeleteEvent) bool {
+	return podEvent(e.Object)
+}
+
+func (p *predicateForPodEvent) Generic(e event.GenericEvent) bool {
+	return podEvent(e.Object)
+}
+
+func podEvent(obj interface{}) bool {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return false
+	}
+	return !pod.Spec.HostNetwork && PodRequireEIP(pod)
+}
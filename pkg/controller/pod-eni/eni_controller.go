@@ -26,9 +26,11 @@ import (
 	aliyunClient "github.com/AliyunContainerService/terway/pkg/aliyun/client"
 	apiErr "github.com/AliyunContainerService/terway/pkg/aliyun/client/errors"
 	"github.com/AliyunContainerService/terway/pkg/apis/network.alibabacloud.com/v1beta1"
+	"github.com/AliyunContainerService/terway/pkg/audit"
 	"github.com/AliyunContainerService/terway/pkg/backoff"
 	register "github.com/AliyunContainerService/terway/pkg/controller"
 	"github.com/AliyunContainerService/terway/pkg/controller/common"
+	"github.com/AliyunContainerService/terway/pkg/controller/shard"
 	"github.com/AliyunContainerService/terway/pkg/utils"
 	"github.com/AliyunContainerService/terway/types"
 	"github.com/AliyunContainerService/terway/types/controlplane"
@@ -70,25 +72,41 @@ func init() {
 		}
 
 		w := &Wrapper{
-			ctrl: c,
-			r:    r,
+			ctrl:    c,
+			r:       r,
+			elected: mgr.Elected(),
 		}
 		err = mgr.Add(w)
 		if err != nil {
 			return err
 		}
 
-		return c.Watch(
+		err = c.Watch(
 			&source.Kind{
 				Type: &v1beta1.PodENI{},
 			},
 			&handler.EnqueueRequestForObject{},
 			&predicate.ResourceVersionChangedPredicate{},
 			&predicateForPodENIEvent{},
+			shard.Predicate(podENINodeName),
+		)
+		if err != nil || !shard.Enabled() {
+			return err
+		}
+
+		// resync podENIs when shards are taken over from other replicas
+		return c.Watch(
+			shard.Source(mgr.GetClient(), func() client.ObjectList { return &v1beta1.PodENIList{} }, podENINodeName),
+			&handler.EnqueueRequestForObject{},
+			shard.Predicate(podENINodeName),
 		)
 	}, true)
 }
 
+func podENINodeName(obj client.Object) string {
+	return obj.GetLabels()[types.ENIRelatedNodeName]
+}
+
 var (
 	leakedENICheckPeriod = 10 * time.Minute
 	podENICheckPeriod    = 1 * time.Minute
@@ -112,12 +130,22 @@ type ReconcilePodENI struct {
 type Wrapper struct {
 	ctrl controller.Controller
 	r    *ReconcilePodENI
+
+	// elected is closed when this replica become the leader
+	elected <-chan struct{}
 }
 
 // Start the controller
 func (w *Wrapper) Start(ctx context.Context) error {
-	// start the gc process
-	w.r.gc(ctx)
+	// start the gc process, gc is only done by the leader
+	go func() {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.elected:
+		}
+		w.r.gc(ctx)
+	}()
 
 	err := w.ctrl.Start(ctx)
 	if err != nil {
@@ -128,9 +156,9 @@ func (w *Wrapper) Start(ctx context.Context) error {
 	return nil
 }
 
-// NeedLeaderElection need election
+// NeedLeaderElection need election, when sharding is enabled every replica reconcile its own shards
 func (w *Wrapper) NeedLeaderElection() bool {
-	return true
+	return !shard.Enabled()
 }
 
 // NewReconcilePod watch pod lifecycle events and sync to podENI resource
@@ -151,6 +179,8 @@ func NewReconcilePod(mgr manager.Manager, aliyunClient register.Interface) *Reco
 func (m *ReconcilePodENI) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
 	l := log.FromContext(ctx)
 	l.Info("Reconcile")
+	ctx = aliyunClient.LaneWithCtx(ctx, aliyunClient.LaneAttach)
+	ctx = audit.WithPodENI(ctx, request.Namespace, request.Name)
 	start := time.Now()
 	podENI := &v1beta1.PodENI{}
 	err := m.client.Get(ctx, request.NamespacedName, podENI)
@@ -160,6 +190,10 @@ func (m *ReconcilePodENI) Reconcile(ctx context.Context, request reconcile.Reque
 		}
 		return reconcile.Result{}, err
 	}
+	// the request may be queued before the shard is released
+	if !shard.Owns(podENINodeName(podENI)) {
+		return reconcile.Result{}, nil
+	}
 
 	if controlplane.GetConfig().EnableENIPool {
 		nodeName := podENI.Labels[types.ENIRelatedNodeName]
@@ -206,12 +240,12 @@ func (m *ReconcilePodENI) Reconcile(ctx context.Context, request reconcile.Reque
 		}
 		result, err := m.podENIDelete(ctx, podENI)
 		m.recordPodENIDeleteErr(podENI, start, err)
-		return result, err
+		return common.RequeueForCloudErr(ctx, result, err)
 	}
 	result, err := m.podENICreate(ctx, request.NamespacedName, podENI)
 	if err != nil {
 		m.recordPodENICreateErr(podENI, start, err)
-		return result, err
+		return common.RequeueForCloudErr(ctx, result, err)
 	}
 
 	if err = common.SetPodAnnotation(ctx, m.client, podENI); err != nil {
@@ -254,14 +288,21 @@ func (m *ReconcilePodENI) NeedLeaderElection() bool {
 // gc will handle following circumstances
 // 1. cr podENI is leaked
 // 2. release fixed ip resource by strategy
+// leaked enis are checked every leakedENICheckPeriod, all categories in one pass share the eniGCMaxPerCycle budget
 func (m *ReconcilePodENI) gc(ctx context.Context) {
+	ctx = aliyunClient.LaneWithCtx(ctx, aliyunClient.LaneGC)
+	var lastENIGC time.Time
 	go wait.JitterUntilWithContext(ctx, func(ctx context.Context) {
-		m.gcSecondaryENI(ctx)
+		budget := newGCBudget()
+		if controlplane.GetConfig().ENIGCMode != controlplane.GCModeOff && time.Since(lastENIGC) >= leakedENICheckPeriod {
+			lastENIGC = time.Now()
+			m.gcSecondaryENI(ctx, budget)
 
-		m.gcMemberENI(ctx)
-	}, leakedENICheckPeriod, 1.1, true)
+			m.gcMemberENI(ctx, budget)
+		}
 
-	go wait.JitterUntilWithContext(ctx, m.gcCRPodENIs, podENICheckPeriod, 1.1, true)
+		m.gcCRPodENIs(ctx, budget)
+	}, podENICheckPeriod, 1.1, true)
 }
 
 func (m *ReconcilePodENI) podENICreate(ctx context.Context, namespacedName client.ObjectKey, podENI *v1beta1.PodENI) (result reconcile.Result, err error) {
@@ -350,16 +391,24 @@ func (m *ReconcilePodENI) podENICreate(ctx context.Context, namespacedName clien
 		if podENICopy.Status.ENIInfos == nil {
 			podENICopy.Status.ENIInfos = make(map[string]v1beta1.ENIInfo)
 		}
+		podENICopy.Status.SetCondition(v1beta1.PodENIConditionCreated, metav1.ConditionTrue, v1beta1.PodENIReasonCreated, "", podENI.Generation)
 
 		ll := l.WithValues("eni", podENICopy.Spec.Allocations[0].ENI.ID, "trunk", podENICopy.Status.TrunkENIID, "instance", podENICopy.Status.InstanceID)
 
 		err = m.attachENI(ctx, podENICopy)
 		if err != nil {
+			// keep the phase, so attach will be retried. Only record the failure detail in status
+			podENICopy.Status.SetCondition(v1beta1.PodENIConditionAttached, metav1.ConditionFalse, v1beta1.PodENIReasonAttachFailed, err.Error(), podENI.Generation)
+			_, updateErr := common.UpdatePodENIStatus(ctx, m.client, podENICopy)
+			if updateErr != nil {
+				ll.Error(updateErr, "update podENI attach failed status")
+			}
 			return reconcile.Result{}, fmt.Errorf("attach eni failed, %w", err)
 		}
 		ll.Info("attached")
 
 		podENICopy.Status.Phase = v1beta1.ENIPhaseBind
+		podENICopy.Status.SetCondition(v1beta1.PodENIConditionAttached, metav1.ConditionTrue, v1beta1.PodENIReasonAttached, fmt.Sprintf("attached to %s", podENICopy.Status.InstanceID), podENI.Generation)
 		if podENICopy.Spec.HaveFixedIP() {
 			podENICopy.Status.PodLastSeen = metav1.Now()
 		}
@@ -403,7 +452,7 @@ func (m *ReconcilePodENI) podENIDelete(ctx context.Context, podENI *v1beta1.PodE
 	return reconcile.Result{}, err
 }
 
-func (m *ReconcilePodENI) gcSecondaryENI(ctx context.Context) {
+func (m *ReconcilePodENI) gcSecondaryENI(ctx context.Context, budget *gcBudget) {
 	// 1. list all available enis ( which type is secondary)
 	enis, err := m.aliyun.DescribeNetworkInterface(ctx, controlplane.GetConfig().VPCID, nil, "", aliyunClient.ENITypeSecondary, aliyunClient.ENIStatusAvailable, nil)
 	if err != nil {
@@ -428,17 +477,17 @@ func (m *ReconcilePodENI) gcSecondaryENI(ctx context.Context) {
 			}
 		}
 
-		networkInterfaces = append(networkInterfaces)
+		networkInterfaces = append(networkInterfaces, networkInterface)
 	}
 
-	err = m.gcENIs(ctx, networkInterfaces)
+	err = m.gcENIs(ctx, gcCategorySecondaryENI, networkInterfaces, budget)
 	if err != nil {
 		ctrlLog.Error(err, "error gc enis")
 		return
 	}
 }
 
-func (m *ReconcilePodENI) gcMemberENI(ctx context.Context) {
+func (m *ReconcilePodENI) gcMemberENI(ctx context.Context, budget *gcBudget) {
 	// 1. list all attached member eni
 	enis, err := m.aliyun.DescribeNetworkInterface(ctx, controlplane.GetConfig().VPCID, nil, "", aliyunClient.ENITypeMember, aliyunClient.ENIStatusInUse, nil)
 	if err != nil {
@@ -462,19 +511,23 @@ func (m *ReconcilePodENI) gcMemberENI(ctx context.Context) {
 			}
 		}
 
-		networkInterfaces = append(networkInterfaces)
+		networkInterfaces = append(networkInterfaces, networkInterface)
 	}
 
-	err = m.gcENIs(ctx, networkInterfaces)
+	err = m.gcENIs(ctx, gcCategoryMemberENI, networkInterfaces, budget)
 	if err != nil {
 		ctrlLog.Error(err, "error gc enis")
 		return
 	}
 }
 
-func (m *ReconcilePodENI) gcENIs(ctx context.Context, enis []*aliyunClient.NetworkInterface) error {
+func (m *ReconcilePodENI) gcENIs(ctx context.Context, category string, enis []*aliyunClient.NetworkInterface, budget *gcBudget) error {
 	l := ctrl.Log.WithName("gc-enis")
 
+	cycle := newGCCycle(category, budget)
+	defer m.saveGCReport(ctx, cycle)
+	gracePeriod := gcGracePeriod()
+
 	eniMap := make(map[string]*aliyunClient.NetworkInterface, len(enis))
 
 	// 1. filter out eni which is created by terway
@@ -493,7 +546,7 @@ func (m *ReconcilePodENI) gcENIs(ctx context.Context, enis []*aliyunClient.Netwo
 			continue
 		}
 		// avoid conflict with create process
-		if t.Add(10 * time.Minute).After(now) {
+		if t.Add(gracePeriod).After(now) {
 			continue
 		}
 
@@ -523,22 +576,33 @@ func (m *ReconcilePodENI) gcENIs(ctx context.Context, enis []*aliyunClient.Netwo
 	}
 
 	// 4. the left eni is going to be deleted
+	reason := "not referenced by any podENI"
 	for _, eni := range eniMap {
 		if eni.Type == aliyunClient.ENITypeMember && eni.Status == aliyunClient.ENIStatusInUse {
+			if !cycle.take(eni.NetworkInterfaceID, gcActionDetach, reason) {
+				l.Info("skip detach eni", "eni", eni.NetworkInterfaceID, "mode", cycle.Mode)
+				continue
+			}
 			l.Info("detach eni", "eni", eni.NetworkInterfaceID, "trunk-eni", eni.TrunkNetworkInterfaceID)
 			err = m.aliyun.DetachNetworkInterface(ctx, eni.NetworkInterfaceID, eni.InstanceID, eni.TrunkNetworkInterfaceID) // still need delegate ? otherwise may break quota
 			if err != nil {
 				l.Error(err, fmt.Sprintf("errot detach eni %s", eni.NetworkInterfaceID))
 			}
+			cycle.done(eni.NetworkInterfaceID, gcActionDetach, reason, err)
 			// we continue here because we can delete eni in next check
 			continue
 		}
 		if eni.Status == aliyunClient.ENIStatusAvailable {
+			if !cycle.take(eni.NetworkInterfaceID, gcActionDelete, reason) {
+				l.Info("skip delete eni", "eni", eni.NetworkInterfaceID, "mode", cycle.Mode)
+				continue
+			}
 			l.Info("delete eni", "eni", eni.NetworkInterfaceID)
 			err = m.aliyun.DeleteNetworkInterface(ctx, eni.NetworkInterfaceID)
 			if err != nil {
 				l.Info(fmt.Sprintf("delete leaked eni %s, %s", eni.NetworkInterfaceID, err))
 			}
+			cycle.done(eni.NetworkInterfaceID, gcActionDelete, reason, err)
 			continue
 		}
 	}
@@ -546,7 +610,7 @@ func (m *ReconcilePodENI) gcENIs(ctx context.Context, enis []*aliyunClient.Netwo
 }
 
 // gcCRPodENIs remove useless cr res
-func (m *ReconcilePodENI) gcCRPodENIs(ctx context.Context) {
+func (m *ReconcilePodENI) gcCRPodENIs(ctx context.Context, budget *gcBudget) {
 	l := ctrl.Log.WithName("gc-podENI")
 
 	podENIs := &v1beta1.PodENIList{}
@@ -556,11 +620,19 @@ func (m *ReconcilePodENI) gcCRPodENIs(ctx context.Context) {
 		return
 	}
 
+	cycle := newGCCycle(gcCategoryPodENI, budget)
+	defer m.saveGCReport(ctx, cycle)
+	gracePeriod := gcGracePeriod()
+
 	// 1. found the pod relate to cr
 	// 2. release res if pod is not present and not use fixed ip
 	// 3. clean fixed ip cr
 	for _, podENI := range podENIs.Items {
 		func() {
+			// avoid conflict with create process
+			if podENI.CreationTimestamp.Add(gracePeriod).After(time.Now()) {
+				return
+			}
 			ctx, cancel := context.WithTimeout(ctx, 1*time.Minute)
 			defer cancel()
 
@@ -573,18 +645,24 @@ func (m *ReconcilePodENI) gcCRPodENIs(ctx context.Context) {
 				l.Error(err, "error get pod")
 				return
 			}
-			ll := l.WithValues("pod", k8stypes.NamespacedName{
+			key := k8stypes.NamespacedName{
 				Namespace: podENI.Namespace,
 				Name:      podENI.Name,
-			}.String())
+			}.String()
+			ll := l.WithValues("pod", key)
 
 			// pod exist just update timestamp
 			if err == nil {
 				if !podRequirePodENI(p) {
+					reason := "pod no longer require podENI"
+					if !cycle.take(key, gcActionDeleting, reason) {
+						return
+					}
 					err = m.deletePodENI(ctx, &podENI)
 					if err != nil {
 						ll.Error(err, "error set podENI to ENIPhaseDeleting")
 					}
+					cycle.done(key, gcActionDeleting, reason, err)
 					return
 				}
 				// for non fixed-ip pod no need to update timeStamp
@@ -651,12 +729,22 @@ func (m *ReconcilePodENI) gcCRPodENIs(ctx context.Context) {
 				return
 			}
 
+			reason, msg := v1beta1.PodENIReasonPodDeleted, "pod not found"
+			if podENI.Spec.HaveFixedIP() {
+				reason, msg = v1beta1.PodENIReasonReleased, "fixed ip reached the release strategy"
+			}
+			if !cycle.take(key, gcActionDeleting, msg) {
+				return
+			}
+
 			update := podENI.DeepCopy()
 			update.Status.Phase = v1beta1.ENIPhaseDeleting
+			update.Status.SetCondition(v1beta1.PodENIConditionDeleting, metav1.ConditionTrue, reason, msg, podENI.Generation)
 			_, err = common.UpdatePodENIStatus(ctx, m.client, update)
 			if err != nil {
 				ll.Error(err, "error prune eni, %s")
 			}
+			cycle.done(key, gcActionDeleting, msg, err)
 		}()
 	}
 }
@@ -669,6 +757,7 @@ func (m *ReconcilePodENI) detach(ctx context.Context, podENI *v1beta1.PodENI) (r
 		return reconcile.Result{}, err
 	}
 	podENICopy.Status.Phase = v1beta1.ENIPhaseUnbind
+	podENICopy.Status.SetCondition(v1beta1.PodENIConditionAttached, metav1.ConditionFalse, v1beta1.PodENIReasonDetached, fmt.Sprintf("detached from %s", podENI.Status.InstanceID), podENI.Generation)
 	podENICopy.Status.InstanceID = ""
 	podENICopy.Status.TrunkENIID = ""
 	for k, v := range podENICopy.Status.ENIInfos {
@@ -717,11 +806,13 @@ func (m *ReconcilePodENI) attachENI(ctx context.Context, podENI *v1beta1.PodENI)
 			ctx := common.WithCtx(ctx, &alloc)
 			err := m.aliyun.AttachNetworkInterface(ctx, alloc.ENI.ID, podENI.Status.InstanceID, podENI.Status.TrunkENIID)
 			if err != nil {
+				ch <- attachFailedENIInfo(alloc.ENI.ID, err)
 				return err
 			}
 
 			eni, err := m.aliyun.WaitForNetworkInterface(ctx, alloc.ENI.ID, aliyunClient.ENIStatusInUse, backoff.Backoff(backoff.WaitENIStatus), false)
 			if err != nil {
+				ch <- attachFailedENIInfo(alloc.ENI.ID, err)
 				return err
 			}
 
@@ -844,11 +935,23 @@ func (m *ReconcilePodENI) getNode(ctx context.Context, name string) (*corev1.Nod
 func (m *ReconcilePodENI) deletePodENI(ctx context.Context, podENI *v1beta1.PodENI) error {
 	update := podENI.DeepCopy()
 	update.Status.Phase = v1beta1.ENIPhaseDeleting
+	update.Status.SetCondition(v1beta1.PodENIConditionDeleting, metav1.ConditionTrue, v1beta1.PodENIReasonPodNotRequired, "pod no longer require podENI", podENI.Generation)
 
 	_, err := common.UpdatePodENIStatus(ctx, m.client, update)
 	return err
 }
 
+// attachFailedENIInfo record the attach error for the eni
+func attachFailedENIInfo(eniID string, err error) *v1beta1.ENIInfo {
+	now := metav1.Now()
+	return &v1beta1.ENIInfo{
+		ID:              eniID,
+		Status:          v1beta1.ENIStatusUnBind,
+		AttachError:     err.Error(),
+		AttachErrorTime: &now,
+	}
+}
+
 func allocIDs(podENI *v1beta1.PodENI) []string {
 	var ids []string
 	for _, alloc := range podENI.Spec.Allocations {
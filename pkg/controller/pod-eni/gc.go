@@ -0,0 +1,187 @@
+/*
+Copyright 2022 Terway Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podeni
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/AliyunContainerService/terway/pkg/metric"
+	"github.com/AliyunContainerService/terway/types/controlplane"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// gcReportName is the configmap which store the latest gc result for each category
+const gcReportName = "terway-controlplane-gc-report"
+
+// keep the configmap small
+const gcReportMaxRecords = 500
+
+// gc category
+const (
+	gcCategorySecondaryENI = "secondaryENI"
+	gcCategoryMemberENI    = "memberENI"
+	gcCategoryPodENI       = "podENI"
+)
+
+// gc action
+const (
+	gcActionDetach   = "detach"
+	gcActionDelete   = "delete"
+	gcActionDeleting = "setDeleting"
+)
+
+// gc action result
+const (
+	gcResultSucceed = "succeed"
+	gcResultFail    = "fail"
+	gcResultDryRun  = "dry-run"
+	gcResultLimited = "limited"
+)
+
+type gcRecord struct {
+	ID     string `json:"id"`
+	Action string `json:"action"`
+	Result string `json:"result"`
+	Reason string `json:"reason,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// gcBudget is the actions allowed in one gc pass, shared by all categories
+type gcBudget struct {
+	limit     int
+	processed int
+}
+
+func newGCBudget() *gcBudget {
+	return &gcBudget{limit: controlplane.GetConfig().ENIGCMaxPerCycle}
+}
+
+// gcCycle track candidates and actions in one gc cycle for a category
+type gcCycle struct {
+	Category   string      `json:"category"`
+	Mode       string      `json:"mode"`
+	StartTime  metav1.Time `json:"startTime"`
+	Candidates int         `json:"candidates"`
+	Truncated  int         `json:"truncated,omitempty"`
+	Records    []gcRecord  `json:"records,omitempty"`
+
+	budget *gcBudget
+}
+
+func newGCCycle(category string, budget *gcBudget) *gcCycle {
+	return &gcCycle{
+		Category:  category,
+		Mode:      controlplane.GetConfig().ENIGCMode,
+		StartTime: metav1.Now(),
+		budget:    budget,
+	}
+}
+
+// gcGracePeriod is the minimum age before resource can be gc
+func gcGracePeriod() time.Duration {
+	d, err := time.ParseDuration(controlplane.GetConfig().ENIGCGracePeriod)
+	if err != nil {
+		// already validated
+		return 10 * time.Minute
+	}
+	return d
+}
+
+// take record the candidate and return true if the action is allowed to perform
+func (c *gcCycle) take(id, action, reason string) bool {
+	c.Candidates++
+	switch c.Mode {
+	case controlplane.GCModeOff:
+		return false
+	case controlplane.GCModeDryRun:
+		c.record(id, action, gcResultDryRun, reason, nil)
+		return false
+	}
+	if c.budget.processed >= c.budget.limit {
+		c.record(id, action, gcResultLimited, reason, nil)
+		return false
+	}
+	c.budget.processed++
+	return true
+}
+
+// done record the result for the action
+func (c *gcCycle) done(id, action, reason string, err error) {
+	if err != nil {
+		c.record(id, action, gcResultFail, reason, err)
+		return
+	}
+	c.record(id, action, gcResultSucceed, reason, nil)
+}
+
+func (c *gcCycle) record(id, action, result, reason string, err error) {
+	metric.GCActions.WithLabelValues(c.Category, action, result).Inc()
+
+	if len(c.Records) >= gcReportMaxRecords {
+		c.Truncated++
+		return
+	}
+	r := gcRecord{
+		ID:     id,
+		Action: action,
+		Result: result,
+		Reason: reason,
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	c.Records = append(c.Records, r)
+}
+
+// saveGCReport publish the gc result to the report configmap, each category is stored in its own key
+func (m *ReconcilePodENI) saveGCReport(ctx context.Context, c *gcCycle) {
+	metric.GCCandidates.WithLabelValues(c.Category).Set(float64(c.Candidates))
+
+	if c.Mode == controlplane.GCModeOff {
+		return
+	}
+	out, err := json.Marshal(c)
+	if err != nil {
+		ctrlLog.Error(err, "error marshal gc report")
+		return
+	}
+
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      gcReportName,
+				Namespace: controlplane.GetConfig().ControllerNamespace,
+			},
+		}
+		_, err := controllerutil.CreateOrUpdate(ctx, m.client, cm, func() error {
+			if cm.Data == nil {
+				cm.Data = make(map[string]string)
+			}
+			cm.Data[c.Category] = string(out)
+			return nil
+		})
+		return err
+	})
+	if err != nil {
+		ctrlLog.Error(err, "error save gc report", "category", c.Category)
+	}
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2022 Terway Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podeni
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/AliyunContainerService/terway/pkg/apis/network.alibabacloud.com/v1beta1"
+	"github.com/AliyunContainerService/terway/types/controlplane"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGCCycle_Take(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        string
+		limit       int
+		wantAllowed int
+		wantResults []string
+	}{
+		{
+			name:        "off",
+			mode:        controlplane.GCModeOff,
+			limit:       10,
+			wantAllowed: 0,
+			wantResults: nil,
+		},
+		{
+			name:        "dry-run",
+			mode:        controlplane.GCModeDryRun,
+			limit:       10,
+			wantAllowed: 0,
+			wantResults: []string{gcResultDryRun, gcResultDryRun, gcResultDryRun},
+		},
+		{
+			name:        "enforce with limit",
+			mode:        controlplane.GCModeEnforce,
+			limit:       2,
+			wantAllowed: 2,
+			wantResults: []string{gcResultSucceed, gcResultFail, gcResultLimited},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controlplane.SetConfig(&controlplane.Config{
+				ENIGCMode:        tt.mode,
+				ENIGCMaxPerCycle: tt.limit,
+			})
+			c := newGCCycle(gcCategorySecondaryENI, newGCBudget())
+
+			allowed := 0
+			for i, id := range []string{"eni-1", "eni-2", "eni-3"} {
+				if !c.take(id, gcActionDelete, "") {
+					continue
+				}
+				allowed++
+				var err error
+				if i == 1 {
+					err = errors.New("foo")
+				}
+				c.done(id, gcActionDelete, "", err)
+			}
+			assert.Equal(t, tt.wantAllowed, allowed)
+			assert.Equal(t, 3, c.Candidates)
+
+			var results []string
+			for _, r := range c.Records {
+				results = append(results, r.Result)
+			}
+			assert.Equal(t, tt.wantResults, results)
+		})
+	}
+}
+
+func TestGCCycle_SharedBudget(t *testing.T) {
+	controlplane.SetConfig(&controlplane.Config{
+		ENIGCMode:        controlplane.GCModeEnforce,
+		ENIGCMaxPerCycle: 3,
+	})
+	budget := newGCBudget()
+	allowed := 0
+	for _, category := range []string{gcCategorySecondaryENI, gcCategoryMemberENI, gcCategoryPodENI} {
+		c := newGCCycle(category, budget)
+		for _, id := range []string{"res-1", "res-2"} {
+			if c.take(id, gcActionDelete, "") {
+				allowed++
+			}
+		}
+	}
+	assert.Equal(t, 3, allowed)
+}
+
+func TestGCCRPodENIs_GracePeriod(t *testing.T) {
+	controlplane.SetConfig(&controlplane.Config{
+		ENIGCMode:        controlplane.GCModeEnforce,
+		ENIGCMaxPerCycle: 10,
+		ENIGCGracePeriod: "10m",
+	})
+	scheme := runtime.NewScheme()
+	assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+	assert.NoError(t, v1beta1.AddToScheme(scheme))
+
+	young := &v1beta1.PodENI{ObjectMeta: metav1.ObjectMeta{
+		Name: "young", Namespace: "default", CreationTimestamp: metav1.Now(),
+	}}
+	old := &v1beta1.PodENI{ObjectMeta: metav1.ObjectMeta{
+		Name: "old", Namespace: "default", CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour)),
+	}}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(young, old).Build()
+	m := &ReconcilePodENI{client: c, scheme: scheme}
+	m.gcCRPodENIs(context.Background(), newGCBudget())
+
+	got := &v1beta1.PodENI{}
+	assert.NoError(t, c.Get(context.Background(), k8stypes.NamespacedName{Namespace: "default", Name: "young"}, got))
+	assert.Equal(t, v1beta1.Phase(v1beta1.ENIPhaseInitial), got.Status.Phase)
+	assert.NoError(t, c.Get(context.Background(), k8stypes.NamespacedName{Namespace: "default", Name: "old"}, got))
+	assert.Equal(t, v1beta1.Phase(v1beta1.ENIPhaseDeleting), got.Status.Phase)
+}
@@ -46,6 +46,11 @@ func (p *predicateForPodENIEvent) Update(e event.UpdateEvent) bool {
 	newPodENICopy.ResourceVersion = ""
 	oldPodENICopy.Status.PodLastSeen = metav1.Unix(0, 0)
 	newPodENICopy.Status.PodLastSeen = metav1.Unix(0, 0)
+	// conditions and eni infos are observations only, ignore them to avoid retry without backoff
+	oldPodENICopy.Status.Conditions = nil
+	newPodENICopy.Status.Conditions = nil
+	oldPodENICopy.Status.ENIInfos = nil
+	newPodENICopy.Status.ENIInfos = nil
 
 	return !reflect.DeepEqual(&oldPodENICopy, &newPodENICopy)
 }
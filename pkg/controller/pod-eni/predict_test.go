@@ -0,0 +1,64 @@
+/*
+Copyright 2022 Terway Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podeni
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/AliyunContainerService/terway/pkg/apis/network.alibabacloud.com/v1beta1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestPredicateForPodENIEvent_Update(t *testing.T) {
+	old := &v1beta1.PodENI{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default", ResourceVersion: "1"},
+	}
+
+	conditionOnly := old.DeepCopy()
+	conditionOnly.ResourceVersion = "2"
+	conditionOnly.Status.SetCondition(v1beta1.PodENIConditionAttached, metav1.ConditionFalse, v1beta1.PodENIReasonAttachFailed, "foo", 0)
+	conditionOnly.Status.ENIInfos = map[string]v1beta1.ENIInfo{
+		"eni-1": *attachFailedENIInfo("eni-1", errors.New("foo")),
+	}
+
+	phaseChanged := conditionOnly.DeepCopy()
+	phaseChanged.ResourceVersion = "3"
+	phaseChanged.Status.Phase = v1beta1.ENIPhaseBind
+
+	p := &predicateForPodENIEvent{}
+	assert.False(t, p.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: conditionOnly}))
+	assert.True(t, p.Update(event.UpdateEvent{ObjectOld: conditionOnly, ObjectNew: phaseChanged}))
+}
+
+func TestPodENIStatus_SetCondition(t *testing.T) {
+	status := &v1beta1.PodENIStatus{}
+	status.SetCondition(v1beta1.PodENIConditionAttached, metav1.ConditionFalse, v1beta1.PodENIReasonAttachFailed, "foo", 1)
+	assert.Len(t, status.Conditions, 1)
+	first := status.Conditions[0].LastTransitionTime
+
+	status.SetCondition(v1beta1.PodENIConditionAttached, metav1.ConditionFalse, v1beta1.PodENIReasonAttachFailed, "bar", 1)
+	assert.Len(t, status.Conditions, 1)
+	assert.Equal(t, "bar", status.Conditions[0].Message)
+	assert.Equal(t, first, status.Conditions[0].LastTransitionTime)
+
+	status.SetCondition(v1beta1.PodENIConditionAttached, metav1.ConditionTrue, v1beta1.PodENIReasonAttached, "", 1)
+	assert.Equal(t, metav1.ConditionTrue, status.Conditions[0].Status)
+	assert.Equal(t, v1beta1.PodENIReasonAttached, status.Conditions[0].Reason)
+}
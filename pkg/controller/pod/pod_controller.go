@@ -22,11 +22,15 @@ import (
 	"strings"
 	"time"
 
+	aliyunClient "github.com/AliyunContainerService/terway/pkg/aliyun/client"
 	"github.com/AliyunContainerService/terway/pkg/apis/network.alibabacloud.com/v1beta1"
+	"github.com/AliyunContainerService/terway/pkg/audit"
 	register "github.com/AliyunContainerService/terway/pkg/controller"
 	"github.com/AliyunContainerService/terway/pkg/controller/common"
 	eni_pool "github.com/AliyunContainerService/terway/pkg/controller/pool"
+	"github.com/AliyunContainerService/terway/pkg/controller/shard"
 	"github.com/AliyunContainerService/terway/pkg/controller/vswitch"
+	"github.com/AliyunContainerService/terway/pkg/telemetry"
 	"github.com/AliyunContainerService/terway/pkg/utils"
 	"github.com/AliyunContainerService/terway/types"
 	"github.com/AliyunContainerService/terway/types/controlplane"
@@ -69,17 +73,37 @@ func init() {
 			return err
 		}
 
-		return c.Watch(
+		err = c.Watch(
 			&source.Kind{
 				Type: &corev1.Pod{},
 			},
 			&handler.EnqueueRequestForObject{},
 			&predicate.ResourceVersionChangedPredicate{},
 			&predicateForPodEvent{},
+			shard.Predicate(podNodeName),
+		)
+		if err != nil || !shard.Enabled() {
+			return err
+		}
+
+		// resync pods when shards are taken over from other replicas
+		return c.Watch(
+			shard.Source(mgr.GetClient(), func() client.ObjectList { return &corev1.PodList{} }, podNodeName),
+			&handler.EnqueueRequestForObject{},
+			&predicateForPodEvent{},
+			shard.Predicate(podNodeName),
 		)
 	}, true)
 }
 
+func podNodeName(obj client.Object) string {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return ""
+	}
+	return pod.Spec.NodeName
+}
+
 // ReconcilePod implements reconcile.Reconciler
 var _ reconcile.Reconciler = &ReconcilePod{}
 
@@ -110,9 +134,9 @@ func (w *Wrapper) Start(ctx context.Context) error {
 	return nil
 }
 
-// NeedLeaderElection need election
+// NeedLeaderElection need election, when sharding is enabled every replica reconcile its own shards
 func (w *Wrapper) NeedLeaderElection() bool {
-	return true
+	return !shard.Enabled()
 }
 
 // NewReconcilePod watch pod lifecycle events and sync to podENI resource
@@ -135,20 +159,36 @@ func NewReconcilePod(mgr manager.Manager, aliyunClient register.Interface, swPoo
 func (m *ReconcilePod) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
 	l := log.FromContext(ctx)
 	l.V(5).Info("Reconcile")
+	ctx = aliyunClient.LaneWithCtx(ctx, aliyunClient.LanePodCreate)
+	ctx = audit.WithPod(ctx, request.Namespace, request.Name)
 	start := time.Now()
 	pod := &corev1.Pod{}
 	err := m.client.Get(ctx, request.NamespacedName, pod)
-	if err != nil {
-		if k8sErr.IsNotFound(err) {
-			result, err := m.podDelete(ctx, request.NamespacedName)
-			m.recordPodDelete(pod, start, err)
-			return result, err
-		}
+	if err != nil && !k8sErr.IsNotFound(err) {
 		return reconcile.Result{}, err
 	}
+	// the request may be queued before the shard is released
+	if err == nil && !shard.Owns(podNodeName(pod)) {
+		return reconcile.Result{}, nil
+	}
+
+	// continue the trace of the daemon waiting for the podENI
+	ctx, span := telemetry.Start(telemetry.ExtractAnnotations(ctx, pod.Annotations), "controlplane/ReconcilePod",
+		telemetry.PodAttributes(request.Namespace, request.Name, "")...)
+	defer func() {
+		telemetry.End(span, err)
+	}()
+
+	if k8sErr.IsNotFound(err) {
+		var result reconcile.Result
+		result, err = m.podDelete(ctx, request.NamespacedName)
+		m.recordPodDelete(pod, start, err)
+		return result, err
+	}
 
 	if utils.PodSandboxExited(pod) {
-		result, err := m.podDelete(ctx, request.NamespacedName)
+		var result reconcile.Result
+		result, err = m.podDelete(ctx, request.NamespacedName)
 		m.recordPodDelete(pod, start, err)
 		return result, err
 	}
@@ -158,9 +198,10 @@ func (m *ReconcilePod) Reconcile(ctx context.Context, request reconcile.Request)
 		return reconcile.Result{RequeueAfter: 2 * time.Second}, nil
 	}
 
-	result, err := m.podCreate(ctx, pod)
+	var result reconcile.Result
+	result, err = m.podCreate(ctx, pod)
 	m.recordPodCreate(pod, start, err)
-	return result, err
+	return common.RequeueForCloudErr(ctx, result, err)
 }
 
 // NeedLeaderElection need election
@@ -299,6 +340,9 @@ func (m *ReconcilePod) podDelete(ctx context.Context, namespacedName client.Obje
 			return reconcile.Result{}, nil
 		}
 	}
+	if err == nil && !shard.Owns(prePodENI.Labels[types.ENIRelatedNodeName]) {
+		return reconcile.Result{}, nil
+	}
 	// already deleting
 	if prePodENI.Status.Phase == v1beta1.ENIPhaseDeleting || !prePodENI.DeletionTimestamp.IsZero() {
 		return reconcile.Result{}, nil
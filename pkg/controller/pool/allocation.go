@@ -147,6 +147,7 @@ func (a *AllocManager) ReleaseIdle() *client.NetworkInterface {
 		}
 		chosen = alloc.GetNetworkInterface()
 		alloc.SetStatus(StatusDeleting)
+		break
 	}
 	return chosen
 }
@@ -17,7 +17,10 @@ limitations under the License.
 package pool
 
 import (
+	"context"
 	"time"
+
+	"github.com/AliyunContainerService/terway/pkg/apis/network.alibabacloud.com/v1beta1"
 )
 
 // Config for eni manager
@@ -41,5 +44,17 @@ type Config struct {
 	MaxIdle int
 	MinIdle int
 
+	// WarmUpPolicy is v1beta1.WarmUpPolicyFixed or v1beta1.WarmUpPolicyChurn
+	WarmUpPolicy string
+	WarmUpWindow time.Duration
+
 	SyncPeriod time.Duration
+
+	// StatusSyncer publish the pool status, optional
+	StatusSyncer StatusSyncer
+}
+
+// StatusSyncer publish the pool status after each sync
+type StatusSyncer interface {
+	SyncStatus(ctx context.Context, status *v1beta1.NodeENIPoolStatus) error
 }
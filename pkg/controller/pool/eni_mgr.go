@@ -21,19 +21,25 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"reflect"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	aliyunClient "github.com/AliyunContainerService/terway/pkg/aliyun/client"
 	apiErr "github.com/AliyunContainerService/terway/pkg/aliyun/client/errors"
+	"github.com/AliyunContainerService/terway/pkg/apis/network.alibabacloud.com/v1beta1"
 	"github.com/AliyunContainerService/terway/pkg/backoff"
 	register "github.com/AliyunContainerService/terway/pkg/controller"
 	"github.com/AliyunContainerService/terway/pkg/controller/common"
+	"github.com/AliyunContainerService/terway/pkg/controller/shard"
 	"github.com/AliyunContainerService/terway/pkg/controller/vswitch"
 	"github.com/AliyunContainerService/terway/types"
 
 	"github.com/aliyun/alibaba-cloud-sdk-go/services/ecs"
 	"github.com/aliyun/alibaba-cloud-sdk-go/services/vpc"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -46,9 +52,14 @@ type Manager struct {
 	aliyun      register.Interface
 	vSwitchPool *vswitch.SwitchPool
 
-	cfg *Config
+	// lock protect the pool size in cfg, which can be changed by SetPoolSize
+	lock sync.Mutex
+	cfg  *Config
 
 	allocations *AllocManager
+
+	churn      *churnTracker
+	lastStatus *v1beta1.NodeENIPoolStatus
 }
 
 func NewManager(cfg *Config, previous map[string]*Allocation, vSwitchPool *vswitch.SwitchPool, aliyun register.Interface) *Manager {
@@ -61,6 +72,7 @@ func NewManager(cfg *Config, previous map[string]*Allocation, vSwitchPool *vswit
 		allocations: NewAllocManager(cfg.MaxENI, cfg.TrunkENIID != ""),
 		aliyun:      aliyun,
 	}
+	mgr.churn = newChurnTracker(cfg.WarmUpWindow, mgr.syncPeriod())
 
 	for _, alloc := range previous {
 		mgr.allocations.Add(alloc)
@@ -79,17 +91,50 @@ func (m *Manager) Run() {
 	l := ctrl.Log.WithName("eni-pool")
 	l.Info("pool manage start", "node", m.cfg.NodeName, "maxENI", m.cfg.MaxENI)
 
-	period := m.cfg.SyncPeriod
-	if period <= 0 {
-		period = 30 * time.Second
-	}
 	wait.JitterUntilWithContext(m.ctx, func(ctx context.Context) {
-		m.cleanUP(ctx)
-	}, period, 1.2, true)
+		// the shard may be released before the pool is stopped
+		if !shard.Owns(m.cfg.NodeName) {
+			return
+		}
+		m.cleanUP(aliyunClient.LaneWithCtx(ctx, aliyunClient.LanePoolWarmUp))
+	}, m.syncPeriod(), 1.2, true)
 
 	l.Info("pool manage exited", "node", m.cfg.NodeName)
 }
 
+func (m *Manager) syncPeriod() time.Duration {
+	if m.cfg.SyncPeriod <= 0 {
+		return 30 * time.Second
+	}
+	return m.cfg.SyncPeriod
+}
+
+// SetPoolSize update the pool size and warm-up policy, take effect in next sync
+func (m *Manager) SetPoolSize(minIdle, maxIdle int, policy string, window time.Duration) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.cfg.WarmUpWindow != window {
+		m.churn = newChurnTracker(window, m.syncPeriod())
+	}
+	m.cfg.MinIdle = minIdle
+	m.cfg.MaxIdle = maxIdle
+	m.cfg.WarmUpPolicy = policy
+	m.cfg.WarmUpWindow = window
+}
+
+// MaxENI is the max eni the pool can allocate
+func (m *Manager) MaxENI() int {
+	return m.cfg.MaxENI
+}
+
+// poolSize return min idle, max idle, warm-up policy and the churn tracker
+func (m *Manager) poolSize() (int, int, string, *churnTracker) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.cfg.MinIdle, m.cfg.MaxIdle, m.cfg.WarmUpPolicy, m.churn
+}
+
 func (m *Manager) CreateNetworkInterface(ctx context.Context, trunk bool, vSwitchID string, securityGroups []string, resourceGroupID string, ipCount, ipv6Count int, eniTags map[string]string) (*aliyunClient.NetworkInterface, error) {
 	realClient, play, err := common.Became(ctx, m.aliyun)
 	if err != nil {
@@ -107,6 +152,9 @@ func (m *Manager) CreateNetworkInterface(ctx context.Context, trunk bool, vSwitc
 		eniTags[types.TagENIAllocPolicy] = "pool"
 		eniTags[types.TagK8SNodeName] = m.cfg.NodeName
 
+		_, _, _, churn := m.poolSize()
+		churn.Inc()
+
 		cached := m.allocations.Alloc(vSwitchID)
 		if cached != nil {
 			// use cached res
@@ -283,6 +331,10 @@ func (m *Manager) UnAssignIpv6Addresses(ctx context.Context, eniID string, ips [
 func (m *Manager) cleanUP(ctx context.Context) {
 	l := log.FromContext(ctx).WithValues("node", m.cfg.NodeName)
 
+	minIdle, maxIdle, policy, churn := m.poolSize()
+	target := targetIdle(policy, minIdle, maxIdle, churn.Rotate())
+	defer m.syncStatus(ctx, minIdle, maxIdle, target)
+
 	var deleting []*aliyunClient.NetworkInterface
 
 	idle := 0
@@ -314,9 +366,9 @@ func (m *Manager) cleanUP(ctx context.Context) {
 	}
 
 	// del
-	if (idle - m.cfg.MaxIdle) > 0 {
-		l.Info("dispose idle", "idle", idle, "maxIdle", m.cfg.MaxIdle)
-		for i := 0; i < (idle - m.cfg.MaxIdle); i++ {
+	if (idle - maxIdle) > 0 {
+		l.Info("dispose idle", "idle", idle, "maxIdle", maxIdle)
+		for i := 0; i < (idle - maxIdle); i++ {
 			m.allocations.ReleaseIdle()
 		}
 	}
@@ -330,9 +382,9 @@ func (m *Manager) cleanUP(ctx context.Context) {
 		v6 = 1
 	}
 	// add
-	if (m.cfg.MinIdle - idle) > 0 {
-		l.Info("add idle", "idle", idle, "minIdle", m.cfg.MinIdle)
-		for i := 0; i < (m.cfg.MinIdle - idle); i++ {
+	if (target - idle) > 0 {
+		l.Info("add idle", "idle", idle, "minIdle", minIdle, "target", target)
+		for i := 0; i < (target - idle); i++ {
 			err := func() error {
 				if !m.allocations.RequireQuota() {
 					l.Info("add idle reach quota")
@@ -353,7 +405,8 @@ func (m *Manager) cleanUP(ctx context.Context) {
 				}
 
 				alloc := &Allocation{
-					AllocType: AllocPolicyPreferPool,
+					NetworkInterface: networkInterface,
+					AllocType:        AllocPolicyPreferPool,
 				}
 
 				defer func() {
@@ -378,6 +431,59 @@ func (m *Manager) cleanUP(ctx context.Context) {
 	}
 }
 
+// syncStatus publish the pool status by StatusSyncer, skipped if nothing changed
+func (m *Manager) syncStatus(ctx context.Context, minIdle, maxIdle, target int) {
+	if m.cfg.StatusSyncer == nil {
+		return
+	}
+	status := m.status(minIdle, maxIdle, target)
+	if m.lastStatus != nil && reflect.DeepEqual(m.lastStatus, status) {
+		return
+	}
+	status.UpdateAt = metav1.Now()
+	err := m.cfg.StatusSyncer.SyncStatus(ctx, status.DeepCopy())
+	if err != nil {
+		log.FromContext(ctx).Error(err, "sync pool status failed", "node", m.cfg.NodeName)
+		return
+	}
+	status.UpdateAt = metav1.Time{}
+	m.lastStatus = status
+}
+
+func (m *Manager) status(minIdle, maxIdle, target int) *v1beta1.NodeENIPoolStatus {
+	status := &v1beta1.NodeENIPoolStatus{
+		InstanceID: m.cfg.InstanceID,
+		MaxENI:     m.cfg.MaxENI,
+		MinIdle:    minIdle,
+		MaxIdle:    maxIdle,
+		TargetIdle: target,
+	}
+	m.allocations.Range(func(key string, value *Allocation) bool {
+		networkInterface := value.GetNetworkInterface()
+		st := value.GetStatus()
+		switch st {
+		case StatusIdle:
+			status.Idle++
+		case StatusInUse:
+			status.InUse++
+		case StatusDeleting:
+			status.Deleting++
+		}
+		status.ENIs = append(status.ENIs, v1beta1.PoolENI{
+			ID:        key,
+			VSwitchID: networkInterface.VSwitchID,
+			Type:      networkInterface.Type,
+			Status:    string(st),
+			FromPool:  value.AllocType == AllocPolicyPreferPool,
+		})
+		return true
+	})
+	sort.Slice(status.ENIs, func(i, j int) bool {
+		return status.ENIs[i].ID < status.ENIs[j].ID
+	})
+	return status
+}
+
 func attachNetworkInterface(ctx context.Context, api register.Interface, eniID, instanceID, trunkENIID string) (*aliyunClient.NetworkInterface, error) {
 	err := api.AttachNetworkInterface(ctx, eniID, instanceID, trunkENIID)
 	if err != nil {
@@ -0,0 +1,77 @@
+/*
+Copyright 2022 Terway Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pool
+
+import (
+	"sync"
+	"time"
+
+	"github.com/AliyunContainerService/terway/pkg/apis/network.alibabacloud.com/v1beta1"
+)
+
+// churnTracker record the pool allocation count for each sync period
+type churnTracker struct {
+	sync.Mutex
+	buckets []int
+	current int
+}
+
+// newChurnTracker create tracker keep window/period buckets
+func newChurnTracker(window, period time.Duration) *churnTracker {
+	size := 1
+	if period > 0 && window > period {
+		size = int(window / period)
+	}
+	return &churnTracker{buckets: make([]int, size)}
+}
+
+// Inc record one allocation
+func (c *churnTracker) Inc() {
+	c.Lock()
+	defer c.Unlock()
+	c.buckets[c.current]++
+}
+
+// Rotate close the current period and return the peak allocation count in the window
+func (c *churnTracker) Rotate() int {
+	c.Lock()
+	defer c.Unlock()
+
+	peak := 0
+	for _, v := range c.buckets {
+		if v > peak {
+			peak = v
+		}
+	}
+	c.current = (c.current + 1) % len(c.buckets)
+	c.buckets[c.current] = 0
+	return peak
+}
+
+// targetIdle is the idle count should be kept by the pool
+func targetIdle(policy string, minIdle, maxIdle, peak int) int {
+	if policy != v1beta1.WarmUpPolicyChurn {
+		return minIdle
+	}
+	if peak < minIdle {
+		return minIdle
+	}
+	if peak > maxIdle {
+		return maxIdle
+	}
+	return peak
+}
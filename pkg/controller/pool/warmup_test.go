@@ -0,0 +1,78 @@
+package pool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AliyunContainerService/terway/pkg/aliyun/client"
+	"github.com/AliyunContainerService/terway/pkg/apis/network.alibabacloud.com/v1beta1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChurnTracker_Rotate(t *testing.T) {
+	c := newChurnTracker(3*time.Minute, time.Minute)
+	assert.Len(t, c.buckets, 3)
+
+	c.Inc()
+	c.Inc()
+	c.Inc()
+	assert.Equal(t, 3, c.Rotate())
+
+	c.Inc()
+	assert.Equal(t, 3, c.Rotate())
+	assert.Equal(t, 3, c.Rotate())
+	// the first period is out of the window
+	assert.Equal(t, 1, c.Rotate())
+	assert.Equal(t, 0, c.Rotate())
+}
+
+func TestTargetIdle(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+		peak   int
+		want   int
+	}{
+		{name: "fixed", policy: v1beta1.WarmUpPolicyFixed, peak: 5, want: 1},
+		{name: "churn below min", policy: v1beta1.WarmUpPolicyChurn, peak: 0, want: 1},
+		{name: "churn", policy: v1beta1.WarmUpPolicyChurn, peak: 3, want: 3},
+		{name: "churn above max", policy: v1beta1.WarmUpPolicyChurn, peak: 10, want: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, targetIdle(tt.policy, 1, 5, tt.peak))
+		})
+	}
+}
+
+func TestManager_Status(t *testing.T) {
+	mgr := NewManager(&Config{MaxENI: 3, InstanceID: "i-1"}, nil, nil, nil)
+	mgr.allocations.Add(&Allocation{NetworkInterface: &client.NetworkInterface{NetworkInterfaceID: "eni-2", Type: "Secondary"}, Status: StatusIdle, AllocType: AllocPolicyPreferPool})
+	mgr.allocations.Add(&Allocation{NetworkInterface: &client.NetworkInterface{NetworkInterfaceID: "eni-1", Type: "Secondary"}, Status: StatusInUse})
+	mgr.allocations.Add(&Allocation{NetworkInterface: &client.NetworkInterface{NetworkInterfaceID: "eni-3", Type: "Secondary"}, Status: StatusDeleting, AllocType: AllocPolicyPreferPool})
+
+	status := mgr.status(1, 2, 1)
+	assert.Equal(t, "i-1", status.InstanceID)
+	assert.Equal(t, 1, status.Idle)
+	assert.Equal(t, 1, status.InUse)
+	assert.Equal(t, 1, status.Deleting)
+	assert.Equal(t, []string{"eni-1", "eni-2", "eni-3"}, []string{status.ENIs[0].ID, status.ENIs[1].ID, status.ENIs[2].ID})
+	assert.False(t, status.ENIs[0].FromPool)
+	assert.True(t, status.ENIs[1].FromPool)
+}
+
+func TestAllocManager_ReleaseIdle(t *testing.T) {
+	a := NewAllocManager(3, false)
+	a.Add(&Allocation{NetworkInterface: &client.NetworkInterface{NetworkInterfaceID: "eni-1"}, Status: StatusIdle})
+	a.Add(&Allocation{NetworkInterface: &client.NetworkInterface{NetworkInterfaceID: "eni-2"}, Status: StatusIdle})
+
+	assert.NotNil(t, a.ReleaseIdle())
+	idle := 0
+	a.Range(func(key string, value *Allocation) bool {
+		if value.GetStatus() == StatusIdle {
+			idle++
+		}
+		return true
+	})
+	assert.Equal(t, 1, idle)
+}
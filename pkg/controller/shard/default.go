@@ -0,0 +1,121 @@
+/*
+Copyright 2022 Terway Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shard
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// defaultManager is nil when sharding is disabled
+var defaultManager *Manager
+
+// SetDefault set the shard manager used by controllers
+func SetDefault(m *Manager) {
+	defaultManager = m
+}
+
+// Enabled return true if sharding is enabled
+func Enabled() bool {
+	return defaultManager != nil
+}
+
+// Owns return true if the node should be reconciled by this replica
+func Owns(nodeName string) bool {
+	if defaultManager == nil {
+		return true
+	}
+	return defaultManager.Owns(nodeName)
+}
+
+// AddHandler register handler for shard changes, no-op if sharding is disabled
+func AddHandler(h Handler) {
+	if defaultManager == nil {
+		return
+	}
+	defaultManager.AddHandler(h)
+}
+
+// NodeNameFunc return the node name the object belongs to
+type NodeNameFunc func(obj client.Object) string
+
+// Predicate filter events for objects not in the shards held by this replica
+func Predicate(nodeName NodeNameFunc) predicate.Predicate {
+	owns := func(obj client.Object) bool {
+		if obj == nil {
+			return false
+		}
+		return Owns(nodeName(obj))
+	}
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return owns(e.Object)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return owns(e.ObjectNew)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return owns(e.Object)
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return owns(e.Object)
+		},
+	}
+}
+
+// Source emit the objects in the newly acquired shards, so they are reconciled by the new holder
+func Source(c client.Reader, newList func() client.ObjectList, nodeName NodeNameFunc) source.Source {
+	ch := make(chan event.GenericEvent)
+	AddHandler(func(acquired, released []int) {
+		if len(acquired) == 0 {
+			return
+		}
+		shards := make(map[int]struct{}, len(acquired))
+		for _, shard := range acquired {
+			shards[shard] = struct{}{}
+		}
+		go func() {
+			list := newList()
+			err := c.List(context.Background(), list)
+			if err != nil {
+				log.Error(err, "list objects for acquired shards failed")
+				return
+			}
+			objs, err := meta.ExtractList(list)
+			if err != nil {
+				log.Error(err, "extract list failed")
+				return
+			}
+			for _, o := range objs {
+				obj, ok := o.(client.Object)
+				if !ok {
+					continue
+				}
+				if _, ok = shards[defaultManager.ShardOf(nodeName(obj))]; !ok {
+					continue
+				}
+				ch <- event.GenericEvent{Object: obj}
+			}
+		}()
+	})
+	return &source.Channel{Source: ch}
+}
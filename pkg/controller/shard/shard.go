@@ -0,0 +1,433 @@
+/*
+Copyright 2022 Terway Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package shard split nodes into shards, each controlplane replica hold part of the shards by leases
+// and only reconcile the resource belongs to the nodes in its shards.
+package shard
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/AliyunContainerService/terway/pkg/metric"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	k8sErr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+var log = ctrl.Log.WithName("shard")
+
+// labels for the leases
+const (
+	LabelShardGroup = "terway.alibabacloud.com/shard-group"
+	LabelShardRole  = "terway.alibabacloud.com/shard-role"
+	LabelShardIndex = "terway.alibabacloud.com/shard-index"
+
+	roleMember = "member"
+	roleShard  = "shard"
+)
+
+// Handler is called when shards held by this replica changed
+type Handler func(acquired, released []int)
+
+// Config for shard manager
+type Config struct {
+	// Name is the prefix for the leases
+	Name      string
+	Namespace string
+	// Identity is the unique id for this replica
+	Identity string
+	Shards   int
+
+	LeaseDuration time.Duration
+	// RenewDeadline is the duration shard is considered lost if lease is not renewed
+	RenewDeadline time.Duration
+	RetryPeriod   time.Duration
+}
+
+var _ manager.Runnable = &Manager{}
+
+// Manager hold the shards for this replica
+type Manager struct {
+	cfg    Config
+	client kubernetes.Interface
+
+	lock     sync.RWMutex
+	owned    map[int]time.Time // shard -> last renew time
+	members  int
+	handlers []Handler
+}
+
+// New create shard manager
+func New(cfg Config, client kubernetes.Interface) *Manager {
+	if cfg.RenewDeadline <= 0 || cfg.RenewDeadline >= cfg.LeaseDuration {
+		cfg.RenewDeadline = cfg.LeaseDuration * 2 / 3
+	}
+	if cfg.RetryPeriod <= 0 {
+		cfg.RetryPeriod = cfg.LeaseDuration / 5
+	}
+	return &Manager{
+		cfg:    cfg,
+		client: client,
+		owned:  make(map[int]time.Time),
+	}
+}
+
+// Start keep the member lease and shard leases until ctx done
+func (m *Manager) Start(ctx context.Context) error {
+	log.Info("shard manager start", "identity", m.cfg.Identity, "shards", m.cfg.Shards)
+
+	wait.UntilWithContext(ctx, m.sync, m.cfg.RetryPeriod)
+
+	m.releaseAll()
+	log.Info("shard manager exited")
+	return nil
+}
+
+// NeedLeaderElection shard manager run on every replica
+func (m *Manager) NeedLeaderElection() bool {
+	return false
+}
+
+// AddHandler register handler for shard changes
+func (m *Manager) AddHandler(h Handler) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.handlers = append(m.handlers, h)
+}
+
+// ShardOf return the shard for the node
+func (m *Manager) ShardOf(nodeName string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(nodeName))
+	return int(h.Sum32() % uint32(m.cfg.Shards))
+}
+
+// Owns return true if the node is in shards held by this replica
+func (m *Manager) Owns(nodeName string) bool {
+	shard := m.ShardOf(nodeName)
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	_, ok := m.owned[shard]
+	return ok
+}
+
+// Owned return the shards held by this replica
+func (m *Manager) Owned() []int {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	var result []int
+	for shard := range m.owned {
+		result = append(result, shard)
+	}
+	sort.Ints(result)
+	return result
+}
+
+func (m *Manager) shardLeaseName(shard int) string {
+	return fmt.Sprintf("%s-shard-%d", m.cfg.Name, shard)
+}
+
+func (m *Manager) memberLeaseName() string {
+	return fmt.Sprintf("%s-member-%s", m.cfg.Name, m.cfg.Identity)
+}
+
+func (m *Manager) sync(ctx context.Context) {
+	now := time.Now()
+
+	err := m.renewMember(ctx, now)
+	if err != nil {
+		log.Error(err, "renew member lease failed")
+	}
+
+	leases, err := m.client.CoordinationV1().Leases(m.cfg.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: LabelShardGroup + "=" + m.cfg.Name,
+	})
+	if err != nil {
+		log.Error(err, "list leases failed")
+		m.dropExpired(now)
+		return
+	}
+
+	members := 0
+	shardLeases := make(map[int]*coordinationv1.Lease)
+	for i := range leases.Items {
+		lease := &leases.Items[i]
+		switch lease.Labels[LabelShardRole] {
+		case roleMember:
+			if !expired(lease, now) {
+				members++
+			}
+		case roleShard:
+			shard, err := strconv.Atoi(lease.Labels[LabelShardIndex])
+			if err != nil || shard >= m.cfg.Shards {
+				continue
+			}
+			shardLeases[shard] = lease
+		}
+	}
+	if members == 0 {
+		members = 1
+	}
+	fair := (m.cfg.Shards + members - 1) / members
+
+	m.lock.Lock()
+	m.members = members
+	m.lock.Unlock()
+
+	// 1. release the shards beyond fair share, release before others can acquire it
+	owned := m.Owned()
+	var released []int
+	for len(owned) > fair {
+		shard := owned[len(owned)-1]
+		owned = owned[:len(owned)-1]
+
+		m.drop(shard)
+		released = append(released, shard)
+		if lease, ok := shardLeases[shard]; ok {
+			err = m.release(ctx, lease)
+			if err != nil {
+				log.Error(err, "release shard failed", "shard", shard)
+			}
+		}
+	}
+
+	// 2. renew held shards
+	for _, shard := range owned {
+		lease, ok := shardLeases[shard]
+		if !ok || lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != m.cfg.Identity {
+			// taken by others
+			m.drop(shard)
+			released = append(released, shard)
+			continue
+		}
+		err = m.renew(ctx, lease, now)
+		if err != nil {
+			log.Error(err, "renew shard failed", "shard", shard)
+			continue
+		}
+		m.hold(shard, now)
+	}
+	released = append(released, m.dropExpired(now)...)
+
+	// 3. acquire free shards up to fair share
+	var acquired []int
+	for shard := 0; shard < m.cfg.Shards && len(m.Owned()) < fair; shard++ {
+		if _, ok := m.ownedAt(shard); ok {
+			continue
+		}
+		lease, ok := shardLeases[shard]
+		if ok && !expired(lease, now) && lease.Spec.HolderIdentity != nil &&
+			*lease.Spec.HolderIdentity != "" && *lease.Spec.HolderIdentity != m.cfg.Identity {
+			continue
+		}
+		err = m.acquire(ctx, shard, lease, now)
+		if err != nil {
+			log.V(4).Info("acquire shard failed", "shard", shard, "err", err.Error())
+			continue
+		}
+		m.hold(shard, now)
+		acquired = append(acquired, shard)
+	}
+
+	m.updateMetrics()
+	if len(acquired) > 0 || len(released) > 0 {
+		log.Info("shards changed", "acquired", acquired, "released", released, "owned", m.Owned(), "members", members)
+		m.notify(acquired, released)
+	}
+}
+
+func (m *Manager) renewMember(ctx context.Context, now time.Time) error {
+	name := m.memberLeaseName()
+	leases := m.client.CoordinationV1().Leases(m.cfg.Namespace)
+	lease, err := leases.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if !k8sErr.IsNotFound(err) {
+			return err
+		}
+		lease = m.newLease(name, roleMember, -1, now)
+		_, err = leases.Create(ctx, lease, metav1.CreateOptions{})
+		return err
+	}
+	setHolder(lease, m.cfg.Identity, m.cfg.LeaseDuration, now, false)
+	_, err = leases.Update(ctx, lease, metav1.UpdateOptions{})
+	return err
+}
+
+func (m *Manager) acquire(ctx context.Context, shard int, lease *coordinationv1.Lease, now time.Time) error {
+	leases := m.client.CoordinationV1().Leases(m.cfg.Namespace)
+	if lease == nil {
+		_, err := leases.Create(ctx, m.newLease(m.shardLeaseName(shard), roleShard, shard, now), metav1.CreateOptions{})
+		return err
+	}
+	update := lease.DeepCopy()
+	setHolder(update, m.cfg.Identity, m.cfg.LeaseDuration, now, true)
+	// resourceVersion is kept, so only one replica can win
+	_, err := leases.Update(ctx, update, metav1.UpdateOptions{})
+	return err
+}
+
+func (m *Manager) renew(ctx context.Context, lease *coordinationv1.Lease, now time.Time) error {
+	update := lease.DeepCopy()
+	setHolder(update, m.cfg.Identity, m.cfg.LeaseDuration, now, false)
+	_, err := m.client.CoordinationV1().Leases(m.cfg.Namespace).Update(ctx, update, metav1.UpdateOptions{})
+	return err
+}
+
+func (m *Manager) release(ctx context.Context, lease *coordinationv1.Lease) error {
+	update := lease.DeepCopy()
+	empty := ""
+	update.Spec.HolderIdentity = &empty
+	_, err := m.client.CoordinationV1().Leases(m.cfg.Namespace).Update(ctx, update, metav1.UpdateOptions{})
+	return err
+}
+
+// releaseAll give up all shards so other replicas can take over without waiting for expire
+func (m *Manager) releaseAll() {
+	ctx, cancel := context.WithTimeout(context.Background(), m.cfg.RetryPeriod)
+	defer cancel()
+
+	released := m.Owned()
+	for _, shard := range released {
+		m.drop(shard)
+		lease, err := m.client.CoordinationV1().Leases(m.cfg.Namespace).Get(ctx, m.shardLeaseName(shard), metav1.GetOptions{})
+		if err != nil {
+			continue
+		}
+		if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != m.cfg.Identity {
+			continue
+		}
+		err = m.release(ctx, lease)
+		if err != nil {
+			log.Error(err, "release shard failed", "shard", shard)
+		}
+	}
+	m.updateMetrics()
+	if len(released) > 0 {
+		m.notify(nil, released)
+	}
+}
+
+func (m *Manager) newLease(name, role string, shard int, now time.Time) *coordinationv1.Lease {
+	lease := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: m.cfg.Namespace,
+			Labels: map[string]string{
+				LabelShardGroup: m.cfg.Name,
+				LabelShardRole:  role,
+			},
+		},
+	}
+	if shard >= 0 {
+		lease.Labels[LabelShardIndex] = strconv.Itoa(shard)
+	}
+	setHolder(lease, m.cfg.Identity, m.cfg.LeaseDuration, now, true)
+	return lease
+}
+
+func (m *Manager) hold(shard int, now time.Time) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.owned[shard] = now
+}
+
+func (m *Manager) drop(shard int) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.owned, shard)
+}
+
+func (m *Manager) ownedAt(shard int) (time.Time, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	t, ok := m.owned[shard]
+	return t, ok
+}
+
+// dropExpired drop the shards not renewed in RenewDeadline
+func (m *Manager) dropExpired(now time.Time) []int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	var released []int
+	for shard, renewAt := range m.owned {
+		if now.Sub(renewAt) > m.cfg.RenewDeadline {
+			delete(m.owned, shard)
+			released = append(released, shard)
+		}
+	}
+	return released
+}
+
+func (m *Manager) notify(acquired, released []int) {
+	m.lock.RLock()
+	handlers := append([]Handler{}, m.handlers...)
+	m.lock.RUnlock()
+
+	for _, h := range handlers {
+		h(acquired, released)
+	}
+}
+
+func (m *Manager) updateMetrics() {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	metric.ShardMembers.Set(float64(m.members))
+	for shard := 0; shard < m.cfg.Shards; shard++ {
+		v := 0.0
+		if _, ok := m.owned[shard]; ok {
+			v = 1
+		}
+		metric.ShardOwned.WithLabelValues(strconv.Itoa(shard)).Set(v)
+	}
+}
+
+func setHolder(lease *coordinationv1.Lease, identity string, duration time.Duration, now time.Time, acquire bool) {
+	seconds := int32(duration.Seconds())
+	renew := metav1.NewMicroTime(now)
+	if acquire && (lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != identity) {
+		lease.Spec.AcquireTime = &renew
+		transitions := int32(0)
+		if lease.Spec.LeaseTransitions != nil {
+			transitions = *lease.Spec.LeaseTransitions + 1
+		}
+		lease.Spec.LeaseTransitions = &transitions
+	}
+	lease.Spec.HolderIdentity = &identity
+	lease.Spec.LeaseDurationSeconds = &seconds
+	lease.Spec.RenewTime = &renew
+}
+
+func expired(lease *coordinationv1.Lease, now time.Time) bool {
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	return lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second).Before(now)
+}
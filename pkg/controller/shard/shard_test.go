@@ -0,0 +1,72 @@
+package shard
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestManager(client *fake.Clientset, identity string) *Manager {
+	return New(Config{
+		Name:          "terway-controlplane",
+		Namespace:     "kube-system",
+		Identity:      identity,
+		Shards:        4,
+		LeaseDuration: time.Minute,
+	}, client)
+}
+
+func TestManager_Rebalance(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	ctx := context.Background()
+
+	m1 := newTestManager(client, "replica-1")
+	var acquired1, released1 []int
+	m1.AddHandler(func(acquired, released []int) {
+		acquired1 = append(acquired1, acquired...)
+		released1 = append(released1, released...)
+	})
+	m1.sync(ctx)
+	assert.Equal(t, []int{0, 1, 2, 3}, m1.Owned())
+	assert.Equal(t, []int{0, 1, 2, 3}, acquired1)
+
+	// new replica join, all shards are held by replica-1
+	m2 := newTestManager(client, "replica-2")
+	m2.sync(ctx)
+	assert.Empty(t, m2.Owned())
+
+	// replica-1 give up shards beyond fair share
+	m1.sync(ctx)
+	assert.Equal(t, []int{0, 1}, m1.Owned())
+	assert.Equal(t, []int{3, 2}, released1)
+
+	m2.sync(ctx)
+	assert.Equal(t, []int{2, 3}, m2.Owned())
+
+	// stable
+	m1.sync(ctx)
+	m2.sync(ctx)
+	assert.Equal(t, []int{0, 1}, m1.Owned())
+	assert.Equal(t, []int{2, 3}, m2.Owned())
+
+	// replica-2 exit
+	m2.releaseAll()
+	assert.Empty(t, m2.Owned())
+}
+
+func TestManager_Owns(t *testing.T) {
+	m := newTestManager(fake.NewSimpleClientset(), "replica-1")
+	shard := m.ShardOf("node-1")
+	assert.Equal(t, shard, m.ShardOf("node-1"))
+	assert.False(t, m.Owns("node-1"))
+
+	m.hold(shard, time.Now())
+	assert.True(t, m.Owns("node-1"))
+
+	// not renewed in time
+	assert.Equal(t, []int{shard}, m.dropExpired(time.Now().Add(time.Minute)))
+	assert.False(t, m.Owns("node-1"))
+}
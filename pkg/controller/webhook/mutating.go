@@ -120,6 +120,9 @@ func podWebhook(ctx context.Context, req *webhook.AdmissionRequest, client clien
 		}
 		if podNetworking == nil {
 			if !types.PodUseENI(pod) && controlplane.GetConfig().IPAMType != types.IPAMTypeCRD {
+				if controlplane.GetConfig().EnableENIIPResource {
+					return eniIPWebhook(original, pod, controlplane.GetConfig().ENIIPResourceNodeSelector)
+				}
 				l.V(5).Info("no selector is matched or CRD is not ready")
 				return webhook.Allowed("not match")
 			}
@@ -222,6 +225,32 @@ func podWebhook(ctx context.Context, req *webhook.AdmissionRequest, client clien
 	return webhook.Patched("ok", patches...)
 }
 
+// eniIPWebhook request one aliyun/eni-ip for the pod using the shared eni ip,
+// so the pod is only scheduled to the node able to give out an ip.
+// Only the pods selecting the nodes by eniIPResourceNodeSelector are injected, as the request is not
+// satisfiable on the nodes not advertising aliyun/eni-ip
+func eniIPWebhook(original []byte, pod *corev1.Pod, nodeSelector map[string]string) webhook.AdmissionResponse {
+	if len(nodeSelector) == 0 || !labels.SelectorFromSet(nodeSelector).Matches(labels.Set(pod.Spec.NodeSelector)) {
+		return webhook.Allowed("node selector not match")
+	}
+	for _, c := range pod.Spec.Containers {
+		if _, ok := c.Resources.Requests[deviceplugin.ENIIPResName]; ok {
+			return webhook.Allowed("eni ip is requested")
+		}
+	}
+	setResourceRequest(pod, deviceplugin.ENIIPResName, 1)
+
+	podPatched, err := json.Marshal(pod)
+	if err != nil {
+		return webhook.Errored(1, err)
+	}
+	patches, err := jsonpatch.CreatePatch(original, podPatched)
+	if err != nil {
+		return webhook.Errored(1, err)
+	}
+	return webhook.Patched("ok", patches...)
+}
+
 func podNetworkingWebhook(ctx context.Context, req webhook.AdmissionRequest, client client.Client) webhook.AdmissionResponse {
 	original := req.Object.Raw
 	podNetworking := &v1beta1.PodNetworking{}
@@ -1,10 +1,13 @@
 package webhook
 
 import (
+	"encoding/json"
 	"reflect"
 	"strconv"
 	"testing"
 
+	"github.com/AliyunContainerService/terway/deviceplugin"
+
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -214,3 +217,39 @@ func Test_setNodeAffinityByZones(t *testing.T) {
 		})
 	}
 }
+
+func Test_eniIPWebhook(t *testing.T) {
+	nodeSelector := map[string]string{"eni-ip": "true"}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "a"}}},
+	}
+	original, _ := json.Marshal(pod)
+
+	// pod may land on the nodes not advertising the resource
+	resp := eniIPWebhook(original, pod, nodeSelector)
+	if !resp.Allowed || len(resp.Patches) != 0 {
+		t.Fatalf("eniIPWebhook() = %v, want no patch for pod not selecting the nodes", resp)
+	}
+	resp = eniIPWebhook(original, pod, nil)
+	if !resp.Allowed || len(resp.Patches) != 0 {
+		t.Fatalf("eniIPWebhook() = %v, want no patch without node selector", resp)
+	}
+
+	pod.Spec.NodeSelector = map[string]string{"eni-ip": "true", "zone": "a"}
+	original, _ = json.Marshal(pod)
+	resp = eniIPWebhook(original, pod, nodeSelector)
+	if !resp.Allowed || len(resp.Patches) != 2 {
+		t.Fatalf("eniIPWebhook() = %v, want patches for limits and requests", resp)
+	}
+	if q := pod.Spec.Containers[0].Resources.Limits[deviceplugin.ENIIPResName]; q.Value() != 1 {
+		t.Errorf("eniIPWebhook() limit = %v, want 1", q.String())
+	}
+
+	// the request set by user is kept
+	original, _ = json.Marshal(pod)
+	resp = eniIPWebhook(original, pod, nodeSelector)
+	if !resp.Allowed || len(resp.Patches) != 0 {
+		t.Errorf("eniIPWebhook() = %v, want no patch", resp)
+	}
+}
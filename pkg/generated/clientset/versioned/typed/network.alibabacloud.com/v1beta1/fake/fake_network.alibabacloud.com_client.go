@@ -27,6 +27,18 @@ type FakeNetworkV1beta1 struct {
 	*testing.Fake
 }
 
+func (c *FakeNetworkV1beta1) NodeENIPools() v1beta1.NodeENIPoolInterface {
+	return &FakeNodeENIPools{c}
+}
+
+func (c *FakeNetworkV1beta1) NodeNetworkStatuses() v1beta1.NodeNetworkStatusInterface {
+	return &FakeNodeNetworkStatuses{c}
+}
+
+func (c *FakeNetworkV1beta1) PodEIPs(namespace string) v1beta1.PodEIPInterface {
+	return &FakePodEIPs{c, namespace}
+}
+
 func (c *FakeNetworkV1beta1) PodENIs(namespace string) v1beta1.PodENIInterface {
 	return &FakePodENIs{c, namespace}
 }
@@ -0,0 +1,132 @@
+/*
+Copyright 2021 Terway Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	v1beta1 "github.com/AliyunContainerService/terway/pkg/apis/network.alibabacloud.com/v1beta1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeNodeENIPools implements NodeENIPoolInterface
+type FakeNodeENIPools struct {
+	Fake *FakeNetworkV1beta1
+}
+
+var nodeenipoolsResource = schema.GroupVersionResource{Group: "network.alibabacloud.com", Version: "v1beta1", Resource: "nodeenipools"}
+
+var nodeenipoolsKind = schema.GroupVersionKind{Group: "network.alibabacloud.com", Version: "v1beta1", Kind: "NodeENIPool"}
+
+// Get takes name of the nodeENIPool, and returns the corresponding nodeENIPool object, and an error if there is any.
+func (c *FakeNodeENIPools) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1beta1.NodeENIPool, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootGetAction(nodeenipoolsResource, name), &v1beta1.NodeENIPool{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.NodeENIPool), err
+}
+
+// List takes label and field selectors, and returns the list of NodeENIPools that match those selectors.
+func (c *FakeNodeENIPools) List(ctx context.Context, opts v1.ListOptions) (result *v1beta1.NodeENIPoolList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootListAction(nodeenipoolsResource, nodeenipoolsKind, opts), &v1beta1.NodeENIPoolList{})
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1beta1.NodeENIPoolList{ListMeta: obj.(*v1beta1.NodeENIPoolList).ListMeta}
+	for _, item := range obj.(*v1beta1.NodeENIPoolList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested nodeENIPools.
+func (c *FakeNodeENIPools) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewRootWatchAction(nodeenipoolsResource, opts))
+}
+
+// Create takes the representation of a nodeENIPool and creates it.  Returns the server's representation of the nodeENIPool, and an error, if there is any.
+func (c *FakeNodeENIPools) Create(ctx context.Context, nodeENIPool *v1beta1.NodeENIPool, opts v1.CreateOptions) (result *v1beta1.NodeENIPool, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootCreateAction(nodeenipoolsResource, nodeENIPool), &v1beta1.NodeENIPool{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.NodeENIPool), err
+}
+
+// Update takes the representation of a nodeENIPool and updates it. Returns the server's representation of the nodeENIPool, and an error, if there is any.
+func (c *FakeNodeENIPools) Update(ctx context.Context, nodeENIPool *v1beta1.NodeENIPool, opts v1.UpdateOptions) (result *v1beta1.NodeENIPool, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateAction(nodeenipoolsResource, nodeENIPool), &v1beta1.NodeENIPool{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.NodeENIPool), err
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeNodeENIPools) UpdateStatus(ctx context.Context, nodeENIPool *v1beta1.NodeENIPool, opts v1.UpdateOptions) (*v1beta1.NodeENIPool, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateSubresourceAction(nodeenipoolsResource, "status", nodeENIPool), &v1beta1.NodeENIPool{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.NodeENIPool), err
+}
+
+// Delete takes name of the nodeENIPool and deletes it. Returns an error if one occurs.
+func (c *FakeNodeENIPools) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewRootDeleteActionWithOptions(nodeenipoolsResource, name, opts), &v1beta1.NodeENIPool{})
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeNodeENIPools) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewRootDeleteCollectionAction(nodeenipoolsResource, listOpts)
+
+	_, err := c.Fake.Invokes(action, &v1beta1.NodeENIPoolList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched nodeENIPool.
+func (c *FakeNodeENIPools) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1beta1.NodeENIPool, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootPatchSubresourceAction(nodeenipoolsResource, name, pt, data, subresources...), &v1beta1.NodeENIPool{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.NodeENIPool), err
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2021 Terway Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	v1beta1 "github.com/AliyunContainerService/terway/pkg/apis/network.alibabacloud.com/v1beta1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeNodeNetworkStatuses implements NodeNetworkStatusInterface
+type FakeNodeNetworkStatuses struct {
+	Fake *FakeNetworkV1beta1
+}
+
+var nodenetworkstatusesResource = schema.GroupVersionResource{Group: "network.alibabacloud.com", Version: "v1beta1", Resource: "nodenetworkstatuses"}
+
+var nodenetworkstatusesKind = schema.GroupVersionKind{Group: "network.alibabacloud.com", Version: "v1beta1", Kind: "NodeNetworkStatus"}
+
+// Get takes name of the nodeNetworkStatus, and returns the corresponding nodeNetworkStatus object, and an error if there is any.
+func (c *FakeNodeNetworkStatuses) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1beta1.NodeNetworkStatus, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootGetAction(nodenetworkstatusesResource, name), &v1beta1.NodeNetworkStatus{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.NodeNetworkStatus), err
+}
+
+// List takes label and field selectors, and returns the list of NodeNetworkStatuses that match those selectors.
+func (c *FakeNodeNetworkStatuses) List(ctx context.Context, opts v1.ListOptions) (result *v1beta1.NodeNetworkStatusList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootListAction(nodenetworkstatusesResource, nodenetworkstatusesKind, opts), &v1beta1.NodeNetworkStatusList{})
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1beta1.NodeNetworkStatusList{ListMeta: obj.(*v1beta1.NodeNetworkStatusList).ListMeta}
+	for _, item := range obj.(*v1beta1.NodeNetworkStatusList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested nodeNetworkStatuses.
+func (c *FakeNodeNetworkStatuses) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewRootWatchAction(nodenetworkstatusesResource, opts))
+}
+
+// Create takes the representation of a nodeNetworkStatus and creates it.  Returns the server's representation of the nodeNetworkStatus, and an error, if there is any.
+func (c *FakeNodeNetworkStatuses) Create(ctx context.Context, nodeNetworkStatus *v1beta1.NodeNetworkStatus, opts v1.CreateOptions) (result *v1beta1.NodeNetworkStatus, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootCreateAction(nodenetworkstatusesResource, nodeNetworkStatus), &v1beta1.NodeNetworkStatus{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.NodeNetworkStatus), err
+}
+
+// Update takes the representation of a nodeNetworkStatus and updates it. Returns the server's representation of the nodeNetworkStatus, and an error, if there is any.
+func (c *FakeNodeNetworkStatuses) Update(ctx context.Context, nodeNetworkStatus *v1beta1.NodeNetworkStatus, opts v1.UpdateOptions) (result *v1beta1.NodeNetworkStatus, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateAction(nodenetworkstatusesResource, nodeNetworkStatus), &v1beta1.NodeNetworkStatus{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.NodeNetworkStatus), err
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeNodeNetworkStatuses) UpdateStatus(ctx context.Context, nodeNetworkStatus *v1beta1.NodeNetworkStatus, opts v1.UpdateOptions) (*v1beta1.NodeNetworkStatus, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateSubresourceAction(nodenetworkstatusesResource, "status", nodeNetworkStatus), &v1beta1.NodeNetworkStatus{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.NodeNetworkStatus), err
+}
+
+// Delete takes name of the nodeNetworkStatus and deletes it. Returns an error if one occurs.
+func (c *FakeNodeNetworkStatuses) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewRootDeleteActionWithOptions(nodenetworkstatusesResource, name, opts), &v1beta1.NodeNetworkStatus{})
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeNodeNetworkStatuses) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewRootDeleteCollectionAction(nodenetworkstatusesResource, listOpts)
+
+	_, err := c.Fake.Invokes(action, &v1beta1.NodeNetworkStatusList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched nodeNetworkStatus.
+func (c *FakeNodeNetworkStatuses) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1beta1.NodeNetworkStatus, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootPatchSubresourceAction(nodenetworkstatusesResource, name, pt, data, subresources...), &v1beta1.NodeNetworkStatus{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.NodeNetworkStatus), err
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2021 Terway Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	v1beta1 "github.com/AliyunContainerService/terway/pkg/apis/network.alibabacloud.com/v1beta1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakePodEIPs implements PodEIPInterface
+type FakePodEIPs struct {
+	Fake *FakeNetworkV1beta1
+	ns   string
+}
+
+var podeipsResource = schema.GroupVersionResource{Group: "network.alibabacloud.com", Version: "v1beta1", Resource: "podeips"}
+
+var podeipsKind = schema.GroupVersionKind{Group: "network.alibabacloud.com", Version: "v1beta1", Kind: "PodEIP"}
+
+// Get takes name of the podEIP, and returns the corresponding podEIP object, and an error if there is any.
+func (c *FakePodEIPs) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1beta1.PodEIP, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(podeipsResource, c.ns, name), &v1beta1.PodEIP{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.PodEIP), err
+}
+
+// List takes label and field selectors, and returns the list of PodEIPs that match those selectors.
+func (c *FakePodEIPs) List(ctx context.Context, opts v1.ListOptions) (result *v1beta1.PodEIPList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(podeipsResource, podeipsKind, c.ns, opts), &v1beta1.PodEIPList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1beta1.PodEIPList{ListMeta: obj.(*v1beta1.PodEIPList).ListMeta}
+	for _, item := range obj.(*v1beta1.PodEIPList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested podEIPs.
+func (c *FakePodEIPs) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(podeipsResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a podEIP and creates it.  Returns the server's representation of the podEIP, and an error, if there is any.
+func (c *FakePodEIPs) Create(ctx context.Context, podEIP *v1beta1.PodEIP, opts v1.CreateOptions) (result *v1beta1.PodEIP, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(podeipsResource, c.ns, podEIP), &v1beta1.PodEIP{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.PodEIP), err
+}
+
+// Update takes the representation of a podEIP and updates it. Returns the server's representation of the podEIP, and an error, if there is any.
+func (c *FakePodEIPs) Update(ctx context.Context, podEIP *v1beta1.PodEIP, opts v1.UpdateOptions) (result *v1beta1.PodEIP, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(podeipsResource, c.ns, podEIP), &v1beta1.PodEIP{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.PodEIP), err
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakePodEIPs) UpdateStatus(ctx context.Context, podEIP *v1beta1.PodEIP, opts v1.UpdateOptions) (*v1beta1.PodEIP, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(podeipsResource, "status", c.ns, podEIP), &v1beta1.PodEIP{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.PodEIP), err
+}
+
+// Delete takes name of the podEIP and deletes it. Returns an error if one occurs.
+func (c *FakePodEIPs) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteActionWithOptions(podeipsResource, c.ns, name, opts), &v1beta1.PodEIP{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakePodEIPs) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(podeipsResource, c.ns, listOpts)
+
+	_, err := c.Fake.Invokes(action, &v1beta1.PodEIPList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched podEIP.
+func (c *FakePodEIPs) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1beta1.PodEIP, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(podeipsResource, c.ns, name, pt, data, subresources...), &v1beta1.PodEIP{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1beta1.PodEIP), err
+}
@@ -17,6 +17,12 @@ limitations under the License.
 
 package v1beta1
 
+type NodeENIPoolExpansion interface{}
+
+type NodeNetworkStatusExpansion interface{}
+
+type PodEIPExpansion interface{}
+
 type PodENIExpansion interface{}
 
 type PodNetworkingExpansion interface{}
@@ -27,6 +27,9 @@ import (
 
 type NetworkV1beta1Interface interface {
 	RESTClient() rest.Interface
+	NodeENIPoolsGetter
+	NodeNetworkStatusesGetter
+	PodEIPsGetter
 	PodENIsGetter
 	PodNetworkingsGetter
 }
@@ -36,6 +39,18 @@ type NetworkV1beta1Client struct {
 	restClient rest.Interface
 }
 
+func (c *NetworkV1beta1Client) NodeENIPools() NodeENIPoolInterface {
+	return newNodeENIPools(c)
+}
+
+func (c *NetworkV1beta1Client) NodeNetworkStatuses() NodeNetworkStatusInterface {
+	return newNodeNetworkStatuses(c)
+}
+
+func (c *NetworkV1beta1Client) PodEIPs(namespace string) PodEIPInterface {
+	return newPodEIPs(c, namespace)
+}
+
 func (c *NetworkV1beta1Client) PodENIs(namespace string) PodENIInterface {
 	return newPodENIs(c, namespace)
 }
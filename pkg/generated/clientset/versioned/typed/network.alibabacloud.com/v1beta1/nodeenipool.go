@@ -0,0 +1,183 @@
+/*
+Copyright 2021 Terway Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	"context"
+	"time"
+
+	v1beta1 "github.com/AliyunContainerService/terway/pkg/apis/network.alibabacloud.com/v1beta1"
+	scheme "github.com/AliyunContainerService/terway/pkg/generated/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// NodeENIPoolsGetter has a method to return a NodeENIPoolInterface.
+// A group's client should implement this interface.
+type NodeENIPoolsGetter interface {
+	NodeENIPools() NodeENIPoolInterface
+}
+
+// NodeENIPoolInterface has methods to work with NodeENIPool resources.
+type NodeENIPoolInterface interface {
+	Create(ctx context.Context, nodeENIPool *v1beta1.NodeENIPool, opts v1.CreateOptions) (*v1beta1.NodeENIPool, error)
+	Update(ctx context.Context, nodeENIPool *v1beta1.NodeENIPool, opts v1.UpdateOptions) (*v1beta1.NodeENIPool, error)
+	UpdateStatus(ctx context.Context, nodeENIPool *v1beta1.NodeENIPool, opts v1.UpdateOptions) (*v1beta1.NodeENIPool, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*v1beta1.NodeENIPool, error)
+	List(ctx context.Context, opts v1.ListOptions) (*v1beta1.NodeENIPoolList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1beta1.NodeENIPool, err error)
+	NodeENIPoolExpansion
+}
+
+// nodeENIPools implements NodeENIPoolInterface
+type nodeENIPools struct {
+	client rest.Interface
+}
+
+// newNodeENIPools returns a NodeENIPools
+func newNodeENIPools(c *NetworkV1beta1Client) *nodeENIPools {
+	return &nodeENIPools{
+		client: c.RESTClient(),
+	}
+}
+
+// Get takes name of the nodeENIPool, and returns the corresponding nodeENIPool object, and an error if there is any.
+func (c *nodeENIPools) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1beta1.NodeENIPool, err error) {
+	result = &v1beta1.NodeENIPool{}
+	err = c.client.Get().
+		Resource("nodeenipools").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of NodeENIPools that match those selectors.
+func (c *nodeENIPools) List(ctx context.Context, opts v1.ListOptions) (result *v1beta1.NodeENIPoolList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1beta1.NodeENIPoolList{}
+	err = c.client.Get().
+		Resource("nodeenipools").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested nodeENIPools.
+func (c *nodeENIPools) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Resource("nodeenipools").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a nodeENIPool and creates it.  Returns the server's representation of the nodeENIPool, and an error, if there is any.
+func (c *nodeENIPools) Create(ctx context.Context, nodeENIPool *v1beta1.NodeENIPool, opts v1.CreateOptions) (result *v1beta1.NodeENIPool, err error) {
+	result = &v1beta1.NodeENIPool{}
+	err = c.client.Post().
+		Resource("nodeenipools").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(nodeENIPool).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a nodeENIPool and updates it. Returns the server's representation of the nodeENIPool, and an error, if there is any.
+func (c *nodeENIPools) Update(ctx context.Context, nodeENIPool *v1beta1.NodeENIPool, opts v1.UpdateOptions) (result *v1beta1.NodeENIPool, err error) {
+	result = &v1beta1.NodeENIPool{}
+	err = c.client.Put().
+		Resource("nodeenipools").
+		Name(nodeENIPool.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(nodeENIPool).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *nodeENIPools) UpdateStatus(ctx context.Context, nodeENIPool *v1beta1.NodeENIPool, opts v1.UpdateOptions) (result *v1beta1.NodeENIPool, err error) {
+	result = &v1beta1.NodeENIPool{}
+	err = c.client.Put().
+		Resource("nodeenipools").
+		Name(nodeENIPool.Name).
+		SubResource("status").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(nodeENIPool).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the nodeENIPool and deletes it. Returns an error if one occurs.
+func (c *nodeENIPools) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	return c.client.Delete().
+		Resource("nodeenipools").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *nodeENIPools) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Resource("nodeenipools").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched nodeENIPool.
+func (c *nodeENIPools) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1beta1.NodeENIPool, err error) {
+	result = &v1beta1.NodeENIPool{}
+	err = c.client.Patch(pt).
+		Resource("nodeenipools").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2021 Terway Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	"context"
+	"time"
+
+	v1beta1 "github.com/AliyunContainerService/terway/pkg/apis/network.alibabacloud.com/v1beta1"
+	scheme "github.com/AliyunContainerService/terway/pkg/generated/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// NodeNetworkStatusesGetter has a method to return a NodeNetworkStatusInterface.
+// A group's client should implement this interface.
+type NodeNetworkStatusesGetter interface {
+	NodeNetworkStatuses() NodeNetworkStatusInterface
+}
+
+// NodeNetworkStatusInterface has methods to work with NodeNetworkStatus resources.
+type NodeNetworkStatusInterface interface {
+	Create(ctx context.Context, nodeNetworkStatus *v1beta1.NodeNetworkStatus, opts v1.CreateOptions) (*v1beta1.NodeNetworkStatus, error)
+	Update(ctx context.Context, nodeNetworkStatus *v1beta1.NodeNetworkStatus, opts v1.UpdateOptions) (*v1beta1.NodeNetworkStatus, error)
+	UpdateStatus(ctx context.Context, nodeNetworkStatus *v1beta1.NodeNetworkStatus, opts v1.UpdateOptions) (*v1beta1.NodeNetworkStatus, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*v1beta1.NodeNetworkStatus, error)
+	List(ctx context.Context, opts v1.ListOptions) (*v1beta1.NodeNetworkStatusList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1beta1.NodeNetworkStatus, err error)
+	NodeNetworkStatusExpansion
+}
+
+// nodeNetworkStatuses implements NodeNetworkStatusInterface
+type nodeNetworkStatuses struct {
+	client rest.Interface
+}
+
+// newNodeNetworkStatuses returns a NodeNetworkStatuses
+func newNodeNetworkStatuses(c *NetworkV1beta1Client) *nodeNetworkStatuses {
+	return &nodeNetworkStatuses{
+		client: c.RESTClient(),
+	}
+}
+
+// Get takes name of the nodeNetworkStatus, and returns the corresponding nodeNetworkStatus object, and an error if there is any.
+func (c *nodeNetworkStatuses) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1beta1.NodeNetworkStatus, err error) {
+	result = &v1beta1.NodeNetworkStatus{}
+	err = c.client.Get().
+		Resource("nodenetworkstatuses").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of NodeNetworkStatuses that match those selectors.
+func (c *nodeNetworkStatuses) List(ctx context.Context, opts v1.ListOptions) (result *v1beta1.NodeNetworkStatusList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1beta1.NodeNetworkStatusList{}
+	err = c.client.Get().
+		Resource("nodenetworkstatuses").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested nodeNetworkStatuses.
+func (c *nodeNetworkStatuses) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Resource("nodenetworkstatuses").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a nodeNetworkStatus and creates it.  Returns the server's representation of the nodeNetworkStatus, and an error, if there is any.
+func (c *nodeNetworkStatuses) Create(ctx context.Context, nodeNetworkStatus *v1beta1.NodeNetworkStatus, opts v1.CreateOptions) (result *v1beta1.NodeNetworkStatus, err error) {
+	result = &v1beta1.NodeNetworkStatus{}
+	err = c.client.Post().
+		Resource("nodenetworkstatuses").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(nodeNetworkStatus).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a nodeNetworkStatus and updates it. Returns the server's representation of the nodeNetworkStatus, and an error, if there is any.
+func (c *nodeNetworkStatuses) Update(ctx context.Context, nodeNetworkStatus *v1beta1.NodeNetworkStatus, opts v1.UpdateOptions) (result *v1beta1.NodeNetworkStatus, err error) {
+	result = &v1beta1.NodeNetworkStatus{}
+	err = c.client.Put().
+		Resource("nodenetworkstatuses").
+		Name(nodeNetworkStatus.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(nodeNetworkStatus).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *nodeNetworkStatuses) UpdateStatus(ctx context.Context, nodeNetworkStatus *v1beta1.NodeNetworkStatus, opts v1.UpdateOptions) (result *v1beta1.NodeNetworkStatus, err error) {
+	result = &v1beta1.NodeNetworkStatus{}
+	err = c.client.Put().
+		Resource("nodenetworkstatuses").
+		Name(nodeNetworkStatus.Name).
+		SubResource("status").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(nodeNetworkStatus).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the nodeNetworkStatus and deletes it. Returns an error if one occurs.
+func (c *nodeNetworkStatuses) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	return c.client.Delete().
+		Resource("nodenetworkstatuses").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *nodeNetworkStatuses) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Resource("nodenetworkstatuses").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched nodeNetworkStatus.
+func (c *nodeNetworkStatuses) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1beta1.NodeNetworkStatus, err error) {
+	result = &v1beta1.NodeNetworkStatus{}
+	err = c.client.Patch(pt).
+		Resource("nodenetworkstatuses").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2021 Terway Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	"context"
+	"time"
+
+	v1beta1 "github.com/AliyunContainerService/terway/pkg/apis/network.alibabacloud.com/v1beta1"
+	scheme "github.com/AliyunContainerService/terway/pkg/generated/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// PodEIPsGetter has a method to return a PodEIPInterface.
+// A group's client should implement this interface.
+type PodEIPsGetter interface {
+	PodEIPs(namespace string) PodEIPInterface
+}
+
+// PodEIPInterface has methods to work with PodEIP resources.
+type PodEIPInterface interface {
+	Create(ctx context.Context, podEIP *v1beta1.PodEIP, opts v1.CreateOptions) (*v1beta1.PodEIP, error)
+	Update(ctx context.Context, podEIP *v1beta1.PodEIP, opts v1.UpdateOptions) (*v1beta1.PodEIP, error)
+	UpdateStatus(ctx context.Context, podEIP *v1beta1.PodEIP, opts v1.UpdateOptions) (*v1beta1.PodEIP, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*v1beta1.PodEIP, error)
+	List(ctx context.Context, opts v1.ListOptions) (*v1beta1.PodEIPList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1beta1.PodEIP, err error)
+	PodEIPExpansion
+}
+
+// podEIPs implements PodEIPInterface
+type podEIPs struct {
+	client rest.Interface
+	ns     string
+}
+
+// newPodEIPs returns a PodEIPs
+func newPodEIPs(c *NetworkV1beta1Client, namespace string) *podEIPs {
+	return &podEIPs{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the podEIP, and returns the corresponding podEIP object, and an error if there is any.
+func (c *podEIPs) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1beta1.PodEIP, err error) {
+	result = &v1beta1.PodEIP{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("podeips").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of PodEIPs that match those selectors.
+func (c *podEIPs) List(ctx context.Context, opts v1.ListOptions) (result *v1beta1.PodEIPList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1beta1.PodEIPList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("podeips").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested podEIPs.
+func (c *podEIPs) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("podeips").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a podEIP and creates it.  Returns the server's representation of the podEIP, and an error, if there is any.
+func (c *podEIPs) Create(ctx context.Context, podEIP *v1beta1.PodEIP, opts v1.CreateOptions) (result *v1beta1.PodEIP, err error) {
+	result = &v1beta1.PodEIP{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("podeips").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(podEIP).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a podEIP and updates it. Returns the server's representation of the podEIP, and an error, if there is any.
+func (c *podEIPs) Update(ctx context.Context, podEIP *v1beta1.PodEIP, opts v1.UpdateOptions) (result *v1beta1.PodEIP, err error) {
+	result = &v1beta1.PodEIP{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("podeips").
+		Name(podEIP.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(podEIP).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *podEIPs) UpdateStatus(ctx context.Context, podEIP *v1beta1.PodEIP, opts v1.UpdateOptions) (result *v1beta1.PodEIP, err error) {
+	result = &v1beta1.PodEIP{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("podeips").
+		Name(podEIP.Name).
+		SubResource("status").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(podEIP).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the podEIP and deletes it. Returns an error if one occurs.
+func (c *podEIPs) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("podeips").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *podEIPs) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("podeips").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched podEIP.
+func (c *podEIPs) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1beta1.PodEIP, err error) {
+	result = &v1beta1.PodEIP{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("podeips").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
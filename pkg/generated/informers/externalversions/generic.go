@@ -52,6 +52,12 @@ func (f *genericInformer) Lister() cache.GenericLister {
 func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource) (GenericInformer, error) {
 	switch resource {
 	// Group=network.alibabacloud.com, Version=v1beta1
+	case v1beta1.SchemeGroupVersion.WithResource("nodeenipools"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Network().V1beta1().NodeENIPools().Informer()}, nil
+	case v1beta1.SchemeGroupVersion.WithResource("nodenetworkstatuses"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Network().V1beta1().NodeNetworkStatuses().Informer()}, nil
+	case v1beta1.SchemeGroupVersion.WithResource("podeips"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Network().V1beta1().PodEIPs().Informer()}, nil
 	case v1beta1.SchemeGroupVersion.WithResource("podenis"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Network().V1beta1().PodENIs().Informer()}, nil
 	case v1beta1.SchemeGroupVersion.WithResource("podnetworkings"):
@@ -23,6 +23,12 @@ import (
 
 // Interface provides access to all the informers in this group version.
 type Interface interface {
+	// NodeENIPools returns a NodeENIPoolInformer.
+	NodeENIPools() NodeENIPoolInformer
+	// NodeNetworkStatuses returns a NodeNetworkStatusInformer.
+	NodeNetworkStatuses() NodeNetworkStatusInformer
+	// PodEIPs returns a PodEIPInformer.
+	PodEIPs() PodEIPInformer
 	// PodENIs returns a PodENIInformer.
 	PodENIs() PodENIInformer
 	// PodNetworkings returns a PodNetworkingInformer.
@@ -40,6 +46,21 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
 }
 
+// NodeENIPools returns a NodeENIPoolInformer.
+func (v *version) NodeENIPools() NodeENIPoolInformer {
+	return &nodeENIPoolInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// NodeNetworkStatuses returns a NodeNetworkStatusInformer.
+func (v *version) NodeNetworkStatuses() NodeNetworkStatusInformer {
+	return &nodeNetworkStatusInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// PodEIPs returns a PodEIPInformer.
+func (v *version) PodEIPs() PodEIPInformer {
+	return &podEIPInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // PodENIs returns a PodENIInformer.
 func (v *version) PodENIs() PodENIInformer {
 	return &podENIInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
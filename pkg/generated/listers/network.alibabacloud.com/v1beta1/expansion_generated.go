@@ -17,6 +17,22 @@ limitations under the License.
 
 package v1beta1
 
+// NodeENIPoolListerExpansion allows custom methods to be added to
+// NodeENIPoolLister.
+type NodeENIPoolListerExpansion interface{}
+
+// NodeNetworkStatusListerExpansion allows custom methods to be added to
+// NodeNetworkStatusLister.
+type NodeNetworkStatusListerExpansion interface{}
+
+// PodEIPListerExpansion allows custom methods to be added to
+// PodEIPLister.
+type PodEIPListerExpansion interface{}
+
+// PodEIPNamespaceListerExpansion allows custom methods to be added to
+// PodEIPNamespaceLister.
+type PodEIPNamespaceListerExpansion interface{}
+
 // PodENIListerExpansion allows custom methods to be added to
 // PodENILister.
 type PodENIListerExpansion interface{}
@@ -0,0 +1,67 @@
+/*
+Copyright 2021 Terway Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	v1beta1 "github.com/AliyunContainerService/terway/pkg/apis/network.alibabacloud.com/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// NodeENIPoolLister helps list NodeENIPools.
+// All objects returned here must be treated as read-only.
+type NodeENIPoolLister interface {
+	// List lists all NodeENIPools in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1beta1.NodeENIPool, err error)
+	// Get retrieves the NodeENIPool from the index for a given name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1beta1.NodeENIPool, error)
+	NodeENIPoolListerExpansion
+}
+
+// nodeENIPoolLister implements the NodeENIPoolLister interface.
+type nodeENIPoolLister struct {
+	indexer cache.Indexer
+}
+
+// NewNodeENIPoolLister returns a new NodeENIPoolLister.
+func NewNodeENIPoolLister(indexer cache.Indexer) NodeENIPoolLister {
+	return &nodeENIPoolLister{indexer: indexer}
+}
+
+// List lists all NodeENIPools in the indexer.
+func (s *nodeENIPoolLister) List(selector labels.Selector) (ret []*v1beta1.NodeENIPool, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1beta1.NodeENIPool))
+	})
+	return ret, err
+}
+
+// Get retrieves the NodeENIPool from the index for a given name.
+func (s *nodeENIPoolLister) Get(name string) (*v1beta1.NodeENIPool, error) {
+	obj, exists, err := s.indexer.GetByKey(name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1beta1.Resource("nodeenipool"), name)
+	}
+	return obj.(*v1beta1.NodeENIPool), nil
+}
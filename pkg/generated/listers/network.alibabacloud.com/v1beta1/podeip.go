@@ -0,0 +1,98 @@
+/*
+Copyright 2021 Terway Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	v1beta1 "github.com/AliyunContainerService/terway/pkg/apis/network.alibabacloud.com/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// PodEIPLister helps list PodEIPs.
+// All objects returned here must be treated as read-only.
+type PodEIPLister interface {
+	// List lists all PodEIPs in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1beta1.PodEIP, err error)
+	// PodEIPs returns an object that can list and get PodEIPs.
+	PodEIPs(namespace string) PodEIPNamespaceLister
+	PodEIPListerExpansion
+}
+
+// podEIPLister implements the PodEIPLister interface.
+type podEIPLister struct {
+	indexer cache.Indexer
+}
+
+// NewPodEIPLister returns a new PodEIPLister.
+func NewPodEIPLister(indexer cache.Indexer) PodEIPLister {
+	return &podEIPLister{indexer: indexer}
+}
+
+// List lists all PodEIPs in the indexer.
+func (s *podEIPLister) List(selector labels.Selector) (ret []*v1beta1.PodEIP, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1beta1.PodEIP))
+	})
+	return ret, err
+}
+
+// PodEIPs returns an object that can list and get PodEIPs.
+func (s *podEIPLister) PodEIPs(namespace string) PodEIPNamespaceLister {
+	return podEIPNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// PodEIPNamespaceLister helps list and get PodEIPs.
+// All objects returned here must be treated as read-only.
+type PodEIPNamespaceLister interface {
+	// List lists all PodEIPs in the indexer for a given namespace.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1beta1.PodEIP, err error)
+	// Get retrieves the PodEIP from the indexer for a given namespace and name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1beta1.PodEIP, error)
+	PodEIPNamespaceListerExpansion
+}
+
+// podEIPNamespaceLister implements the PodEIPNamespaceLister
+// interface.
+type podEIPNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all PodEIPs in the indexer for a given namespace.
+func (s podEIPNamespaceLister) List(selector labels.Selector) (ret []*v1beta1.PodEIP, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1beta1.PodEIP))
+	})
+	return ret, err
+}
+
+// Get retrieves the PodEIP from the indexer for a given namespace and name.
+func (s podEIPNamespaceLister) Get(name string) (*v1beta1.PodEIP, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1beta1.Resource("podeip"), name)
+	}
+	return obj.(*v1beta1.PodEIP), nil
+}
@@ -21,12 +21,14 @@ type API interface {
 	UnAssignIPsForENI(ctx context.Context, eniID, mac string, ipv4s []net.IP, ipv6s []net.IP) error
 	GetAttachedSecurityGroups(ctx context.Context, instanceID string) ([]string, error)
 	CheckEniSecurityGroup(ctx context.Context, sgIDs []string) error
+	GetENISecurityGroups(ctx context.Context, eniIDs []string) (map[string][]string, error)
 	DescribeInstanceTypes(ctx context.Context, types []string) ([]ecs.InstanceType, error)
 
 	// FIXME remove vendor for vpc
 	DescribeVSwitchByID(ctx context.Context, vSwitch string) (*vpc.VSwitch, error)
 	// EIP
 	AllocateEipAddress(ctx context.Context, bandwidth int, chargeType types.InternetChargeType, eipID, eniID string, eniIP net.IP, allowRob bool, isp, bandwidthPackageID, poolID string) (*types.EIP, error)
+	CreateEipAddress(ctx context.Context, bandwidth int, chargeType types.InternetChargeType, isp, bandwidthPackageID, poolID string) (*types.EIP, error)
 	UnassociateEipAddress(ctx context.Context, eipID, eniID, eniIP string) error
 	ReleaseEipAddress(ctx context.Context, eipID, eniID string, eniIP net.IP) error
 	QueryEniIDByIP(ctx context.Context, vpcID string, address net.IP) (string, error)
@@ -21,4 +21,79 @@ var (
 		},
 		[]string{"url", "error"},
 	)
+	// ENICacheRequests counter of eni cache lookups, result is hit, miss or fallback
+	ENICacheRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "aliyun_eni_cache_requests",
+			Help: "counter of eni cache lookups, result is hit, miss or fallback",
+		},
+		[]string{"field", "result"},
+	)
+	// MetadataLag time for local mutations to be reflected in metadata in ms
+	MetadataLag = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "aliyun_metadata_lag",
+			Help:    "time for local mutations to be reflected in metadata in ms",
+			Buckets: prometheus.ExponentialBuckets(50, 2, 12),
+		},
+		[]string{"field"},
+	)
+
+	// OpenAPILimiterQueueDepth amount of openapi calls waiting for the limiter
+	OpenAPILimiterQueueDepth = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "aliyun_openapi_limiter_queue_depth",
+			Help: "amount of openapi calls waiting for the limiter",
+		},
+		[]string{"limiter", "lane"},
+	)
+	// OpenAPILimiterWaitTime time waited for the limiter in ms
+	OpenAPILimiterWaitTime = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "aliyun_openapi_limiter_wait_time",
+			Help:    "time waited for the limiter in ms",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 16),
+		},
+		[]string{"limiter", "lane"},
+	)
+	// OpenAPILimiterRate current rate of the limiter
+	OpenAPILimiterRate = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "aliyun_openapi_limiter_rate",
+			Help: "current rate of the limiter",
+		},
+		[]string{"limiter"},
+	)
+	// OpenAPIThrottled counter of rate decrease caused by throttling error
+	OpenAPIThrottled = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "aliyun_openapi_throttled",
+			Help: "counter of rate decrease caused by throttling error",
+		},
+		[]string{"limiter"},
+	)
+	// CredentialExpiry seconds until the credential expires
+	CredentialExpiry = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "aliyun_credential_expiry_seconds",
+			Help: "seconds until the credential of the provider expires",
+		},
+		[]string{"provider"},
+	)
+	// OpenAPIErrors counter of openapi errors by class
+	OpenAPIErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "aliyun_openapi_errors",
+			Help: "counter of openapi errors by class",
+		},
+		[]string{"api", "class"},
+	)
+	// OpenAPICircuitBreakerState state of the circuit breaker, 0 closed, 1 half open, 2 open
+	OpenAPICircuitBreakerState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "aliyun_openapi_circuit_breaker_state",
+			Help: "state of the circuit breaker, 0 closed, 1 half open, 2 open",
+		},
+		[]string{"api"},
+	)
 )
@@ -0,0 +1,44 @@
+package metric
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	// GCCandidates amount of resources found to be garbage collected in last gc cycle
+	GCCandidates = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "terway_controlplane_gc_candidates",
+			Help: "amount of resources found to be garbage collected in last gc cycle",
+		},
+		// category in "secondaryENI", "memberENI" or "podENI"
+		[]string{"category"},
+	)
+
+	// GCActions counter of actions taken by garbage collection
+	GCActions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "terway_controlplane_gc_actions",
+			Help: "counter of actions taken by garbage collection",
+		},
+		// result in "succeed", "fail", "dry-run" or "limited"
+		[]string{"category", "action", "result"},
+	)
+)
+
+var (
+	// ShardOwned shards held by this replica, 1 if held
+	ShardOwned = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "terway_controlplane_shard_owned",
+			Help: "shards held by this replica, 1 if held",
+		},
+		[]string{"shard"},
+	)
+
+	// ShardMembers amount of live replicas sharing the shards
+	ShardMembers = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "terway_controlplane_shard_members",
+			Help: "amount of live replicas sharing the shards",
+		},
+	)
+)
@@ -31,6 +31,16 @@ var (
 		// status in "succeed" or "fail"
 		[]string{"eni", "status"},
 	)
+
+	// ENIIPFactoryIPReleaseCount counter of eniip factory ip release
+	ENIIPFactoryIPReleaseCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "terway_eniip_factory_ip_release_count",
+			Help: "counter of eniip factory ip release",
+		},
+		// status in "succeed" or "fail"
+		[]string{"eni", "status"},
+	)
 )
 
 const (
@@ -11,6 +11,7 @@ import (
 	apiErr "github.com/AliyunContainerService/terway/pkg/aliyun/client/errors"
 	"github.com/AliyunContainerService/terway/pkg/logger"
 	"github.com/AliyunContainerService/terway/pkg/metric"
+	"github.com/AliyunContainerService/terway/pkg/telemetry"
 	"github.com/AliyunContainerService/terway/pkg/tracing"
 	"github.com/AliyunContainerService/terway/types"
 
@@ -27,6 +28,9 @@ var (
 	ErrNotFound            = errors.New("not found")
 	ErrContextDone         = errors.New("context done")
 	ErrInvalidArguments    = errors.New("invalid arguments")
+	// ErrInvalidResource returned by factory Dispose when the resource is left in unknown state,
+	// the resource will be put to invalid and disposed later
+	ErrInvalidResource = errors.New("invalid resource")
 )
 
 const (
@@ -97,8 +101,8 @@ type ResourceHolder interface {
 
 // ObjectFactory interface of network resource object factory
 type ObjectFactory interface {
-	// Create res with count
-	Create(count int) ([]types.NetworkResource, error)
+	// Create res with count, ctx carries the trace of the request and is never canceled
+	Create(ctx context.Context, count int) ([]types.NetworkResource, error)
 	Dispose(types.NetworkResource) error
 	ListResource() (map[string]types.NetworkResource, error)
 	Check(types.NetworkResource) error
@@ -106,6 +110,18 @@ type ObjectFactory interface {
 	Reconcile()
 }
 
+// Drainer is implemented by factory which want to take some resources out of service,
+// draining resources are allocated only when there is no other idle resource, and disposed once idle
+type Drainer interface {
+	Draining(res types.NetworkResource) bool
+}
+
+// BatchDisposer is implemented by factory which can aggregate the dispose of resources,
+// the returned errors is in the same order as res
+type BatchDisposer interface {
+	DisposeBatch(res []types.NetworkResource) []error
+}
+
 type simpleObjectPool struct {
 	name     string
 	inuse    map[string]poolItem
@@ -280,32 +296,109 @@ func (p *simpleObjectPool) peekOverfullIdle() *poolItem {
 	return p.idle.Pop()
 }
 
+// popDrainingIdle pop all the idle resources which are draining
+func (p *simpleObjectPool) popDrainingIdle() []types.NetworkResource {
+	drainer, ok := p.factory.(Drainer)
+	if !ok {
+		return nil
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	now := time.Now()
+	var ids []string
+	for i := 0; i < p.idle.Size(); i++ {
+		item := p.idle.slots[i]
+		if item.reservation.After(now) {
+			continue
+		}
+		if drainer.Draining(item.res) {
+			ids = append(ids, item.res.GetResourceID())
+		}
+	}
+
+	var result []types.NetworkResource
+	for _, id := range ids {
+		item := p.idle.Rob(id)
+		if item == nil {
+			continue
+		}
+		p.metricIdle.Dec()
+		p.metricTotal.Dec()
+		result = append(result, item.res)
+	}
+	return result
+}
+
 //found resources that can be disposed, put them into dispose channel
 func (p *simpleObjectPool) checkIdle() {
+	// failed draining resources will retry in next check, no backoff for them
+	p.dispose(p.popDrainingIdle())
+
+	_, batch := p.factory.(BatchDisposer)
 	for {
-		item := p.peekOverfullIdle()
-		if item == nil {
-			break
+		var toDispose []types.NetworkResource
+		for {
+			item := p.peekOverfullIdle()
+			if item == nil {
+				break
+			}
+
+			p.metricIdle.Dec()
+			p.metricTotal.Dec()
+
+			log.Infof("try dispose res %+v", item.res)
+			toDispose = append(toDispose, item.res)
+			if !batch {
+				break
+			}
+		}
+		if len(toDispose) == 0 {
+			return
 		}
 
-		p.metricIdle.Dec()
-		p.metricTotal.Dec()
+		if p.dispose(toDispose) {
+			p.backoffTime = p.backoffTime * 2
+			time.Sleep(p.backoffTime)
+		} else {
+			p.backoffTime = defaultPoolBackoff
+		}
+	}
+}
 
-		res := item.res
-		log.Infof("try dispose res %+v", res)
-		err := p.factory.Dispose(res)
-		if err == nil {
+// dispose the resources popped from idle, return true if any of them failed and put back to idle
+func (p *simpleObjectPool) dispose(toDispose []types.NetworkResource) bool {
+	if len(toDispose) == 0 {
+		return false
+	}
+
+	var errs []error
+	if batchDisposer, ok := p.factory.(BatchDisposer); ok {
+		errs = batchDisposer.DisposeBatch(toDispose)
+	} else {
+		for _, res := range toDispose {
+			errs = append(errs, p.factory.Dispose(res))
+		}
+	}
+
+	failed := false
+	for i, res := range toDispose {
+		err := errs[i]
+		switch {
+		case err == nil:
 			p.tokenCh <- struct{}{}
-			p.backoffTime = defaultPoolBackoff
 			// one item popped from idle and total
 			p.metricDisposed.Inc()
-		} else {
+		case errors.Is(err, ErrInvalidResource):
+			log.Warnf("error dispose res %s, mark as invalid: %+v", res.GetResourceID(), err)
+			p.AddInvalid(res)
+		default:
 			log.Warnf("error dispose res: %+v", err)
-			p.backoffTime = p.backoffTime * 2
+			failed = true
 			p.AddIdle(res)
-			time.Sleep(p.backoffTime)
 		}
 	}
+	return failed
 }
 
 func (p *simpleObjectPool) checkInsufficient() {
@@ -327,7 +420,7 @@ func (p *simpleObjectPool) checkInsufficient() {
 	if tokenAcquired <= 0 {
 		return
 	}
-	resList, err := p.factory.Create(tokenAcquired)
+	resList, err := p.factory.Create(context.Background(), tokenAcquired)
 	if err != nil {
 		log.Errorf("error add idle network resources: %v", err)
 	}
@@ -377,10 +470,36 @@ func (p *simpleObjectPool) getOneLocked(resID string) *poolItem {
 			return item
 		}
 	}
+	if drainer, ok := p.factory.(Drainer); ok {
+		// prefer the resource not draining
+		var found *poolItem
+		for i := 0; i < p.idle.Size(); i++ {
+			item := p.idle.slots[i]
+			if drainer.Draining(item.res) {
+				continue
+			}
+			if found == nil || item.lessThan(found) {
+				found = item
+			}
+		}
+		if found != nil {
+			return p.idle.Rob(found.res.GetResourceID())
+		}
+	}
 	return p.idle.Pop()
 }
 
 func (p *simpleObjectPool) Acquire(ctx context.Context, resID, idempotentKey string) (types.NetworkResource, error) {
+	ctx, span := telemetry.Start(ctx, "pool/Acquire", telemetry.AttrPool.String(p.name), telemetry.AttrResourceID.String(resID))
+	res, err := p.acquire(ctx, resID, idempotentKey)
+	if res != nil {
+		span.SetAttributes(telemetry.AttrResourceID.String(res.GetResourceID()))
+	}
+	telemetry.End(span, err)
+	return res, err
+}
+
+func (p *simpleObjectPool) acquire(ctx context.Context, resID, idempotentKey string) (types.NetworkResource, error) {
 	p.lock.Lock()
 	if resItem, ok := p.inuse[resID]; ok && resItem.idempotentKey == idempotentKey {
 		p.lock.Unlock()
@@ -407,11 +526,11 @@ func (p *simpleObjectPool) Acquire(ctx context.Context, resID, idempotentKey str
 
 	select {
 	case <-p.tokenCh:
-		//should we pass ctx into factory.Create?
-		res, err := p.factory.Create(1)
+		// the creation is not canceled with the request, only the trace is passed
+		res, err := p.factory.Create(telemetry.Detach(ctx), 1)
 		if err != nil || len(res) == 0 {
 			p.tokenCh <- struct{}{}
-			return nil, fmt.Errorf("error create from factory: %v", err)
+			return nil, fmt.Errorf("error create from factory: %w", err)
 		}
 		log.Infof("acquire (expect %s): return newly %s", resID, res[0].GetResourceID())
 		p.AddInuse(res[0], idempotentKey)
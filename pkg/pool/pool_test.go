@@ -10,6 +10,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/AliyunContainerService/terway/pkg/metric"
 	"github.com/AliyunContainerService/terway/types"
 	"github.com/stretchr/testify/assert"
 )
@@ -68,7 +69,7 @@ func (f *mockObjectFactory) ListResource() (map[string]types.NetworkResource, er
 	return mapping, nil
 }
 
-func (f *mockObjectFactory) Create(count int) ([]types.NetworkResource, error) {
+func (f *mockObjectFactory) Create(_ context.Context, count int) ([]types.NetworkResource, error) {
 	time.Sleep(f.createDelay)
 	if f.err != nil {
 		return nil, f.err
@@ -333,3 +334,100 @@ func TestGetResourceMapping(t *testing.T) {
 	assert.NotNil(t, mapping.GetLocal())
 	assert.NotNil(t, mapping.GetRemote())
 }
+
+type mockBatchFactory struct {
+	*mockObjectFactory
+	invalid map[string]bool
+	batches []int
+}
+
+func (f *mockBatchFactory) DisposeBatch(res []types.NetworkResource) []error {
+	f.lock.Lock()
+	f.batches = append(f.batches, len(res))
+	f.lock.Unlock()
+
+	var errs []error
+	for _, r := range res {
+		if f.invalid[r.GetResourceID()] {
+			errs = append(errs, ErrInvalidResource)
+			continue
+		}
+		errs = append(errs, f.Dispose(r))
+	}
+	return errs
+}
+
+func TestCheckIdleBatchInvalid(t *testing.T) {
+	factory := &mockBatchFactory{
+		mockObjectFactory: newMockObjectFactory(1000),
+		invalid:           map[string]bool{"1001": true},
+	}
+	idle, err := factory.Put(4)
+	assert.NoError(t, err)
+
+	p := &simpleObjectPool{
+		idle:           newPriorityQueue(),
+		inuse:          make(map[string]poolItem),
+		invalid:        make(map[string]poolItem),
+		maxIdle:        1,
+		capacity:       10,
+		factory:        factory,
+		tokenCh:        make(chan struct{}, 10),
+		backoffTime:    defaultPoolBackoff,
+		metricIdle:     metric.ResourcePoolIdle.WithLabelValues("test", "mock", "10", "1", "0"),
+		metricTotal:    metric.ResourcePoolTotal.WithLabelValues("test", "mock", "10", "1", "0"),
+		metricDisposed: metric.ResourcePoolDisposed.WithLabelValues("test", "mock", "10", "1", "0"),
+	}
+	for _, res := range idle {
+		p.AddIdle(res)
+	}
+	p.checkIdle()
+
+	assert.Equal(t, []int{3}, factory.batches)
+	assert.Equal(t, 1, p.idle.Size())
+	assert.Contains(t, p.invalid, "1001")
+	assert.Equal(t, 2, factory.getTotalDisposed())
+}
+
+type mockDrainFactory struct {
+	*mockObjectFactory
+	draining map[string]bool
+}
+
+func (f *mockDrainFactory) Draining(res types.NetworkResource) bool {
+	return f.draining[res.GetResourceID()]
+}
+
+func TestDraining(t *testing.T) {
+	factory := &mockDrainFactory{
+		mockObjectFactory: newMockObjectFactory(1000),
+		draining:          map[string]bool{"1001": true, "1002": true},
+	}
+	idle, err := factory.Put(3)
+	assert.NoError(t, err)
+
+	p := &simpleObjectPool{
+		idle:           newPriorityQueue(),
+		inuse:          make(map[string]poolItem),
+		invalid:        make(map[string]poolItem),
+		maxIdle:        5,
+		capacity:       10,
+		factory:        factory,
+		tokenCh:        make(chan struct{}, 10),
+		backoffTime:    defaultPoolBackoff,
+		metricIdle:     metric.ResourcePoolIdle.WithLabelValues("test", "mock", "10", "5", "0"),
+		metricTotal:    metric.ResourcePoolTotal.WithLabelValues("test", "mock", "10", "5", "0"),
+		metricDisposed: metric.ResourcePoolDisposed.WithLabelValues("test", "mock", "10", "5", "0"),
+	}
+	for _, res := range idle {
+		p.AddIdle(res)
+	}
+
+	res, err := p.AcquireAny(context.Background(), "")
+	assert.NoError(t, err)
+	assert.Equal(t, "1003", res.GetResourceID())
+
+	p.checkIdle()
+	assert.Equal(t, 0, p.idle.Size())
+	assert.Equal(t, 2, factory.getTotalDisposed())
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2022 Terway Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"github.com/AliyunContainerService/terway/pkg/aliyun"
+	"github.com/AliyunContainerService/terway/pkg/aliyun/client"
+	"github.com/AliyunContainerService/terway/pkg/ipam"
+	"github.com/AliyunContainerService/terway/types"
+)
+
+// Aliyun is the provider backed by aliyun openapi and metadata
+type Aliyun struct {
+	api ipam.API
+}
+
+// NewAliyun create the provider
+func NewAliyun(openAPI *client.OpenAPI, needENITypeAttr bool, ipFamily *types.IPFamily) *Aliyun {
+	return &Aliyun{
+		api: aliyun.NewAliyunImpl(openAPI, needENITypeAttr, ipFamily),
+	}
+}
+
+func (a *Aliyun) Name() string {
+	return NameAliyun
+}
+
+func (a *Aliyun) Instance() *aliyun.Instance {
+	return aliyun.GetInstanceMeta()
+}
+
+func (a *Aliyun) API() ipam.API {
+	return a.api
+}
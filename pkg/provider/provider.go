@@ -0,0 +1,47 @@
+/*
+Copyright 2022 Terway Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package provider is the cloud backend of terway, the pool, datapaths and crd machinery are independent of it
+package provider
+
+import (
+	"errors"
+
+	"github.com/AliyunContainerService/terway/pkg/aliyun"
+	"github.com/AliyunContainerService/terway/pkg/ipam"
+	"github.com/AliyunContainerService/terway/pkg/logger"
+)
+
+var log = logger.DefaultLogger.WithField("subSys", "provider")
+
+// provider names
+const (
+	NameAliyun = "aliyun"
+	NameStatic = "static"
+)
+
+// ErrNotSupported is returned for the operations the provider can't do, e.g. create nic on a bare-metal node
+var ErrNotSupported = errors.New("not supported by the provider")
+
+// Provider is the cloud backend of the daemon
+type Provider interface {
+	// Name of the provider
+	Name() string
+	// Instance return the info of the node
+	Instance() *aliyun.Instance
+	// API return the eni and ip operations used by the pool
+	API() ipam.API
+}
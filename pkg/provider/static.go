@@ -0,0 +1,604 @@
+/*
+Copyright 2022 Terway Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/AliyunContainerService/terway/pkg/aliyun"
+	terwayIP "github.com/AliyunContainerService/terway/pkg/ip"
+	"github.com/AliyunContainerService/terway/pkg/ipam"
+	"github.com/AliyunContainerService/terway/types"
+	"github.com/AliyunContainerService/terway/types/daemon"
+
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/ecs"
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/vpc"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// defaults for the static provider
+const (
+	StaticInstanceType = "static"
+	StaticVSwitchID    = "static"
+	StaticStateFile    = "/var/lib/cni/terway/static-provider.json"
+
+	// maxRangeSize limit the addresses of one range, as they are expanded in memory
+	maxRangeSize = 65536
+)
+
+var _ ipam.API = &Static{}
+
+type staticNIC struct {
+	eni   *types.ENI
+	ipv4s []net.IP // addresses can be assigned, primary ip excluded
+	ipv6s []net.IP
+	inUse bool
+}
+
+// staticState is persisted to the state file, so assigned ips survive restart
+type staticState struct {
+	// Assigned ips index by nic id
+	Assigned map[string][]string `json:"assigned"`
+}
+
+// Static is the provider for nodes with pre-provisioned secondary nics and static ip ranges, e.g. bare-metal
+// servers on-prem. Nics can't be created, allocating an eni take an unused nic, and ips are assigned from the ranges.
+type Static struct {
+	ipFamily   *types.IPFamily
+	instance   *aliyun.Instance
+	primaryENI *types.ENI
+	stateFile  string
+
+	lock     sync.Mutex
+	nics     []*staticNIC
+	assigned map[string]sets.String
+}
+
+// NewStatic create the provider from config, ips already assigned are loaded from the state file
+func NewStatic(cfg *daemon.StaticProvider, ipFamily *types.IPFamily) (*Static, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("static_provider is required by the static provider")
+	}
+	s := &Static{
+		ipFamily:  ipFamily,
+		stateFile: cfg.StateFile,
+		assigned:  make(map[string]sets.String),
+		instance: &aliyun.Instance{
+			ZoneID:       cfg.ZoneID,
+			VPCID:        cfg.VPCID,
+			VSwitchID:    StaticVSwitchID,
+			PrimaryMAC:   cfg.PrimaryMAC,
+			InstanceID:   cfg.InstanceID,
+			InstanceType: cfg.InstanceType,
+		},
+	}
+	if s.stateFile == "" {
+		s.stateFile = StaticStateFile
+	}
+	if s.instance.InstanceID == "" {
+		s.instance.InstanceID = os.Getenv("NODE_NAME")
+	}
+	if s.instance.InstanceType == "" {
+		s.instance.InstanceType = StaticInstanceType
+	}
+	if cfg.PrimaryMAC != "" {
+		s.primaryENI = &types.ENI{ID: cfg.PrimaryMAC, MAC: cfg.PrimaryMAC, VSwitchID: StaticVSwitchID}
+	}
+
+	ids := sets.NewString()
+	for _, c := range cfg.NICs {
+		nic, err := newStaticNIC(c)
+		if err != nil {
+			return nil, err
+		}
+		if ids.Has(nic.eni.ID) {
+			return nil, fmt.Errorf("duplicate nic %s", nic.eni.ID)
+		}
+		ids.Insert(nic.eni.ID)
+		s.nics = append(s.nics, nic)
+	}
+
+	err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func newStaticNIC(c daemon.StaticNIC) (*staticNIC, error) {
+	mac, err := net.ParseMAC(c.MAC)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mac %s of static nic, %w", c.MAC, err)
+	}
+	eni := &types.ENI{
+		ID:        c.ID,
+		MAC:       mac.String(),
+		VSwitchID: StaticVSwitchID,
+	}
+	if eni.ID == "" {
+		eni.ID = eni.MAC
+	}
+
+	nic := &staticNIC{eni: eni, inUse: true}
+	if c.PrimaryIP != "" {
+		eni.PrimaryIP.IPv4 = net.ParseIP(c.PrimaryIP)
+		if eni.PrimaryIP.IPv4 == nil {
+			return nil, fmt.Errorf("invalid primary ip %s of nic %s", c.PrimaryIP, eni.ID)
+		}
+	}
+	eni.GatewayIP.IPv4 = net.ParseIP(c.Gateway)
+	eni.GatewayIP.IPv6 = net.ParseIP(c.GatewayV6)
+	if c.CIDR != "" {
+		_, eni.VSwitchCIDR.IPv4, err = net.ParseCIDR(c.CIDR)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cidr of nic %s, %w", eni.ID, err)
+		}
+	}
+	if c.CIDRV6 != "" {
+		_, eni.VSwitchCIDR.IPv6, err = net.ParseCIDR(c.CIDRV6)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ipv6 cidr of nic %s, %w", eni.ID, err)
+		}
+	}
+
+	exclude := sets.NewString(terwayIP.IPs2str([]net.IP{eni.PrimaryIP.IPv4, eni.GatewayIP.IPv4, eni.GatewayIP.IPv6})...)
+	nic.ipv4s, err = expandRanges(c.IPRanges, exclude)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ip ranges of nic %s, %w", eni.ID, err)
+	}
+	nic.ipv6s, err = expandRanges(c.IPv6Ranges, exclude)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ipv6 ranges of nic %s, %w", eni.ID, err)
+	}
+	return nic, nil
+}
+
+// expandRanges parse cidr or range like 192.168.0.10-192.168.0.20 to ips, the network and broadcast
+// address of ipv4 cidr are excluded
+func expandRanges(ranges []string, exclude sets.String) ([]net.IP, error) {
+	var result []net.IP
+	seen := sets.NewString()
+	for _, r := range ranges {
+		var start, end net.IP
+		if strings.Contains(r, "/") {
+			_, ipNet, err := net.ParseCIDR(r)
+			if err != nil {
+				return nil, err
+			}
+			start = ipNet.IP
+			end = make(net.IP, len(ipNet.IP))
+			for i := range ipNet.IP {
+				end[i] = ipNet.IP[i] | ^ipNet.Mask[i]
+			}
+			if start.To4() != nil {
+				ones, bits := ipNet.Mask.Size()
+				if bits-ones > 1 {
+					start = addIP(start, 1)
+					end = addIP(end, -1)
+				}
+			}
+		} else {
+			parts := strings.SplitN(r, "-", 2)
+			start = net.ParseIP(strings.TrimSpace(parts[0]))
+			end = start
+			if len(parts) == 2 {
+				end = net.ParseIP(strings.TrimSpace(parts[1]))
+			}
+			if start == nil || end == nil || (start.To4() == nil) != (end.To4() == nil) {
+				return nil, fmt.Errorf("invalid range %s", r)
+			}
+		}
+
+		size := new(big.Int).Sub(ipToInt(end), ipToInt(start))
+		if size.Sign() < 0 {
+			return nil, fmt.Errorf("invalid range %s", r)
+		}
+		if size.Cmp(big.NewInt(maxRangeSize)) >= 0 {
+			return nil, fmt.Errorf("range %s is larger than %d", r, maxRangeSize)
+		}
+		for i := int64(0); i <= size.Int64(); i++ {
+			ip := addIP(start, i)
+			if exclude.Has(ip.String()) || seen.Has(ip.String()) {
+				continue
+			}
+			seen.Insert(ip.String())
+			result = append(result, ip)
+		}
+	}
+	return result, nil
+}
+
+func ipToInt(ip net.IP) *big.Int {
+	if v4 := ip.To4(); v4 != nil {
+		return new(big.Int).SetBytes(v4)
+	}
+	return new(big.Int).SetBytes(ip.To16())
+}
+
+func addIP(ip net.IP, n int64) net.IP {
+	size := net.IPv6len
+	if ip.To4() != nil {
+		size = net.IPv4len
+	}
+	b := new(big.Int).Add(ipToInt(ip), big.NewInt(n)).Bytes()
+	r := make(net.IP, size)
+	copy(r[size-len(b):], b)
+	return r
+}
+
+func (s *Static) Name() string {
+	return NameStatic
+}
+
+func (s *Static) Instance() *aliyun.Instance {
+	return s.instance
+}
+
+func (s *Static) API() ipam.API {
+	return s
+}
+
+func (s *Static) AllocateENI(ctx context.Context, vSwitch string, securityGroup []string, instanceID string, trunk bool, ipCount int, eniTags map[string]string) (*types.ENI, error) {
+	if trunk {
+		return nil, fmt.Errorf("trunk eni, %w", ErrNotSupported)
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, nic := range s.nics {
+		if nic.inUse {
+			continue
+		}
+		nic.inUse = true
+		if ipCount > 1 || s.ipFamily.IPv6 {
+			var v4Count, v6Count int
+			if s.ipFamily.IPv4 {
+				v4Count = ipCount - 1
+			}
+			if s.ipFamily.IPv6 {
+				v6Count = ipCount
+			}
+			_, _, err := s.assign(nic, v4Count, v6Count)
+			if err != nil {
+				nic.inUse = false
+				return nil, err
+			}
+		}
+		log.Infof("static nic %s allocated", nic.eni.ID)
+		eni := *nic.eni
+		return &eni, nil
+	}
+	return nil, fmt.Errorf("no free static nic")
+}
+
+func (s *Static) GetAttachedENIs(ctx context.Context, containsMainENI bool, trunkENIID string) ([]*types.ENI, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var enis []*types.ENI
+	if containsMainENI && s.primaryENI != nil {
+		eni := *s.primaryENI
+		enis = append(enis, &eni)
+	}
+	for _, nic := range s.nics {
+		if !nic.inUse {
+			continue
+		}
+		eni := *nic.eni
+		enis = append(enis, &eni)
+	}
+	return enis, nil
+}
+
+func (s *Static) GetSecondaryENIMACs(ctx context.Context) ([]string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var macs []string
+	for _, nic := range s.nics {
+		if nic.inUse {
+			macs = append(macs, nic.eni.MAC)
+		}
+	}
+	return macs, nil
+}
+
+func (s *Static) GetENIByMac(ctx context.Context, mac string) (*types.ENI, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	nic, err := s.nicByMAC(mac)
+	if err != nil {
+		return nil, err
+	}
+	eni := *nic.eni
+	return &eni, nil
+}
+
+func (s *Static) FreeENI(ctx context.Context, eniID string, instanceID string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, nic := range s.nics {
+		if nic.eni.ID != eniID {
+			continue
+		}
+		nic.inUse = false
+		delete(s.assigned, eniID)
+		log.Infof("static nic %s released", eniID)
+		return s.save()
+	}
+	return nil
+}
+
+func (s *Static) GetENIIPs(ctx context.Context, mac string) ([]net.IP, []net.IP, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	nic, err := s.nicByMAC(mac)
+	if err != nil {
+		return nil, nil, err
+	}
+	var ipv4s, ipv6s []net.IP
+	if s.ipFamily.IPv4 && nic.eni.PrimaryIP.IPv4 != nil {
+		ipv4s = append(ipv4s, nic.eni.PrimaryIP.IPv4)
+	}
+	for _, ip := range nic.ipv4s {
+		if s.assigned[nic.eni.ID].Has(ip.String()) {
+			ipv4s = append(ipv4s, ip)
+		}
+	}
+	for _, ip := range nic.ipv6s {
+		if s.assigned[nic.eni.ID].Has(ip.String()) {
+			ipv6s = append(ipv6s, ip)
+		}
+	}
+	return ipv4s, ipv6s, nil
+}
+
+func (s *Static) AssignNIPsForENI(ctx context.Context, eniID, mac string, count int) ([]net.IP, []net.IP, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	nic, err := s.nicByMAC(mac)
+	if err != nil {
+		return nil, nil, err
+	}
+	var v4Count, v6Count int
+	if s.ipFamily.IPv4 {
+		v4Count = count
+	}
+	if s.ipFamily.IPv6 {
+		v6Count = count
+	}
+	return s.assign(nic, v4Count, v6Count)
+}
+
+func (s *Static) UnAssignIPsForENI(ctx context.Context, eniID, mac string, ipv4s []net.IP, ipv6s []net.IP) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	nic, err := s.nicByMAC(mac)
+	if err != nil {
+		return err
+	}
+	assigned := s.assigned[nic.eni.ID]
+	assigned.Delete(terwayIP.IPs2str(ipv4s)...)
+	assigned.Delete(terwayIP.IPs2str(ipv6s)...)
+	return s.save()
+}
+
+// assign pick free ips from the ranges of the nic, must be called with lock held
+func (s *Static) assign(nic *staticNIC, v4Count, v6Count int) ([]net.IP, []net.IP, error) {
+	assigned, ok := s.assigned[nic.eni.ID]
+	if !ok {
+		assigned = sets.NewString()
+		s.assigned[nic.eni.ID] = assigned
+	}
+	pick := func(candidates []net.IP, count int) ([]net.IP, error) {
+		var ips []net.IP
+		for _, ip := range candidates {
+			if len(ips) == count {
+				break
+			}
+			if !assigned.Has(ip.String()) {
+				ips = append(ips, ip)
+			}
+		}
+		if len(ips) < count {
+			return nil, fmt.Errorf("ip ranges of nic %s exhausted, want %d got %d", nic.eni.ID, count, len(ips))
+		}
+		return ips, nil
+	}
+	ipv4s, err := pick(nic.ipv4s, v4Count)
+	if err != nil {
+		return nil, nil, err
+	}
+	ipv6s, err := pick(nic.ipv6s, v6Count)
+	if err != nil {
+		return nil, nil, err
+	}
+	assigned.Insert(terwayIP.IPs2str(ipv4s)...)
+	assigned.Insert(terwayIP.IPs2str(ipv6s)...)
+	err = s.save()
+	if err != nil {
+		assigned.Delete(terwayIP.IPs2str(ipv4s)...)
+		assigned.Delete(terwayIP.IPs2str(ipv6s)...)
+		return nil, nil, err
+	}
+	return ipv4s, ipv6s, nil
+}
+
+func (s *Static) nicByMAC(mac string) (*staticNIC, error) {
+	for _, nic := range s.nics {
+		if nic.eni.MAC == mac {
+			return nic, nil
+		}
+	}
+	return nil, fmt.Errorf("static nic with mac %s not found", mac)
+}
+
+func (s *Static) GetAttachedSecurityGroups(ctx context.Context, instanceID string) ([]string, error) {
+	return nil, nil
+}
+
+func (s *Static) CheckEniSecurityGroup(ctx context.Context, sgIDs []string) error {
+	return nil
+}
+
+func (s *Static) GetENISecurityGroups(ctx context.Context, eniIDs []string) (map[string][]string, error) {
+	return map[string][]string{}, nil
+}
+
+// DescribeInstanceTypes return the limits derived from the nics, the primary ip of nic is counted
+func (s *Static) DescribeInstanceTypes(ctx context.Context, instanceTypes []string) ([]ecs.InstanceType, error) {
+	var ipv4, ipv6 int
+	for _, nic := range s.nics {
+		if len(nic.ipv4s)+1 > ipv4 {
+			ipv4 = len(nic.ipv4s) + 1
+		}
+		if len(nic.ipv6s) > ipv6 {
+			ipv6 = len(nic.ipv6s)
+		}
+	}
+	return []ecs.InstanceType{
+		{
+			InstanceTypeId:              s.instance.InstanceType,
+			EniQuantity:                 len(s.nics) + 1,
+			EniTotalQuantity:            len(s.nics) + 1,
+			EniPrivateIpAddressQuantity: ipv4,
+			EniIpv6AddressQuantity:      ipv6,
+		},
+	}, nil
+}
+
+// DescribeVSwitchByID return all the nics as one vSwitch
+func (s *Static) DescribeVSwitchByID(ctx context.Context, vSwitch string) (*vpc.VSwitch, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	vsw := &vpc.VSwitch{
+		VSwitchId: vSwitch,
+		ZoneId:    s.instance.ZoneID,
+		VpcId:     s.instance.VPCID,
+	}
+	for _, nic := range s.nics {
+		if vsw.CidrBlock == "" && nic.eni.VSwitchCIDR.IPv4 != nil {
+			vsw.CidrBlock = nic.eni.VSwitchCIDR.IPv4.String()
+		}
+		if vsw.Ipv6CidrBlock == "" && nic.eni.VSwitchCIDR.IPv6 != nil {
+			vsw.Ipv6CidrBlock = nic.eni.VSwitchCIDR.IPv6.String()
+		}
+		// assigned holds the ipv6 addresses too, only the ipv4 ones are counted
+		for _, ip := range nic.ipv4s {
+			if !s.assigned[nic.eni.ID].Has(ip.String()) {
+				vsw.AvailableIpAddressCount++
+			}
+		}
+	}
+	return vsw, nil
+}
+
+func (s *Static) AllocateEipAddress(ctx context.Context, bandwidth int, chargeType types.InternetChargeType, eipID, eniID string, eniIP net.IP, allowRob bool, isp, bandwidthPackageID, poolID string) (*types.EIP, error) {
+	return nil, fmt.Errorf("eip, %w", ErrNotSupported)
+}
+
+func (s *Static) CreateEipAddress(ctx context.Context, bandwidth int, chargeType types.InternetChargeType, isp, bandwidthPackageID, poolID string) (*types.EIP, error) {
+	return nil, fmt.Errorf("eip, %w", ErrNotSupported)
+}
+
+func (s *Static) UnassociateEipAddress(ctx context.Context, eipID, eniID, eniIP string) error {
+	return fmt.Errorf("eip, %w", ErrNotSupported)
+}
+
+func (s *Static) ReleaseEipAddress(ctx context.Context, eipID, eniID string, eniIP net.IP) error {
+	return fmt.Errorf("eip, %w", ErrNotSupported)
+}
+
+func (s *Static) QueryEniIDByIP(ctx context.Context, vpcID string, address net.IP) (string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, nic := range s.nics {
+		if nic.eni.PrimaryIP.IPv4.Equal(address) || s.assigned[nic.eni.ID].Has(address.String()) {
+			return nic.eni.ID, nil
+		}
+	}
+	return "", fmt.Errorf("no static nic has ip %s", address)
+}
+
+func (s *Static) load() error {
+	data, err := os.ReadFile(s.stateFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("error read static provider state, %w", err)
+	}
+	state := &staticState{}
+	err = json.Unmarshal(data, state)
+	if err != nil {
+		return fmt.Errorf("error parse static provider state %s, %w", s.stateFile, err)
+	}
+	for id, ips := range state.Assigned {
+		found := false
+		for _, nic := range s.nics {
+			if nic.eni.ID == id {
+				found = true
+				break
+			}
+		}
+		if !found {
+			log.Warnf("static nic %s in state is not configured, ignored", id)
+			continue
+		}
+		s.assigned[id] = sets.NewString(ips...)
+	}
+	return nil
+}
+
+// save write the state file atomically, must be called with lock held
+func (s *Static) save() error {
+	state := &staticState{Assigned: make(map[string][]string)}
+	for id, ips := range s.assigned {
+		if ips.Len() > 0 {
+			state.Assigned[id] = ips.List()
+		}
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(s.stateFile), 0700)
+	if err != nil {
+		return fmt.Errorf("error create dir for static provider state, %w", err)
+	}
+	tmp := s.stateFile + ".tmp"
+	err = os.WriteFile(tmp, data, 0600)
+	if err != nil {
+		return fmt.Errorf("error write static provider state, %w", err)
+	}
+	return os.Rename(tmp, s.stateFile)
+}
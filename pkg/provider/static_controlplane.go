@@ -0,0 +1,126 @@
+//go:build default_build
+
+/*
+Copyright 2022 Terway Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/AliyunContainerService/terway/pkg/aliyun/client"
+	apiErr "github.com/AliyunContainerService/terway/pkg/aliyun/client/errors"
+	"github.com/AliyunContainerService/terway/types/controlplane"
+
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/ecs"
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/vpc"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+var _ client.VSwitch = &StaticControlPlane{}
+var _ client.ENI = &StaticControlPlane{}
+var _ client.ECS = &StaticControlPlane{}
+
+// StaticControlPlane is the static provider for terway-controlplane. Nics are pre-provisioned on the nodes,
+// so nothing is created or deleted, only the limits and vSwitches in config are served.
+type StaticControlPlane struct {
+	cfg controlplane.StaticProvider
+}
+
+// NewStaticControlPlane create the provider
+func NewStaticControlPlane(cfg controlplane.StaticProvider) *StaticControlPlane {
+	return &StaticControlPlane{cfg: cfg}
+}
+
+func (s *StaticControlPlane) DescribeInstanceTypes(ctx context.Context, types []string) ([]ecs.InstanceType, error) {
+	if len(types) == 0 {
+		types = []string{StaticInstanceType}
+	}
+	var result []ecs.InstanceType
+	for _, t := range types {
+		result = append(result, ecs.InstanceType{
+			InstanceTypeId:              t,
+			EniQuantity:                 s.cfg.Adapters,
+			EniTotalQuantity:            s.cfg.Adapters,
+			EniPrivateIpAddressQuantity: s.cfg.IPv4PerAdapter,
+			EniIpv6AddressQuantity:      s.cfg.IPv6PerAdapter,
+		})
+	}
+	return result, nil
+}
+
+func (s *StaticControlPlane) DescribeVSwitchByID(ctx context.Context, vSwitchID string) (*vpc.VSwitch, error) {
+	for _, vsw := range s.cfg.VSwitches {
+		if vsw.ID != vSwitchID {
+			continue
+		}
+		return &vpc.VSwitch{
+			VSwitchId:               vsw.ID,
+			ZoneId:                  vsw.ZoneID,
+			CidrBlock:               vsw.CIDR,
+			Ipv6CidrBlock:           vsw.IPv6CIDR,
+			AvailableIpAddressCount: vsw.AvailableIPCount,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w, vSwitch %s", apiErr.ErrNotFound, vSwitchID)
+}
+
+func (s *StaticControlPlane) CreateNetworkInterface(ctx context.Context, trunk bool, vSwitchID string, securityGroups []string, resourceGroupID string, ipCount, ipv6Count int, eniTags map[string]string) (*client.NetworkInterface, error) {
+	return nil, fmt.Errorf("create eni, %w", ErrNotSupported)
+}
+
+// DescribeNetworkInterface return nothing, as no eni is managed by the controlplane
+func (s *StaticControlPlane) DescribeNetworkInterface(ctx context.Context, vpcID string, eniID []string, instanceID string, instanceType string, status string, tags map[string]string) ([]*client.NetworkInterface, error) {
+	return nil, nil
+}
+
+func (s *StaticControlPlane) AttachNetworkInterface(ctx context.Context, eniID, instanceID, trunkENIID string) error {
+	return fmt.Errorf("attach eni, %w", ErrNotSupported)
+}
+
+func (s *StaticControlPlane) DetachNetworkInterface(ctx context.Context, eniID, instanceID, trunkENIID string) error {
+	return fmt.Errorf("detach eni, %w", ErrNotSupported)
+}
+
+func (s *StaticControlPlane) DeleteNetworkInterface(ctx context.Context, eniID string) error {
+	return fmt.Errorf("delete eni, %w", ErrNotSupported)
+}
+
+func (s *StaticControlPlane) WaitForNetworkInterface(ctx context.Context, eniID string, status string, backoff wait.Backoff, ignoreNotExist bool) (*client.NetworkInterface, error) {
+	return nil, fmt.Errorf("%w, eni %s", apiErr.ErrNotFound, eniID)
+}
+
+func (s *StaticControlPlane) AssignPrivateIPAddress(ctx context.Context, eniID string, count int, idempotent string) ([]net.IP, error) {
+	return nil, fmt.Errorf("assign ip, %w", ErrNotSupported)
+}
+
+func (s *StaticControlPlane) UnAssignPrivateIPAddresses(ctx context.Context, eniID string, ips []net.IP) error {
+	return fmt.Errorf("unassign ip, %w", ErrNotSupported)
+}
+
+func (s *StaticControlPlane) AssignIpv6Addresses(ctx context.Context, eniID string, count int, idempotentKey string) ([]net.IP, error) {
+	return nil, fmt.Errorf("assign ipv6, %w", ErrNotSupported)
+}
+
+func (s *StaticControlPlane) UnAssignIpv6Addresses(ctx context.Context, eniID string, ips []net.IP) error {
+	return fmt.Errorf("unassign ipv6, %w", ErrNotSupported)
+}
+
+func (s *StaticControlPlane) ModifyNetworkInterfaceAttribute(ctx context.Context, eniID string, securityGroupIDs []string) error {
+	return fmt.Errorf("modify eni, %w", ErrNotSupported)
+}
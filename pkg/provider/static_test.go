@@ -0,0 +1,185 @@
+package provider
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+
+	terwayIP "github.com/AliyunContainerService/terway/pkg/ip"
+	"github.com/AliyunContainerService/terway/types"
+	"github.com/AliyunContainerService/terway/types/daemon"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestExpandRanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		ranges  []string
+		exclude []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:   "range",
+			ranges: []string{"192.168.0.10-192.168.0.12"},
+			want:   []string{"192.168.0.10", "192.168.0.11", "192.168.0.12"},
+		},
+		{
+			name:    "cidr exclude network and broadcast",
+			ranges:  []string{"192.168.0.0/30"},
+			exclude: []string{"192.168.0.1"},
+			want:    []string{"192.168.0.2"},
+		},
+		{
+			name:   "single ip and duplicate",
+			ranges: []string{"192.168.0.1", "192.168.0.1-192.168.0.2"},
+			want:   []string{"192.168.0.1", "192.168.0.2"},
+		},
+		{
+			name:   "ipv6",
+			ranges: []string{"fd00::fe-fd00::101"},
+			want:   []string{"fd00::fe", "fd00::ff", "fd00::100", "fd00::101"},
+		},
+		{
+			name:    "reversed",
+			ranges:  []string{"192.168.0.12-192.168.0.10"},
+			wantErr: true,
+		},
+		{
+			name:    "mixed family",
+			ranges:  []string{"192.168.0.1-fd00::1"},
+			wantErr: true,
+		},
+		{
+			name:    "too large",
+			ranges:  []string{"fd00::/64"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandRanges(tt.ranges, sets.NewString(tt.exclude...))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, terwayIP.IPs2str(got))
+		})
+	}
+}
+
+func TestStatic(t *testing.T) {
+	cfg := &daemon.StaticProvider{
+		InstanceID: "node-1",
+		StateFile:  filepath.Join(t.TempDir(), "state.json"),
+		NICs: []daemon.StaticNIC{
+			{
+				MAC:       "00:16:3e:00:00:01",
+				PrimaryIP: "192.168.0.2",
+				Gateway:   "192.168.0.1",
+				CIDR:      "192.168.0.0/24",
+				IPRanges:  []string{"192.168.0.1-192.168.0.5"},
+			},
+			{
+				ID:        "nic-2",
+				MAC:       "00:16:3e:00:00:02",
+				PrimaryIP: "192.168.1.2",
+				Gateway:   "192.168.1.1",
+				CIDR:      "192.168.1.0/24",
+				IPRanges:  []string{"192.168.1.10-192.168.1.11"},
+			},
+		},
+	}
+	ipFamily := types.NewIPFamilyFromIPStack(types.IPStackIPv4)
+	s, err := NewStatic(cfg, ipFamily)
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	assert.Equal(t, StaticInstanceType, s.Instance().InstanceType)
+	instanceTypes, err := s.DescribeInstanceTypes(ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, instanceTypes[0].EniQuantity)
+	// primary and gateway excluded from the range
+	assert.Equal(t, 4, instanceTypes[0].EniPrivateIpAddressQuantity)
+
+	// all nics are attached at start
+	enis, err := s.GetAttachedENIs(ctx, false, "")
+	assert.NoError(t, err)
+	assert.Len(t, enis, 2)
+	assert.Equal(t, "00:16:3e:00:00:01", enis[0].ID)
+
+	v4, _, err := s.AssignNIPsForENI(ctx, "nic-2", "00:16:3e:00:00:02", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"192.168.1.10", "192.168.1.11"}, terwayIP.IPs2str(v4))
+	_, _, err = s.AssignNIPsForENI(ctx, "nic-2", "00:16:3e:00:00:02", 1)
+	assert.Error(t, err)
+
+	eniID, err := s.QueryEniIDByIP(ctx, "", net.ParseIP("192.168.1.11"))
+	assert.NoError(t, err)
+	assert.Equal(t, "nic-2", eniID)
+
+	// free and allocate again
+	assert.NoError(t, s.FreeENI(ctx, "00:16:3e:00:00:01", ""))
+	macs, err := s.GetSecondaryENIMACs(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"00:16:3e:00:00:02"}, macs)
+	eni, err := s.AllocateENI(ctx, StaticVSwitchID, nil, "", false, 3, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "00:16:3e:00:00:01", eni.ID)
+	_, err = s.AllocateENI(ctx, StaticVSwitchID, nil, "", false, 1, nil)
+	assert.Error(t, err)
+
+	v4, _, err = s.GetENIIPs(ctx, "00:16:3e:00:00:01")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"192.168.0.2", "192.168.0.3", "192.168.0.4"}, terwayIP.IPs2str(v4))
+
+	// the assigned ips are restored from the state file
+	assert.NoError(t, s.UnAssignIPsForENI(ctx, "nic-2", "00:16:3e:00:00:02", []net.IP{net.ParseIP("192.168.1.10")}, nil))
+	s, err = NewStatic(cfg, ipFamily)
+	assert.NoError(t, err)
+	v4, _, err = s.GetENIIPs(ctx, "00:16:3e:00:00:02")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"192.168.1.2", "192.168.1.11"}, terwayIP.IPs2str(v4))
+
+	vsw, err := s.DescribeVSwitchByID(ctx, StaticVSwitchID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), vsw.AvailableIpAddressCount)
+
+	_, err = s.AllocateEipAddress(ctx, 5, "", "", "", nil, false, "", "", "")
+	assert.ErrorIs(t, err, ErrNotSupported)
+}
+
+func TestStatic_DescribeVSwitchByIDDualStack(t *testing.T) {
+	cfg := &daemon.StaticProvider{
+		InstanceID: "node-1",
+		StateFile:  filepath.Join(t.TempDir(), "state.json"),
+		NICs: []daemon.StaticNIC{
+			{
+				MAC:        "00:16:3e:00:00:01",
+				PrimaryIP:  "192.168.0.2",
+				Gateway:    "192.168.0.1",
+				CIDR:       "192.168.0.0/24",
+				IPRanges:   []string{"192.168.0.10-192.168.0.12"},
+				GatewayV6:  "fd00::1",
+				CIDRV6:     "fd00::/64",
+				IPv6Ranges: []string{"fd00::10-fd00::12"},
+			},
+		},
+	}
+	s, err := NewStatic(cfg, types.NewIPFamilyFromIPStack(types.IPStackDual))
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	v4, v6, err := s.AssignNIPsForENI(ctx, "00:16:3e:00:00:01", "00:16:3e:00:00:01", 1)
+	assert.NoError(t, err)
+	assert.Len(t, v4, 1)
+	assert.Len(t, v6, 1)
+
+	vsw, err := s.DescribeVSwitchByID(ctx, StaticVSwitchID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), vsw.AvailableIpAddressCount)
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2022 Terway Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+// ErrLocked the db file is held by another process, like the running daemon
+var ErrLocked = errors.New("storage locked by another process")
+
+// Export is the portable format of the records in a bucket, the data is in the version of the export
+type Export struct {
+	Bucket  string   `json:"bucket"`
+	Version int      `json:"version"`
+	Records []Record `json:"records"`
+}
+
+// Record is a record in Export
+type Record struct {
+	Key  string          `json:"key"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Info is the summary of a db file
+type Info struct {
+	Path    string       `json:"path"`
+	Size    int64        `json:"size"`
+	Buckets []BucketInfo `json:"buckets"`
+	// Errors is the inconsistency found by the integrity check
+	Errors []string `json:"errors"`
+}
+
+// BucketInfo is the summary of records in a bucket
+type BucketInfo struct {
+	Name    string `json:"name"`
+	Records int    `json:"records"`
+	// Versions is the count of records in each schema version
+	Versions map[int]int `json:"versions"`
+	// Invalid is the count of records can not be decoded
+	Invalid int `json:"invalid"`
+}
+
+// openDB open the bolt db, the errors are converted to ErrCorrupted and ErrLocked
+func openDB(path string, options *bolt.Options) (*bolt.DB, error) {
+	db, err := bolt.Open(path, 0600, options)
+	if err == nil {
+		return db, nil
+	}
+	var pathErr *os.PathError
+	switch {
+	case errors.Is(err, bolt.ErrTimeout):
+		return nil, fmt.Errorf("%w: %s", ErrLocked, path)
+	case errors.As(err, &pathErr):
+		return nil, err
+	}
+	return nil, fmt.Errorf("%w: error open %s, %v", ErrCorrupted, path, err)
+}
+
+// view open the db file read only and run fn on it
+func view(path string, timeout time.Duration, fn func(tx *bolt.Tx) error) (err error) {
+	db, err := openDB(path, &bolt.Options{ReadOnly: true, Timeout: timeout})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrCorrupted, r)
+		}
+	}()
+	return db.View(fn)
+}
+
+// ExportFile read the records in the bucket of db file, and migrate them to the version of schema.
+// ErrLocked is returned if the file is not released in timeout.
+func ExportFile(path, name string, schema *Schema, timeout time.Duration) (*Export, error) {
+	export := &Export{
+		Bucket:  name,
+		Version: schema.Version,
+		Records: []Record{},
+	}
+	err := view(path, timeout, func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(name))
+		if b == nil {
+			return fmt.Errorf("bucket %s not found", name)
+		}
+		return b.ForEach(func(k, v []byte) error {
+			data, _, err := schema.Migrate(v)
+			if err != nil {
+				return fmt.Errorf("error export %s, %w", k, err)
+			}
+			export.Records = append(export.Records, Record{
+				Key:  string(k),
+				Data: append(json.RawMessage{}, data...),
+			})
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// ImportFile replace the records in the bucket of db file with the export.
+// The records are migrated to the version of schema before written.
+func ImportFile(path string, schema *Schema, export *Export, timeout time.Duration) error {
+	if export.Bucket == "" {
+		return fmt.Errorf("bucket of export is empty")
+	}
+	values := make(map[string][]byte, len(export.Records))
+	for _, record := range export.Records {
+		data, err := schema.MigrateFrom(export.Version, record.Data)
+		if err != nil {
+			return fmt.Errorf("error import %s, %w", record.Key, err)
+		}
+		values[record.Key], err = schema.Stamp(data)
+		if err != nil {
+			return fmt.Errorf("error import %s, %w", record.Key, err)
+		}
+	}
+
+	db, err := openDB(path, &bolt.Options{Timeout: timeout})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket([]byte(export.Bucket)) != nil {
+			err := tx.DeleteBucket([]byte(export.Bucket))
+			if err != nil {
+				return err
+			}
+		}
+		b, err := tx.CreateBucket([]byte(export.Bucket))
+		if err != nil {
+			return err
+		}
+		for k, v := range values {
+			err = b.Put([]byte(k), v)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// InspectFile summarize the buckets and records in db file, and check the integrity of it
+func InspectFile(path string, timeout time.Duration) (*Info, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	info := &Info{
+		Path:    path,
+		Size:    stat.Size(),
+		Buckets: []BucketInfo{},
+		Errors:  []string{},
+	}
+	err = view(path, timeout, func(tx *bolt.Tx) error {
+		for err := range tx.Check() {
+			info.Errors = append(info.Errors, err.Error())
+		}
+		return tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+			bucket := BucketInfo{
+				Name:     string(name),
+				Versions: make(map[int]int),
+			}
+			err := b.ForEach(func(k, v []byte) error {
+				// nested bucket
+				if v == nil {
+					return nil
+				}
+				bucket.Records++
+				version, err := RecordVersion(v)
+				if err != nil {
+					bucket.Invalid++
+					return nil
+				}
+				bucket.Versions[version]++
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			info.Buckets = append(info.Buckets, bucket)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2022 Terway Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+var (
+	// ErrCorrupted the db file or the records in it can not be read
+	ErrCorrupted = errors.New("storage corrupted")
+	// ErrUnsupportedVersion the record is written by a newer version
+	ErrUnsupportedVersion = errors.New("unsupported schema version")
+)
+
+// VersionField is the field of the record holding its schema version. The version is kept inside the record,
+// so the older versions of terway ignore the unknown field and still read the records after a rollback.
+const VersionField = "schemaVersion"
+
+// Migration converts the data of a record from the previous version to the next one
+type Migration func(data []byte) ([]byte, error)
+
+// Schema is the version of the records in a bucket and the migrations to it.
+// The serializer of a versioned storage must output json object, the version is written to the VersionField of it.
+// A migration should keep the record readable by the previous version, e.g. only add fields, or the rollback breaks.
+type Schema struct {
+	// Version is the current version, records are always written with it
+	Version int
+	// Migrations are keyed by the version they migrate from,
+	// version 0 is the records written before the version field is introduced
+	Migrations map[int]Migration
+}
+
+// Validate check every version before the current one can be migrated
+func (s *Schema) Validate() error {
+	if s.Version < 1 {
+		return fmt.Errorf("schema version must be positive, got %d", s.Version)
+	}
+	for v := 0; v < s.Version; v++ {
+		if s.Migrations[v] == nil {
+			return fmt.Errorf("missing migration from version %d", v)
+		}
+	}
+	return nil
+}
+
+// Stamp write the current version into the record
+func (s *Schema) Stamp(data []byte) ([]byte, error) {
+	obj := map[string]json.RawMessage{}
+	err := json.Unmarshal(data, &obj)
+	if err != nil {
+		return nil, err
+	}
+	obj[VersionField] = json.RawMessage(strconv.Itoa(s.Version))
+	return json.Marshal(obj)
+}
+
+// Migrate migrate the record to current version, the version of the record is returned
+func (s *Schema) Migrate(value []byte) ([]byte, int, error) {
+	version, err := RecordVersion(value)
+	if err != nil {
+		return nil, 0, err
+	}
+	data, err := s.MigrateFrom(version, value)
+	return data, version, err
+}
+
+// MigrateFrom migrate the data from the given version to current version, the migrated data is stamped with it
+func (s *Schema) MigrateFrom(version int, data []byte) ([]byte, error) {
+	if version > s.Version {
+		return nil, fmt.Errorf("%w: %d, current %d", ErrUnsupportedVersion, version, s.Version)
+	}
+	if version == s.Version {
+		return data, nil
+	}
+	for v := version; v < s.Version; v++ {
+		migration := s.Migrations[v]
+		if migration == nil {
+			return nil, fmt.Errorf("missing migration from version %d", v)
+		}
+		var err error
+		data, err = migration(data)
+		if err != nil {
+			return nil, fmt.Errorf("error migrate from version %d, %w", v, err)
+		}
+	}
+	return s.Stamp(data)
+}
+
+// RecordVersion returns the schema version of a record, records without the version field are version 0
+func RecordVersion(value []byte) (int, error) {
+	record := struct {
+		Version int `json:"schemaVersion"`
+	}{}
+	err := json.Unmarshal(value, &record)
+	if err != nil {
+		return 0, err
+	}
+	return record.Version, nil
+}
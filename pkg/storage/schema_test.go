@@ -0,0 +1,246 @@
+/*
+Copyright 2022 Terway Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/stretchr/testify/assert"
+)
+
+type record struct {
+	Name string `json:"name"`
+}
+
+var testSchema = &Schema{
+	Version: 2,
+	Migrations: map[int]Migration{
+		0: func(data []byte) ([]byte, error) {
+			return data, nil
+		},
+		// rename field id to name
+		1: func(data []byte) ([]byte, error) {
+			return []byte(strings.Replace(string(data), `"id"`, `"name"`, 1)), nil
+		},
+	},
+}
+
+func deserializeRecord(data []byte) (interface{}, error) {
+	r := record{}
+	err := json.Unmarshal(data, &r)
+	return r, err
+}
+
+func writeRaw(t *testing.T, path string, values map[string]string) {
+	db, err := bolt.Open(path, 0600, nil)
+	assert.NoError(t, err)
+	defer db.Close()
+	err = db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte("test"))
+		if err != nil {
+			return err
+		}
+		for k, v := range values {
+			if err = b.Put([]byte(k), []byte(v)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func readRaw(t *testing.T, path, key string) string {
+	db, err := bolt.Open(path, 0600, nil)
+	assert.NoError(t, err)
+	defer db.Close()
+	var value string
+	err = db.View(func(tx *bolt.Tx) error {
+		value = string(tx.Bucket([]byte("test")).Get([]byte(key)))
+		return nil
+	})
+	assert.NoError(t, err)
+	return value
+}
+
+func TestSchemaValidate(t *testing.T) {
+	assert.NoError(t, testSchema.Validate())
+	assert.Error(t, (&Schema{}).Validate())
+	assert.Error(t, (&Schema{Version: 2, Migrations: map[int]Migration{0: testSchema.Migrations[0]}}).Validate())
+}
+
+func TestSchemaMigrate(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		version int
+		err     error
+	}{
+		{
+			name:    "without version",
+			value:   `{"id":"foo"}`,
+			want:    `{"name":"foo","schemaVersion":2}`,
+			version: 0,
+		},
+		{
+			name:    "version 1",
+			value:   `{"id":"foo","schemaVersion":1}`,
+			want:    `{"name":"foo","schemaVersion":2}`,
+			version: 1,
+		},
+		{
+			name:    "current version",
+			value:   `{"name":"foo","schemaVersion":2}`,
+			want:    `{"name":"foo","schemaVersion":2}`,
+			version: 2,
+		},
+		{
+			name:    "newer version",
+			value:   `{"name":"foo","schemaVersion":3}`,
+			version: 3,
+			err:     ErrUnsupportedVersion,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, version, err := testSchema.Migrate([]byte(tt.value))
+			assert.Equal(t, tt.version, version)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(data))
+		})
+	}
+
+	_, _, err := testSchema.Migrate([]byte("not json"))
+	assert.Error(t, err)
+	_, _, err = testSchema.Migrate([]byte(`["foo"]`))
+	assert.Error(t, err)
+}
+
+func TestVersionedDiskStorageMigrate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	writeRaw(t, path, map[string]string{
+		"a": `{"id":"a"}`,
+		"b": `{"name":"b","schemaVersion":2}`,
+	})
+
+	s, err := NewVersionedDiskStorage("test", path, testSchema, json.Marshal, deserializeRecord)
+	assert.NoError(t, err)
+	v, err := s.Get("a")
+	assert.NoError(t, err)
+	assert.Equal(t, record{Name: "a"}, v)
+	v, err = s.Get("b")
+	assert.NoError(t, err)
+	assert.Equal(t, record{Name: "b"}, v)
+
+	assert.NoError(t, s.Put("c", record{Name: "c"}))
+	assert.NoError(t, s.(*DiskStorage).db.Close())
+
+	// the old record is rewritten in the current version
+	assert.JSONEq(t, `{"name":"a","schemaVersion":2}`, readRaw(t, path, "a"))
+	assert.JSONEq(t, `{"name":"c","schemaVersion":2}`, readRaw(t, path, "c"))
+
+	// the records are still readable by the older versions without schema
+	v, err = deserializeRecord([]byte(readRaw(t, path, "c")))
+	assert.NoError(t, err)
+	assert.Equal(t, record{Name: "c"}, v)
+}
+
+func TestVersionedDiskStorageUnreadable(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "newer.db")
+	writeRaw(t, path, map[string]string{"a": `{"name":"a","schemaVersion":3}`})
+	_, err := NewVersionedDiskStorage("test", path, testSchema, json.Marshal, deserializeRecord)
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+
+	path = filepath.Join(dir, "record.db")
+	writeRaw(t, path, map[string]string{"a": `{"name":`})
+	_, err = NewVersionedDiskStorage("test", path, testSchema, json.Marshal, deserializeRecord)
+	assert.ErrorIs(t, err, ErrCorrupted)
+
+	path = filepath.Join(dir, "file.db")
+	assert.NoError(t, os.WriteFile(path, []byte(strings.Repeat("corrupted", 1024)), 0600))
+	_, err = NewVersionedDiskStorage("test", path, testSchema, json.Marshal, deserializeRecord)
+	assert.ErrorIs(t, err, ErrCorrupted)
+
+	backup, err := Quarantine(path)
+	assert.NoError(t, err)
+	assert.FileExists(t, backup)
+	s, err := NewVersionedDiskStorage("test", path, testSchema, json.Marshal, deserializeRecord)
+	assert.NoError(t, err)
+	list, err := s.List()
+	assert.NoError(t, err)
+	assert.Empty(t, list)
+}
+
+func TestExportImport(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.db")
+	writeRaw(t, path, map[string]string{
+		"a": `{"id":"a"}`,
+		"b": `{"name":"b","schemaVersion":2}`,
+	})
+
+	export, err := ExportFile(path, "test", testSchema, time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "test", export.Bucket)
+	assert.Equal(t, 2, export.Version)
+	assert.Len(t, export.Records, 2)
+	assert.Equal(t, "a", export.Records[0].Key)
+	assert.JSONEq(t, `{"name":"a","schemaVersion":2}`, string(export.Records[0].Data))
+
+	info, err := InspectFile(path, time.Second)
+	assert.NoError(t, err)
+	assert.Empty(t, info.Errors)
+	assert.Equal(t, []BucketInfo{{Name: "test", Records: 2, Versions: map[int]int{0: 1, 2: 1}}}, info.Buckets)
+
+	// import an export of older version into a new file
+	target := filepath.Join(dir, "target.db")
+	err = ImportFile(target, testSchema, &Export{
+		Bucket:  "test",
+		Version: 1,
+		Records: []Record{{Key: "c", Data: json.RawMessage(`{"id":"c"}`)}},
+	}, time.Second)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"name":"c","schemaVersion":2}`, readRaw(t, target, "c"))
+
+	// the records are replaced
+	assert.NoError(t, ImportFile(target, testSchema, export, time.Second))
+	assert.Equal(t, "", readRaw(t, target, "c"))
+	s, err := NewVersionedDiskStorage("test", target, testSchema, json.Marshal, deserializeRecord)
+	assert.NoError(t, err)
+	list, err := s.List()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []interface{}{record{Name: "a"}, record{Name: "b"}}, list)
+
+	// the file is held by the storage
+	_, err = ExportFile(target, "test", testSchema, 100*time.Millisecond)
+	assert.ErrorIs(t, err, ErrLocked)
+	err = ImportFile(target, testSchema, export, 100*time.Millisecond)
+	assert.ErrorIs(t, err, ErrLocked)
+}
@@ -2,9 +2,11 @@ package storage
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/AliyunContainerService/terway/pkg/logger"
 
@@ -85,6 +87,7 @@ type Deserializer func([]byte) (interface{}, error)
 type DiskStorage struct {
 	db           *bolt.DB
 	name         string
+	schema       *Schema
 	memory       *MemoryStorage
 	serializer   Serializer
 	deserializer Deserializer
@@ -92,11 +95,23 @@ type DiskStorage struct {
 
 // NewDiskStorage return new disk storage
 func NewDiskStorage(name string, path string, serializer Serializer, deserializer Deserializer) (Storage, error) {
+	return NewVersionedDiskStorage(name, path, nil, serializer, deserializer)
+}
+
+// NewVersionedDiskStorage return new disk storage, the records are stamped with the version of schema,
+// and the records of older version are migrated on load.
+// ErrCorrupted or ErrUnsupportedVersion is returned if the db can not be loaded, see Quarantine.
+func NewVersionedDiskStorage(name string, path string, schema *Schema, serializer Serializer, deserializer Deserializer) (Storage, error) {
+	if schema != nil {
+		if err := schema.Validate(); err != nil {
+			return nil, err
+		}
+	}
 	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
 		return nil, err
 	}
 
-	db, err := bolt.Open(path, 0600, nil)
+	db, err := openDB(path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -104,6 +119,7 @@ func NewDiskStorage(name string, path string, serializer Serializer, deserialize
 	diskstorage := &DiskStorage{
 		db:           db,
 		name:         name,
+		schema:       schema,
 		memory:       NewMemoryStorage(),
 		serializer:   serializer,
 		deserializer: deserializer,
@@ -112,18 +128,36 @@ func NewDiskStorage(name string, path string, serializer Serializer, deserialize
 	err = diskstorage.load()
 
 	if err != nil {
+		_ = db.Close()
 		return nil, err
 	}
 
 	return diskstorage, nil
 }
 
+// Quarantine move the db file aside, so a new one can be created on the path.
+// The path of the moved file is returned.
+func Quarantine(path string) (string, error) {
+	backup := fmt.Sprintf("%s.corrupted.%d", path, time.Now().Unix())
+	err := os.Rename(path, backup)
+	if err != nil {
+		return "", err
+	}
+	return backup, nil
+}
+
 // Put somethings into disk storage
 func (d *DiskStorage) Put(key string, value interface{}) error {
 	data, err := d.serializer(value)
 	if err != nil {
 		return err
 	}
+	if d.schema != nil {
+		data, err = d.schema.Stamp(data)
+		if err != nil {
+			return err
+		}
+	}
 
 	err = d.db.Update(func(tx *bolt.Tx) error {
 		b := tx.Bucket([]byte(d.name))
@@ -135,23 +169,45 @@ func (d *DiskStorage) Put(key string, value interface{}) error {
 	return d.memory.Put(key, value)
 }
 
-// load all data from disk db
-func (d *DiskStorage) load() error {
-	err := d.db.Update(func(tx *bolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists([]byte(d.name))
-		return err
-	})
-	if err != nil {
-		return err
-	}
+// load all data from disk db, the records of older version are migrated in the same transaction
+func (d *DiskStorage) load() (err error) {
+	// bolt panics on the corrupted pages
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrCorrupted, r)
+		}
+	}()
 
-	err = d.db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(d.name))
+	return d.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(d.name))
+		if err != nil {
+			return err
+		}
+
+		migrated := make(map[string][]byte)
 		cursor := b.Cursor()
 		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
 			log.Infof("load pod cache %s from db", k)
-			obj, err := d.deserializer(v)
+			data := v
+			if d.schema != nil {
+				var version int
+				data, version, err = d.schema.Migrate(v)
+				if errors.Is(err, ErrUnsupportedVersion) {
+					return fmt.Errorf("error load %s, %w", k, err)
+				}
+				if err != nil {
+					return fmt.Errorf("%w: error load %s, %v", ErrCorrupted, k, err)
+				}
+				if version != d.schema.Version {
+					log.Infof("migrate %s from version %d to %d", k, version, d.schema.Version)
+					migrated[string(k)] = data
+				}
+			}
+			obj, err := d.deserializer(data)
 			if err != nil {
+				if d.schema != nil {
+					return fmt.Errorf("%w: error load %s, %v", ErrCorrupted, k, err)
+				}
 				return err
 			}
 			err = d.memory.Put(string(k), obj)
@@ -159,9 +215,16 @@ func (d *DiskStorage) load() error {
 				return err
 			}
 		}
+
+		// the bucket can not be modified while iterating
+		for k, v := range migrated {
+			err = b.Put([]byte(k), v)
+			if err != nil {
+				return err
+			}
+		}
 		return nil
 	})
-	return err
 }
 
 // Get value in disk storage
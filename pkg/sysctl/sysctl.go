@@ -27,3 +27,12 @@ func EnsureConf(fPath string, cfg string) error {
 	}
 	return os.WriteFile(fPath, []byte(cfg), 0644)
 }
+
+// GetConf read the conf without the trailing spaces
+func GetConf(fPath string) (string, error) {
+	content, err := os.ReadFile(fPath)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes.TrimSpace(content)), nil
+}
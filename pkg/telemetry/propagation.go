@@ -0,0 +1,91 @@
+/*
+Copyright 2022 Terway Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package telemetry
+
+import (
+	"context"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+// the w3c trace context is kept in the pod annotations, so terway-controlplane can continue the trace of the daemon
+const (
+	AnnotationTraceParent = "k8s.aliyun.com/traceparent"
+	AnnotationTraceState  = "k8s.aliyun.com/tracestate"
+)
+
+var annotationKeys = map[string]string{
+	"traceparent": AnnotationTraceParent,
+	"tracestate":  AnnotationTraceState,
+}
+
+// annotationCarrier map the w3c headers to the pod annotations
+type annotationCarrier map[string]string
+
+func (c annotationCarrier) Get(key string) string {
+	return c[annotationKeys[key]]
+}
+
+func (c annotationCarrier) Set(key, value string) {
+	if k, ok := annotationKeys[key]; ok {
+		c[k] = value
+	}
+}
+
+func (c annotationCarrier) Keys() []string {
+	var keys []string
+	for k, v := range annotationKeys {
+		if _, ok := c[v]; ok {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+var _ propagation.TextMapCarrier = annotationCarrier{}
+
+// InjectAnnotations return the annotations carrying the trace context of ctx, nil if ctx is not sampled
+func InjectAnnotations(ctx context.Context) map[string]string {
+	if !trace.SpanContextFromContext(ctx).IsSampled() {
+		return nil
+	}
+	annotations := map[string]string{}
+	otel.GetTextMapPropagator().Inject(ctx, annotationCarrier(annotations))
+	return annotations
+}
+
+// ExtractAnnotations return ctx with the remote trace context in the annotations
+func ExtractAnnotations(ctx context.Context, annotations map[string]string) context.Context {
+	if annotations[AnnotationTraceParent] == "" {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, annotationCarrier(annotations))
+}
+
+// UnaryClientInterceptor start a client span for each call and inject the trace context into the grpc metadata
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return otelgrpc.UnaryClientInterceptor()
+}
+
+// UnaryServerInterceptor start a server span for each call as child of the trace context in the grpc metadata
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return otelgrpc.UnaryServerInterceptor()
+}
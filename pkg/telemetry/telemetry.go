@@ -0,0 +1,155 @@
+/*
+Copyright 2022 Terway Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package telemetry export the spans of cni calls, daemon rpc, pool waits and openapi calls to an otlp collector.
+// The trace context is propagated from the cni plugin over grpc metadata, and to terway-controlplane over pod annotation.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/AliyunContainerService/terway"
+
+// attributes of terway spans
+const (
+	AttrPool         = attribute.Key("terway.pool.name")
+	AttrResourceID   = attribute.Key("terway.resource.id")
+	AttrResourceType = attribute.Key("terway.resource.type")
+	AttrENIID        = attribute.Key("terway.eni.id")
+	AttrIPCount      = attribute.Key("terway.ip.count")
+	AttrRequestID    = attribute.Key("terway.openapi.request_id")
+	AttrErrorType    = attribute.Key("terway.openapi.error_type")
+)
+
+// Config is the span export config
+type Config struct {
+	// Endpoint is the otlp grpc endpoint as host:port, prefix it with http:// to disable tls, empty to disable tracing
+	Endpoint string
+	// SamplingRatio is the ratio of the root spans sampled, the decision of the remote parent is always respected.
+	// 0 is treated as 1
+	SamplingRatio float64
+}
+
+func init() {
+	// the trace context is passed through even tracing is disabled in this process
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+}
+
+// Setup install the otlp exporter as the global tracer provider, the returned func flush and stop the exporter.
+// Nothing is installed if the endpoint is empty.
+func Setup(ctx context.Context, serviceName string, cfg Config) (func(context.Context) error, error) {
+	if cfg.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	if cfg.SamplingRatio < 0 || cfg.SamplingRatio > 1 {
+		return nil, fmt.Errorf("invalid sampling ratio %v, must be in [0, 1]", cfg.SamplingRatio)
+	}
+
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithTimeout(5 * time.Second)}
+	if strings.HasPrefix(cfg.Endpoint, "http://") {
+		opts = append(opts, otlptracegrpc.WithEndpoint(strings.TrimPrefix(cfg.Endpoint, "http://")), otlptracegrpc.WithInsecure())
+	} else {
+		opts = append(opts, otlptracegrpc.WithEndpoint(strings.TrimPrefix(cfg.Endpoint, "https://")))
+	}
+	exporter, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error create otlp exporter, %w", err)
+	}
+
+	provider := NewTracerProvider(serviceName, cfg.SamplingRatio, sdktrace.WithBatcher(exporter))
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// NewTracerProvider create the tracer provider with the terway sampler and resource
+func NewTracerProvider(serviceName string, samplingRatio float64, opts ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	if samplingRatio == 0 {
+		samplingRatio = 1
+	}
+	opts = append([]sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(samplingRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(serviceName))),
+	}, opts...)
+	return sdktrace.NewTracerProvider(opts...)
+}
+
+// Start start a span from the global tracer provider
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartBatch start a span for the work aggregated from several requests, the span is the child of the first sampled
+// request and linked to the others. The returned context is never canceled.
+func StartBatch(name string, requests []trace.SpanContext, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	ctx := context.Background()
+	var links []trace.Link
+	for _, sc := range requests {
+		if !sc.IsValid() {
+			continue
+		}
+		if !trace.SpanContextFromContext(ctx).IsValid() && sc.IsSampled() {
+			ctx = trace.ContextWithSpanContext(ctx, sc)
+			continue
+		}
+		links = append(links, trace.Link{SpanContext: sc})
+	}
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...), trace.WithLinks(links...))
+}
+
+// End record the error and end the span
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// PodAttributes is the attributes to identify the pod
+func PodAttributes(namespace, name, containerID string) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		semconv.K8SNamespaceNameKey.String(namespace),
+		semconv.K8SPodNameKey.String(name),
+	}
+	if containerID != "" {
+		attrs = append(attrs, semconv.ContainerIDKey.String(containerID))
+	}
+	return attrs
+}
+
+// SetPodAttributes add the pod attributes to the current span
+func SetPodAttributes(ctx context.Context, namespace, name, containerID string) {
+	trace.SpanFromContext(ctx).SetAttributes(PodAttributes(namespace, name, containerID)...)
+}
+
+// Detach return a background context carrying the span of ctx, for the work outlives the request
+func Detach(ctx context.Context) context.Context {
+	return trace.ContextWithSpanContext(context.Background(), trace.SpanContextFromContext(ctx))
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2022 Terway Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package telemetry
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// collector is an in-process otlp collector
+type collector struct {
+	collectortrace.UnimplementedTraceServiceServer
+
+	lock  sync.Mutex
+	spans []*tracepb.ResourceSpans
+}
+
+func (c *collector) Export(ctx context.Context, req *collectortrace.ExportTraceServiceRequest) (*collectortrace.ExportTraceServiceResponse, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.spans = append(c.spans, req.ResourceSpans...)
+	return &collectortrace.ExportTraceServiceResponse{}, nil
+}
+
+func startCollector(t *testing.T) (*collector, string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	c := &collector{}
+	server := grpc.NewServer()
+	collectortrace.RegisterTraceServiceServer(server, c)
+	go func() {
+		_ = server.Serve(l)
+	}()
+	t.Cleanup(server.Stop)
+	return c, l.Addr().String()
+}
+
+func useRecorder() *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(NewTracerProvider("test", 1, sdktrace.WithSpanProcessor(recorder)))
+	return recorder
+}
+
+func TestSetup(t *testing.T) {
+	c, addr := startCollector(t)
+
+	shutdown, err := Setup(context.Background(), "terway-daemon", Config{Endpoint: "http://" + addr})
+	assert.NoError(t, err)
+
+	ctx, parent := Start(context.Background(), "AllocIP", PodAttributes("default", "foo", "abcd")...)
+	_, child := Start(ctx, "pool/Acquire")
+	End(child, nil)
+	End(parent, nil)
+	assert.NoError(t, shutdown(context.Background()))
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	assert.Len(t, c.spans, 1)
+	assert.Equal(t, "terway-daemon", c.spans[0].Resource.Attributes[0].Value.GetStringValue())
+
+	spans := c.spans[0].ScopeSpans[0].Spans
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "pool/Acquire", spans[0].Name)
+	assert.Equal(t, "AllocIP", spans[1].Name)
+	assert.Equal(t, spans[1].SpanId, spans[0].ParentSpanId)
+
+	attrs := map[string]string{}
+	for _, attr := range spans[1].Attributes {
+		attrs[attr.Key] = attr.Value.GetStringValue()
+	}
+	assert.Equal(t, map[string]string{
+		"k8s.namespace.name": "default",
+		"k8s.pod.name":       "foo",
+		"container.id":       "abcd",
+	}, attrs)
+}
+
+func TestSetupDisabled(t *testing.T) {
+	shutdown, err := Setup(context.Background(), "terway-cni", Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	_, err = Setup(context.Background(), "terway-cni", Config{Endpoint: "127.0.0.1:4317", SamplingRatio: 2})
+	assert.Error(t, err)
+}
+
+func TestGRPCPropagation(t *testing.T) {
+	recorder := useRecorder()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := grpc.NewServer(grpc.UnaryInterceptor(UnaryServerInterceptor()))
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	go func() {
+		_ = server.Serve(l)
+	}()
+	defer server.Stop()
+
+	conn, err := grpc.Dial(l.Addr().String(), grpc.WithInsecure(), grpc.WithUnaryInterceptor(UnaryClientInterceptor()))
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	ctx, root := Start(context.Background(), "cni/ADD")
+	_, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	assert.NoError(t, err)
+	root.End()
+
+	var client, srv sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		switch span.SpanKind() {
+		case trace.SpanKindClient:
+			client = span
+		case trace.SpanKindServer:
+			srv = span
+		}
+	}
+	if assert.NotNil(t, client) && assert.NotNil(t, srv) {
+		assert.Equal(t, root.SpanContext().TraceID(), srv.SpanContext().TraceID())
+		assert.Equal(t, client.SpanContext().SpanID(), srv.Parent().SpanID())
+		assert.True(t, srv.Parent().IsRemote())
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	useRecorder()
+
+	assert.Nil(t, InjectAnnotations(context.Background()))
+
+	ctx, span := Start(context.Background(), "WaitPodENI")
+	defer span.End()
+	annotations := InjectAnnotations(ctx)
+	assert.Contains(t, annotations, AnnotationTraceParent)
+
+	// the pod annotations are kept
+	annotations["foo"] = "bar"
+	remote := trace.SpanContextFromContext(ExtractAnnotations(context.Background(), annotations))
+	assert.True(t, remote.IsRemote())
+	assert.Equal(t, span.SpanContext().TraceID(), remote.TraceID())
+	assert.Equal(t, span.SpanContext().SpanID(), remote.SpanID())
+
+	ctx = ExtractAnnotations(context.Background(), map[string]string{"foo": "bar"})
+	assert.False(t, trace.SpanContextFromContext(ctx).IsValid())
+}
+
+func TestStartBatch(t *testing.T) {
+	recorder := useRecorder()
+
+	_, first := Start(context.Background(), "first")
+	_, second := Start(context.Background(), "second")
+	_, span := StartBatch("eni/AllocateIPs", []trace.SpanContext{{}, first.SpanContext(), second.SpanContext()})
+	span.End()
+
+	ended := recorder.Ended()
+	assert.Len(t, ended, 1)
+	assert.Equal(t, first.SpanContext().SpanID(), ended[0].Parent().SpanID())
+	assert.Len(t, ended[0].Links(), 1)
+	assert.Equal(t, second.SpanContext().SpanID(), ended[0].Links()[0].SpanContext.SpanID())
+}
+
+func TestDetach(t *testing.T) {
+	useRecorder()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx, span := Start(ctx, "pool/Acquire")
+	defer span.End()
+	cancel()
+
+	detached := Detach(ctx)
+	assert.NoError(t, detached.Err())
+	assert.Equal(t, span.SpanContext(), trace.SpanContextFromContext(detached))
+}
@@ -99,6 +99,32 @@ func (t *tracingRPC) GetResourceMapping(_ context.Context, _ *rpc.Placeholder) (
 	}, nil
 }
 
+func (t *tracingRPC) GetLeaks(_ context.Context, request *rpc.LeakRequest) (*rpc.LeakReply, error) {
+	leaks, err := t.tracer.FindLeaks(request.Repair)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*rpc.Leak
+	for _, l := range leaks {
+		result = append(result, toRPCLeak(*l))
+	}
+
+	return &rpc.LeakReply{
+		Leaks: result,
+	}, nil
+}
+
+func toRPCLeak(leak Leak) *rpc.Leak {
+	return &rpc.Leak{
+		Type:       rpc.LeakType(leak.Type),
+		ResourceID: leak.ResourceID,
+		PodName:    leak.PodName,
+		Message:    leak.Message,
+		Repaired:   leak.Repaired,
+	}
+}
+
 func toRPCMapping(res PodMapping) *rpc.PodResourceMapping {
 	rMapping := rpc.PodResourceMapping{
 		Type:                rpc.ResourceMappingType_MappingTypeNormal,
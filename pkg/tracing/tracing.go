@@ -15,6 +15,8 @@ const (
 	ResourceTypeResourcePool = "resource_pool"
 	// ResourceTypeFactory represents resource of a factory(eniip/eni)
 	ResourceTypeFactory = "factory"
+	// ResourceTypeCircuitBreaker represents the circuit breakers of openapi
+	ResourceTypeCircuitBreaker = "circuit_breaker"
 
 	// DisposeResourceFailed DisposeResourceFailed
 	DisposeResourceFailed = "DisposeResourceFailed"
@@ -33,6 +35,35 @@ type PodMapping struct {
 	RemoteResID  string
 }
 
+// LeakType is the kind of discrepancy found between metadata, resource db, pool and pods
+type LeakType int
+
+// LeakType, the values are the same as rpc.LeakType
+const (
+	// LeakStaleRelation relation in db, but the pod is not exist
+	LeakStaleRelation LeakType = iota
+	// LeakOrphanInUse in use in pool, but no relation in db
+	LeakOrphanInUse
+	// LeakMissingLocal relation in db, but not in pool
+	LeakMissingLocal
+	// LeakMissingRemote in pool, but not in metadata
+	LeakMissingRemote
+	// LeakUntracked in metadata, but not in pool
+	LeakUntracked
+	// LeakInvalid marked invalid in pool, wait to be disposed
+	LeakInvalid
+)
+
+// Leak is a resource which is not backing any pod as expected
+type Leak struct {
+	Type       LeakType
+	ResourceID string
+	// PodName is namespace/name of the related pod
+	PodName  string
+	Message  string
+	Repaired bool
+}
+
 var (
 	defaultTracer Tracer
 )
@@ -86,6 +117,11 @@ type ResMapping interface {
 	GetResourceMapping() ([]*PodMapping, error)
 }
 
+// LeakFinder finds the leaked resources, and repair the safe cases if repair is true
+type LeakFinder interface {
+	FindLeaks(repair bool) ([]*Leak, error)
+}
+
 // PodEventRecorder records event on pod
 type PodEventRecorder func(podName, podNamespace, eventType, reason, message string) error
 
@@ -101,6 +137,7 @@ type Tracer struct {
 	// store TraceHandler by resource name
 	traceMap        map[string]resourceMap
 	resourceMapping ResMapping
+	leakFinder      LeakFinder
 	podEvent        PodEventRecorder
 	nodeEvent       NodeEventRecorder
 }
@@ -146,6 +183,11 @@ func (t *Tracer) RegisterResourceMapping(mapping ResMapping) {
 	t.resourceMapping = mapping
 }
 
+// RegisterLeakFinder registers leak finder to the tracer
+func (t *Tracer) RegisterLeakFinder(finder LeakFinder) {
+	t.leakFinder = finder
+}
+
 // RegisterEventRecorder registers pod & node event recorder to a tracer
 func (t *Tracer) RegisterEventRecorder(node NodeEventRecorder, pod PodEventRecorder) {
 	t.nodeEvent = node
@@ -264,6 +306,16 @@ func (t *Tracer) GetResourceMapping() ([]*PodMapping, error) {
 	return t.resourceMapping.GetResourceMapping()
 }
 
+// FindLeaks finds the leaked resources from the handler
+// if the handler has not been registered, there will be error
+func (t *Tracer) FindLeaks(repair bool) ([]*Leak, error) {
+	if t.leakFinder == nil {
+		return nil, errors.New("no leak finder registered")
+	}
+
+	return t.leakFinder.FindLeaks(repair)
+}
+
 // Register registers a TraceHandler to the default tracer
 func Register(typ, resourceName string, handler TraceHandler) error {
 	return defaultTracer.Register(typ, resourceName, handler)
@@ -274,6 +326,11 @@ func RegisterResourceMapping(handler ResMapping) {
 	defaultTracer.RegisterResourceMapping(handler)
 }
 
+// RegisterLeakFinder register leak finder to the default tracer
+func RegisterLeakFinder(finder LeakFinder) {
+	defaultTracer.RegisterLeakFinder(finder)
+}
+
 // Unregister removes TraceHandler from tracer. do nothing if not found
 func Unregister(typ, resourceName string) {
 	defaultTracer.Unregister(typ, resourceName)
@@ -0,0 +1,331 @@
+package datapath
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/AliyunContainerService/terway/plugin/driver/nic"
+	"github.com/AliyunContainerService/terway/plugin/driver/types"
+	"github.com/AliyunContainerService/terway/plugin/driver/utils"
+	terwayTypes "github.com/AliyunContainerService/terway/types"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	hintContainer   = "pod network config is broken, recreate the pod to rebuild it"
+	hintHostVETH    = "host side veth config is missing, check terway logs or recreate the pod"
+	hintENI         = "eni config on host is not as expected, restart terway to reconcile it"
+	hintIPVlanSlave = "ipvlan slave is not as expected, host stack and service traffic may not work, restart terway to reconcile it"
+	hintFilter      = "tc redirect filter is missing, host stack and service traffic will not work, restart terway to reconcile it"
+	hintBandwidth   = "bandwidth limit is not applied, recreate the pod to rebuild it"
+)
+
+// CheckResult is the result of one diagnose item
+type CheckResult struct {
+	Name    string
+	Passed  bool
+	Message string
+	Hint    string
+}
+
+func passed(name string) CheckResult {
+	return CheckResult{Name: name, Passed: true}
+}
+
+func failed(name, hint string, format string, a ...interface{}) CheckResult {
+	return CheckResult{Name: name, Message: fmt.Sprintf(format, a...), Hint: hint}
+}
+
+func diffResult(name, hint string, link netlink.Link, conf *nic.Conf) CheckResult {
+	diffs, err := nic.Diff(link, conf)
+	if err != nil {
+		return failed(name, hint, "%v", err)
+	}
+	if len(diffs) > 0 {
+		return failed(name, hint, "%s", strings.Join(diffs, "; "))
+	}
+	return passed(name)
+}
+
+func tcResult(name string, link netlink.Link, bandwidthInBytes uint64) CheckResult {
+	found, err := utils.FoundTC(link, bandwidthInBytes)
+	if err != nil {
+		return failed(name, hintBandwidth, "%v", err)
+	}
+	if !found {
+		return failed(name, hintBandwidth, "tbf qdisc with rate %d is missing on %s", bandwidthInBytes, link.Attrs().Name)
+	}
+	return passed(name)
+}
+
+// Diagnose check the datapath of the pod against the setup config, nothing is changed.
+// eniMAC is the mac of the eni allocated to the pod, used to verify the exclusive eni in container.
+func Diagnose(cfg *types.SetupConfig, eniMAC string, netNS ns.NetNS) []CheckResult {
+	switch cfg.DP {
+	case types.VPCRoute:
+		return diagnoseVPCRoute(cfg, netNS)
+	case types.PolicyRoute:
+		return diagnosePolicyRoute(cfg, netNS)
+	case types.IPVlan:
+		return diagnoseIPVlan(cfg, netNS)
+	case types.ExclusiveENI:
+		return diagnoseExclusiveENI(cfg, eniMAC, netNS)
+	case types.Vlan:
+		return diagnoseVlan(cfg, netNS)
+	}
+	return []CheckResult{failed("datapath", "", "unsupported datapath %d", cfg.DP)}
+}
+
+// diagnoseContainer check the container link in netns
+func diagnoseContainer(cfg *types.SetupConfig, netNS ns.NetNS, gen func(link netlink.Link) *nic.Conf, extra func(link netlink.Link) []CheckResult) []CheckResult {
+	name := fmt.Sprintf("container %s", cfg.ContainerIfName)
+	var results []CheckResult
+	err := netNS.Do(func(_ ns.NetNS) error {
+		contLink, err := netlink.LinkByName(cfg.ContainerIfName)
+		if err != nil {
+			return err
+		}
+		results = append(results, diffResult(name, hintContainer, contLink, gen(contLink)))
+		if extra != nil {
+			results = append(results, extra(contLink)...)
+		}
+		return nil
+	})
+	if err != nil {
+		return []CheckResult{failed(name, hintContainer, "error find link %s in container, %v", cfg.ContainerIfName, err)}
+	}
+	return results
+}
+
+func egressResult(cfg *types.SetupConfig) func(link netlink.Link) []CheckResult {
+	return func(link netlink.Link) []CheckResult {
+		if cfg.Egress == 0 {
+			return nil
+		}
+		return []CheckResult{tcResult("egress bandwidth", link, cfg.Egress)}
+	}
+}
+
+func diagnoseHostVETH(cfg *types.SetupConfig) (netlink.Link, []CheckResult) {
+	name := fmt.Sprintf("host veth %s", cfg.HostVETHName)
+	hostVETH, err := netlink.LinkByName(cfg.HostVETHName)
+	if err != nil {
+		return nil, []CheckResult{failed(name, hintHostVETH, "error get host veth %s, %v", cfg.HostVETHName, err)}
+	}
+	return hostVETH, nil
+}
+
+func diagnoseVPCRoute(cfg *types.SetupConfig, netNS ns.NetNS) []CheckResult {
+	hostVETH, results := diagnoseHostVETH(cfg)
+	if hostVETH == nil {
+		return results
+	}
+
+	// the container ip is allocated by the delegate ipam, find it from the pod cidr
+	podCIDR := cfg.ContainerIPNet.IPv4
+	err := netNS.Do(func(_ ns.NetNS) error {
+		contLink, err := netlink.LinkByName(cfg.ContainerIfName)
+		if err != nil {
+			return err
+		}
+		addrs, err := netlink.AddrList(contLink, netlink.FAMILY_V4)
+		if err != nil {
+			return err
+		}
+		for _, addr := range addrs {
+			if podCIDR.Contains(addr.IP) {
+				cfg.ContainerIPNet = &terwayTypes.IPNetSet{IPv4: &net.IPNet{IP: addr.IP, Mask: podCIDR.Mask}}
+				return nil
+			}
+		}
+		return fmt.Errorf("no address in pod cidr %s", podCIDR)
+	})
+	if err != nil {
+		return append(results, failed(fmt.Sprintf("container %s", cfg.ContainerIfName), hintContainer, "%v", err))
+	}
+
+	results = append(results, diagnoseContainer(cfg, netNS, func(link netlink.Link) *nic.Conf {
+		return generateContCfgForVPCRoute(cfg, link, hostVETH.Attrs().HardwareAddr)
+	}, egressResult(cfg))...)
+
+	results = append(results, diffResult(fmt.Sprintf("host veth %s", cfg.HostVETHName), hintHostVETH, hostVETH, generateHostPeerCfgForVPCRoute(cfg, hostVETH)))
+	if cfg.Ingress > 0 {
+		results = append(results, tcResult("ingress bandwidth", hostVETH, cfg.Ingress))
+	}
+	return results
+}
+
+func diagnosePolicyRoute(cfg *types.SetupConfig, netNS ns.NetNS) []CheckResult {
+	hostVETH, results := diagnoseHostVETH(cfg)
+	if hostVETH == nil {
+		return results
+	}
+
+	results = append(results, diagnoseContainer(cfg, netNS, func(link netlink.Link) *nic.Conf {
+		return generateContCfgForPolicy(cfg, link, hostVETH.Attrs().HardwareAddr)
+	}, egressResult(cfg))...)
+
+	eni, err := netlink.LinkByIndex(cfg.ENIIndex)
+	if err != nil {
+		return append(results, failed("eni", hintENI, "error get eni by index %d, %v", cfg.ENIIndex, err))
+	}
+	table := utils.GetRouteTableID(eni.Attrs().Index)
+
+	results = append(results, diffResult(fmt.Sprintf("eni %s", eni.Attrs().Name), hintENI, eni, generateENICfgForPolicy(cfg, eni, table)))
+	results = append(results, diffResult(fmt.Sprintf("host veth %s", cfg.HostVETHName), hintHostVETH, hostVETH, generateHostPeerCfgForPolicy(cfg, hostVETH, table)))
+	if cfg.Ingress > 0 {
+		results = append(results, tcResult("ingress bandwidth", hostVETH, cfg.Ingress))
+	}
+	return results
+}
+
+func diagnoseIPVlan(cfg *types.SetupConfig, netNS ns.NetNS) []CheckResult {
+	var results []CheckResult
+
+	results = append(results, diagnoseContainer(cfg, netNS, func(link netlink.Link) *nic.Conf {
+		return generateContCfgForIPVlan(cfg, link)
+	}, func(link netlink.Link) []CheckResult {
+		// edt mode is handled by ebpf, only the fq qdisc is set
+		if cfg.Egress == 0 || cfg.BandwidthMode == "edt" {
+			return nil
+		}
+		return []CheckResult{tcResult("egress bandwidth", link, cfg.Egress)}
+	})...)
+
+	parentLink, err := netlink.LinkByIndex(cfg.ENIIndex)
+	if err != nil {
+		return append(results, failed("eni", hintENI, "error get eni by index %d, %v", cfg.ENIIndex, err))
+	}
+	results = append(results, diffResult(fmt.Sprintf("eni %s", parentLink.Attrs().Name), hintENI, parentLink, generateENICfgForIPVlan(cfg, parentLink)))
+
+	slaveName := (&IPvlanDriver{}).initSlaveName(parentLink.Attrs().Index)
+	name := fmt.Sprintf("ipvlan slave %s", slaveName)
+	slaveLink, err := netlink.LinkByName(slaveName)
+	if err != nil {
+		return append(results, failed(name, hintIPVlanSlave, "error get ipvlan slave %s, %v", slaveName, err))
+	}
+	result := diffResult(name, hintIPVlanSlave, slaveLink, generateSlaveLinkCfgForIPVlan(cfg, slaveLink))
+	if slaveLink.Attrs().Flags&unix.IFF_NOARP == 0 {
+		msg := fmt.Sprintf("link %s arp is on", slaveName)
+		if !result.Passed {
+			msg = result.Message + "; " + msg
+		}
+		result = failed(name, hintIPVlanSlave, "%s", msg)
+	}
+	results = append(results, result)
+
+	redirectCIDRs := append(cfg.HostStackCIDRs, cfg.ServiceCIDR.IPv4)
+	diffs, err := diffRedirectFilters(parentLink, redirectCIDRs, slaveLink.Attrs().Index)
+	if err != nil {
+		results = append(results, failed("tc redirect filter", hintFilter, "%v", err))
+	} else if len(diffs) > 0 {
+		results = append(results, failed("tc redirect filter", hintFilter, "%s", strings.Join(diffs, "; ")))
+	} else {
+		results = append(results, passed("tc redirect filter"))
+	}
+	return results
+}
+
+// diffRedirectFilters return the cidrs which is not redirected to the dst link
+func diffRedirectFilters(link netlink.Link, cidrs []*net.IPNet, dstIndex int) ([]string, error) {
+	parent := uint32(netlink.HANDLE_CLSACT&0xffff0000 | netlink.HANDLE_MIN_EGRESS&0x0000ffff)
+	filters, err := netlink.FilterList(link, parent)
+	if err != nil {
+		return nil, fmt.Errorf("list egress filter for %s error, %w", link.Attrs().Name, err)
+	}
+
+	var diffs []string
+	for _, v := range cidrs {
+		if v == nil {
+			continue
+		}
+		rule, err := dstIPRule(link.Attrs().Index, v, dstIndex, netlink.TCA_INGRESS_REDIR)
+		if err != nil {
+			return nil, fmt.Errorf("create redirect rule error, %w", err)
+		}
+		found := false
+		for _, filter := range filters {
+			if rule.isMatch(filter) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			diffs = append(diffs, fmt.Sprintf("redirect filter for %s is missing on %s", v, link.Attrs().Name))
+		}
+	}
+	return diffs, nil
+}
+
+func diagnoseExclusiveENI(cfg *types.SetupConfig, eniMAC string, netNS ns.NetNS) []CheckResult {
+	var results []CheckResult
+
+	createPeer := !cfg.DisableCreatePeer && cfg.ContainerIfName == "eth0"
+	var hostVETH netlink.Link
+	if createPeer {
+		hostVETH, results = diagnoseHostVETH(cfg)
+		if hostVETH == nil {
+			return results
+		}
+	}
+
+	results = append(results, diagnoseContainer(cfg, netNS, func(link netlink.Link) *nic.Conf {
+		return generateContCfgForExclusiveENI(cfg, link)
+	}, func(link netlink.Link) []CheckResult {
+		var r []CheckResult
+		if eniMAC != "" {
+			mac := link.Attrs().HardwareAddr.String()
+			if !strings.EqualFold(mac, eniMAC) {
+				r = append(r, failed("eni mac", hintContainer, "link %s mac is %s, expected %s", link.Attrs().Name, mac, eniMAC))
+			} else {
+				r = append(r, passed("eni mac"))
+			}
+		}
+		r = append(r, egressResult(cfg)(link)...)
+
+		if !createPeer {
+			return r
+		}
+		name := fmt.Sprintf("container %s", defaultVethForENI)
+		veth1, err := netlink.LinkByName(defaultVethForENI)
+		if err != nil {
+			return append(r, failed(name, hintContainer, "error find link %s in container, %v", defaultVethForENI, err))
+		}
+		return append(r, diffResult(name, hintContainer, veth1, generateVeth1Cfg(cfg, veth1, hostVETH.Attrs().HardwareAddr)))
+	})...)
+
+	if createPeer {
+		results = append(results, diffResult(fmt.Sprintf("host veth %s", cfg.HostVETHName), hintHostVETH, hostVETH, generateHostSlaveCfg(cfg, hostVETH)))
+	}
+	return results
+}
+
+func diagnoseVlan(cfg *types.SetupConfig, netNS ns.NetNS) []CheckResult {
+	var results []CheckResult
+
+	results = append(results, diagnoseContainer(cfg, netNS, func(link netlink.Link) *nic.Conf {
+		return generateContCfgForVlan(cfg, link)
+	}, func(link netlink.Link) []CheckResult {
+		var r []CheckResult
+		vlanLink, ok := link.(*netlink.Vlan)
+		if !ok {
+			r = append(r, failed("vlan id", hintContainer, "link %s is %s, expected vlan", link.Attrs().Name, link.Type()))
+		} else if vlanLink.VlanId != cfg.Vid {
+			r = append(r, failed("vlan id", hintContainer, "link %s vlan id is %d, expected %d", link.Attrs().Name, vlanLink.VlanId, cfg.Vid))
+		} else {
+			r = append(r, passed("vlan id"))
+		}
+		return append(r, egressResult(cfg)(link)...)
+	})...)
+
+	master, err := netlink.LinkByIndex(cfg.ENIIndex)
+	if err != nil {
+		return append(results, failed("eni", hintENI, "error get link by index %d, %v", cfg.ENIIndex, err))
+	}
+	results = append(results, diffResult(fmt.Sprintf("eni %s", master.Attrs().Name), hintENI, master, generateENICfgForVlan(cfg)))
+	return results
+}
@@ -0,0 +1,423 @@
+//go:build privileged
+
+package datapath
+
+import (
+	"net"
+	"runtime"
+	"testing"
+
+	"github.com/AliyunContainerService/terway/plugin/driver/types"
+	terwayTypes "github.com/AliyunContainerService/terway/types"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/containernetworking/plugins/pkg/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink"
+)
+
+// failedResults return the names of the failed check
+func failedResults(results []CheckResult) []string {
+	var names []string
+	for _, r := range results {
+		if !r.Passed {
+			names = append(names, r.Name)
+		}
+	}
+	return names
+}
+
+func TestDiagnoseVPCRoute(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var err error
+	hostNS, err := testutils.NewNS()
+	assert.NoError(t, err)
+
+	containerNS, err := testutils.NewNS()
+	assert.NoError(t, err)
+
+	err = hostNS.Set()
+	assert.NoError(t, err)
+
+	defer func() {
+		err := containerNS.Close()
+		assert.NoError(t, err)
+
+		err = testutils.UnmountNS(containerNS)
+		assert.NoError(t, err)
+
+		err = hostNS.Close()
+		assert.NoError(t, err)
+
+		err = testutils.UnmountNS(hostNS)
+		assert.NoError(t, err)
+	}()
+
+	cfg := &types.SetupConfig{
+		DP:              types.VPCRoute,
+		HostVETHName:    "veth1",
+		ContainerIfName: "eth0",
+		ContainerIPNet: &terwayTypes.IPNetSet{
+			IPv4: containerIPNet,
+		},
+		GatewayIP: &terwayTypes.IPSet{
+			IPv4: ipv4GW,
+		},
+		MTU: 1499,
+	}
+	err = NewVPCRoute().Setup(cfg, containerNS)
+	assert.NoError(t, err)
+
+	// the daemon only known the pod cidr
+	diagnoseCfg := *cfg
+	diagnoseCfg.ContainerIPNet = &terwayTypes.IPNetSet{
+		IPv4: &net.IPNet{IP: containerIPNet.IP.Mask(containerIPNet.Mask), Mask: containerIPNet.Mask},
+	}
+	results := Diagnose(&diagnoseCfg, "", containerNS)
+	assert.NotEmpty(t, results)
+	for _, r := range results {
+		assert.True(t, r.Passed, "%s: %s", r.Name, r.Message)
+	}
+
+	// remove the default route in container
+	_ = containerNS.Do(func(netNS ns.NetNS) error {
+		routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{
+			Dst: nil,
+		}, netlink.RT_FILTER_DST)
+		assert.NoError(t, err)
+		for _, route := range routes {
+			err = netlink.RouteDel(&route)
+			assert.NoError(t, err)
+		}
+		return nil
+	})
+
+	diagnoseCfg.ContainerIPNet = &terwayTypes.IPNetSet{
+		IPv4: &net.IPNet{IP: containerIPNet.IP.Mask(containerIPNet.Mask), Mask: containerIPNet.Mask},
+	}
+	results = Diagnose(&diagnoseCfg, "", containerNS)
+	failed := 0
+	for _, r := range results {
+		if !r.Passed {
+			failed++
+			assert.Equal(t, "container eth0", r.Name)
+			assert.NotEmpty(t, r.Hint)
+		}
+	}
+	assert.Equal(t, 1, failed)
+}
+
+func TestDiagnosePolicyRoute(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var err error
+	hostNS, err := testutils.NewNS()
+	assert.NoError(t, err)
+
+	containerNS, err := testutils.NewNS()
+	assert.NoError(t, err)
+
+	err = hostNS.Set()
+	assert.NoError(t, err)
+
+	defer func() {
+		err := containerNS.Close()
+		assert.NoError(t, err)
+
+		err = testutils.UnmountNS(containerNS)
+		assert.NoError(t, err)
+
+		err = hostNS.Close()
+		assert.NoError(t, err)
+
+		err = testutils.UnmountNS(hostNS)
+		assert.NoError(t, err)
+	}()
+
+	err = netlink.LinkAdd(&netlink.Dummy{
+		LinkAttrs: netlink.LinkAttrs{Name: "eni"},
+	})
+	assert.NoError(t, err)
+	eni, err := netlink.LinkByName("eni")
+	assert.NoError(t, err)
+
+	cfg := &types.SetupConfig{
+		DP:              types.PolicyRoute,
+		HostVETHName:    "hostveth",
+		ContainerIfName: "eth0",
+		ContainerIPNet: &terwayTypes.IPNetSet{
+			IPv4: containerIPNet,
+			IPv6: containerIPNetIPv6,
+		},
+		GatewayIP: &terwayTypes.IPSet{
+			IPv4: ipv4GW,
+			IPv6: ipv6GW,
+		},
+		MTU:      1499,
+		ENIIndex: eni.Attrs().Index,
+		HostIPSet: &terwayTypes.IPNetSet{
+			IPv4: eth0IPNet,
+			IPv6: eth0IPNetIPv6,
+		},
+		DefaultRoute: true,
+	}
+	err = NewPolicyRoute().Setup(cfg, containerNS)
+	assert.NoError(t, err)
+
+	results := Diagnose(cfg, "", containerNS)
+	assert.NotEmpty(t, results)
+	assert.Empty(t, failedResults(results))
+
+	// change the mtu of host veth
+	hostVETH, err := netlink.LinkByName(cfg.HostVETHName)
+	assert.NoError(t, err)
+	err = netlink.LinkSetMTU(hostVETH, 1500)
+	assert.NoError(t, err)
+
+	results = Diagnose(cfg, "", containerNS)
+	assert.Equal(t, []string{"host veth hostveth"}, failedResults(results))
+}
+
+func TestDiagnoseIPVlan(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var err error
+	hostNS, err := testutils.NewNS()
+	assert.NoError(t, err)
+
+	containerNS, err := testutils.NewNS()
+	assert.NoError(t, err)
+
+	err = hostNS.Set()
+	assert.NoError(t, err)
+
+	defer func() {
+		err := containerNS.Close()
+		assert.NoError(t, err)
+
+		err = testutils.UnmountNS(containerNS)
+		assert.NoError(t, err)
+
+		err = hostNS.Close()
+		assert.NoError(t, err)
+
+		err = testutils.UnmountNS(hostNS)
+		assert.NoError(t, err)
+	}()
+
+	err = netlink.LinkAdd(&netlink.Dummy{
+		LinkAttrs: netlink.LinkAttrs{Name: "eni"},
+	})
+	assert.NoError(t, err)
+	eni, err := netlink.LinkByName("eni")
+	assert.NoError(t, err)
+
+	cfg := &types.SetupConfig{
+		DP:              types.IPVlan,
+		HostVETHName:    "hostipvl",
+		ContainerIfName: "eth0",
+		ContainerIPNet: &terwayTypes.IPNetSet{
+			IPv4: containerIPNet,
+			IPv6: containerIPNetIPv6,
+		},
+		GatewayIP: &terwayTypes.IPSet{
+			IPv4: ipv4GW,
+			IPv6: ipv6GW,
+		},
+		MTU:      1499,
+		ENIIndex: eni.Attrs().Index,
+		ServiceCIDR: &terwayTypes.IPNetSet{
+			IPv4: serviceCIDR,
+			IPv6: serviceCIDRIPv6,
+		},
+		HostIPSet: &terwayTypes.IPNetSet{
+			IPv4: eth0IPNet,
+			IPv6: eth0IPNetIPv6,
+		},
+		DefaultRoute: true,
+	}
+	err = NewIPVlanDriver().Setup(cfg, containerNS)
+	assert.NoError(t, err)
+
+	results := Diagnose(cfg, "", containerNS)
+	assert.NotEmpty(t, results)
+	assert.Empty(t, failedResults(results))
+
+	// remove the redirect filters on eni
+	parent := uint32(netlink.HANDLE_CLSACT&0xffff0000 | netlink.HANDLE_MIN_EGRESS&0x0000ffff)
+	filters, err := netlink.FilterList(eni, parent)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, filters)
+	for _, filter := range filters {
+		err = netlink.FilterDel(filter)
+		assert.NoError(t, err)
+	}
+
+	results = Diagnose(cfg, "", containerNS)
+	assert.Equal(t, []string{"tc redirect filter"}, failedResults(results))
+	for _, r := range results {
+		if !r.Passed {
+			assert.Contains(t, r.Message, serviceCIDR.String())
+			assert.Equal(t, hintFilter, r.Hint)
+		}
+	}
+}
+
+func TestDiagnoseExclusiveENI(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var err error
+	hostNS, err := testutils.NewNS()
+	assert.NoError(t, err)
+
+	containerNS, err := testutils.NewNS()
+	assert.NoError(t, err)
+
+	err = hostNS.Set()
+	assert.NoError(t, err)
+
+	defer func() {
+		err := containerNS.Close()
+		assert.NoError(t, err)
+
+		err = testutils.UnmountNS(containerNS)
+		assert.NoError(t, err)
+
+		err = hostNS.Close()
+		assert.NoError(t, err)
+
+		err = testutils.UnmountNS(hostNS)
+		assert.NoError(t, err)
+	}()
+
+	err = netlink.LinkAdd(&netlink.Dummy{
+		LinkAttrs: netlink.LinkAttrs{Name: "eni"},
+	})
+	assert.NoError(t, err)
+	eni, err := netlink.LinkByName("eni")
+	assert.NoError(t, err)
+
+	eniMAC := eni.Attrs().HardwareAddr.String()
+
+	cfg := &types.SetupConfig{
+		DP:              types.ExclusiveENI,
+		HostVETHName:    "hostveth",
+		ContainerIfName: "eth0",
+		ContainerIPNet: &terwayTypes.IPNetSet{
+			IPv4: containerIPNet,
+			IPv6: containerIPNetIPv6,
+		},
+		GatewayIP: &terwayTypes.IPSet{
+			IPv4: ipv4GW,
+			IPv6: ipv6GW,
+		},
+		MTU:      1499,
+		ENIIndex: eni.Attrs().Index,
+		ServiceCIDR: &terwayTypes.IPNetSet{
+			IPv4: serviceCIDR,
+			IPv6: serviceCIDRIPv6,
+		},
+		HostIPSet: &terwayTypes.IPNetSet{
+			IPv4: eth0IPNet,
+			IPv6: eth0IPNetIPv6,
+		},
+		DefaultRoute: true,
+	}
+	err = NewExclusiveENIDriver().Setup(cfg, containerNS)
+	assert.NoError(t, err)
+
+	results := Diagnose(cfg, eniMAC, containerNS)
+	assert.NotEmpty(t, results)
+	assert.Empty(t, failedResults(results))
+
+	// the eni in container is not the one allocated
+	results = Diagnose(cfg, "00:16:3e:00:00:01", containerNS)
+	assert.Equal(t, []string{"eni mac"}, failedResults(results))
+
+	// remove the veth in container
+	_ = containerNS.Do(func(netNS ns.NetNS) error {
+		veth1, err := netlink.LinkByName(defaultVethForENI)
+		assert.NoError(t, err)
+		err = netlink.LinkDel(veth1)
+		assert.NoError(t, err)
+		return nil
+	})
+
+	// the peer is gone with veth1
+	results = Diagnose(cfg, eniMAC, containerNS)
+	assert.Equal(t, []string{"host veth hostveth"}, failedResults(results))
+}
+
+func TestDiagnoseVlan(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var err error
+	hostNS, err := testutils.NewNS()
+	assert.NoError(t, err)
+
+	containerNS, err := testutils.NewNS()
+	assert.NoError(t, err)
+
+	err = hostNS.Set()
+	assert.NoError(t, err)
+
+	defer func() {
+		err := containerNS.Close()
+		assert.NoError(t, err)
+
+		err = testutils.UnmountNS(containerNS)
+		assert.NoError(t, err)
+
+		err = hostNS.Close()
+		assert.NoError(t, err)
+
+		err = testutils.UnmountNS(hostNS)
+		assert.NoError(t, err)
+	}()
+
+	err = netlink.LinkAdd(&netlink.Dummy{
+		LinkAttrs: netlink.LinkAttrs{Name: "eni"},
+	})
+	assert.NoError(t, err)
+	eni, err := netlink.LinkByName("eni")
+	assert.NoError(t, err)
+
+	cfg := &types.SetupConfig{
+		DP:              types.Vlan,
+		ContainerIfName: "eth0",
+		ContainerIPNet: &terwayTypes.IPNetSet{
+			IPv4: containerIPNet,
+		},
+		GatewayIP: &terwayTypes.IPSet{
+			IPv4: ipv4GW,
+		},
+		MTU:          1499,
+		ENIIndex:     eni.Attrs().Index,
+		Vid:          100,
+		DefaultRoute: true,
+	}
+	err = NewVlan().Setup(cfg, containerNS)
+	assert.NoError(t, err)
+
+	results := Diagnose(cfg, "", containerNS)
+	assert.NotEmpty(t, results)
+	assert.Empty(t, failedResults(results))
+
+	diagnoseCfg := *cfg
+	diagnoseCfg.Vid = 101
+	results = Diagnose(&diagnoseCfg, "", containerNS)
+	assert.Equal(t, []string{"vlan id"}, failedResults(results))
+
+	// change the mtu of the trunk eni
+	err = netlink.LinkSetMTU(eni, 1500)
+	assert.NoError(t, err)
+
+	results = Diagnose(cfg, "", containerNS)
+	assert.Equal(t, []string{"eni eni"}, failedResults(results))
+}
@@ -18,6 +18,7 @@ package nic
 
 import (
 	"fmt"
+	"net"
 
 	terwaySysctl "github.com/AliyunContainerService/terway/pkg/sysctl"
 	"github.com/AliyunContainerService/terway/plugin/driver/utils"
@@ -110,3 +111,104 @@ func Setup(link netlink.Link, conf *Conf) error {
 	}
 	return nil
 }
+
+// Diff compare the link with the conf, return the items not as expected. Unlike Setup, nothing is changed.
+func Diff(link netlink.Link, conf *Conf) ([]string, error) {
+	var diffs []string
+	name := link.Attrs().Name
+	if conf.IfName != "" && name != conf.IfName {
+		diffs = append(diffs, fmt.Sprintf("link name is %s, expected %s", name, conf.IfName))
+	}
+	if conf.MTU > 0 && link.Attrs().MTU != conf.MTU {
+		diffs = append(diffs, fmt.Sprintf("link %s mtu is %d, expected %d", name, link.Attrs().MTU, conf.MTU))
+	}
+	if link.Attrs().Flags&net.FlagUp == 0 {
+		diffs = append(diffs, fmt.Sprintf("link %s is down", name))
+	}
+
+	for _, v := range conf.SysCtl {
+		if len(v) != 2 {
+			return nil, fmt.Errorf("sysctl config err")
+		}
+		value, err := terwaySysctl.GetConf(v[0])
+		if err != nil {
+			diffs = append(diffs, fmt.Sprintf("read %s failed, %v", v[0], err))
+			continue
+		}
+		if value != v[1] {
+			diffs = append(diffs, fmt.Sprintf("%s is %s, expected %s", v[0], value, v[1]))
+		}
+	}
+
+	for _, expect := range conf.Addrs {
+		addrs, err := netlink.AddrList(link, utils.NetlinkFamily(expect.IP))
+		if err != nil {
+			return nil, fmt.Errorf("error list address from if %s, %w", name, err)
+		}
+		found := false
+		for _, addr := range addrs {
+			if addr.IPNet.String() == expect.IPNet.String() && addr.Scope == expect.Scope {
+				found = true
+				break
+			}
+		}
+		if !found {
+			diffs = append(diffs, fmt.Sprintf("address %s is missing on %s", expect.IPNet, name))
+		}
+	}
+
+	for _, expect := range conf.Neighs {
+		neighs, err := netlink.NeighList(expect.LinkIndex, utils.NetlinkFamily(expect.IP))
+		if err != nil {
+			return nil, fmt.Errorf("error list neigh from if %s, %w", name, err)
+		}
+		found := false
+		for _, n := range neighs {
+			if n.IP.Equal(expect.IP) && n.HardwareAddr.String() == expect.HardwareAddr.String() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			diffs = append(diffs, fmt.Sprintf("neigh %s lladdr %s is missing on %s", expect.IP, expect.HardwareAddr, name))
+		}
+	}
+
+	for _, expect := range conf.Routes {
+		routes, err := utils.FoundRoutes(expect)
+		if err != nil {
+			return nil, fmt.Errorf("error list route, %w", err)
+		}
+		if len(routes) == 0 {
+			diffs = append(diffs, fmt.Sprintf("route %s is missing", expect))
+		}
+	}
+
+	for _, expect := range conf.Rules {
+		rules, err := utils.FindIPRule(expect)
+		if err != nil {
+			return nil, fmt.Errorf("error list rule, %w", err)
+		}
+		found := false
+		for _, rule := range rules {
+			if rule.Table == expect.Table && rule.Priority == expect.Priority {
+				found = true
+				break
+			}
+		}
+		if !found {
+			diffs = append(diffs, fmt.Sprintf("rule %s is missing", expect))
+		}
+	}
+
+	if conf.StripVlan {
+		found, err := utils.FoundVlanUntagger(link)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			diffs = append(diffs, fmt.Sprintf("vlan untag filter is missing on %s", name))
+		}
+	}
+	return diffs, nil
+}
@@ -0,0 +1,87 @@
+//go:build privileged
+
+package nic
+
+import (
+	"net"
+	"runtime"
+	"testing"
+
+	"github.com/containernetworking/plugins/pkg/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink"
+)
+
+func TestDiff(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var err error
+	hostNS, err := testutils.NewNS()
+	assert.NoError(t, err)
+
+	err = hostNS.Set()
+	assert.NoError(t, err)
+
+	defer func() {
+		err := hostNS.Close()
+		assert.NoError(t, err)
+
+		err = testutils.UnmountNS(hostNS)
+		assert.NoError(t, err)
+	}()
+
+	err = netlink.LinkAdd(&netlink.Veth{
+		LinkAttrs: netlink.LinkAttrs{Name: "eni", MTU: 1500},
+		PeerName:  "peer",
+	})
+	assert.NoError(t, err)
+	link, err := netlink.LinkByName("eni")
+	assert.NoError(t, err)
+
+	addr := &netlink.Addr{IPNet: &net.IPNet{IP: net.ParseIP("192.168.0.10"), Mask: net.CIDRMask(24, 32)}}
+	route := &netlink.Route{
+		LinkIndex: link.Attrs().Index,
+		Scope:     netlink.SCOPE_LINK,
+		Dst:       &net.IPNet{IP: net.ParseIP("10.0.0.0"), Mask: net.CIDRMask(8, 32)},
+	}
+	conf := &Conf{
+		IfName: "eni",
+		MTU:    1499,
+		Addrs:  []*netlink.Addr{addr},
+		Routes: []*netlink.Route{route},
+	}
+
+	// nothing is configured
+	diffs, err := Diff(link, conf)
+	assert.NoError(t, err)
+	assert.Len(t, diffs, 4)
+
+	err = Setup(link, conf)
+	assert.NoError(t, err)
+	link, err = netlink.LinkByName("eni")
+	assert.NoError(t, err)
+
+	diffs, err = Diff(link, conf)
+	assert.NoError(t, err)
+	assert.Empty(t, diffs)
+
+	err = netlink.LinkSetMTU(link, 1500)
+	assert.NoError(t, err)
+	err = netlink.RouteDel(route)
+	assert.NoError(t, err)
+	link, err = netlink.LinkByName("eni")
+	assert.NoError(t, err)
+
+	diffs, err = Diff(link, conf)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"link eni mtu is 1500, expected 1499",
+		"route " + route.String() + " is missing",
+	}, diffs)
+
+	// Diff never change the link
+	link, err = netlink.LinkByName("eni")
+	assert.NoError(t, err)
+	assert.Equal(t, 1500, link.Attrs().MTU)
+}
@@ -38,6 +38,13 @@ type CNIConf struct {
 	// EnableNetworkPriority by enable priority control, eni qdisc is replaced with tc_prio
 	EnableNetworkPriority bool `json:"enable_network_priority"`
 
+	// TracingEndpoint is the otlp grpc endpoint the spans of cni calls are exported to, prefix it with http:// to disable tls,
+	// empty to disable tracing. The trace context is always passed to terway daemon
+	TracingEndpoint string `json:"tracing_endpoint,omitempty"`
+
+	// TracingSamplingRatio is the ratio of the cni calls traced, default 1
+	TracingSamplingRatio float64 `json:"tracing_sampling_ratio,omitempty"`
+
 	// Debug
 	Debug bool `json:"debug"`
 }
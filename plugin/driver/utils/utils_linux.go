@@ -342,6 +342,46 @@ var ipv6NetConfig = [][]string{
 	{"/proc/sys/net/ipv6/conf/%s/disable_ipv6", "0"},
 }
 
+// DiffHostNsConfig compare the host namespace configs with the ones set by EnsureHostNsConfig, nothing is changed
+func DiffHostNsConfig(ipv4, ipv6 bool) ([]string, error) {
+	links, err := netlink.LinkList()
+	if err != nil {
+		return nil, err
+	}
+
+	var cfgs [][]string
+	for _, key := range []string{"default", "all"} {
+		for _, cfg := range ipv4NetConfig {
+			cfgs = append(cfgs, []string{fmt.Sprintf(cfg[0], key), cfg[1]})
+		}
+	}
+	for _, link := range links {
+		if ipv4 {
+			for _, cfg := range ipv4NetConfig {
+				cfgs = append(cfgs, []string{fmt.Sprintf(cfg[0], link.Attrs().Name), cfg[1]})
+			}
+		}
+		if ipv6 {
+			for _, cfg := range ipv6NetConfig {
+				cfgs = append(cfgs, []string{fmt.Sprintf(cfg[0], link.Attrs().Name), cfg[1]})
+			}
+		}
+	}
+
+	var diffs []string
+	for _, cfg := range cfgs {
+		value, err := terwaySysctl.GetConf(cfg[0])
+		if err != nil {
+			diffs = append(diffs, fmt.Sprintf("read %s failed, %v", cfg[0], err))
+			continue
+		}
+		if value != cfg[1] {
+			diffs = append(diffs, fmt.Sprintf("%s is %s, expected %s", cfg[0], value, cfg[1]))
+		}
+	}
+	return diffs, nil
+}
+
 // EnsureNetConfSet will set net config to all link
 func EnsureNetConfSet(ipv4, ipv6 bool) error {
 	links, err := netlink.LinkList()
@@ -370,13 +410,11 @@ func EnsureNetConfSet(ipv4, ipv6 bool) error {
 	return err
 }
 
-func EnsureVlanUntagger(link netlink.Link) error {
-	if err := EnsureClsActQdsic(link); err != nil {
-		return fmt.Errorf("error ensure cls act qdisc for %s vlan untag, %w", link.Attrs().Name, err)
-	}
+// FoundVlanUntagger look up the ingress filter to pop the vlan tag
+func FoundVlanUntagger(link netlink.Link) (bool, error) {
 	filters, err := netlink.FilterList(link, netlink.HANDLE_MIN_INGRESS)
 	if err != nil {
-		return fmt.Errorf("list ingress filter for %s error, %w", link.Attrs().Name, err)
+		return false, fmt.Errorf("list ingress filter for %s error, %w", link.Attrs().Name, err)
 	}
 	for _, filter := range filters {
 		if u32, ok := filter.(*netlink.U32); ok {
@@ -386,12 +424,26 @@ func EnsureVlanUntagger(link netlink.Link) error {
 				len(u32.Actions) == 1 {
 				if action, ok := u32.Actions[0].(*netlink.VlanAction); ok {
 					if action.Action == netlink.TCA_VLAN_KEY_POP {
-						return nil
+						return true, nil
 					}
 				}
 			}
 		}
 	}
+	return false, nil
+}
+
+func EnsureVlanUntagger(link netlink.Link) error {
+	if err := EnsureClsActQdsic(link); err != nil {
+		return fmt.Errorf("error ensure cls act qdisc for %s vlan untag, %w", link.Attrs().Name, err)
+	}
+	found, err := FoundVlanUntagger(link)
+	if err != nil {
+		return err
+	}
+	if found {
+		return nil
+	}
 
 	vlanAct := netlink.NewVlanKeyAction()
 	vlanAct.Action = netlink.TCA_VLAN_KEY_POP
@@ -722,6 +774,21 @@ func SetupTC(link netlink.Link, bandwidthInBytes uint64) error {
 	return tc.SetRule(link, rule)
 }
 
+// FoundTC look up the tbf qdisc set by SetupTC
+func FoundTC(link netlink.Link, bandwidthInBytes uint64) (bool, error) {
+	qdiscs, err := netlink.QdiscList(link)
+	if err != nil {
+		return false, fmt.Errorf("list qdisc for %s error, %w", link.Attrs().Name, err)
+	}
+	for _, qdisc := range qdiscs {
+		tbf, ok := qdisc.(*netlink.Tbf)
+		if ok && tbf.Parent == netlink.HANDLE_ROOT && tbf.Rate == bandwidthInBytes {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // GenericTearDown target to clean all related resource as much as possible
 func GenericTearDown(netNS ns.NetNS) error {
 	var errList []error
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/AliyunContainerService/terway/pkg/link"
+	"github.com/AliyunContainerService/terway/pkg/telemetry"
 	"github.com/AliyunContainerService/terway/plugin/datapath"
 	"github.com/AliyunContainerService/terway/plugin/driver/types"
 	"github.com/AliyunContainerService/terway/plugin/driver/utils"
@@ -45,6 +46,9 @@ const (
 `
 
 	terwayCNILock = "/var/run/eni/terway_cni.lock"
+
+	// defaultTraceFlushTimeout limit the time waiting for the collector before the plugin exits
+	defaultTraceFlushTimeout = time.Second
 )
 
 func init() {
@@ -80,6 +84,11 @@ func cmdAdd(args *skel.CmdArgs) error {
 	ctx, cancel := context.WithTimeout(context.Background(), defaultCniTimeout)
 	defer cancel()
 
+	ctx, endTrace := startTrace(ctx, conf, k8sConfig, "ADD")
+	defer func() {
+		endTrace(err)
+	}()
+
 	client, conn, err := getNetworkClient(ctx)
 	if err != nil {
 		return fmt.Errorf("error create grpc client, %w", err)
@@ -150,6 +159,11 @@ func cmdDel(args *skel.CmdArgs) error {
 	ctx, cancel := context.WithTimeout(context.Background(), defaultCniTimeout)
 	defer cancel()
 
+	ctx, endTrace := startTrace(ctx, conf, k8sConfig, "DEL")
+	defer func() {
+		endTrace(err)
+	}()
+
 	client, conn, err := getNetworkClient(ctx)
 	if err != nil {
 		return fmt.Errorf("error create grpc client, %w", err)
@@ -198,6 +212,11 @@ func cmdCheck(args *skel.CmdArgs) error {
 	ctx, cancel := context.WithTimeout(context.Background(), defaultCniTimeout)
 	defer cancel()
 
+	ctx, endTrace := startTrace(ctx, conf, k8sConfig, "CHECK")
+	defer func() {
+		endTrace(err)
+	}()
+
 	client, conn, err := getNetworkClient(ctx)
 	if err != nil {
 		return fmt.Errorf("error create grpc client, %w", err)
@@ -212,8 +231,29 @@ func cmdCheck(args *skel.CmdArgs) error {
 	return nil
 }
 
+// startTrace start the span of the cni command, the spans are exported to the collector in the cni config.
+// The returned func end the span and flush it before the plugin exits.
+func startTrace(ctx context.Context, conf *types.CNIConf, k8sConfig *types.K8SArgs, cmd string) (context.Context, func(error)) {
+	shutdown, err := telemetry.Setup(ctx, "terway-cni", telemetry.Config{
+		Endpoint:      conf.TracingEndpoint,
+		SamplingRatio: conf.TracingSamplingRatio,
+	})
+	if err != nil {
+		utils.Log.Warnf("error setup tracing, %v", err)
+		shutdown = func(context.Context) error { return nil }
+	}
+	ctx, span := telemetry.Start(ctx, "cni/"+cmd, telemetry.PodAttributes(string(k8sConfig.K8S_POD_NAMESPACE),
+		string(k8sConfig.K8S_POD_NAME), string(k8sConfig.K8S_POD_INFRA_CONTAINER_ID))...)
+	return ctx, func(err error) {
+		telemetry.End(span, err)
+		flushCtx, cancel := context.WithTimeout(context.Background(), defaultTraceFlushTimeout)
+		defer cancel()
+		_ = shutdown(flushCtx)
+	}
+}
+
 func getNetworkClient(ctx context.Context) (rpc.TerwayBackendClient, *grpc.ClientConn, error) {
-	conn, err := grpc.DialContext(ctx, defaultSocketPath, grpc.WithInsecure(), grpc.WithContextDialer(
+	conn, err := grpc.DialContext(ctx, defaultSocketPath, grpc.WithInsecure(), grpc.WithUnaryInterceptor(telemetry.UnaryClientInterceptor()), grpc.WithContextDialer(
 		func(ctx context.Context, s string) (net.Conn, error) {
 			unixAddr, err := net.ResolveUnixAddr("unix", defaultSocketPath)
 			if err != nil {
@@ -230,139 +270,6 @@ func getNetworkClient(ctx context.Context) (rpc.TerwayBackendClient, *grpc.Clien
 	return client, conn, nil
 }
 
-func parseSetupConf(args *skel.CmdArgs, alloc *rpc.NetConf, conf *types.CNIConf, ipType rpc.IPType) (*types.SetupConfig, error) {
-	var (
-		err            error
-		containerIPNet *terwayTypes.IPNetSet
-		gatewayIP      *terwayTypes.IPSet
-		serviceCIDR    *terwayTypes.IPNetSet
-		eniGatewayIP   *terwayTypes.IPSet
-		deviceID       int32
-		trunkENI       bool
-		vid            uint32
-
-		ingress         uint64
-		egress          uint64
-		networkPriority uint32
-
-		routes []cniTypes.Route
-
-		disableCreatePeer bool
-	)
-
-	serviceCIDR, err = terwayTypes.ToIPNetSet(alloc.GetBasicInfo().GetServiceCIDR())
-	if err != nil {
-		return nil, err
-	}
-
-	if ipType == rpc.IPType_TypeVPCIP {
-		subnetStr := alloc.GetBasicInfo().GetPodCIDR().GetIPv4()
-		_, subnet, err := net.ParseCIDR(subnetStr)
-		if err != nil {
-			return nil, fmt.Errorf("parse cidr %s, %w", subnetStr, err)
-		}
-		containerIPNet = &terwayTypes.IPNetSet{
-			IPv4: subnet,
-			IPv6: nil,
-		}
-	} else if alloc.GetBasicInfo() != nil {
-		podIP := alloc.GetBasicInfo().GetPodIP()
-		subNet := alloc.GetBasicInfo().GetPodCIDR()
-		gw := alloc.GetBasicInfo().GetGatewayIP()
-
-		containerIPNet, err = terwayTypes.BuildIPNet(podIP, subNet)
-		if err != nil {
-			return nil, err
-		}
-		gatewayIP, err = terwayTypes.ToIPSet(gw)
-		if err != nil {
-			return nil, err
-		}
-		disableCreatePeer = conf.DisableHostPeer
-	}
-
-	if alloc.GetENIInfo() != nil {
-		mac := alloc.GetENIInfo().GetMAC()
-		if mac != "" {
-			deviceID, err = link.GetDeviceNumber(mac)
-			if err != nil {
-				return nil, err
-			}
-		}
-		trunkENI = alloc.GetENIInfo().GetTrunk()
-		vid = alloc.GetENIInfo().GetVid()
-		if alloc.GetENIInfo().GetGatewayIP() != nil {
-			eniGatewayIP, err = terwayTypes.ToIPSet(alloc.GetENIInfo().GetGatewayIP())
-			if err != nil {
-				return nil, err
-			}
-		}
-	}
-	if alloc.GetPod() != nil {
-		ingress = alloc.GetPod().GetIngress()
-		egress = alloc.GetPod().GetEgress()
-		networkPriority = datapath.PrioMap[alloc.GetPod().GetNetworkPriority()]
-	}
-	if conf.RuntimeConfig.Bandwidth.EgressRate > 0 {
-		egress = uint64(conf.RuntimeConfig.Bandwidth.EgressRate / 8)
-	}
-	if conf.RuntimeConfig.Bandwidth.IngressRate > 0 {
-		ingress = uint64(conf.RuntimeConfig.Bandwidth.IngressRate / 8)
-	}
-
-	hostStackCIDRs := make([]*net.IPNet, 0)
-	for _, v := range conf.HostStackCIDRs {
-		_, cidr, err := net.ParseCIDR(v)
-		if err != nil {
-			return nil, fmt.Errorf("host_stack_cidrs(%s) is invaild: %v", v, err)
-
-		}
-		hostStackCIDRs = append(hostStackCIDRs, cidr)
-	}
-
-	name := alloc.IfName
-	if name == "" {
-		name = args.IfName
-	}
-	for _, r := range alloc.GetExtraRoutes() {
-		ip, n, err := net.ParseCIDR(r.Dst)
-		if err != nil {
-			return nil, fmt.Errorf("error parse extra routes, %w", err)
-		}
-		route := cniTypes.Route{Dst: *n}
-		if ip.To4() != nil {
-			route.GW = gatewayIP.IPv4
-		} else {
-			route.GW = gatewayIP.IPv6
-		}
-		routes = append(routes, route)
-	}
-
-	dp := getDatePath(ipType, conf.VlanStripType, trunkENI)
-	return &types.SetupConfig{
-		DP:                    dp,
-		ContainerIfName:       name,
-		ContainerIPNet:        containerIPNet,
-		GatewayIP:             gatewayIP,
-		MTU:                   conf.MTU,
-		ENIIndex:              int(deviceID),
-		ENIGatewayIP:          eniGatewayIP,
-		ServiceCIDR:           serviceCIDR,
-		HostStackCIDRs:        hostStackCIDRs,
-		BandwidthMode:         conf.BandwidthMode,
-		EnableNetworkPriority: conf.EnableNetworkPriority,
-		Ingress:               ingress,
-		Egress:                egress,
-		StripVlan:             trunkENI,
-		Vid:                   int(vid),
-		DefaultRoute:          alloc.GetDefaultRoute(),
-		ExtraRoutes:           routes,
-		DisableCreatePeer:     disableCreatePeer,
-		RuntimeConfig:         conf.RuntimeConfig,
-		NetworkPriority:       networkPriority,
-	}, nil
-}
-
 func parseTearDownConf(alloc *rpc.NetConf, conf *types.CNIConf, ipType rpc.IPType) (*types.TeardownCfg, error) {
 	if alloc.GetBasicInfo() == nil {
 		return nil, fmt.Errorf("return empty pod alloc info: %v", alloc)
@@ -406,7 +313,7 @@ func parseTearDownConf(alloc *rpc.NetConf, conf *types.CNIConf, ipType rpc.IPTyp
 		}
 	}
 
-	dp := getDatePath(ipType, conf.VlanStripType, false)
+	dp := datapath.GetDataPath(ipType, conf.VlanStripType, false)
 	return &types.TeardownCfg{
 		DP:                    dp,
 		ContainerIPNet:        containerIPNet,
@@ -455,7 +362,7 @@ func parseCheckConf(args *skel.CmdArgs, alloc *rpc.NetConf, conf *types.CNIConf,
 		name = args.IfName
 	}
 
-	dp := getDatePath(ipType, conf.VlanStripType, trunkENI)
+	dp := datapath.GetDataPath(ipType, conf.VlanStripType, trunkENI)
 	return &types.CheckConfig{
 		DP:              dp,
 		ContainerIfName: name,
@@ -467,22 +374,3 @@ func parseCheckConf(args *skel.CmdArgs, alloc *rpc.NetConf, conf *types.CNIConf,
 		DefaultRoute:    alloc.GetDefaultRoute(),
 	}, nil
 }
-
-func getDatePath(ipType rpc.IPType, vlanStripType types.VlanStripType, trunk bool) types.DataPath {
-	switch ipType {
-	case rpc.IPType_TypeVPCIP:
-		return types.VPCRoute
-	case rpc.IPType_TypeVPCENI:
-		if trunk {
-			return types.Vlan
-		}
-		return types.ExclusiveENI
-	case rpc.IPType_TypeENIMultiIP:
-		if trunk && vlanStripType == types.VlanStripTypeVlan {
-			return types.Vlan
-		}
-		return types.IPVlan
-	default:
-		panic(fmt.Sprintf("unsupported ipType %s", ipType))
-	}
-}
@@ -177,7 +177,7 @@ func doCmdAdd(ctx context.Context, logger *logrus.Entry, client rpc.TerwayBacken
 
 	for _, netConf := range allocResult.NetConfs {
 		var setupCfg *types.SetupConfig
-		setupCfg, err = parseSetupConf(args, netConf, conf, allocResult.IPType)
+		setupCfg, err = datapath.ParseSetupConf(args.IfName, netConf, conf, allocResult.IPType)
 		if err != nil {
 			err = fmt.Errorf("error parse config, %w", err)
 			return
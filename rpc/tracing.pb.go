@@ -1,6 +1,6 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.28.0
+// 	protoc-gen-go v1.28.1
 // 	protoc        v3.19.4
 // source: tracing.proto
 
@@ -69,6 +69,64 @@ func (ResourceMappingType) EnumDescriptor() ([]byte, []int) {
 	return file_tracing_proto_rawDescGZIP(), []int{0}
 }
 
+type LeakType int32
+
+const (
+	LeakType_LeakTypeStaleRelation LeakType = 0 // relation in db, pod not exist
+	LeakType_LeakTypeOrphanInUse   LeakType = 1 // in use in pool, no relation in db
+	LeakType_LeakTypeMissingLocal  LeakType = 2 // relation in db, not in pool
+	LeakType_LeakTypeMissingRemote LeakType = 3 // in pool, not in metadata
+	LeakType_LeakTypeUntracked     LeakType = 4 // in metadata, not in pool
+	LeakType_LeakTypeInvalid       LeakType = 5 // invalid in pool, wait to be disposed
+)
+
+// Enum value maps for LeakType.
+var (
+	LeakType_name = map[int32]string{
+		0: "LeakTypeStaleRelation",
+		1: "LeakTypeOrphanInUse",
+		2: "LeakTypeMissingLocal",
+		3: "LeakTypeMissingRemote",
+		4: "LeakTypeUntracked",
+		5: "LeakTypeInvalid",
+	}
+	LeakType_value = map[string]int32{
+		"LeakTypeStaleRelation": 0,
+		"LeakTypeOrphanInUse":   1,
+		"LeakTypeMissingLocal":  2,
+		"LeakTypeMissingRemote": 3,
+		"LeakTypeUntracked":     4,
+		"LeakTypeInvalid":       5,
+	}
+)
+
+func (x LeakType) Enum() *LeakType {
+	p := new(LeakType)
+	*p = x
+	return p
+}
+
+func (x LeakType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (LeakType) Descriptor() protoreflect.EnumDescriptor {
+	return file_tracing_proto_enumTypes[1].Descriptor()
+}
+
+func (LeakType) Type() protoreflect.EnumType {
+	return &file_tracing_proto_enumTypes[1]
+}
+
+func (x LeakType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use LeakType.Descriptor instead.
+func (LeakType) EnumDescriptor() ([]byte, []int) {
+	return file_tracing_proto_rawDescGZIP(), []int{1}
+}
+
 type Placeholder struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -688,6 +746,179 @@ func (x *PodResourceMappingReply) GetInfo() []*PodResourceMapping {
 	return nil
 }
 
+type LeakRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Repair bool `protobuf:"varint,1,opt,name=Repair,proto3" json:"Repair,omitempty"`
+}
+
+func (x *LeakRequest) Reset() {
+	*x = LeakRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_tracing_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LeakRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeakRequest) ProtoMessage() {}
+
+func (x *LeakRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tracing_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeakRequest.ProtoReflect.Descriptor instead.
+func (*LeakRequest) Descriptor() ([]byte, []int) {
+	return file_tracing_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *LeakRequest) GetRepair() bool {
+	if x != nil {
+		return x.Repair
+	}
+	return false
+}
+
+type Leak struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type       LeakType `protobuf:"varint,1,opt,name=Type,proto3,enum=rpc.LeakType" json:"Type,omitempty"`
+	ResourceID string   `protobuf:"bytes,2,opt,name=ResourceID,proto3" json:"ResourceID,omitempty"`
+	PodName    string   `protobuf:"bytes,3,opt,name=PodName,proto3" json:"PodName,omitempty"`
+	Message    string   `protobuf:"bytes,4,opt,name=Message,proto3" json:"Message,omitempty"`
+	Repaired   bool     `protobuf:"varint,5,opt,name=Repaired,proto3" json:"Repaired,omitempty"`
+}
+
+func (x *Leak) Reset() {
+	*x = Leak{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_tracing_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Leak) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Leak) ProtoMessage() {}
+
+func (x *Leak) ProtoReflect() protoreflect.Message {
+	mi := &file_tracing_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Leak.ProtoReflect.Descriptor instead.
+func (*Leak) Descriptor() ([]byte, []int) {
+	return file_tracing_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *Leak) GetType() LeakType {
+	if x != nil {
+		return x.Type
+	}
+	return LeakType_LeakTypeStaleRelation
+}
+
+func (x *Leak) GetResourceID() string {
+	if x != nil {
+		return x.ResourceID
+	}
+	return ""
+}
+
+func (x *Leak) GetPodName() string {
+	if x != nil {
+		return x.PodName
+	}
+	return ""
+}
+
+func (x *Leak) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Leak) GetRepaired() bool {
+	if x != nil {
+		return x.Repaired
+	}
+	return false
+}
+
+type LeakReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Leaks []*Leak `protobuf:"bytes,1,rep,name=Leaks,proto3" json:"Leaks,omitempty"`
+}
+
+func (x *LeakReply) Reset() {
+	*x = LeakReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_tracing_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LeakReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeakReply) ProtoMessage() {}
+
+func (x *LeakReply) ProtoReflect() protoreflect.Message {
+	mi := &file_tracing_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeakReply.ProtoReflect.Descriptor instead.
+func (*LeakReply) Descriptor() ([]byte, []int) {
+	return file_tracing_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *LeakReply) GetLeaks() []*Leak {
+	if x != nil {
+		return x.Leaks
+	}
+	return nil
+}
+
 var File_tracing_proto protoreflect.FileDescriptor
 
 var file_tracing_proto_rawDesc = []byte{
@@ -745,42 +976,70 @@ var file_tracing_proto_rawDesc = []byte{
 	0x70, 0x70, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x2b, 0x0a, 0x04, 0x69, 0x6e,
 	0x66, 0x6f, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x50,
 	0x6f, 0x64, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x4d, 0x61, 0x70, 0x70, 0x69, 0x6e,
-	0x67, 0x52, 0x04, 0x69, 0x6e, 0x66, 0x6f, 0x2a, 0x57, 0x0a, 0x13, 0x52, 0x65, 0x73, 0x6f, 0x75,
-	0x72, 0x63, 0x65, 0x4d, 0x61, 0x70, 0x70, 0x69, 0x6e, 0x67, 0x54, 0x79, 0x70, 0x65, 0x12, 0x15,
-	0x0a, 0x11, 0x4d, 0x61, 0x70, 0x70, 0x69, 0x6e, 0x67, 0x54, 0x79, 0x70, 0x65, 0x4e, 0x6f, 0x72,
-	0x6d, 0x61, 0x6c, 0x10, 0x00, 0x12, 0x13, 0x0a, 0x0f, 0x4d, 0x61, 0x70, 0x70, 0x69, 0x6e, 0x67,
-	0x54, 0x79, 0x70, 0x65, 0x49, 0x64, 0x6c, 0x65, 0x10, 0x01, 0x12, 0x14, 0x0a, 0x10, 0x4d, 0x61,
-	0x70, 0x70, 0x69, 0x6e, 0x67, 0x54, 0x79, 0x70, 0x65, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x10, 0x02,
-	0x32, 0xbe, 0x03, 0x0a, 0x0d, 0x54, 0x65, 0x72, 0x77, 0x61, 0x79, 0x54, 0x72, 0x61, 0x63, 0x69,
-	0x6e, 0x67, 0x12, 0x3e, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63,
-	0x65, 0x54, 0x79, 0x70, 0x65, 0x73, 0x12, 0x10, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x50, 0x6c, 0x61,
-	0x63, 0x65, 0x68, 0x6f, 0x6c, 0x64, 0x65, 0x72, 0x1a, 0x18, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x52,
-	0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x73, 0x54, 0x79, 0x70, 0x65, 0x73, 0x52, 0x65, 0x70,
-	0x6c, 0x79, 0x12, 0x42, 0x0a, 0x0c, 0x47, 0x65, 0x74, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63,
-	0x65, 0x73, 0x12, 0x18, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63,
-	0x65, 0x54, 0x79, 0x70, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e, 0x72,
-	0x70, 0x63, 0x2e, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x73, 0x4e, 0x61, 0x6d, 0x65,
-	0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x4b, 0x0a, 0x11, 0x47, 0x65, 0x74, 0x52, 0x65, 0x73,
-	0x6f, 0x75, 0x72, 0x63, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x1c, 0x2e, 0x72, 0x70,
-	0x63, 0x2e, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x54, 0x79, 0x70, 0x65, 0x4e, 0x61,
-	0x6d, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e, 0x72, 0x70, 0x63, 0x2e,
-	0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x65,
-	0x70, 0x6c, 0x79, 0x12, 0x49, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72,
-	0x63, 0x65, 0x54, 0x72, 0x61, 0x63, 0x65, 0x12, 0x1c, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x52, 0x65,
-	0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x54, 0x79, 0x70, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x52, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x52, 0x65, 0x73, 0x6f,
-	0x75, 0x72, 0x63, 0x65, 0x54, 0x72, 0x61, 0x63, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x4b,
-	0x0a, 0x0f, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74,
-	0x65, 0x12, 0x1b, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65,
-	0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19,
-	0x2e, 0x72, 0x70, 0x63, 0x2e, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x45, 0x78, 0x65,
-	0x63, 0x75, 0x74, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x30, 0x01, 0x12, 0x44, 0x0a, 0x12, 0x47,
-	0x65, 0x74, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x4d, 0x61, 0x70, 0x70, 0x69, 0x6e,
-	0x67, 0x12, 0x10, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x50, 0x6c, 0x61, 0x63, 0x65, 0x68, 0x6f, 0x6c,
-	0x64, 0x65, 0x72, 0x1a, 0x1c, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x50, 0x6f, 0x64, 0x52, 0x65, 0x73,
-	0x6f, 0x75, 0x72, 0x63, 0x65, 0x4d, 0x61, 0x70, 0x70, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x70, 0x6c,
-	0x79, 0x42, 0x07, 0x5a, 0x05, 0x2e, 0x3b, 0x72, 0x70, 0x63, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x33,
+	0x67, 0x52, 0x04, 0x69, 0x6e, 0x66, 0x6f, 0x22, 0x25, 0x0a, 0x0b, 0x4c, 0x65, 0x61, 0x6b, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x52, 0x65, 0x70, 0x61, 0x69, 0x72,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x52, 0x65, 0x70, 0x61, 0x69, 0x72, 0x22, 0x99,
+	0x01, 0x0a, 0x04, 0x4c, 0x65, 0x61, 0x6b, 0x12, 0x21, 0x0a, 0x04, 0x54, 0x79, 0x70, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0d, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x4c, 0x65, 0x61, 0x6b,
+	0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x54, 0x79, 0x70, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x52, 0x65,
+	0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x49, 0x44, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a,
+	0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x49, 0x44, 0x12, 0x18, 0x0a, 0x07, 0x50, 0x6f,
+	0x64, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x50, 0x6f, 0x64,
+	0x4e, 0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1a,
+	0x0a, 0x08, 0x52, 0x65, 0x70, 0x61, 0x69, 0x72, 0x65, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x08, 0x52, 0x65, 0x70, 0x61, 0x69, 0x72, 0x65, 0x64, 0x22, 0x2c, 0x0a, 0x09, 0x4c, 0x65,
+	0x61, 0x6b, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x1f, 0x0a, 0x05, 0x4c, 0x65, 0x61, 0x6b, 0x73,
+	0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x4c, 0x65, 0x61,
+	0x6b, 0x52, 0x05, 0x4c, 0x65, 0x61, 0x6b, 0x73, 0x2a, 0x57, 0x0a, 0x13, 0x52, 0x65, 0x73, 0x6f,
+	0x75, 0x72, 0x63, 0x65, 0x4d, 0x61, 0x70, 0x70, 0x69, 0x6e, 0x67, 0x54, 0x79, 0x70, 0x65, 0x12,
+	0x15, 0x0a, 0x11, 0x4d, 0x61, 0x70, 0x70, 0x69, 0x6e, 0x67, 0x54, 0x79, 0x70, 0x65, 0x4e, 0x6f,
+	0x72, 0x6d, 0x61, 0x6c, 0x10, 0x00, 0x12, 0x13, 0x0a, 0x0f, 0x4d, 0x61, 0x70, 0x70, 0x69, 0x6e,
+	0x67, 0x54, 0x79, 0x70, 0x65, 0x49, 0x64, 0x6c, 0x65, 0x10, 0x01, 0x12, 0x14, 0x0a, 0x10, 0x4d,
+	0x61, 0x70, 0x70, 0x69, 0x6e, 0x67, 0x54, 0x79, 0x70, 0x65, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x10,
+	0x02, 0x2a, 0x9f, 0x01, 0x0a, 0x08, 0x4c, 0x65, 0x61, 0x6b, 0x54, 0x79, 0x70, 0x65, 0x12, 0x19,
+	0x0a, 0x15, 0x4c, 0x65, 0x61, 0x6b, 0x54, 0x79, 0x70, 0x65, 0x53, 0x74, 0x61, 0x6c, 0x65, 0x52,
+	0x65, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x10, 0x00, 0x12, 0x17, 0x0a, 0x13, 0x4c, 0x65, 0x61,
+	0x6b, 0x54, 0x79, 0x70, 0x65, 0x4f, 0x72, 0x70, 0x68, 0x61, 0x6e, 0x49, 0x6e, 0x55, 0x73, 0x65,
+	0x10, 0x01, 0x12, 0x18, 0x0a, 0x14, 0x4c, 0x65, 0x61, 0x6b, 0x54, 0x79, 0x70, 0x65, 0x4d, 0x69,
+	0x73, 0x73, 0x69, 0x6e, 0x67, 0x4c, 0x6f, 0x63, 0x61, 0x6c, 0x10, 0x02, 0x12, 0x19, 0x0a, 0x15,
+	0x4c, 0x65, 0x61, 0x6b, 0x54, 0x79, 0x70, 0x65, 0x4d, 0x69, 0x73, 0x73, 0x69, 0x6e, 0x67, 0x52,
+	0x65, 0x6d, 0x6f, 0x74, 0x65, 0x10, 0x03, 0x12, 0x15, 0x0a, 0x11, 0x4c, 0x65, 0x61, 0x6b, 0x54,
+	0x79, 0x70, 0x65, 0x55, 0x6e, 0x74, 0x72, 0x61, 0x63, 0x6b, 0x65, 0x64, 0x10, 0x04, 0x12, 0x13,
+	0x0a, 0x0f, 0x4c, 0x65, 0x61, 0x6b, 0x54, 0x79, 0x70, 0x65, 0x49, 0x6e, 0x76, 0x61, 0x6c, 0x69,
+	0x64, 0x10, 0x05, 0x32, 0xec, 0x03, 0x0a, 0x0d, 0x54, 0x65, 0x72, 0x77, 0x61, 0x79, 0x54, 0x72,
+	0x61, 0x63, 0x69, 0x6e, 0x67, 0x12, 0x3e, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x52, 0x65, 0x73, 0x6f,
+	0x75, 0x72, 0x63, 0x65, 0x54, 0x79, 0x70, 0x65, 0x73, 0x12, 0x10, 0x2e, 0x72, 0x70, 0x63, 0x2e,
+	0x50, 0x6c, 0x61, 0x63, 0x65, 0x68, 0x6f, 0x6c, 0x64, 0x65, 0x72, 0x1a, 0x18, 0x2e, 0x72, 0x70,
+	0x63, 0x2e, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x73, 0x54, 0x79, 0x70, 0x65, 0x73,
+	0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x42, 0x0a, 0x0c, 0x47, 0x65, 0x74, 0x52, 0x65, 0x73, 0x6f,
+	0x75, 0x72, 0x63, 0x65, 0x73, 0x12, 0x18, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x52, 0x65, 0x73, 0x6f,
+	0x75, 0x72, 0x63, 0x65, 0x54, 0x79, 0x70, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x18, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x73, 0x4e,
+	0x61, 0x6d, 0x65, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x4b, 0x0a, 0x11, 0x47, 0x65, 0x74,
+	0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x1c,
+	0x2e, 0x72, 0x70, 0x63, 0x2e, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x54, 0x79, 0x70,
+	0x65, 0x4e, 0x61, 0x6d, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e, 0x72,
+	0x70, 0x63, 0x2e, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69,
+	0x67, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x49, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x52, 0x65, 0x73,
+	0x6f, 0x75, 0x72, 0x63, 0x65, 0x54, 0x72, 0x61, 0x63, 0x65, 0x12, 0x1c, 0x2e, 0x72, 0x70, 0x63,
+	0x2e, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x54, 0x79, 0x70, 0x65, 0x4e, 0x61, 0x6d,
+	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x52,
+	0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x54, 0x72, 0x61, 0x63, 0x65, 0x52, 0x65, 0x70, 0x6c,
+	0x79, 0x12, 0x4b, 0x0a, 0x0f, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x45, 0x78, 0x65,
+	0x63, 0x75, 0x74, 0x65, 0x12, 0x1b, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x52, 0x65, 0x73, 0x6f, 0x75,
+	0x72, 0x63, 0x65, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x19, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65,
+	0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x30, 0x01, 0x12, 0x44,
+	0x0a, 0x12, 0x47, 0x65, 0x74, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x4d, 0x61, 0x70,
+	0x70, 0x69, 0x6e, 0x67, 0x12, 0x10, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x50, 0x6c, 0x61, 0x63, 0x65,
+	0x68, 0x6f, 0x6c, 0x64, 0x65, 0x72, 0x1a, 0x1c, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x50, 0x6f, 0x64,
+	0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x4d, 0x61, 0x70, 0x70, 0x69, 0x6e, 0x67, 0x52,
+	0x65, 0x70, 0x6c, 0x79, 0x12, 0x2c, 0x0a, 0x08, 0x47, 0x65, 0x74, 0x4c, 0x65, 0x61, 0x6b, 0x73,
+	0x12, 0x10, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x4c, 0x65, 0x61, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x0e, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x4c, 0x65, 0x61, 0x6b, 0x52, 0x65, 0x70,
+	0x6c, 0x79, 0x42, 0x07, 0x5a, 0x05, 0x2e, 0x3b, 0x72, 0x70, 0x63, 0x62, 0x06, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x33,
 }
 
 var (
@@ -795,45 +1054,53 @@ func file_tracing_proto_rawDescGZIP() []byte {
 	return file_tracing_proto_rawDescData
 }
 
-var file_tracing_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_tracing_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_tracing_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_tracing_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
 var file_tracing_proto_goTypes = []interface{}{
 	(ResourceMappingType)(0),        // 0: rpc.ResourceMappingType
-	(*Placeholder)(nil),             // 1: rpc.Placeholder
-	(*ResourcesTypesReply)(nil),     // 2: rpc.ResourcesTypesReply
-	(*ResourcesNamesReply)(nil),     // 3: rpc.ResourcesNamesReply
-	(*ResourceTypeRequest)(nil),     // 4: rpc.ResourceTypeRequest
-	(*ResourceTypeNameRequest)(nil), // 5: rpc.ResourceTypeNameRequest
-	(*ResourceExecuteRequest)(nil),  // 6: rpc.ResourceExecuteRequest
-	(*ResourceExecuteReply)(nil),    // 7: rpc.ResourceExecuteReply
-	(*MapKeyValueEntry)(nil),        // 8: rpc.MapKeyValueEntry
-	(*ResourceConfigReply)(nil),     // 9: rpc.ResourceConfigReply
-	(*ResourceTraceReply)(nil),      // 10: rpc.ResourceTraceReply
-	(*PodResourceMapping)(nil),      // 11: rpc.PodResourceMapping
-	(*PodResourceMappingReply)(nil), // 12: rpc.PodResourceMappingReply
+	(LeakType)(0),                   // 1: rpc.LeakType
+	(*Placeholder)(nil),             // 2: rpc.Placeholder
+	(*ResourcesTypesReply)(nil),     // 3: rpc.ResourcesTypesReply
+	(*ResourcesNamesReply)(nil),     // 4: rpc.ResourcesNamesReply
+	(*ResourceTypeRequest)(nil),     // 5: rpc.ResourceTypeRequest
+	(*ResourceTypeNameRequest)(nil), // 6: rpc.ResourceTypeNameRequest
+	(*ResourceExecuteRequest)(nil),  // 7: rpc.ResourceExecuteRequest
+	(*ResourceExecuteReply)(nil),    // 8: rpc.ResourceExecuteReply
+	(*MapKeyValueEntry)(nil),        // 9: rpc.MapKeyValueEntry
+	(*ResourceConfigReply)(nil),     // 10: rpc.ResourceConfigReply
+	(*ResourceTraceReply)(nil),      // 11: rpc.ResourceTraceReply
+	(*PodResourceMapping)(nil),      // 12: rpc.PodResourceMapping
+	(*PodResourceMappingReply)(nil), // 13: rpc.PodResourceMappingReply
+	(*LeakRequest)(nil),             // 14: rpc.LeakRequest
+	(*Leak)(nil),                    // 15: rpc.Leak
+	(*LeakReply)(nil),               // 16: rpc.LeakReply
 }
 var file_tracing_proto_depIdxs = []int32{
-	8,  // 0: rpc.ResourceConfigReply.Config:type_name -> rpc.MapKeyValueEntry
-	8,  // 1: rpc.ResourceTraceReply.Trace:type_name -> rpc.MapKeyValueEntry
+	9,  // 0: rpc.ResourceConfigReply.Config:type_name -> rpc.MapKeyValueEntry
+	9,  // 1: rpc.ResourceTraceReply.Trace:type_name -> rpc.MapKeyValueEntry
 	0,  // 2: rpc.PodResourceMapping.type:type_name -> rpc.ResourceMappingType
-	11, // 3: rpc.PodResourceMappingReply.info:type_name -> rpc.PodResourceMapping
-	1,  // 4: rpc.TerwayTracing.GetResourceTypes:input_type -> rpc.Placeholder
-	4,  // 5: rpc.TerwayTracing.GetResources:input_type -> rpc.ResourceTypeRequest
-	5,  // 6: rpc.TerwayTracing.GetResourceConfig:input_type -> rpc.ResourceTypeNameRequest
-	5,  // 7: rpc.TerwayTracing.GetResourceTrace:input_type -> rpc.ResourceTypeNameRequest
-	6,  // 8: rpc.TerwayTracing.ResourceExecute:input_type -> rpc.ResourceExecuteRequest
-	1,  // 9: rpc.TerwayTracing.GetResourceMapping:input_type -> rpc.Placeholder
-	2,  // 10: rpc.TerwayTracing.GetResourceTypes:output_type -> rpc.ResourcesTypesReply
-	3,  // 11: rpc.TerwayTracing.GetResources:output_type -> rpc.ResourcesNamesReply
-	9,  // 12: rpc.TerwayTracing.GetResourceConfig:output_type -> rpc.ResourceConfigReply
-	10, // 13: rpc.TerwayTracing.GetResourceTrace:output_type -> rpc.ResourceTraceReply
-	7,  // 14: rpc.TerwayTracing.ResourceExecute:output_type -> rpc.ResourceExecuteReply
-	12, // 15: rpc.TerwayTracing.GetResourceMapping:output_type -> rpc.PodResourceMappingReply
-	10, // [10:16] is the sub-list for method output_type
-	4,  // [4:10] is the sub-list for method input_type
-	4,  // [4:4] is the sub-list for extension type_name
-	4,  // [4:4] is the sub-list for extension extendee
-	0,  // [0:4] is the sub-list for field type_name
+	12, // 3: rpc.PodResourceMappingReply.info:type_name -> rpc.PodResourceMapping
+	1,  // 4: rpc.Leak.Type:type_name -> rpc.LeakType
+	15, // 5: rpc.LeakReply.Leaks:type_name -> rpc.Leak
+	2,  // 6: rpc.TerwayTracing.GetResourceTypes:input_type -> rpc.Placeholder
+	5,  // 7: rpc.TerwayTracing.GetResources:input_type -> rpc.ResourceTypeRequest
+	6,  // 8: rpc.TerwayTracing.GetResourceConfig:input_type -> rpc.ResourceTypeNameRequest
+	6,  // 9: rpc.TerwayTracing.GetResourceTrace:input_type -> rpc.ResourceTypeNameRequest
+	7,  // 10: rpc.TerwayTracing.ResourceExecute:input_type -> rpc.ResourceExecuteRequest
+	2,  // 11: rpc.TerwayTracing.GetResourceMapping:input_type -> rpc.Placeholder
+	14, // 12: rpc.TerwayTracing.GetLeaks:input_type -> rpc.LeakRequest
+	3,  // 13: rpc.TerwayTracing.GetResourceTypes:output_type -> rpc.ResourcesTypesReply
+	4,  // 14: rpc.TerwayTracing.GetResources:output_type -> rpc.ResourcesNamesReply
+	10, // 15: rpc.TerwayTracing.GetResourceConfig:output_type -> rpc.ResourceConfigReply
+	11, // 16: rpc.TerwayTracing.GetResourceTrace:output_type -> rpc.ResourceTraceReply
+	8,  // 17: rpc.TerwayTracing.ResourceExecute:output_type -> rpc.ResourceExecuteReply
+	13, // 18: rpc.TerwayTracing.GetResourceMapping:output_type -> rpc.PodResourceMappingReply
+	16, // 19: rpc.TerwayTracing.GetLeaks:output_type -> rpc.LeakReply
+	13, // [13:20] is the sub-list for method output_type
+	6,  // [6:13] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
 }
 
 func init() { file_tracing_proto_init() }
@@ -986,14 +1253,50 @@ func file_tracing_proto_init() {
 				return nil
 			}
 		}
+		file_tracing_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LeakRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_tracing_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Leak); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_tracing_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LeakReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_tracing_proto_rawDesc,
-			NumEnums:      1,
-			NumMessages:   12,
+			NumEnums:      2,
+			NumMessages:   15,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
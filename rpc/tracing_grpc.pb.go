@@ -28,6 +28,7 @@ type TerwayTracingClient interface {
 	GetResourceTrace(ctx context.Context, in *ResourceTypeNameRequest, opts ...grpc.CallOption) (*ResourceTraceReply, error)
 	ResourceExecute(ctx context.Context, in *ResourceExecuteRequest, opts ...grpc.CallOption) (TerwayTracing_ResourceExecuteClient, error)
 	GetResourceMapping(ctx context.Context, in *Placeholder, opts ...grpc.CallOption) (*PodResourceMappingReply, error)
+	GetLeaks(ctx context.Context, in *LeakRequest, opts ...grpc.CallOption) (*LeakReply, error)
 }
 
 type terwayTracingClient struct {
@@ -115,6 +116,15 @@ func (c *terwayTracingClient) GetResourceMapping(ctx context.Context, in *Placeh
 	return out, nil
 }
 
+func (c *terwayTracingClient) GetLeaks(ctx context.Context, in *LeakRequest, opts ...grpc.CallOption) (*LeakReply, error) {
+	out := new(LeakReply)
+	err := c.cc.Invoke(ctx, "/rpc.TerwayTracing/GetLeaks", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // TerwayTracingServer is the server API for TerwayTracing service.
 // All implementations must embed UnimplementedTerwayTracingServer
 // for forward compatibility
@@ -125,6 +135,7 @@ type TerwayTracingServer interface {
 	GetResourceTrace(context.Context, *ResourceTypeNameRequest) (*ResourceTraceReply, error)
 	ResourceExecute(*ResourceExecuteRequest, TerwayTracing_ResourceExecuteServer) error
 	GetResourceMapping(context.Context, *Placeholder) (*PodResourceMappingReply, error)
+	GetLeaks(context.Context, *LeakRequest) (*LeakReply, error)
 	mustEmbedUnimplementedTerwayTracingServer()
 }
 
@@ -150,6 +161,9 @@ func (UnimplementedTerwayTracingServer) ResourceExecute(*ResourceExecuteRequest,
 func (UnimplementedTerwayTracingServer) GetResourceMapping(context.Context, *Placeholder) (*PodResourceMappingReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetResourceMapping not implemented")
 }
+func (UnimplementedTerwayTracingServer) GetLeaks(context.Context, *LeakRequest) (*LeakReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLeaks not implemented")
+}
 func (UnimplementedTerwayTracingServer) mustEmbedUnimplementedTerwayTracingServer() {}
 
 // UnsafeTerwayTracingServer may be embedded to opt out of forward compatibility for this service.
@@ -274,6 +288,24 @@ func _TerwayTracing_GetResourceMapping_Handler(srv interface{}, ctx context.Cont
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TerwayTracing_GetLeaks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LeakRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TerwayTracingServer).GetLeaks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/rpc.TerwayTracing/GetLeaks",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TerwayTracingServer).GetLeaks(ctx, req.(*LeakRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // TerwayTracing_ServiceDesc is the grpc.ServiceDesc for TerwayTracing service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -301,6 +333,10 @@ var TerwayTracing_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetResourceMapping",
 			Handler:    _TerwayTracing_GetResourceMapping_Handler,
 		},
+		{
+			MethodName: "GetLeaks",
+			Handler:    _TerwayTracing_GetLeaks_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
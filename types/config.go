@@ -1,5 +1,7 @@
 package types
 
+import "time"
+
 // PoolConfig configuration of pool and resource factory
 type PoolConfig struct {
 	MaxPoolSize               int
@@ -20,6 +22,20 @@ type PoolConfig struct {
 	EnableENITrunking         bool
 	ENICapPolicy              ENICapPolicy
 	DisableDevicePlugin       bool
+	EnableENIIPResource       bool
 	WaitTrunkENI              bool
 	DisableSecurityGroupCheck bool
+	IPAllocationPolicy        IPAllocationPolicy
+	ENIConsolidateInterval    time.Duration
+	ENIConsolidateThreshold   float64
+	ENIGroups                 []ENIGroupConfig
+}
+
+// ENIGroupConfig configuration of the eni group, the eniip pool is partitioned by the groups
+type ENIGroupConfig struct {
+	Name           string
+	VSwitch        []string
+	SecurityGroups []string
+	MaxPoolSize    int
+	MinPoolSize    int
 }
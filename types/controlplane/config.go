@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/AliyunContainerService/terway/pkg/aliyun/metadata"
 	"github.com/AliyunContainerService/terway/pkg/backoff"
@@ -32,6 +33,13 @@ var (
 	cfg *Config
 )
 
+// modes for eni gc
+const (
+	GCModeOff     = "off"
+	GCModeDryRun  = "dry-run"
+	GCModeEnforce = "enforce"
+)
+
 func GetConfig() *Config {
 	return cfg
 }
@@ -85,6 +93,10 @@ func ParseAndValidate(configFilePath, credentialFilePath string) (*Config, error
 		t := true
 		c.EnableTrunk = &t
 	}
+	if c.AuditLogMaxBackups == nil {
+		n := 5
+		c.AuditLogMaxBackups = &n
+	}
 	if c.RegionID == "" {
 		c.RegionID, err = metadata.GetLocalRegion()
 		if err != nil || c.RegionID == "" {
@@ -101,6 +113,22 @@ func ParseAndValidate(configFilePath, credentialFilePath string) (*Config, error
 		return nil, err
 	}
 
+	_, err = time.ParseDuration(c.ENIGCGracePeriod)
+	if err != nil {
+		return nil, fmt.Errorf("error parse eniGCGracePeriod, %w", err)
+	}
+	_, err = time.ParseDuration(c.CircuitBreakerCooldown)
+	if err != nil {
+		return nil, fmt.Errorf("error parse circuitBreakerCooldown, %w", err)
+	}
+	_, err = time.ParseDuration(c.ShardLeaseDuration)
+	if err != nil {
+		return nil, fmt.Errorf("error parse shardLeaseDuration, %w", err)
+	}
+	if c.EnableENIIPResource && len(c.ENIIPResourceNodeSelector) == 0 {
+		return nil, fmt.Errorf("eniIPResourceNodeSelector is required when enableENIIPResource is set")
+	}
+
 	backoff.OverrideBackoff(c.BackoffOverride)
 	cfg = &c
 
@@ -19,6 +19,7 @@ limitations under the License.
 package controlplane
 
 import (
+	"github.com/AliyunContainerService/terway/pkg/aliyun/credential"
 	"github.com/AliyunContainerService/terway/types/secret"
 
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -43,6 +44,12 @@ type Config struct {
 	PodMaxConcurrent    int `json:"podMaxConcurrent" validate:"gt=0,lte=10000" mod:"default=10"`
 	PodENIMaxConcurrent int `json:"podENIMaxConcurrent" validate:"gt=0,lte=10000" mod:"default=10"`
 
+	// ShardCount split nodes into shards, replicas hold shards by leases and only reconcile pods, podENIs
+	// and node pools in the shards they hold. 0 to disable sharding, all work is done by the leader
+	ShardCount int `json:"shardCount" validate:"gte=0,lte=1024"`
+	// ShardLeaseDuration is the lease duration for each shard
+	ShardLeaseDuration string `json:"shardLeaseDuration" mod:"default=15s"`
+
 	Controllers []string `json:"controllers"`
 
 	// cluster info for controlplane
@@ -54,6 +61,12 @@ type Config struct {
 	EnableTrunk        *bool  `json:"enableTrunk,omitempty"`
 	EnableDevicePlugin bool   `json:"enableDevicePlugin"`
 	IPStack            string `json:"ipStack,omitempty" validate:"oneof=ipv4 ipv6 dual" mod:"default=ipv4"`
+	// EnableENIIPResource inject the aliyun/eni-ip request to the pods using the shared eni ips,
+	// the daemon should advertise it by enable_eni_ip_resource
+	EnableENIIPResource bool `json:"enableENIIPResource"`
+	// ENIIPResourceNodeSelector limit the injection to the pods with all these labels in the node selector,
+	// so the pods only land on the nodes advertising aliyun/eni-ip. Required when EnableENIIPResource is set
+	ENIIPResourceNodeSelector map[string]string `json:"eniIPResourceNodeSelector"`
 
 	KubeClientQPS   float32 `json:"kubeClientQPS" validate:"gt=0,lte=10000" mod:"default=20"`
 	KubeClientBurst int     `json:"kubeClientBurst" validate:"gt=0,lte=10000" mod:"default=30"`
@@ -63,21 +76,87 @@ type Config struct {
 	MutatingQPS   float32 `json:"mutatingQPS" validate:"gt=0,lte=10000" mod:"default=4"`
 	MutatingBurst int     `json:"mutatingBurst" validate:"gt=0,lte=10000" mod:"default=5"`
 
+	// CircuitBreakerThreshold is the consecutive network, server or throttling failures of an openapi to open the breaker.
+	// Set a negative value to disable the breaker, 0 is taken as unset and the default is used
+	CircuitBreakerThreshold int `json:"circuitBreakerThreshold" mod:"default=10"`
+	// CircuitBreakerCooldown is the time the breaker stays open before a probe call is allowed
+	CircuitBreakerCooldown string `json:"circuitBreakerCooldown" mod:"default=30s"`
+
 	VSwitchPoolSize int    `json:"vSwitchPoolSize" validate:"gt=0" mod:"default=1000"`
 	VSwitchCacheTTL string `json:"vSwitchCacheTTL" mod:"default=20m0s"`
 
 	CustomStatefulWorkloadKinds []string `json:"customStatefulWorkloadKinds"`
 
+	// ENIGCMode is the mode for leaked eni gc, one of off, dry-run, enforce. Default dry-run only reports the candidates,
+	// set enforce to delete them
+	ENIGCMode string `json:"eniGCMode" validate:"oneof=off dry-run enforce" mod:"default=dry-run"`
+	// ENIGCGracePeriod is the minimum age before an eni or podENI can be garbage collected
+	ENIGCGracePeriod string `json:"eniGCGracePeriod" mod:"default=10m0s"`
+	// ENIGCMaxPerCycle is the maximum resource deleted in one gc cycle, shared by the leaked eni and podENI categories
+	ENIGCMaxPerCycle int `json:"eniGCMaxPerCycle" validate:"gt=0" mod:"default=50"`
+
+	// AuditLogPath is the file to record the cloud mutations as json lines, empty to disable
+	AuditLogPath string `json:"auditLogPath"`
+	// AuditLogMaxSize is the size in MB to rotate the audit log
+	AuditLogMaxSize int `json:"auditLogMaxSize" validate:"gt=0" mod:"default=100"`
+	// AuditLogMaxBackups is the amount of rotated audit log kept, default 5, 0 to keep none
+	AuditLogMaxBackups *int `json:"auditLogMaxBackups,omitempty" validate:"gte=0"`
+	// AuditEvent record the cloud mutations as pod events
+	AuditEvent bool `json:"auditEvent"`
+
+	// Provider is the cloud backend, aliyun or static
+	Provider       string         `json:"provider" validate:"oneof=aliyun static" mod:"default=aliyun"`
+	StaticProvider StaticProvider `json:"staticProvider"`
+
+	// TracingEndpoint is the otlp grpc endpoint the spans are exported to, prefix it with http:// to disable tls,
+	// empty to disable tracing
+	TracingEndpoint string `json:"tracingEndpoint"`
+	// TracingSamplingRatio is the ratio of the reconciles traced when no trace is propagated from the daemon, default 1
+	TracingSamplingRatio float64 `json:"tracingSamplingRatio" validate:"gte=0,lte=1"`
+
 	BackoffOverride map[string]wait.Backoff `json:"backoffOverride,omitempty"`
 	IPAMType        string                  `json:"ipamType"`
 
 	Credential
 }
 
+// StaticProvider is the limits and vSwitches served by the static provider, for nodes not running on aliyun
+type StaticProvider struct {
+	Adapters       int             `json:"adapters"`
+	IPv4PerAdapter int             `json:"ipv4PerAdapter"`
+	IPv6PerAdapter int             `json:"ipv6PerAdapter"`
+	VSwitches      []StaticVSwitch `json:"vSwitches"`
+}
+
+// StaticVSwitch is the subnet of the pre-provisioned nics
+type StaticVSwitch struct {
+	ID               string `json:"id"`
+	ZoneID           string `json:"zoneID"`
+	CIDR             string `json:"cidr"`
+	IPv6CIDR         string `json:"ipv6CIDR"`
+	AvailableIPCount int64  `json:"availableIPCount"`
+}
+
 type Credential struct {
 	AccessKey       secret.Secret `json:"accessKey" validate:"required_with=AccessSecret"`
 	AccessSecret    secret.Secret `json:"accessSecret" validate:"required_with=AccessKey"`
 	CredentialPath  string        `json:"credentialPath"`
 	SecretNamespace string        `json:"secretNamespace" validate:"required_with=SecretName"`
 	SecretName      string        `json:"secretName" validate:"required_with=SecretNamespace"`
+
+	// OIDCRoleARN is the ram role assumed by the service account token (RRSA)
+	OIDCRoleARN     string `json:"oidcRoleARN" validate:"required_with=OIDCProviderARN"`
+	OIDCProviderARN string `json:"oidcProviderARN" validate:"required_with=OIDCRoleARN"`
+	OIDCTokenFile   string `json:"oidcTokenFile"`
+	STSEndpoint     string `json:"stsEndpoint"`
+}
+
+// GetOIDCConfig return the oidc config, unset fields are read from env
+func (c *Credential) GetOIDCConfig() credential.OIDCConfig {
+	return credential.OIDCConfigFromEnv(credential.OIDCConfig{
+		RoleARN:     c.OIDCRoleARN,
+		ProviderARN: c.OIDCProviderARN,
+		TokenFile:   c.OIDCTokenFile,
+		STSEndpoint: c.STSEndpoint,
+	})
 }
@@ -3,9 +3,11 @@ package daemon
 import (
 	"os"
 
+	"github.com/AliyunContainerService/terway/pkg/aliyun/credential"
 	"github.com/AliyunContainerService/terway/types"
 	"github.com/AliyunContainerService/terway/types/route"
 	jsonpatch "github.com/evanphx/json-patch"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/json"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -33,18 +35,134 @@ type Config struct {
 	EnableEIPPool          string              `yaml:"enable_eip_pool" json:"enable_eip_pool"`
 	IPStack                string              `yaml:"ip_stack" json:"ip_stack" validate:"oneof=ipv4 ipv6 dual" mod:"default=ipv4"` // default ipv4 , support ipv4 dual
 	// rob the eip instance even the eip already bound to other resource
-	AllowEIPRob                 string                  `yaml:"allow_eip_rob" json:"allow_eip_rob"`
-	EnableENITrunking           bool                    `yaml:"enable_eni_trunking" json:"enable_eni_trunking"`
-	CustomStatefulWorkloadKinds []string                `yaml:"custom_stateful_workload_kinds" json:"custom_stateful_workload_kinds"`
-	IPAMType                    types.IPAMType          `yaml:"ipam_type" json:"ipam_type"`           // crd or default
-	ENICapPolicy                types.ENICapPolicy      `yaml:"eni_cap_policy" json:"eni_cap_policy"` // prefer trunk or secondary
-	BackoffOverride             map[string]wait.Backoff `json:"backoff_override,omitempty"`
-	ExtraRoutes                 []route.Route           `json:"extra_routes,omitempty"`
-	DisableDevicePlugin         bool                    `json:"disable_device_plugin"`
-	WaitTrunkENI                bool                    `json:"wait_trunk_eni"` // true for don't create trunk eni
-	DisableSecurityGroupCheck   bool                    `json:"disable_security_group_check"`
-	KubeClientQPS               float32                 `json:"kube_client_qps"`
-	KubeClientBurst             int                     `json:"kube_client_burst"`
+	AllowEIPRob                 string                   `yaml:"allow_eip_rob" json:"allow_eip_rob"`
+	EnableENITrunking           bool                     `yaml:"enable_eni_trunking" json:"enable_eni_trunking"`
+	CustomStatefulWorkloadKinds []string                 `yaml:"custom_stateful_workload_kinds" json:"custom_stateful_workload_kinds"`
+	IPAMType                    types.IPAMType           `yaml:"ipam_type" json:"ipam_type"`           // crd or default
+	ENICapPolicy                types.ENICapPolicy       `yaml:"eni_cap_policy" json:"eni_cap_policy"` // prefer trunk or secondary
+	BackoffOverride             map[string]wait.Backoff  `json:"backoff_override,omitempty"`
+	ExtraRoutes                 []route.Route            `json:"extra_routes,omitempty"`
+	DisableDevicePlugin         bool                     `json:"disable_device_plugin"`
+	WaitTrunkENI                bool                     `json:"wait_trunk_eni"` // true for don't create trunk eni
+	DisableSecurityGroupCheck   bool                     `json:"disable_security_group_check"`
+	KubeClientQPS               float32                  `json:"kube_client_qps"`
+	KubeClientBurst             int                      `json:"kube_client_burst"`
+	IPAllocationPolicy          types.IPAllocationPolicy `json:"ip_allocation_policy"` // spread or pack
+	// ENIConsolidateInterval is the interval to drain the emptiest eni, only work with pack policy, empty to disable
+	ENIConsolidateInterval string `json:"eni_consolidate_interval"`
+	// ENIConsolidateThreshold eni with ip usage ratio below it can be drained
+	ENIConsolidateThreshold float64 `json:"eni_consolidate_threshold"`
+	// ENIGroups partition the eniip pool by security groups
+	ENIGroups []ENIGroup `json:"eni_groups,omitempty"`
+	// OIDCRoleARN is the ram role assumed by the service account token (RRSA)
+	OIDCRoleARN     string `yaml:"oidc_role_arn" json:"oidc_role_arn"`
+	OIDCProviderARN string `yaml:"oidc_provider_arn" json:"oidc_provider_arn"`
+	OIDCTokenFile   string `yaml:"oidc_token_file" json:"oidc_token_file"`
+	STSEndpoint     string `yaml:"sts_endpoint" json:"sts_endpoint"`
+	// AuditLogPath is the file to record the cloud mutations as json lines, empty to disable
+	AuditLogPath string `json:"audit_log_path"`
+	// AuditLogMaxSize is the size in MB to rotate the audit log
+	AuditLogMaxSize int `json:"audit_log_max_size"`
+	// AuditLogMaxBackups is the amount of rotated audit log kept, default 5, 0 to keep none
+	AuditLogMaxBackups *int `json:"audit_log_max_backups,omitempty"`
+	// AuditEvent record the cloud mutations as node or pod events
+	AuditEvent bool `json:"audit_event"`
+	// Provider is the cloud backend, aliyun or static
+	Provider string `json:"provider"`
+	// StaticProvider is the pre-provisioned nics used by the static provider
+	StaticProvider *StaticProvider `json:"static_provider,omitempty"`
+	// EnablePodEIP leave the pod eip to the PodEIP cr reconciled by terway-controlplane, the daemon only read the result
+	EnablePodEIP bool `json:"enable_pod_eip"`
+	// InstanceTypeLimits override the limits of instance types, take precedence over the embedded table and the openapi
+	InstanceTypeLimits map[string]InstanceTypeLimit `json:"instance_type_limits,omitempty"`
+	// EIPReuseTTL keep the eip of stateful workload pods for the ttl after the pod is deleted, the pod with the same
+	// name on any node reuse it, empty to disable. The reservation is recorded in the PodEIP cr and released by the
+	// pod-eip-reservation controller of terway-controlplane after the ttl
+	EIPReuseTTL string `json:"eip_reuse_ttl"`
+	// EIPPool keep a warm pool of unassociated eips on the node
+	EIPPool *EIPPool `json:"eip_pool,omitempty"`
+	// TracingEndpoint is the otlp grpc endpoint the spans are exported to, prefix it with http:// to disable tls,
+	// empty to disable tracing
+	TracingEndpoint string `json:"tracing_endpoint"`
+	// TracingSamplingRatio is the ratio of the requests traced when the cni plugin is not tracing, default 1
+	TracingSamplingRatio float64 `json:"tracing_sampling_ratio"`
+	// TracingPropagateRatio is the ratio of the sampled requests waiting for the PodENI whose trace context is passed to
+	// terway-controlplane, default 0 to disable. The context is patched to the pod annotations in background and removed
+	// after the wait, so each propagated request costs two pod updates
+	TracingPropagateRatio float64 `json:"tracing_propagate_ratio"`
+	// NodeNetworkStatusInterval is the min interval between the updates of the NodeNetworkStatus cr of the node,
+	// go duration like 10s, empty to disable
+	NodeNetworkStatusInterval string `json:"node_network_status_interval"`
+	// NetworkUnavailableThreshold is the duration the allocation keeps failing for the eni/ip limit or the vswitch running out of ips
+	// before the node condition TerwayNetworkAvailable is set to false, go duration like 1m, empty to disable.
+	// It is set back when an allocation succeeds, a resource is released, or the probe every minute finds an idle
+	// resource in the pool or free ips in the vswitches
+	NetworkUnavailableThreshold string `json:"network_unavailable_threshold"`
+	// NetworkUnavailableTaint put a NoSchedule taint on the node while TerwayNetworkAvailable is false
+	NetworkUnavailableTaint bool `json:"network_unavailable_taint"`
+	// EnableENIIPResource advertise the eni ips the node can give out as the aliyun/eni-ip resource in eni multi-ip mode,
+	// enableENIIPResource of terway-controlplane should be set too for the pods to request it, and the node should have
+	// the labels of its eniIPResourceNodeSelector. The ips held by the running pods not requesting it are not advertised
+	EnableENIIPResource bool `json:"enable_eni_ip_resource"`
+}
+
+// EIPPool is the warm pool of eips, pods requiring the same spec draw eip from the pool
+type EIPPool struct {
+	MinPoolSize           int    `json:"min_pool_size"`
+	MaxPoolSize           int    `json:"max_pool_size"`
+	Bandwidth             int    `json:"bandwidth"`
+	InternetChargeType    string `json:"internet_charge_type"`
+	ISP                   string `json:"isp"`
+	BandwidthPackageID    string `json:"bandwidth_package_id"`
+	PublicIPAddressPoolID string `json:"public_ip_address_pool_id"`
+}
+
+// InstanceTypeLimit is the eni and ip limits of an instance type
+type InstanceTypeLimit struct {
+	Adapters              int `json:"adapters"`
+	TotalAdapters         int `json:"total_adapters"`
+	IPv4PerAdapter        int `json:"ipv4_per_adapter"`
+	IPv6PerAdapter        int `json:"ipv6_per_adapter"`
+	MemberAdapterLimit    int `json:"member_adapter_limit"`
+	MaxMemberAdapterLimit int `json:"max_member_adapter_limit"`
+}
+
+// StaticProvider describe the node and its pre-provisioned secondary nics, for nodes not running on aliyun
+type StaticProvider struct {
+	InstanceID   string `json:"instance_id"` // use the node name if not set
+	InstanceType string `json:"instance_type"`
+	ZoneID       string `json:"zone_id"`
+	VPCID        string `json:"vpc_id"`
+	PrimaryMAC   string `json:"primary_mac"`
+	// StateFile persist the ips assigned from the ranges
+	StateFile string      `json:"state_file"`
+	NICs      []StaticNIC `json:"nics"`
+}
+
+// StaticNIC is a pre-provisioned secondary nic, ips in the ranges are assigned to pods
+type StaticNIC struct {
+	ID         string   `json:"id"` // use the mac if not set
+	MAC        string   `json:"mac"`
+	PrimaryIP  string   `json:"primary_ip"`
+	Gateway    string   `json:"gateway"`
+	CIDR       string   `json:"cidr"`
+	IPRanges   []string `json:"ip_ranges"` // cidr or range like 192.168.0.10-192.168.0.20
+	GatewayV6  string   `json:"gateway_v6"`
+	CIDRV6     string   `json:"cidr_v6"`
+	IPv6Ranges []string `json:"ipv6_ranges"`
+}
+
+// ENIGroup is a set of enis with their own vSwitches and security groups,
+// pods are placed in the groups by the selectors, the annotation k8s.aliyun.com/eni-group picks one of the groups selecting the pod
+type ENIGroup struct {
+	Name           string              `json:"name"`
+	VSwitches      map[string][]string `json:"vswitches,omitempty"` // use the default vSwitches if not set
+	SecurityGroups []string            `json:"security_groups"`
+	MaxPoolSize    int                 `json:"max_pool_size"`
+	MinPoolSize    int                 `json:"min_pool_size"`
+
+	PodSelector       *metav1.LabelSelector `json:"pod_selector,omitempty"`
+	NamespaceSelector *metav1.LabelSelector `json:"namespace_selector,omitempty"`
 }
 
 func (c *Config) GetSecurityGroups() []string {
@@ -56,6 +174,16 @@ func (c *Config) GetSecurityGroups() []string {
 	return sgIDs.List()
 }
 
+// GetOIDCConfig return the oidc config, unset fields are read from env
+func (c *Config) GetOIDCConfig() credential.OIDCConfig {
+	return credential.OIDCConfigFromEnv(credential.OIDCConfig{
+		RoleARN:     c.OIDCRoleARN,
+		ProviderARN: c.OIDCProviderARN,
+		TokenFile:   c.OIDCTokenFile,
+		STSEndpoint: c.STSEndpoint,
+	})
+}
+
 func (c *Config) GetVSwitchIDs() []string {
 	var vsws []string
 	for _, ids := range c.VSwitches {
@@ -56,11 +56,46 @@ const (
 	ENIRelatedNodeName = AnnotationPrefix + "node"
 
 	PodIPs = AnnotationPrefix + "pod-ips"
+
+	// PodENIGroup choose the eni group for the pod in eniip mode, it only takes effect when the group selects the pod
+	PodENIGroup = AnnotationPrefix + "eni-group"
+)
+
+// node condition and taint set by terway daemon
+const (
+	// NodeConditionNetworkAvailable is false when the allocation keeps failing for the eni/ip limit or the vswitch running out of ips
+	NodeConditionNetworkAvailable corev1.NodeConditionType = "TerwayNetworkAvailable"
+	// TaintNetworkUnavailable is the NoSchedule taint put on the node when NodeConditionNetworkAvailable is false
+	TaintNetworkUnavailable = AnnotationPrefix + "network-unavailable"
+)
+
+// annotations for pod eip
+const (
+	PodEIP                   = AnnotationPrefix + "pod-with-eip"
+	ECIEIP                   = AnnotationPrefix + "eci-with-eip" // to adopt ask annotation
+	PodEIPBandwidth          = AnnotationPrefix + "eip-bandwidth"
+	PodEIPChargeType         = AnnotationPrefix + "eip-charge-type"
+	PodEIPInternetChargeType = AnnotationPrefix + "eip-internet-charge-type" // to adopt ask annotation
+	ECIEIPInstanceID         = AnnotationPrefix + "eci-eip-instanceid"       // to adopt ask annotation
+	PodEIPInstanceID         = AnnotationPrefix + "pod-eip-instanceid"
+	PodEIPAddress            = AnnotationPrefix + "allocated-eipAddress"
+	EIPBandwidthPackageID    = AnnotationPrefix + "eip-common-bandwidth-package-id"
+	EIPISP                   = AnnotationPrefix + "eip-isp"
+	EIPPublicIPAddressPoolID = AnnotationPrefix + "eip-public-ip-address-pool-id"
+	// PodEIPReuseTTL keep the eip for the pod with the same name after the pod is deleted
+	PodEIPReuseTTL = AnnotationPrefix + "eip-reuse-ttl"
 )
 
 // FinalizerPodENI finalizer for podENI resource
 const FinalizerPodENI = "pod-eni"
 
+// FinalizerPodEIP finalizer for podEIP resource
+const FinalizerPodEIP = "pod-eip"
+
+// LabelEIPReservation mark the PodEIP written by the daemon to reserve the eip for the pod with the same name,
+// terway-controlplane only release it after the ttl and never associate it
+const LabelEIPReservation = AnnotationPrefix + "eip-reservation"
+
 // events for control plane
 const (
 	EventCreateENISucceed = "CreateENISucceed"
@@ -77,6 +112,10 @@ const (
 
 	EventSyncPodNetworkingSucceed = "SyncPodNetworkingSucceed"
 	EventSyncPodNetworkingFailed  = "SyncPodNetworkingFailed"
+
+	EventAssociateEIPSucceed = "AssociateEIPSucceed"
+	EventAssociateEIPFailed  = "AssociateEIPFailed"
+	EventReleaseEIPFailed    = "ReleaseEIPFailed"
 )
 
 // PodUseENI whether pod is use podENI cr res
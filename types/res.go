@@ -3,6 +3,8 @@ package types
 import (
 	"net"
 	"time"
+
+	"github.com/AliyunContainerService/terway/pkg/storage"
 )
 
 // PodEipInfo store pod eip info
@@ -16,6 +18,7 @@ type PodEipInfo struct {
 	PodEipISP                string
 	PodEipPoolID             string
 	PodEipBandwidthPackageID string
+	PodEipReuseTTL           time.Duration
 }
 
 // PodInfo store the pod info
@@ -35,6 +38,9 @@ type PodInfo struct {
 	PodENI          bool
 	PodUID          string
 	NetworkPriority string
+	ENIGroup        string
+	HostNetwork     bool
+	ENIIPRequested  bool
 }
 
 // ExtraEipInfo store extra eip info
@@ -43,6 +49,12 @@ type ExtraEipInfo struct {
 	Delete         bool   `json:"delete"` // delete related eip on pod deletion
 	AssociateENI   string `json:"associate_eni"`
 	AssociateENIIP net.IP `json:"associate_eniip"`
+
+	// ReuseKey and ReuseTTL reserve the eip for the pod identity after the pod is deleted
+	ReuseKey string        `json:"reuse_key,omitempty"`
+	ReuseTTL time.Duration `json:"reuse_ttl,omitempty"`
+	// Pooled eip match the spec of the warm pool, it is put back to the pool on release
+	Pooled bool `json:"pooled,omitempty"`
 }
 
 // ResourceItem to be store
@@ -65,6 +77,18 @@ type PodResources struct {
 	ContainerID *string
 }
 
+// PodResourcesSchema is the schema of PodResources in the resource db.
+// Bump the version and register the migration from the previous one when the format of PodResources changes.
+var PodResourcesSchema = &storage.Schema{
+	Version: 1,
+	Migrations: map[int]storage.Migration{
+		// PodResources json without the version field
+		0: func(data []byte) ([]byte, error) {
+			return data, nil
+		},
+	},
+}
+
 // GetResourceItemByType get pod resource by resource type
 func (p PodResources) GetResourceItemByType(resType string) []ResourceItem {
 	var ret []ResourceItem
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"net"
 	"strings"
+	"time"
 
 	terwayIP "github.com/AliyunContainerService/terway/pkg/ip"
 	"github.com/AliyunContainerService/terway/pkg/utils"
@@ -82,6 +83,18 @@ const (
 	ENICapPolicyDefault     = ""
 )
 
+// IPAllocationPolicy how eniip factory choose the eni for new ip
+type IPAllocationPolicy string
+
+// how eniip factory choose the eni for new ip
+const (
+	// IPAllocationPolicySpread prefer the eni with the least ips
+	IPAllocationPolicySpread = "spread"
+	// IPAllocationPolicyPack prefer the eni with the most ips
+	IPAllocationPolicyPack    = "pack"
+	IPAllocationPolicyDefault = ""
+)
+
 // NewIPFamilyFromIPStack parse IPStack to IPFamily
 func NewIPFamilyFromIPStack(ipStack IPStack) *IPFamily {
 	f := &IPFamily{}
@@ -313,6 +326,10 @@ type EIP struct {
 	Delete         bool // delete related eip on pod deletion
 	AssociateENI   string
 	AssociateENIIP net.IP
+
+	ReuseKey string
+	ReuseTTL time.Duration
+	Pooled   bool
 }
 
 // GetResourceID return eip id
@@ -334,6 +351,9 @@ func (e *EIP) ToResItems() []ResourceItem {
 				Delete:         e.Delete,
 				AssociateENI:   e.AssociateENI,
 				AssociateENIIP: e.AssociateENIIP,
+				ReuseKey:       e.ReuseKey,
+				ReuseTTL:       e.ReuseTTL,
+				Pooled:         e.Pooled,
 			},
 		},
 	}